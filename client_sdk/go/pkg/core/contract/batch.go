@@ -0,0 +1,121 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package contract
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// BatchRequest is a single transaction invocation of a batch submitted with SubmitBatch
+type BatchRequest struct {
+	// Name is the name of the transaction function
+	Name string
+	// Args are the arguments passed to the transaction function
+	Args []string
+}
+
+// SubmitBatch submits multiple transaction invocations within a single Fabric transaction.
+// The requests are executed by the enclave in the given order, where each request observes the writes of the
+// previous requests. If any request fails, none of the requests is committed.
+// On success, the return values of the transaction functions are returned in the order of the requests.
+// As for SubmitTransaction, the batch is sent to every enclave peer if WithResponseConsistencyCheck is set, where the
+// responses to all requests of the batch as well as the shared read/write set must be consistent.
+func (c *contractImpl) SubmitBatch(requests ...BatchRequest) ([][]byte, error) {
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("no requests to submit")
+	}
+
+	ctxs, err := c.newEncryptionContexts(len(requests))
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &protos.ChaincodeBatchRequestMessage{}
+	for i, r := range requests {
+		encryptedRequest, err := ctxs[i].Conceal(r.Name, r.Args)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot encrypt batch request %d", i)
+		}
+
+		// Conceal returns a base64-encoded ChaincodeRequestMessage
+		chaincodeRequestMessage, err := base64.StdEncoding.DecodeString(encryptedRequest)
+		if err != nil {
+			return nil, err
+		}
+		batch.ChaincodeRequestMessages = append(batch.ChaincodeRequestMessages, chaincodeRequestMessage)
+	}
+
+	batchBytes, err := proto.Marshal(batch)
+	if err != nil {
+		return nil, err
+	}
+
+	// call __invokeBatch
+	encryptedResponse, err := c.invokeBatch(ctxs, base64.StdEncoding.EncodeToString(batchBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debugf("calling __endorse!")
+	_, err = c.target.SubmitTransaction(endorseFunction, string(encryptedResponse))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]byte, len(requests))
+	for i, ctx := range ctxs {
+		clearResponseBytes, err := ctx.RevealBatchResponse(encryptedResponse, i)
+		if err != nil {
+			return nil, err
+		}
+
+		// unwrap Response.Payload
+		results[i], err = utils.UnwrapResponse(clearResponseBytes)
+		if err != nil {
+			return nil, errors.Wrapf(err, "batch request %d failed", i)
+		}
+	}
+
+	return results, nil
+}
+
+// newEncryptionContexts returns an encryption context for each of the n requests of a batch; if supported by the
+// encryption provider, the chaincode encryption key is retrieved from ERCC only once for the whole batch.
+func (c *contractImpl) newEncryptionContexts(n int) ([]crypto.EncryptionContext, error) {
+	if bp, ok := c.ep.(crypto.BatchEncryptionProvider); ok {
+		return bp.NewEncryptionContexts(n)
+	}
+
+	ctxs := make([]crypto.EncryptionContext, n)
+	for i := range ctxs {
+		ctx, err := c.ep.NewEncryptionContext()
+		if err != nil {
+			return nil, err
+		}
+		ctxs[i] = ctx
+	}
+	return ctxs, nil
+}
+
+func (c *contractImpl) invokeBatch(ctxs []crypto.EncryptionContext, args ...string) ([]byte, error) {
+	if c.checkConsistency {
+		peers, err := c.getPeerEndpoints()
+		if err != nil {
+			return nil, err
+		}
+		return c.invokeConsistent(invokeBatchFunction, revealBatch(ctxs), peers, c.minResponses, args...)
+	}
+
+	return c.invokeWithFailover(invokeBatchFunction, args...)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package contract_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+
+	fpccontract "github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/contract"
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/contract/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContractSubmitBatch(t *testing.T) {
+	mockEncryptionContext := &fakes.EncryptionContext{}
+	mockEncryptionContext.ConcealCalls(func(function string, args []string) (string, error) {
+		return base64.StdEncoding.EncodeToString([]byte(function)), nil
+	})
+	mockEncryptionContext.RevealBatchResponseCalls(func(input []byte, index int) ([]byte, error) {
+		return asResponseBytes([]byte(fmt.Sprintf("result%d", index))), nil
+	})
+	mockEncryptionProvider := &fakes.EncryptionProvider{}
+	mockEncryptionProvider.NewEncryptionContextReturns(mockEncryptionContext, nil)
+
+	invokeTx := &fakes.Transaction{}
+	invokeTx.EvaluateReturns([]byte("someEncryptedResponse"), nil)
+
+	mockContract := &fakes.Contract{}
+	mockContract.CreateTransactionReturns(invokeTx, nil)
+
+	contract := fpccontract.New(mockContract, nil, []string{"peer1:7051"}, mockEncryptionProvider)
+
+	_, err := contract.SubmitBatch()
+	assert.EqualError(t, err, "no requests to submit")
+
+	results, err := contract.SubmitBatch(
+		fpccontract.BatchRequest{Name: "transfer", Args: []string{"alice", "bob", "10"}},
+		fpccontract.BatchRequest{Name: "transfer", Args: []string{"bob", "carol", "5"}},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("result0"), []byte("result1")}, results)
+
+	// one encryption context per request
+	assert.Equal(t, 2, mockEncryptionProvider.NewEncryptionContextCallCount())
+	fn, args := mockEncryptionContext.ConcealArgsForCall(1)
+	assert.Equal(t, "transfer", fn)
+	assert.Equal(t, []string{"bob", "carol", "5"}, args)
+
+	// requests are sent in a single __invokeBatch
+	assert.Equal(t, 1, mockContract.CreateTransactionCallCount())
+	fn, peers := mockContract.CreateTransactionArgsForCall(0)
+	assert.Equal(t, "__invokeBatch", fn)
+	assert.Equal(t, []string{"peer1:7051"}, peers)
+	batchB64 := invokeTx.EvaluateArgsForCall(0)
+	assert.Len(t, batchB64, 1)
+	batchBytes, err := base64.StdEncoding.DecodeString(batchB64[0])
+	assert.NoError(t, err)
+	batch, err := utils.UnmarshalChaincodeBatchRequestMessage(batchBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("transfer"), []byte("transfer")}, batch.GetChaincodeRequestMessages())
+
+	// and endorsed in a single __endorse
+	assert.Equal(t, 1, mockContract.SubmitTransactionCallCount())
+	fn, args = mockContract.SubmitTransactionArgsForCall(0)
+	assert.Equal(t, "__endorse", fn)
+	assert.Equal(t, []string{"someEncryptedResponse"}, args)
+
+	// error at batch invocation
+	invokeTx.EvaluateReturns(nil, fmt.Errorf("batch aborted"))
+	_, err = contract.SubmitBatch(fpccontract.BatchRequest{Name: "transfer"})
+	assert.EqualError(t, err, "batch aborted")
+	assert.Equal(t, 1, mockContract.SubmitTransactionCallCount())
+
+	// chaincode error of a single request
+	invokeTx.EvaluateReturns([]byte("someEncryptedResponse"), nil)
+	mockEncryptionContext.RevealBatchResponseReturns(protoutil.MarshalOrPanic(&peer.Response{Status: 500, Message: "insufficient funds"}), nil)
+	_, err = contract.SubmitBatch(fpccontract.BatchRequest{Name: "transfer"})
+	var ccErr *fpccontract.ChaincodeError
+	assert.True(t, errors.As(err, &ccErr))
+	assert.Equal(t, "insufficient funds", ccErr.Message)
+}
+
+// batchEncryptionProvider creates the encryption contexts of a batch at once (see crypto.BatchEncryptionProvider)
+type batchEncryptionProvider struct {
+	*fakes.EncryptionProvider
+	ctx   crypto.EncryptionContext
+	calls []int
+}
+
+func (p *batchEncryptionProvider) NewEncryptionContexts(n int) ([]crypto.EncryptionContext, error) {
+	p.calls = append(p.calls, n)
+	ctxs := make([]crypto.EncryptionContext, n)
+	for i := range ctxs {
+		ctxs[i] = p.ctx
+	}
+	return ctxs, nil
+}
+
+func TestContractSubmitBatchEncryptionContexts(t *testing.T) {
+	mockEncryptionContext := &fakes.EncryptionContext{}
+	mockEncryptionContext.ConcealReturns(base64.StdEncoding.EncodeToString([]byte("someRequest")), nil)
+	mockEncryptionContext.RevealBatchResponseReturns(asResponseBytes([]byte("result")), nil)
+	mockEncryptionProvider := &batchEncryptionProvider{EncryptionProvider: &fakes.EncryptionProvider{}, ctx: mockEncryptionContext}
+
+	invokeTx := &fakes.Transaction{}
+	invokeTx.EvaluateReturns([]byte("someEncryptedResponse"), nil)
+	mockContract := &fakes.Contract{}
+	mockContract.CreateTransactionReturns(invokeTx, nil)
+
+	contract := fpccontract.New(mockContract, nil, []string{"peer1:7051"}, mockEncryptionProvider)
+	results, err := contract.SubmitBatch(
+		fpccontract.BatchRequest{Name: "transfer", Args: []string{"alice", "bob", "10"}},
+		fpccontract.BatchRequest{Name: "transfer", Args: []string{"bob", "carol", "5"}},
+		fpccontract.BatchRequest{Name: "transfer", Args: []string{"carol", "alice", "1"}},
+	)
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+
+	// the contexts of all requests are created at once
+	assert.Equal(t, []int{3}, mockEncryptionProvider.calls)
+	assert.Equal(t, 0, mockEncryptionProvider.NewEncryptionContextCallCount())
+	assert.Equal(t, 3, mockEncryptionContext.ConcealCallCount())
+}
@@ -0,0 +1,89 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package contract
+
+import (
+	"sync"
+	"time"
+)
+
+// enclaveCache caches the enclave peer endpoints and their organizations as queried from ERCC.
+// The entries expire after the TTL or, if known from the organizations, once the first of the enclave credentials
+// expires, such that enclaves whose credentials expire are no longer used.
+// The cache is safe for concurrent use, e.g., by contracts created with WithTargetEndpoints.
+type enclaveCache struct {
+	mu  sync.Mutex
+	ttl time.Duration
+	now func() time.Time
+
+	endpoints          []string
+	endpointsExpiresAt time.Time
+	mspIds             map[string]string
+	mspIdsExpiresAt    time.Time
+}
+
+func newEnclaveCache(ttl time.Duration) *enclaveCache {
+	return &enclaveCache{
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// getEndpoints returns the cached enclave peer endpoints or nil if expired
+func (e *enclaveCache) getEndpoints() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.now().Before(e.endpointsExpiresAt) {
+		return nil
+	}
+	return e.endpoints
+}
+
+func (e *enclaveCache) setEndpoints(endpoints []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.endpoints = endpoints
+	e.endpointsExpiresAt = e.now().Add(e.ttl)
+}
+
+// getMspIds returns the cached organizations of the enclave peers or nil if expired
+func (e *enclaveCache) getMspIds() map[string]string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.now().Before(e.mspIdsExpiresAt) {
+		return nil
+	}
+	return e.mspIds
+}
+
+// setMspIds caches the organizations of the enclave peers until the TTL passes or, if earlier, credentialsExpireAt
+// (if not zero); the cached endpoints expire with the credentials as well
+func (e *enclaveCache) setMspIds(mspIds map[string]string, credentialsExpireAt time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.mspIds = mspIds
+	e.mspIdsExpiresAt = e.now().Add(e.ttl)
+	if credentialsExpireAt.IsZero() {
+		return
+	}
+	if credentialsExpireAt.Before(e.mspIdsExpiresAt) {
+		e.mspIdsExpiresAt = credentialsExpireAt
+	}
+	if credentialsExpireAt.Before(e.endpointsExpiresAt) {
+		e.endpointsExpiresAt = credentialsExpireAt
+	}
+}
+
+// invalidate drops all entries, e.g., after requests to the cached enclave peers failed
+func (e *enclaveCache) invalidate() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.endpoints = nil
+	e.endpointsExpiresAt = time.Time{}
+	e.mspIds = nil
+	e.mspIdsExpiresAt = time.Time{}
+}
@@ -0,0 +1,340 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package contract
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
+)
+
+// ResponseDivergence describes a single difference between the response of an enclave peer and the reference response.
+type ResponseDivergence struct {
+	// Peer is the endpoint of the enclave peer whose response diverges
+	Peer string
+	// Field identifies the diverging part of the response, e.g., `payload hash` or `writes[someKey]`
+	Field string
+	// Expected is the value of the reference response
+	Expected string
+	// Actual is the value of the diverging response
+	Actual string
+}
+
+// ResponseDivergenceError is returned if the responses of the enclave peers are not consistent
+// (see WithResponseConsistencyCheck).
+type ResponseDivergenceError struct {
+	// ReferencePeer is the endpoint of the enclave peer whose response is used as reference
+	ReferencePeer string
+	// Divergences lists all differences found
+	Divergences []ResponseDivergence
+}
+
+func (e *ResponseDivergenceError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "responses of enclave peers diverge from reference response of %s:", e.ReferencePeer)
+	for _, d := range e.Divergences {
+		fmt.Fprintf(&sb, "\n\t%s: %s expected '%s' but got '%s'", d.Peer, d.Field, d.Expected, d.Actual)
+	}
+	return sb.String()
+}
+
+// enclaveResponse holds the response of a single enclave peer to an `__invoke` or `__invokeBatch` request
+type enclaveResponse struct {
+	peer      string
+	encrypted []byte
+	responses []*responseSummary
+	rwset     *protos.FPCKVSet
+}
+
+type responseSummary struct {
+	status      int32
+	message     string
+	payloadHash string
+}
+
+// revealFunc decrypts the (serialized) peer.Response messages of an encrypted enclave response, i.e., a single one
+// for `__invoke` and one per request for `__invokeBatch`
+type revealFunc func(encryptedResponse []byte) ([][]byte, error)
+
+// revealSingle returns the revealFunc for the response to an `__invoke` request
+func revealSingle(ctx crypto.EncryptionContext) revealFunc {
+	return func(encryptedResponse []byte) ([][]byte, error) {
+		clearResponseBytes, err := ctx.Reveal(encryptedResponse)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{clearResponseBytes}, nil
+	}
+}
+
+// revealBatch returns the revealFunc for the response to an `__invokeBatch` request, where ctxs are the encryption
+// contexts of the requests of the batch
+func revealBatch(ctxs []crypto.EncryptionContext) revealFunc {
+	return func(encryptedResponse []byte) ([][]byte, error) {
+		clearResponses := make([][]byte, len(ctxs))
+		for i, ctx := range ctxs {
+			clearResponseBytes, err := ctx.RevealBatchResponse(encryptedResponse, i)
+			if err != nil {
+				return nil, errors.Wrapf(err, "batch request %d", i)
+			}
+			clearResponses[i] = clearResponseBytes
+		}
+		return clearResponses, nil
+	}
+}
+
+// invokeConsistent sends the encrypted request to all given enclave peers individually by calling the given enclave
+// function (i.e., `__invoke` or `__invokeBatch`) and checks that at least minResponses enclaves return consistent
+// responses. On success, the (encrypted) response of the first peer is returned, i.e., the response that is passed
+// on to `__endorse`.
+func (c *contractImpl) invokeConsistent(function string, reveal revealFunc, peers []string, minResponses int, args ...string) ([]byte, error) {
+	if minResponses <= 0 || minResponses > len(peers) {
+		minResponses = len(peers)
+	}
+
+	results := make([]*enclaveResponse, len(peers))
+	errs := make([]error, len(peers))
+
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(i int, peer string) {
+			defer wg.Done()
+			encryptedResponse, err := c.invoke(function, []string{peer}, args...)
+			if err != nil {
+				c.health.markFailure(peer)
+				errs[i] = err
+				return
+			}
+			c.health.markSuccess(peer)
+
+			results[i], errs[i] = parseEnclaveResponse(reveal, peer, encryptedResponse)
+		}(i, peer)
+	}
+	wg.Wait()
+
+	var responses []*enclaveResponse
+	var failures []string
+	for i, peer := range peers {
+		if errs[i] != nil {
+			logger.Warningf("%s at enclave peer %s failed: %s", function, peer, errs[i])
+			failures = append(failures, fmt.Sprintf("%s: %s", peer, errs[i]))
+			continue
+		}
+		responses = append(responses, results[i])
+	}
+
+	if len(responses) < minResponses {
+		// the enclaves may have changed, e.g., as their credentials expired
+		c.cache.invalidate()
+		return nil, fmt.Errorf("received %d valid responses but %d are required for consistency check; failed enclave peers: [%s]",
+			len(responses), minResponses, strings.Join(failures, "; "))
+	}
+
+	if err := checkConsistency(responses); err != nil {
+		return nil, err
+	}
+
+	return responses[0].encrypted, nil
+}
+
+func parseEnclaveResponse(reveal revealFunc, peer string, encryptedResponse []byte) (*enclaveResponse, error) {
+	clearResponses, err := reveal(encryptedResponse)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decrypt response")
+	}
+
+	summaries := make([]*responseSummary, len(clearResponses))
+	for i, clearResponseBytes := range clearResponses {
+		response, err := protoutil.UnmarshalResponse(clearResponseBytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal peer.Response message")
+		}
+		payloadHash := sha256.Sum256(response.GetPayload())
+		summaries[i] = &responseSummary{
+			status:      response.GetStatus(),
+			message:     response.GetMessage(),
+			payloadHash: hex.EncodeToString(payloadHash[:]),
+		}
+	}
+
+	rwset, err := extractFPCKVSet(encryptedResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &enclaveResponse{
+		peer:      peer,
+		encrypted: encryptedResponse,
+		responses: summaries,
+		rwset:     rwset,
+	}, nil
+}
+
+func extractFPCKVSet(signedResponseBytesB64 []byte) (*protos.FPCKVSet, error) {
+	signedResponseBytes, err := base64.StdEncoding.DecodeString(string(signedResponseBytesB64))
+	if err != nil {
+		return nil, err
+	}
+
+	signedResponse, err := utils.UnmarshalSignedChaincodeResponseMessage(signedResponseBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to extract signed response message")
+	}
+
+	response, err := utils.UnmarshalChaincodeResponseMessage(signedResponse.GetChaincodeResponseMessage())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to extract response message")
+	}
+
+	return response.GetFpcRwSet(), nil
+}
+
+// checkConsistency compares all responses with the first one; for batches of multiple requests, the responses to the
+// individual requests are compared with the field prefix `responses[i].`.
+// Note that written values are encrypted by each enclave using randomized encryption, hence, for writes we can only
+// compare keys, delete flags and value sizes; reads are compared including the hashes of the values read.
+// As enclaves do not return reads and writes in a deterministic order, the read/write sets are compared by key.
+func checkConsistency(responses []*enclaveResponse) error {
+	reference := responses[0]
+
+	var divergences []ResponseDivergence
+	for _, r := range responses[1:] {
+		report := func(field string, expected, actual any) {
+			divergences = append(divergences, ResponseDivergence{
+				Peer:     r.peer,
+				Field:    field,
+				Expected: fmt.Sprint(expected),
+				Actual:   fmt.Sprint(actual),
+			})
+		}
+
+		if len(reference.responses) != len(r.responses) {
+			report("responses", len(reference.responses), len(r.responses))
+		} else {
+			for i, expected := range reference.responses {
+				prefix := ""
+				if len(reference.responses) > 1 {
+					prefix = fmt.Sprintf("responses[%d].", i)
+				}
+				actual := r.responses[i]
+				if expected.status != actual.status {
+					report(prefix+"status", expected.status, actual.status)
+				}
+				if expected.message != actual.message {
+					report(prefix+"message", expected.message, actual.message)
+				}
+				if expected.payloadHash != actual.payloadHash {
+					report(prefix+"payload hash", expected.payloadHash, actual.payloadHash)
+				}
+			}
+		}
+
+		compareFPCKVSet(reference.rwset, r.rwset, report)
+	}
+
+	if len(divergences) > 0 {
+		return &ResponseDivergenceError{
+			ReferencePeer: reference.peer,
+			Divergences:   divergences,
+		}
+	}
+	return nil
+}
+
+func compareFPCKVSet(expected, actual *protos.FPCKVSet, report func(field string, expected, actual any)) {
+	expectedReads, err := readsByKey(expected)
+	if err != nil {
+		report("reads", "well-formed read set", err)
+		return
+	}
+	actualReads, err := readsByKey(actual)
+	if err != nil {
+		report("reads", "well-formed read set", err)
+		return
+	}
+
+	for _, key := range sortedKeys(expectedReads, actualReads) {
+		e, inExpected := expectedReads[key]
+		a, inActual := actualReads[key]
+		switch {
+		case !inActual:
+			report(fmt.Sprintf("reads[%s]", key), "read", "missing")
+		case !inExpected:
+			report(fmt.Sprintf("reads[%s]", key), "missing", "read")
+		case !bytes.Equal(e, a):
+			report(fmt.Sprintf("reads[%s].value_hash", key), hex.EncodeToString(e), hex.EncodeToString(a))
+		}
+	}
+
+	expectedWrites := writesByKey(expected)
+	actualWrites := writesByKey(actual)
+	for _, key := range sortedKeys(expectedWrites, actualWrites) {
+		e, inExpected := expectedWrites[key]
+		a, inActual := actualWrites[key]
+		switch {
+		case !inActual:
+			report(fmt.Sprintf("writes[%s]", key), "write", "missing")
+		case !inExpected:
+			report(fmt.Sprintf("writes[%s]", key), "missing", "write")
+		case e.GetIsDelete() != a.GetIsDelete():
+			report(fmt.Sprintf("writes[%s].is_delete", key), e.GetIsDelete(), a.GetIsDelete())
+		case len(e.GetValue()) != len(a.GetValue()):
+			report(fmt.Sprintf("writes[%s].value_size", key), len(e.GetValue()), len(a.GetValue()))
+		}
+	}
+}
+
+// readsByKey returns a mapping from the keys read to the hashes of the values read
+func readsByKey(rwset *protos.FPCKVSet) (map[string][]byte, error) {
+	reads := rwset.GetRwSet().GetReads()
+	hashes := rwset.GetReadValueHashes()
+	if len(reads) != len(hashes) {
+		return nil, fmt.Errorf("%d read value hashes but %d reads", len(hashes), len(reads))
+	}
+
+	m := make(map[string][]byte, len(reads))
+	for i, r := range reads {
+		m[r.GetKey()] = hashes[i]
+	}
+	return m, nil
+}
+
+func writesByKey(rwset *protos.FPCKVSet) map[string]*kvrwset.KVWrite {
+	m := make(map[string]*kvrwset.KVWrite)
+	for _, w := range rwset.GetRwSet().GetWrites() {
+		m[w.GetKey()] = w
+	}
+	return m
+}
+
+// sortedKeys returns the union of the keys of both maps in sorted order
+func sortedKeys[V any](a, b map[string]V) []string {
+	var keys []string
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
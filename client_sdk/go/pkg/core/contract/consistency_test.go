@@ -0,0 +1,214 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package contract_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	fpccontract "github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/contract"
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/contract/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// asSignedResponse returns a base64-encoded SignedChaincodeResponseMessage with the given (cleartext) payload
+// as encrypted response, see newConsistencyEncryptionProvider
+func asSignedResponse(payload string, writes ...*kvrwset.KVWrite) []byte {
+	response := &protos.ChaincodeResponseMessage{
+		EncryptedResponse: []byte(payload),
+		FpcRwSet: &protos.FPCKVSet{
+			RwSet: &kvrwset.KVRWSet{
+				Reads:  []*kvrwset.KVRead{{Key: "someKey"}},
+				Writes: writes,
+			},
+			ReadValueHashes: [][]byte{[]byte("someHash")},
+		},
+	}
+	signedResponse := &protos.SignedChaincodeResponseMessage{
+		ChaincodeResponseMessage: protoutil.MarshalOrPanic(response),
+	}
+	return []byte(base64.StdEncoding.EncodeToString(protoutil.MarshalOrPanic(signedResponse)))
+}
+
+func newConsistencyEncryptionProvider() *fakes.EncryptionProvider {
+	mockEncryptionContext := &fakes.EncryptionContext{}
+	mockEncryptionContext.ConcealReturns("someEncryptedArgs", nil)
+	mockEncryptionContext.RevealCalls(func(input []byte) ([]byte, error) {
+		signedResponseBytes, err := base64.StdEncoding.DecodeString(string(input))
+		if err != nil {
+			return nil, err
+		}
+		signedResponse, err := utils.UnmarshalSignedChaincodeResponseMessage(signedResponseBytes)
+		if err != nil {
+			return nil, err
+		}
+		response, err := utils.UnmarshalChaincodeResponseMessage(signedResponse.GetChaincodeResponseMessage())
+		if err != nil {
+			return nil, err
+		}
+		return asResponseBytes(response.GetEncryptedResponse()), nil
+	})
+
+	mockEncryptionProvider := &fakes.EncryptionProvider{}
+	mockEncryptionProvider.NewEncryptionContextReturns(mockEncryptionContext, nil)
+	return mockEncryptionProvider
+}
+
+func newConsistencyContract(responses map[string][]byte) *fakes.Contract {
+	mockContract := &fakes.Contract{}
+	mockContract.CreateTransactionCalls(func(name string, peers ...string) (fpccontract.Transaction, error) {
+		txn := &fakes.Transaction{}
+		if len(peers) != 1 {
+			txn.EvaluateReturns(nil, fmt.Errorf("expected a single peer but got %v", peers))
+			return txn, nil
+		}
+		if resp, ok := responses[peers[0]]; ok {
+			txn.EvaluateReturns(resp, nil)
+		} else {
+			txn.EvaluateReturns(nil, fmt.Errorf("enclave not available"))
+		}
+		return txn, nil
+	})
+	return mockContract
+}
+
+func TestContractConsistencyCheck(t *testing.T) {
+	peers := []string{"peer1:7051", "peer2:7051", "peer3:7051"}
+	write := &kvrwset.KVWrite{Key: "someKey", Value: []byte("encryptedValue")}
+
+	// all enclaves agree
+	mockContract := newConsistencyContract(map[string][]byte{
+		"peer1:7051": asSignedResponse("result", write),
+		"peer2:7051": asSignedResponse("result", write),
+		"peer3:7051": asSignedResponse("result", write),
+	})
+	contract := fpccontract.New(mockContract, nil, peers, newConsistencyEncryptionProvider(),
+		fpccontract.WithResponseConsistencyCheck(0),
+	)
+
+	resp, err := contract.SubmitTransaction("someFunction", "arg1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("result"), resp)
+	assert.Equal(t, 3, mockContract.CreateTransactionCallCount())
+	assert.Equal(t, 1, mockContract.SubmitTransactionCallCount())
+	fn, args := mockContract.SubmitTransactionArgsForCall(0)
+	assert.Equal(t, "__endorse", fn)
+	assert.Equal(t, []string{string(asSignedResponse("result", write))}, args)
+
+	// peer3 returns a different result and writes a different key
+	mockContract = newConsistencyContract(map[string][]byte{
+		"peer1:7051": asSignedResponse("result", write),
+		"peer2:7051": asSignedResponse("result", write),
+		"peer3:7051": asSignedResponse("other", &kvrwset.KVWrite{Key: "otherKey", Value: []byte("encryptedValue")}),
+	})
+	contract = fpccontract.New(mockContract, nil, peers, newConsistencyEncryptionProvider(),
+		fpccontract.WithResponseConsistencyCheck(0),
+	)
+
+	_, err = contract.SubmitTransaction("someFunction", "arg1")
+	var divergenceErr *fpccontract.ResponseDivergenceError
+	assert.True(t, errors.As(err, &divergenceErr))
+	assert.Equal(t, "peer1:7051", divergenceErr.ReferencePeer)
+	assert.Len(t, divergenceErr.Divergences, 3)
+	assert.Equal(t, "peer3:7051", divergenceErr.Divergences[0].Peer)
+	assert.Equal(t, "payload hash", divergenceErr.Divergences[0].Field)
+	assert.Equal(t, "writes[otherKey]", divergenceErr.Divergences[1].Field)
+	assert.Equal(t, "missing", divergenceErr.Divergences[1].Expected)
+	assert.Equal(t, "write", divergenceErr.Divergences[1].Actual)
+	assert.Equal(t, "writes[someKey]", divergenceErr.Divergences[2].Field)
+	assert.Equal(t, "missing", divergenceErr.Divergences[2].Actual)
+	assert.Equal(t, 0, mockContract.SubmitTransactionCallCount())
+}
+
+func TestContractConsistencyCheckMinResponses(t *testing.T) {
+	peers := []string{"peer1:7051", "peer2:7051", "peer3:7051"}
+
+	// peer1 is down
+	mockContract := newConsistencyContract(map[string][]byte{
+		"peer2:7051": asSignedResponse("result"),
+		"peer3:7051": asSignedResponse("result"),
+	})
+
+	contract := fpccontract.New(mockContract, nil, peers, newConsistencyEncryptionProvider(),
+		fpccontract.WithResponseConsistencyCheck(0),
+	)
+	_, err := contract.EvaluateTransaction("someFunction", "arg1")
+	assert.ErrorContains(t, err, "received 2 valid responses but 3 are required")
+
+	contract = fpccontract.New(mockContract, nil, peers, newConsistencyEncryptionProvider(),
+		fpccontract.WithResponseConsistencyCheck(2),
+	)
+	resp, err := contract.EvaluateTransaction("someFunction", "arg1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("result"), resp)
+}
+
+func TestContractConsistencyCheckBatch(t *testing.T) {
+	peers := []string{"peer1:7051", "peer2:7051"}
+	write := &kvrwset.KVWrite{Key: "someKey", Value: []byte("encryptedValue")}
+
+	// the payloads of the requests of a batch are encoded as comma-separated list, see asSignedResponse
+	newBatchEncryptionProvider := func() *fakes.EncryptionProvider {
+		mockEncryptionProvider := newConsistencyEncryptionProvider()
+		mockEncryptionContext, _ := mockEncryptionProvider.NewEncryptionContext()
+		mockEncryptionContext.(*fakes.EncryptionContext).ConcealReturns(base64.StdEncoding.EncodeToString([]byte("someRequest")), nil)
+		mockEncryptionContext.(*fakes.EncryptionContext).RevealBatchResponseCalls(func(input []byte, index int) ([]byte, error) {
+			payload, err := mockEncryptionContext.Reveal(input)
+			if err != nil {
+				return nil, err
+			}
+			response, err := protoutil.UnmarshalResponse(payload)
+			if err != nil {
+				return nil, err
+			}
+			return asResponseBytes([]byte(strings.Split(string(response.GetPayload()), ",")[index])), nil
+		})
+		return mockEncryptionProvider
+	}
+	requests := []fpccontract.BatchRequest{{Name: "first"}, {Name: "second"}}
+
+	// all enclaves agree
+	mockContract := newConsistencyContract(map[string][]byte{
+		"peer1:7051": asSignedResponse("result0,result1", write),
+		"peer2:7051": asSignedResponse("result0,result1", write),
+	})
+	contract := fpccontract.New(mockContract, nil, peers, newBatchEncryptionProvider(),
+		fpccontract.WithResponseConsistencyCheck(0),
+	)
+	results, err := contract.SubmitBatch(requests...)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("result0"), []byte("result1")}, results)
+	assert.Equal(t, 2, mockContract.CreateTransactionCallCount())
+	for i := range peers {
+		fn, _ := mockContract.CreateTransactionArgsForCall(i)
+		assert.Equal(t, "__invokeBatch", fn)
+	}
+	assert.Equal(t, 1, mockContract.SubmitTransactionCallCount())
+
+	// peer2 returns a different result for the second request
+	mockContract = newConsistencyContract(map[string][]byte{
+		"peer1:7051": asSignedResponse("result0,result1", write),
+		"peer2:7051": asSignedResponse("result0,other", write),
+	})
+	contract = fpccontract.New(mockContract, nil, peers, newBatchEncryptionProvider(),
+		fpccontract.WithResponseConsistencyCheck(0),
+	)
+	_, err = contract.SubmitBatch(requests...)
+	var divergenceErr *fpccontract.ResponseDivergenceError
+	assert.True(t, errors.As(err, &divergenceErr))
+	assert.Len(t, divergenceErr.Divergences, 1)
+	assert.Equal(t, "peer2:7051", divergenceErr.Divergences[0].Peer)
+	assert.Equal(t, "responses[1].payload hash", divergenceErr.Divergences[0].Field)
+	assert.Equal(t, 0, mockContract.SubmitTransactionCallCount())
+}
@@ -9,15 +9,24 @@ SPDX-License-Identifier: Apache-2.0
 package contract
 
 import (
+	"encoding/json"
 	"strings"
+	"time"
 
 	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
 	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/pkg/errors"
 )
 
 var logger = flogging.MustGetLogger("fpc-client-contract")
 
+const (
+	invokeFunction      = "__invoke"
+	invokeBatchFunction = "__invokeBatch"
+	endorseFunction     = "__endorse"
+)
+
 // Transaction interface that is needed by the FPC contract implementation
 type Transaction interface {
 	Evaluate(args ...string) ([]byte, error)
@@ -40,17 +49,22 @@ type Provider interface {
 //	Parameters:
 //	network is an initialized Fabric network object
 //	chaincodeID is the ID of the target chaincode
+//	opts are optional settings such as the peer selection strategy (see Option)
 //
 //	Returns:
 //	The contractImpl object
-func GetContract(p Provider, chaincodeID string) *contractImpl {
+func GetContract(p Provider, chaincodeID string, opts ...Option) *contractImpl {
 	ercc := p.GetContract("ercc")
 	return New(p.GetContract(chaincodeID), ercc, nil, &crypto.EncryptionProviderImpl{
 		CSP: crypto.GetDefaultCSP(),
 		GetCcEncryptionKey: func() ([]byte, error) {
 			// Note that this function is called during EncryptionProvider.NewEncryptionContext()
 			return ercc.EvaluateTransaction("queryChaincodeEncryptionKey", chaincodeID)
-		}})
+		},
+		GetCcEncryptionKeys: func() ([]byte, error) {
+			// Note that this function is called during EncryptionProvider.NewEncryptionContext() to negotiate the cipher suite
+			return ercc.EvaluateTransaction("queryChaincodeEncryptionKeys", chaincodeID)
+		}}, opts...)
 }
 
 // contractImpl implements the client-side FPC protocol
@@ -58,16 +72,47 @@ type contractImpl struct {
 	target        Contract
 	ercc          Contract
 	peerEndpoints []string
+	cache         *enclaveCache
 	ep            crypto.EncryptionProvider
+	strategy      PeerSelectionStrategy
+	maxAttempts   int
+	health        *peerHealth
+
+	// consistency check across multiple enclaves, see WithResponseConsistencyCheck
+	checkConsistency bool
+	minResponses     int
 }
 
-func New(fpc Contract, ercc Contract, peerEndpoints []string, ep crypto.EncryptionProvider) *contractImpl {
-	return &contractImpl{
+func New(fpc Contract, ercc Contract, peerEndpoints []string, ep crypto.EncryptionProvider, opts ...Option) *contractImpl {
+	c := &contractImpl{
 		target:        fpc,
 		ercc:          ercc,
 		peerEndpoints: peerEndpoints,
 		ep:            ep,
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.health == nil {
+		c.health = newPeerHealth(DefaultUnhealthyPeerCoolDown)
+	}
+
+	if c.cache == nil {
+		c.cache = newEnclaveCache(DefaultEnclaveCacheTTL)
+	}
+
+	return c
+}
+
+// WithTargetEndpoints returns a copy of this contract that sends `__invoke` requests only to the given enclave peer
+// endpoints (in the format `host:port`). The returned contract shares the peer selection strategy and peer health
+// information with the original contract.
+func (c *contractImpl) WithTargetEndpoints(peerEndpoints ...string) *contractImpl {
+	targeted := *c
+	targeted.peerEndpoints = peerEndpoints
+	return &targeted
 }
 
 func (c *contractImpl) Name() string {
@@ -86,7 +131,7 @@ func (c *contractImpl) EvaluateTransaction(name string, args ...string) ([]byte,
 	}
 
 	// call __invoke
-	encryptedResponse, err := c.evaluateTransaction(encryptedRequest)
+	encryptedResponse, err := c.evaluateTransaction(ctx, encryptedRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -112,13 +157,13 @@ func (c *contractImpl) SubmitTransaction(name string, args ...string) ([]byte, e
 	}
 
 	// call __invoke
-	encryptedResponse, err := c.evaluateTransaction(encryptedRequest)
+	encryptedResponse, err := c.evaluateTransaction(ctx, encryptedRequest)
 	if err != nil {
 		return nil, err
 	}
 
 	logger.Debugf("calling __endorse!")
-	_, err = c.target.SubmitTransaction("__endorse", string(encryptedResponse))
+	_, err = c.target.SubmitTransaction(endorseFunction, string(encryptedResponse))
 	if err != nil {
 		return nil, err
 	}
@@ -133,32 +178,162 @@ func (c *contractImpl) SubmitTransaction(name string, args ...string) ([]byte, e
 }
 
 // getPeerEndpoints returns an array of peer endpoints that host the FPC chaincode enclave
-// An endpoint is a simple string with the format `host:port`
+// An endpoint is a simple string with the format `host:port`.
+// Note that ERCC only returns the endpoints of enclaves whose credentials are not expired; the endpoints are cached
+// for a limited time only (see WithEnclaveCacheTTL).
 func (c *contractImpl) getPeerEndpoints() ([]string, error) {
-	if len(c.peerEndpoints) == 0 {
-		resp, err := c.ercc.EvaluateTransaction("queryChaincodeEndPoints", c.Name())
+	if len(c.peerEndpoints) > 0 {
+		return c.peerEndpoints, nil
+	}
+
+	if endpoints := c.cache.getEndpoints(); endpoints != nil {
+		return endpoints, nil
+	}
+
+	resp, err := c.ercc.EvaluateTransaction("queryChaincodeEndPoints", c.Name())
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) == 0 {
+		return nil, errors.Errorf("no enclave with valid credentials available for chaincode %s", c.Name())
+	}
+	endpoints := strings.Split(string(resp), ",")
+	c.cache.setEndpoints(endpoints)
+	return endpoints, nil
+}
+
+// getPeerMspIds returns a mapping from the endpoint of a peer hosting the FPC chaincode enclave to the MSP ID of its
+// organization, as registered with the enclave credentials at ERCC. Enclaves with expired credentials are omitted;
+// the mapping is cached until the first of the credentials expires (see also WithEnclaveCacheTTL).
+func (c *contractImpl) getPeerMspIds() (map[string]string, error) {
+	if mspIds := c.cache.getMspIds(); mspIds != nil {
+		return mspIds, nil
+	}
+
+	resp, err := c.ercc.EvaluateTransaction("queryListEnclaveCredentials", c.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var allCredentials []string
+	if err := json.Unmarshal(resp, &allCredentials); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal enclave credentials list")
+	}
+
+	now := c.cache.now()
+	var firstExpiry time.Time
+	mspIds := make(map[string]string)
+	for _, credentialsBase64 := range allCredentials {
+		credentials, err := utils.UnmarshalCredentials(credentialsBase64)
 		if err != nil {
 			return nil, err
 		}
-		c.peerEndpoints = strings.Split(string(resp), ",")
+
+		if utils.CredentialsExpired(credentials, now) {
+			continue
+		}
+		if expiresAt := credentials.GetExpiresAt(); expiresAt != nil && (firstExpiry.IsZero() || expiresAt.AsTime().Before(firstExpiry)) {
+			firstExpiry = expiresAt.AsTime()
+		}
+
+		attestedData, err := utils.UnmarshalAttestedData(credentials.GetSerializedAttestedData())
+		if err != nil {
+			return nil, err
+		}
+
+		hostParams := attestedData.GetHostParams()
+		mspIds[hostParams.GetPeerEndpoint()] = hostParams.GetPeerMspId()
 	}
-	return c.peerEndpoints, nil
+	c.cache.setMspIds(mspIds, firstExpiry)
+	return mspIds, nil
 }
 
-func (c *contractImpl) evaluateTransaction(args ...string) ([]byte, error) {
+// selectPeerEndpoints returns the enclave peers in the order in which they should be tried according to the
+// configured peer selection strategy; peers that recently failed are tried last.
+func (c *contractImpl) selectPeerEndpoints(peers []string) ([]string, error) {
+	var ordered []string
+	if s, ok := c.strategy.(orgAwareStrategy); ok {
+		mspIds, err := c.getPeerMspIds()
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot get organizations of enclave peers")
+		}
+		ordered = s.SelectWithOrgs(peers, mspIds)
+	} else {
+		ordered = c.strategy.Select(peers)
+	}
+
+	ordered = c.health.prioritize(ordered)
+	if c.maxAttempts > 0 && c.maxAttempts < len(ordered) {
+		ordered = ordered[:c.maxAttempts]
+	}
+	return ordered, nil
+}
+
+func (c *contractImpl) evaluateTransaction(ctx crypto.EncryptionContext, args ...string) ([]byte, error) {
+	if c.checkConsistency {
+		peers, err := c.getPeerEndpoints()
+		if err != nil {
+			return nil, err
+		}
+		return c.invokeConsistent(invokeFunction, revealSingle(ctx), peers, c.minResponses, args...)
+	}
+
+	return c.invokeWithFailover(invokeFunction, args...)
+}
+
+// invokeWithFailover calls the given enclave function (i.e., `__invoke` or `__invokeBatch`) at the enclave peers.
+// If a peer selection strategy is set, the peers are tried one after another until one succeeds.
+func (c *contractImpl) invokeWithFailover(function string, args ...string) ([]byte, error) {
 	peers, err := c.getPeerEndpoints()
 	if err != nil {
 		return nil, err
 	}
 
+	// without peer selection strategy we send the request to all enclave peers at once
+	if c.strategy == nil {
+		resp, err := c.invoke(function, peers, args...)
+		if err != nil {
+			// the enclaves may have changed, e.g., as their credentials expired
+			c.cache.invalidate()
+		}
+		return resp, err
+	}
+
+	candidates, err := c.selectPeerEndpoints(peers)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New("no enclave peers available")
+	}
+
+	var lastErr error
+	for _, peer := range candidates {
+		resp, err := c.invoke(function, []string{peer}, args...)
+		if err == nil {
+			c.health.markSuccess(peer)
+			return resp, nil
+		}
+
+		logger.Warningf("%s at enclave peer %s failed: %s", function, peer, err)
+		c.health.markFailure(peer)
+		lastErr = err
+	}
+
+	// the enclaves may have changed, e.g., as their credentials expired
+	c.cache.invalidate()
+	return nil, errors.Wrapf(lastErr, "%s failed at all %d selected enclave peers", function, len(candidates))
+}
+
+func (c *contractImpl) invoke(function string, peers []string, args ...string) ([]byte, error) {
 	txn, err := c.target.CreateTransaction(
-		"__invoke",
+		function,
 		peers...,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	logger.Debugf("calling __invoke!")
+	logger.Debugf("calling %s!", function)
 	return txn.Evaluate(args...)
 }
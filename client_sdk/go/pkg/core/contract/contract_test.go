@@ -7,15 +7,24 @@ SPDX-License-Identifier: Apache-2.0
 package contract_test
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	fpccontract "github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/contract"
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/contract/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/csp"
 	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 	"github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/protoutil"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 //go:generate counterfeiter -o fakes/contract_provider.go -fake-name ContractProvider . contractProvider
@@ -61,6 +70,74 @@ func TestNewContract(t *testing.T) {
 	assert.Equal(t, chaincodeID, mockProvider.GetContractArgsForCall(1))
 }
 
+func TestContractWithLegacyERCC(t *testing.T) {
+	chaincodeID := "myChaincode"
+	pubKey, _, err := crypto.GetDefaultCSP().NewRSAKeys()
+	assert.NoError(t, err)
+
+	// ERCC without queryChaincodeEncryptionKeys
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionStub = func(name string, args ...string) ([]byte, error) {
+		switch name {
+		case "queryChaincodeEncryptionKey":
+			return []byte(base64.StdEncoding.EncodeToString(pubKey)), nil
+		case "queryChaincodeEndPoints":
+			return nil, fmt.Errorf("no endpoints")
+		default:
+			return nil, fmt.Errorf("unknown function %s", name)
+		}
+	}
+	mockProvider := &fakes.ContractProvider{}
+	mockProvider.GetContractReturnsOnCall(0, mockERCC)
+	mockProvider.GetContractReturnsOnCall(1, &fakes.Contract{})
+
+	// the client falls back to CIPHER_SUITE_RSA_OAEP_AES128_GCM and proceeds with the invocation
+	contract := fpccontract.GetContract(mockProvider, chaincodeID)
+	_, err = contract.EvaluateTransaction("someFunction")
+	assert.EqualError(t, err, "no endpoints")
+	var called []string
+	for i := 0; i < mockERCC.EvaluateTransactionCallCount(); i++ {
+		name, _ := mockERCC.EvaluateTransactionArgsForCall(i)
+		called = append(called, name)
+	}
+	assert.Equal(t, []string{"queryChaincodeEncryptionKeys", "queryChaincodeEncryptionKey", "queryChaincodeEndPoints"}, called)
+
+	// unless the client does not accept CIPHER_SUITE_RSA_OAEP_AES128_GCM
+	mockProvider.GetContractReturnsOnCall(2, mockERCC)
+	mockProvider.GetContractReturnsOnCall(3, &fakes.Contract{})
+	contract = fpccontract.GetContract(mockProvider, chaincodeID, fpccontract.WithCipherSuites(csp.CipherSuiteECIESX25519))
+	_, err = contract.EvaluateTransaction("someFunction")
+	assert.EqualError(t, err, "failed to get chaincode encryption keys from ercc: unknown function queryChaincodeEncryptionKeys")
+}
+
+func TestContractWithCipherSuites(t *testing.T) {
+	ep := &crypto.EncryptionProviderImpl{CSP: crypto.GetDefaultCSP()}
+	fpccontract.New(&fakes.Contract{}, &fakes.Contract{}, nil, ep, fpccontract.WithCipherSuites(crypto.PostQuantumCipherSuites...))
+	assert.Equal(t, crypto.PostQuantumCipherSuites, ep.CipherSuites)
+
+	// other encryption providers are not affected
+	contract := fpccontract.New(&fakes.Contract{}, &fakes.Contract{}, nil, &fakes.EncryptionProvider{}, fpccontract.WithCipherSuites(crypto.PostQuantumCipherSuites...))
+	assert.NotNil(t, contract)
+}
+
+func TestContractWithPostQuantumKeyTransport(t *testing.T) {
+	ep := &crypto.EncryptionProviderImpl{CSP: crypto.GetDefaultCSP()}
+	fpccontract.New(&fakes.Contract{}, &fakes.Contract{}, nil, ep, fpccontract.WithPostQuantumKeyTransport())
+	assert.Equal(t, csp.CipherSuiteHybridMLKEM768X25519, ep.CipherSuites[0])
+	assert.Equal(t, crypto.PostQuantumCipherSuites, ep.CipherSuites)
+
+	ep = &crypto.EncryptionProviderImpl{CSP: crypto.GetDefaultCSP()}
+	fpccontract.New(&fakes.Contract{}, &fakes.Contract{}, nil, ep, fpccontract.WithCipherSuites(csp.CipherSuiteECIESP256, csp.CipherSuiteRSAOAEP))
+	assert.Equal(t, []csp.CipherSuite{csp.CipherSuiteECIESP256, csp.CipherSuiteRSAOAEP}, ep.CipherSuites)
+}
+
+func TestContractWithCSP(t *testing.T) {
+	provider := crypto.NewGoCrypto()
+	ep := &crypto.EncryptionProviderImpl{}
+	fpccontract.New(&fakes.Contract{}, &fakes.Contract{}, nil, ep, fpccontract.WithCSP(provider))
+	assert.Same(t, provider, ep.CSP)
+}
+
 func TestContractName(t *testing.T) {
 
 	chaincodeID := "myChaincode"
@@ -182,15 +259,17 @@ func TestContractEvaluateAndSubmitTransactionFail(t *testing.T) {
 
 	// see what happens if creating transaction fails
 	mockContract.CreateTransactionReturns(nil, fmt.Errorf("error while creating transaction"))
-	mockERCC.EvaluateTransactionReturns(nil, nil)
+	mockERCC.EvaluateTransactionReturns([]byte("peer1:7051"), nil)
 
 	// failed
 	resp, err = contract.EvaluateTransaction("someFunction", "arg1", "arg2")
 	assert.Nil(t, resp)
-	assert.Error(t, err)
+	assert.EqualError(t, err, "error while creating transaction")
 	resp, err = contract.SubmitTransaction("someFunction", "arg1", "arg2")
 	assert.Nil(t, resp)
-	assert.Error(t, err)
+	assert.EqualError(t, err, "error while creating transaction")
+	_, peers := mockContract.CreateTransactionArgsForCall(0)
+	assert.Equal(t, []string{"peer1:7051"}, peers)
 
 	// see what happens if __endorse fails
 	txn.EvaluateReturnsOnCall(0, expectedResult, nil)
@@ -248,6 +327,360 @@ func TestContractSubmitTransaction(t *testing.T) {
 	assert.Equal(t, 1, mockContract.SubmitTransactionCallCount())
 }
 
+func newMockEncryptionProvider() *fakes.EncryptionProvider {
+	mockEncryptionContext := &fakes.EncryptionContext{}
+	mockEncryptionContext.ConcealReturns("someEncryptedArgs", nil)
+	mockEncryptionContext.RevealCalls(func(input []byte) ([]byte, error) {
+		return asResponseBytes(input), nil
+	})
+
+	mockEncryptionProvider := &fakes.EncryptionProvider{}
+	mockEncryptionProvider.NewEncryptionContextReturns(mockEncryptionContext, nil)
+	return mockEncryptionProvider
+}
+
+func TestContractFailover(t *testing.T) {
+	expectedResult := []byte("result")
+
+	failingTxn := &fakes.Transaction{}
+	failingTxn.EvaluateReturns(nil, fmt.Errorf("enclave not available"))
+	txn := &fakes.Transaction{}
+	txn.EvaluateReturns(expectedResult, nil)
+
+	// peer1 is down
+	mockContract := &fakes.Contract{}
+	mockContract.CreateTransactionCalls(func(name string, peers ...string) (fpccontract.Transaction, error) {
+		if len(peers) == 1 && peers[0] == "peer1:7051" {
+			return failingTxn, nil
+		}
+		return txn, nil
+	})
+
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionReturns([]byte("peer1:7051,peer2:7051,peer3:7051"), nil)
+
+	contract := fpccontract.New(mockContract, mockERCC, nil, newMockEncryptionProvider(),
+		fpccontract.WithPeerSelectionStrategy(fpccontract.NewRoundRobinStrategy()),
+	)
+
+	// first request tries peer1, then fails over to peer2
+	resp, err := contract.EvaluateTransaction("someFunction", "arg1")
+	assert.NoError(t, err)
+	assert.Equal(t, expectedResult, resp)
+	assert.Equal(t, 2, mockContract.CreateTransactionCallCount())
+	_, peers := mockContract.CreateTransactionArgsForCall(0)
+	assert.Equal(t, []string{"peer1:7051"}, peers)
+	_, peers = mockContract.CreateTransactionArgsForCall(1)
+	assert.Equal(t, []string{"peer2:7051"}, peers)
+
+	// second request starts with peer2 (round robin) and succeeds directly
+	resp, err = contract.EvaluateTransaction("someFunction", "arg1")
+	assert.NoError(t, err)
+	assert.Equal(t, expectedResult, resp)
+	assert.Equal(t, 3, mockContract.CreateTransactionCallCount())
+	_, peers = mockContract.CreateTransactionArgsForCall(2)
+	assert.Equal(t, []string{"peer2:7051"}, peers)
+
+	// third request would start with peer3 and after that peer1, which is still unhealthy and hence tried last
+	resp, err = contract.EvaluateTransaction("someFunction", "arg1")
+	assert.NoError(t, err)
+	assert.Equal(t, expectedResult, resp)
+	_, peers = mockContract.CreateTransactionArgsForCall(3)
+	assert.Equal(t, []string{"peer3:7051"}, peers)
+
+	// ercc is only queried once
+	assert.Equal(t, 1, mockERCC.EvaluateTransactionCallCount())
+}
+
+func TestContractFailoverMaxAttempts(t *testing.T) {
+	failingTxn := &fakes.Transaction{}
+	failingTxn.EvaluateReturns(nil, fmt.Errorf("enclave not available"))
+
+	mockContract := &fakes.Contract{}
+	mockContract.CreateTransactionReturns(failingTxn, nil)
+
+	contract := fpccontract.New(mockContract, nil, []string{"peer1:7051", "peer2:7051", "peer3:7051"}, newMockEncryptionProvider(),
+		fpccontract.WithPeerSelectionStrategy(fpccontract.NewRoundRobinStrategy()),
+		fpccontract.WithMaxAttempts(2),
+	)
+
+	resp, err := contract.EvaluateTransaction("someFunction", "arg1")
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "__invoke failed at all 2 selected enclave peers: enclave not available")
+	assert.Equal(t, 2, mockContract.CreateTransactionCallCount())
+
+	// submit must not call __endorse if __invoke failed
+	resp, err = contract.SubmitTransaction("someFunction", "arg1")
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+	assert.Equal(t, 0, mockContract.SubmitTransactionCallCount())
+}
+
+func TestContractWithTargetEndpoints(t *testing.T) {
+	txn := &fakes.Transaction{}
+	txn.EvaluateReturns([]byte("result"), nil)
+
+	mockContract := &fakes.Contract{}
+	mockContract.CreateTransactionReturns(txn, nil)
+
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionReturns([]byte("peer1:7051,peer2:7051"), nil)
+
+	contract := fpccontract.New(mockContract, mockERCC, nil, newMockEncryptionProvider())
+
+	_, err := contract.WithTargetEndpoints("peer3:7051").EvaluateTransaction("someFunction", "arg1")
+	assert.NoError(t, err)
+	_, peers := mockContract.CreateTransactionArgsForCall(0)
+	assert.Equal(t, []string{"peer3:7051"}, peers)
+
+	// no need to ask ercc for endpoints
+	assert.Equal(t, 0, mockERCC.EvaluateTransactionCallCount())
+
+	// the original contract is not affected
+	_, err = contract.EvaluateTransaction("someFunction", "arg1")
+	assert.NoError(t, err)
+	_, peers = mockContract.CreateTransactionArgsForCall(1)
+	assert.Equal(t, []string{"peer1:7051", "peer2:7051"}, peers)
+}
+
+func TestContractConcurrentRequests(t *testing.T) {
+	txn := &fakes.Transaction{}
+	txn.EvaluateReturns([]byte("result"), nil)
+
+	mockContract := &fakes.Contract{}
+	mockContract.NameReturns("myChaincode")
+	mockContract.CreateTransactionReturns(txn, nil)
+
+	credentialsList, _ := json.Marshal([]string{asCredentials("peer1:7051", "Org1MSP")})
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionCalls(func(name string, args ...string) ([]byte, error) {
+		switch name {
+		case "queryChaincodeEndPoints":
+			return []byte("peer1:7051"), nil
+		case "queryListEnclaveCredentials":
+			return credentialsList, nil
+		}
+		return nil, fmt.Errorf("unexpected call %s", name)
+	})
+
+	// contracts and their targeted copies share the cached enclave peers
+	contract := fpccontract.New(mockContract, mockERCC, nil, newMockEncryptionProvider(),
+		fpccontract.WithPeerSelectionStrategy(fpccontract.NewPreferLocalOrgStrategy("Org1MSP")),
+		fpccontract.WithEnclaveCacheTTL(0),
+	)
+	targeted := contract.WithTargetEndpoints("peer1:7051")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := contract.EvaluateTransaction("someFunction", "arg1")
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := targeted.EvaluateTransaction("someFunction", "arg1")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestContractPreferLocalOrg(t *testing.T) {
+	txn := &fakes.Transaction{}
+	txn.EvaluateReturns([]byte("result"), nil)
+
+	mockContract := &fakes.Contract{}
+	mockContract.NameReturns("myChaincode")
+	mockContract.CreateTransactionReturns(txn, nil)
+
+	credentialsList, _ := json.Marshal([]string{
+		asCredentials("peer1:7051", "Org1MSP"),
+		asCredentials("peer2:7051", "Org2MSP"),
+	})
+
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionCalls(func(name string, args ...string) ([]byte, error) {
+		switch name {
+		case "queryChaincodeEndPoints":
+			return []byte("peer1:7051,peer2:7051"), nil
+		case "queryListEnclaveCredentials":
+			return credentialsList, nil
+		}
+		return nil, fmt.Errorf("unexpected call %s", name)
+	})
+
+	contract := fpccontract.New(mockContract, mockERCC, nil, newMockEncryptionProvider(),
+		fpccontract.WithPeerSelectionStrategy(fpccontract.NewPreferLocalOrgStrategy("Org2MSP")),
+	)
+
+	for i := 0; i < 3; i++ {
+		_, err := contract.EvaluateTransaction("someFunction", "arg1")
+		assert.NoError(t, err)
+		_, peers := mockContract.CreateTransactionArgsForCall(i)
+		assert.Equal(t, []string{"peer2:7051"}, peers)
+	}
+
+	// invalid credentials list
+	mockERCC.EvaluateTransactionReturns([]byte("not a json list"), nil)
+	mockERCC.EvaluateTransactionCalls(nil)
+	contract = fpccontract.New(mockContract, mockERCC, []string{"peer1:7051"}, newMockEncryptionProvider(),
+		fpccontract.WithPeerSelectionStrategy(fpccontract.NewPreferLocalOrgStrategy("Org2MSP")),
+	)
+	_, err := contract.EvaluateTransaction("someFunction", "arg1")
+	assert.Error(t, err)
+}
+
+func TestContractExpiredEnclaves(t *testing.T) {
+	txn := &fakes.Transaction{}
+	txn.EvaluateReturns([]byte("result"), nil)
+
+	mockContract := &fakes.Contract{}
+	mockContract.NameReturns("myChaincode")
+	mockContract.CreateTransactionReturns(txn, nil)
+
+	// ERCC does not return endpoints of enclaves with expired credentials
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionReturns([]byte(""), nil)
+	contract := fpccontract.New(mockContract, mockERCC, nil, newMockEncryptionProvider())
+	_, err := contract.EvaluateTransaction("someFunction", "arg1")
+	assert.EqualError(t, err, "no enclave with valid credentials available for chaincode myChaincode")
+
+	// the organization of an enclave with expired credentials is unknown
+	serializedAttestedData, _ := anypb.New(&protos.AttestedData{
+		HostParams: &protos.HostParameters{
+			PeerEndpoint: "peer2:7051",
+			PeerMspId:    "Org2MSP",
+		},
+	})
+	expired := utils.MarshallProtoBase64(&protos.Credentials{
+		SerializedAttestedData: serializedAttestedData,
+		ExpiresAt:              timestamppb.New(time.Now().Add(-time.Minute)),
+	})
+	credentialsList, _ := json.Marshal([]string{
+		asCredentials("peer1:7051", "Org1MSP"),
+		expired,
+	})
+	mockERCC.EvaluateTransactionCalls(func(name string, args ...string) ([]byte, error) {
+		switch name {
+		case "queryChaincodeEndPoints":
+			return []byte("peer1:7051,peer2:7051"), nil
+		case "queryListEnclaveCredentials":
+			return credentialsList, nil
+		}
+		return nil, fmt.Errorf("unexpected call %s", name)
+	})
+	contract = fpccontract.New(mockContract, mockERCC, nil, newMockEncryptionProvider(),
+		fpccontract.WithPeerSelectionStrategy(fpccontract.NewPreferLocalOrgStrategy("Org2MSP")),
+	)
+	_, err = contract.EvaluateTransaction("someFunction", "arg1")
+	assert.NoError(t, err)
+	_, peers := mockContract.CreateTransactionArgsForCall(0)
+	assert.Equal(t, []string{"peer1:7051"}, peers)
+}
+
+func TestContractEnclaveCache(t *testing.T) {
+	txn := &fakes.Transaction{}
+	txn.EvaluateReturns([]byte("result"), nil)
+
+	mockContract := &fakes.Contract{}
+	mockContract.NameReturns("myChaincode")
+	mockContract.CreateTransactionReturns(txn, nil)
+
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	queries := make(map[string]int)
+	endpoints := "peer1:7051,peer2:7051"
+	credentialsList, _ := json.Marshal([]string{
+		asCredentials("peer1:7051", "Org1MSP"),
+		asExpiringCredentials("peer2:7051", "Org2MSP", now.Add(time.Minute)),
+	})
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionCalls(func(name string, args ...string) ([]byte, error) {
+		queries[name]++
+		switch name {
+		case "queryChaincodeEndPoints":
+			return []byte(endpoints), nil
+		case "queryListEnclaveCredentials":
+			return credentialsList, nil
+		}
+		return nil, fmt.Errorf("unexpected call %s", name)
+	})
+
+	// the endpoints are cached
+	contract := fpccontract.New(mockContract, mockERCC, nil, newMockEncryptionProvider())
+	fpccontract.SetClock(contract, clock)
+	for i := 0; i < 2; i++ {
+		_, err := contract.EvaluateTransaction("someFunction", "arg1")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, queries["queryChaincodeEndPoints"])
+
+	// until a request fails at the enclave peers
+	txn.EvaluateReturnsOnCall(2, nil, fmt.Errorf("credentials expired"))
+	endpoints = "peer1:7051"
+	_, err := contract.EvaluateTransaction("someFunction", "arg1")
+	assert.Error(t, err)
+	_, err = contract.EvaluateTransaction("someFunction", "arg1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, queries["queryChaincodeEndPoints"])
+	_, peers := mockContract.CreateTransactionArgsForCall(3)
+	assert.Equal(t, []string{"peer1:7051"}, peers)
+
+	// or the ttl passes
+	contract = fpccontract.New(mockContract, mockERCC, nil, newMockEncryptionProvider(), fpccontract.WithEnclaveCacheTTL(0))
+	for i := 0; i < 2; i++ {
+		_, err := contract.EvaluateTransaction("someFunction", "arg1")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 4, queries["queryChaincodeEndPoints"])
+
+	// the organizations of the enclave peers are cached until the first credentials expire
+	endpoints = "peer1:7051,peer2:7051"
+	contract = fpccontract.New(mockContract, mockERCC, nil, newMockEncryptionProvider(),
+		fpccontract.WithPeerSelectionStrategy(fpccontract.NewPreferLocalOrgStrategy("Org2MSP")),
+		fpccontract.WithMaxAttempts(1),
+	)
+	fpccontract.SetClock(contract, clock)
+	_, err = contract.EvaluateTransaction("someFunction", "arg1")
+	assert.NoError(t, err)
+	_, peers = mockContract.CreateTransactionArgsForCall(mockContract.CreateTransactionCallCount() - 1)
+	assert.Equal(t, []string{"peer2:7051"}, peers)
+	assert.Equal(t, 1, queries["queryListEnclaveCredentials"])
+
+	// ERCC no longer returns the enclave with expired credentials
+	endpoints = "peer1:7051"
+	now = now.Add(2 * time.Minute)
+	_, err = contract.EvaluateTransaction("someFunction", "arg1")
+	assert.NoError(t, err)
+	_, peers = mockContract.CreateTransactionArgsForCall(mockContract.CreateTransactionCallCount() - 1)
+	assert.Equal(t, []string{"peer1:7051"}, peers)
+	assert.Equal(t, 2, queries["queryListEnclaveCredentials"])
+	assert.Equal(t, 6, queries["queryChaincodeEndPoints"])
+}
+
+func asExpiringCredentials(endpoint, mspId string, expiresAt time.Time) string {
+	serializedAttestedData, _ := anypb.New(&protos.AttestedData{
+		HostParams: &protos.HostParameters{
+			PeerEndpoint: endpoint,
+			PeerMspId:    mspId,
+		},
+	})
+	return utils.MarshallProtoBase64(&protos.Credentials{SerializedAttestedData: serializedAttestedData, ExpiresAt: timestamppb.New(expiresAt)})
+}
+
+func asCredentials(endpoint, mspId string) string {
+	serializedAttestedData, _ := anypb.New(&protos.AttestedData{
+		HostParams: &protos.HostParameters{
+			PeerEndpoint: endpoint,
+			PeerMspId:    mspId,
+		},
+	})
+	return utils.MarshallProtoBase64(&protos.Credentials{SerializedAttestedData: serializedAttestedData})
+}
+
 func asResponseBytes(input []byte) []byte {
 	return protoutil.MarshalOrPanic(&peer.Response{Payload: input, Status: 200})
 }
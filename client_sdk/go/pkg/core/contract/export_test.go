@@ -0,0 +1,14 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package contract
+
+import "time"
+
+// SetClock replaces the clock used to expire the cached enclave peers and their credentials
+func SetClock(c *contractImpl, now func() time.Time) {
+	c.cache.now = now
+}
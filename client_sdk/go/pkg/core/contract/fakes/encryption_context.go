@@ -33,6 +33,20 @@ type EncryptionContext struct {
 		result1 []byte
 		result2 error
 	}
+	RevealBatchResponseStub        func([]byte, int) ([]byte, error)
+	revealBatchResponseMutex       sync.RWMutex
+	revealBatchResponseArgsForCall []struct {
+		arg1 []byte
+		arg2 int
+	}
+	revealBatchResponseReturns struct {
+		result1 []byte
+		result2 error
+	}
+	revealBatchResponseReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -176,13 +190,79 @@ func (fake *EncryptionContext) RevealReturnsOnCall(i int, result1 []byte, result
 	}{result1, result2}
 }
 
+func (fake *EncryptionContext) RevealBatchResponse(arg1 []byte, arg2 int) ([]byte, error) {
+	var arg1Copy []byte
+	if arg1 != nil {
+		arg1Copy = make([]byte, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.revealBatchResponseMutex.Lock()
+	ret, specificReturn := fake.revealBatchResponseReturnsOnCall[len(fake.revealBatchResponseArgsForCall)]
+	fake.revealBatchResponseArgsForCall = append(fake.revealBatchResponseArgsForCall, struct {
+		arg1 []byte
+		arg2 int
+	}{arg1Copy, arg2})
+	stub := fake.RevealBatchResponseStub
+	fakeReturns := fake.revealBatchResponseReturns
+	fake.recordInvocation("RevealBatchResponse", []interface{}{arg1Copy, arg2})
+	fake.revealBatchResponseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *EncryptionContext) RevealBatchResponseCallCount() int {
+	fake.revealBatchResponseMutex.RLock()
+	defer fake.revealBatchResponseMutex.RUnlock()
+	return len(fake.revealBatchResponseArgsForCall)
+}
+
+func (fake *EncryptionContext) RevealBatchResponseCalls(stub func([]byte, int) ([]byte, error)) {
+	fake.revealBatchResponseMutex.Lock()
+	defer fake.revealBatchResponseMutex.Unlock()
+	fake.RevealBatchResponseStub = stub
+}
+
+func (fake *EncryptionContext) RevealBatchResponseArgsForCall(i int) ([]byte, int) {
+	fake.revealBatchResponseMutex.RLock()
+	defer fake.revealBatchResponseMutex.RUnlock()
+	argsForCall := fake.revealBatchResponseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *EncryptionContext) RevealBatchResponseReturns(result1 []byte, result2 error) {
+	fake.revealBatchResponseMutex.Lock()
+	defer fake.revealBatchResponseMutex.Unlock()
+	fake.RevealBatchResponseStub = nil
+	fake.revealBatchResponseReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *EncryptionContext) RevealBatchResponseReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.revealBatchResponseMutex.Lock()
+	defer fake.revealBatchResponseMutex.Unlock()
+	fake.RevealBatchResponseStub = nil
+	if fake.revealBatchResponseReturnsOnCall == nil {
+		fake.revealBatchResponseReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.revealBatchResponseReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *EncryptionContext) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.concealMutex.RLock()
-	defer fake.concealMutex.RUnlock()
-	fake.revealMutex.RLock()
-	defer fake.revealMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
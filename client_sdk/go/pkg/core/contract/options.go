@@ -0,0 +1,110 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package contract
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/csp"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+)
+
+// DefaultUnhealthyPeerCoolDown is the default duration an enclave peer is deprioritized after a failed request
+const DefaultUnhealthyPeerCoolDown = 30 * time.Second
+
+// DefaultEnclaveCacheTTL is the default duration for which the enclave peers queried from ERCC are cached
+const DefaultEnclaveCacheTTL = time.Minute
+
+// Option configures a FPC contract created with GetContract or New
+type Option func(*contractImpl)
+
+// WithPeerSelectionStrategy enables failover across the FPC endorsing peers.
+// Instead of sending `__invoke` to all enclave peers at once, each attempt targets a single peer as ordered by the
+// given strategy; if the attempt fails, the next peer is tried (see also WithMaxAttempts).
+func WithPeerSelectionStrategy(strategy PeerSelectionStrategy) Option {
+	return func(c *contractImpl) {
+		c.strategy = strategy
+	}
+}
+
+// WithMaxAttempts limits the number of enclave peers tried per request when a PeerSelectionStrategy is set.
+// By default, all available enclave peers are tried.
+func WithMaxAttempts(attempts int) Option {
+	return func(c *contractImpl) {
+		c.maxAttempts = attempts
+	}
+}
+
+// WithUnhealthyPeerCoolDown sets the duration an enclave peer is deprioritized after a failed request.
+// If not set, DefaultUnhealthyPeerCoolDown is used.
+func WithUnhealthyPeerCoolDown(coolDown time.Duration) Option {
+	return func(c *contractImpl) {
+		c.health = newPeerHealth(coolDown)
+	}
+}
+
+// WithPeerEndpoints sets the enclave peer endpoints (in the format `host:port`) used for all requests.
+// If not set, the endpoints are queried from ERCC using `queryChaincodeEndPoints`.
+func WithPeerEndpoints(peerEndpoints ...string) Option {
+	return func(c *contractImpl) {
+		c.peerEndpoints = peerEndpoints
+	}
+}
+
+// WithEnclaveCacheTTL sets the duration for which the enclave peer endpoints and their organizations queried from
+// ERCC are cached; afterwards, ERCC is queried again such that enclaves whose credentials expired in the meantime are
+// no longer used. The cache is also dropped when a request fails at all enclave peers. A ttl of 0 disables caching.
+// If not set, DefaultEnclaveCacheTTL is used.
+func WithEnclaveCacheTTL(ttl time.Duration) Option {
+	return func(c *contractImpl) {
+		c.cache = newEnclaveCache(ttl)
+	}
+}
+
+// WithResponseConsistencyCheck enables Byzantine-resilient execution across multiple enclaves.
+// Each `__invoke` and `__invokeBatch` request is sent to every enclave peer individually, and the decrypted responses
+// as well as the read/write sets returned by the enclaves are compared. If they diverge, a *ResponseDivergenceError is
+// returned; otherwise, a single consistent response is used for `__endorse`.
+// At least minResponses enclaves must respond successfully; if minResponses is 0, all enclave peers must respond.
+// Note that this option takes precedence over WithPeerSelectionStrategy.
+func WithResponseConsistencyCheck(minResponses int) Option {
+	return func(c *contractImpl) {
+		c.checkConsistency = true
+		c.minResponses = minResponses
+	}
+}
+
+// WithCipherSuites sets the cipher suites (see package csp) acceptable for encrypting requests in order of preference;
+// the first suite also supported by the chaincode is used. If not set, the ECIES suites are preferred over RSA-OAEP.
+// Note that this option only applies to the default encryption provider (i.e., crypto.EncryptionProviderImpl).
+func WithCipherSuites(suites ...csp.CipherSuite) Option {
+	return func(c *contractImpl) {
+		if ep, ok := c.ep.(*crypto.EncryptionProviderImpl); ok {
+			ep.CipherSuites = suites
+		}
+	}
+}
+
+// WithPostQuantumKeyTransport opts in to the hybrid ML-KEM-768 + X25519 key transport if supported by the chaincode;
+// otherwise, the default cipher suites are used.
+// Note that this option only applies to the default encryption provider (i.e., crypto.EncryptionProviderImpl).
+func WithPostQuantumKeyTransport() Option {
+	return WithCipherSuites(crypto.PostQuantumCipherSuites...)
+}
+
+// WithCSP sets the CSP used to encrypt requests and decrypt responses (see package csp). If not set, csp.Default() is
+// used. Note that the encryption only involves the public key of the chaincode and ephemeral symmetric keys; hence,
+// with a PKCS#11 CSP (see package csp/pkcs11), the operations are routed through the CSP but no key is kept in the
+// token.
+// Note that this option only applies to the default encryption provider (i.e., crypto.EncryptionProviderImpl).
+func WithCSP(provider csp.CSP) Option {
+	return func(c *contractImpl) {
+		if ep, ok := c.ep.(*crypto.EncryptionProviderImpl); ok {
+			ep.CSP = provider
+		}
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package contract
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PeerSelectionStrategy defines the order in which the FPC endorsing peers (i.e., the peers hosting an enclave of the
+// FPC chaincode) are tried when calling `__invoke`.
+type PeerSelectionStrategy interface {
+	// Select returns the given peer endpoints in the order in which they should be tried.
+	// The input slice must not be modified.
+	Select(peerEndpoints []string) []string
+}
+
+// orgAwareStrategy is implemented by strategies that require the MSP ID of the organization hosting each enclave peer.
+type orgAwareStrategy interface {
+	PeerSelectionStrategy
+	// SelectWithOrgs is like Select but additionally receives a mapping from peer endpoint to MSP ID.
+	SelectWithOrgs(peerEndpoints []string, mspIds map[string]string) []string
+}
+
+type roundRobinStrategy struct {
+	next uint64
+}
+
+// NewRoundRobinStrategy returns a PeerSelectionStrategy that rotates the starting peer with every request.
+func NewRoundRobinStrategy() PeerSelectionStrategy {
+	return &roundRobinStrategy{}
+}
+
+func (s *roundRobinStrategy) Select(peerEndpoints []string) []string {
+	if len(peerEndpoints) == 0 {
+		return nil
+	}
+
+	offset := int((atomic.AddUint64(&s.next, 1) - 1) % uint64(len(peerEndpoints)))
+	ordered := make([]string, 0, len(peerEndpoints))
+	ordered = append(ordered, peerEndpoints[offset:]...)
+	return append(ordered, peerEndpoints[:offset]...)
+}
+
+type randomStrategy struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// NewRandomStrategy returns a PeerSelectionStrategy that shuffles the peers for every request.
+func NewRandomStrategy() PeerSelectionStrategy {
+	return &randomStrategy{rnd: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+func (s *randomStrategy) Select(peerEndpoints []string) []string {
+	ordered := make([]string, len(peerEndpoints))
+	copy(ordered, peerEndpoints)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rnd.Shuffle(len(ordered), func(i, j int) {
+		ordered[i], ordered[j] = ordered[j], ordered[i]
+	})
+	return ordered
+}
+
+type preferLocalOrgStrategy struct {
+	mspId    string
+	fallback PeerSelectionStrategy
+}
+
+// NewPreferLocalOrgStrategy returns a PeerSelectionStrategy that tries the enclave peers hosted by the organization
+// identified by mspId first, and only then the peers of other organizations.
+// Within each group, peers are ordered using round robin.
+func NewPreferLocalOrgStrategy(mspId string) PeerSelectionStrategy {
+	return &preferLocalOrgStrategy{
+		mspId:    mspId,
+		fallback: NewRoundRobinStrategy(),
+	}
+}
+
+func (s *preferLocalOrgStrategy) Select(peerEndpoints []string) []string {
+	return s.fallback.Select(peerEndpoints)
+}
+
+func (s *preferLocalOrgStrategy) SelectWithOrgs(peerEndpoints []string, mspIds map[string]string) []string {
+	var local, remote []string
+	for _, p := range s.fallback.Select(peerEndpoints) {
+		if mspIds[p] == s.mspId {
+			local = append(local, p)
+		} else {
+			remote = append(remote, p)
+		}
+	}
+	return append(local, remote...)
+}
+
+// peerHealth keeps track of enclave peers that recently failed to serve an `__invoke` request.
+// A failed peer is considered unhealthy until the cool-down period has passed or it succeeds again.
+type peerHealth struct {
+	mu       sync.Mutex
+	coolDown time.Duration
+	failures map[string]time.Time
+	now      func() time.Time
+}
+
+func newPeerHealth(coolDown time.Duration) *peerHealth {
+	return &peerHealth{
+		coolDown: coolDown,
+		failures: make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+func (h *peerHealth) markFailure(endpoint string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures[endpoint] = h.now()
+}
+
+func (h *peerHealth) markSuccess(endpoint string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.failures, endpoint)
+}
+
+func (h *peerHealth) isHealthy(endpoint string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	failedAt, ok := h.failures[endpoint]
+	if !ok {
+		return true
+	}
+	if h.now().Sub(failedAt) >= h.coolDown {
+		delete(h.failures, endpoint)
+		return true
+	}
+	return false
+}
+
+// prioritize moves unhealthy peers to the end, keeping the relative order within healthy and unhealthy peers.
+// Note that unhealthy peers are not dropped, so that we still have a chance to succeed if all peers are marked unhealthy.
+func (h *peerHealth) prioritize(peerEndpoints []string) []string {
+	var healthy, unhealthy []string
+	for _, p := range peerEndpoints {
+		if h.isHealthy(p) {
+			healthy = append(healthy, p)
+		} else {
+			unhealthy = append(unhealthy, p)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
@@ -0,0 +1,39 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package contract_test
+
+import (
+	"testing"
+
+	fpccontract "github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/contract"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundRobinStrategy(t *testing.T) {
+	peers := []string{"peer1:7051", "peer2:7051", "peer3:7051"}
+	s := fpccontract.NewRoundRobinStrategy()
+
+	assert.Equal(t, []string{"peer1:7051", "peer2:7051", "peer3:7051"}, s.Select(peers))
+	assert.Equal(t, []string{"peer2:7051", "peer3:7051", "peer1:7051"}, s.Select(peers))
+	assert.Equal(t, []string{"peer3:7051", "peer1:7051", "peer2:7051"}, s.Select(peers))
+	assert.Equal(t, []string{"peer1:7051", "peer2:7051", "peer3:7051"}, s.Select(peers))
+
+	// input must not be modified
+	assert.Equal(t, []string{"peer1:7051", "peer2:7051", "peer3:7051"}, peers)
+
+	assert.Empty(t, s.Select(nil))
+}
+
+func TestRandomStrategy(t *testing.T) {
+	peers := []string{"peer1:7051", "peer2:7051", "peer3:7051"}
+	s := fpccontract.NewRandomStrategy()
+
+	for i := 0; i < 10; i++ {
+		assert.ElementsMatch(t, peers, s.Select(peers))
+	}
+	assert.Equal(t, []string{"peer1:7051", "peer2:7051", "peer3:7051"}, peers)
+}
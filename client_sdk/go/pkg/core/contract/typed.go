@@ -0,0 +1,184 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	//lint:ignore SA1019 fabric protos still implement the old proto API
+	protoV1 "github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// ChaincodeError is returned if the FPC chaincode completed the invocation with an error status,
+// i.e., the decrypted peer.Response has a status other than 200.
+type ChaincodeError = utils.ChaincodeError
+
+// Invoker is the subset of the Contract interface required by the typed invocation helpers.
+// It is implemented by the FPC contracts of this package as well as by the contracts of the FPC gateway package.
+type Invoker interface {
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+	SubmitTransaction(name string, args ...string) ([]byte, error)
+}
+
+// Codec defines how the typed invocation helpers encode arguments and decode responses.
+type Codec interface {
+	Encode(v any) (string, error)
+	Decode(data []byte, v any) error
+}
+
+// JSONCodec encodes arguments and decodes responses as JSON.
+// String and []byte arguments are passed unmodified.
+var JSONCodec Codec = jsonCodec{}
+
+// ProtoCodec encodes arguments and decodes responses using the protobuf wire format.
+// String and []byte arguments are passed unmodified. Both, APIv1 (e.g., fabric-protos-go) and APIv2 messages are supported.
+var ProtoCodec Codec = protoCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(v any) (string, error) {
+	if s, ok := rawArg(v); ok {
+		return s, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (jsonCodec) Decode(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type protoCodec struct{}
+
+func (protoCodec) Encode(v any) (string, error) {
+	if s, ok := rawArg(v); ok {
+		return s, nil
+	}
+
+	msg, ok := toProtoMessage(v)
+	if !ok {
+		return "", fmt.Errorf("%T is not a proto message", v)
+	}
+
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (protoCodec) Decode(data []byte, v any) error {
+	msg, ok := toProtoMessage(v)
+	if !ok {
+		return fmt.Errorf("%T is not a proto message", v)
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+func toProtoMessage(v any) (proto.Message, bool) {
+	switch msg := v.(type) {
+	case proto.Message:
+		return msg, true
+	case protoV1.Message:
+		return protoV1.MessageV2(msg), true
+	}
+	return nil, false
+}
+
+func rawArg(v any) (string, bool) {
+	switch arg := v.(type) {
+	case string:
+		return arg, true
+	case []byte:
+		return string(arg), true
+	}
+	return "", false
+}
+
+// Evaluate invokes the transaction function fn using EvaluateTransaction and returns the response decoded as T.
+// The arguments and the response are encoded as JSON (see JSONCodec). If the chaincode returns an error status,
+// the returned error is a *ChaincodeError.
+func Evaluate[T any](c Invoker, fn string, args ...any) (T, error) {
+	return EvaluateWithCodec[T](c, JSONCodec, fn, args...)
+}
+
+// Submit invokes the transaction function fn using SubmitTransaction and returns the response decoded as T.
+// The arguments and the response are encoded as JSON (see JSONCodec). If the chaincode returns an error status,
+// the returned error is a *ChaincodeError.
+func Submit[T any](c Invoker, fn string, args ...any) (T, error) {
+	return SubmitWithCodec[T](c, JSONCodec, fn, args...)
+}
+
+// EvaluateWithCodec is like Evaluate but uses the given codec.
+func EvaluateWithCodec[T any](c Invoker, codec Codec, fn string, args ...any) (T, error) {
+	return invokeTyped[T](c.EvaluateTransaction, codec, fn, args...)
+}
+
+// SubmitWithCodec is like Submit but uses the given codec.
+func SubmitWithCodec[T any](c Invoker, codec Codec, fn string, args ...any) (T, error) {
+	return invokeTyped[T](c.SubmitTransaction, codec, fn, args...)
+}
+
+func invokeTyped[T any](invoke func(name string, args ...string) ([]byte, error), codec Codec, fn string, args ...any) (T, error) {
+	var result T
+
+	encodedArgs := make([]string, len(args))
+	for i, arg := range args {
+		encoded, err := codec.Encode(arg)
+		if err != nil {
+			return result, errors.Wrapf(err, "cannot encode argument %d", i)
+		}
+		encodedArgs[i] = encoded
+	}
+
+	payload, err := invoke(fn, encodedArgs...)
+	if err != nil {
+		return result, err
+	}
+
+	if err := decodeResult(codec, payload, &result); err != nil {
+		return result, errors.Wrapf(err, "cannot decode response as %T", result)
+	}
+
+	return result, nil
+}
+
+func decodeResult[T any](codec Codec, payload []byte, result *T) error {
+	switch r := any(result).(type) {
+	case *string:
+		*r = string(payload)
+		return nil
+	case *[]byte:
+		*r = payload
+		return nil
+	}
+
+	if len(payload) == 0 {
+		return nil
+	}
+
+	// for pointer types such as proto messages we allocate a new value to decode into
+	if t := reflect.TypeOf(*result); t != nil && t.Kind() == reflect.Ptr {
+		v := reflect.New(t.Elem())
+		if err := codec.Decode(payload, v.Interface()); err != nil {
+			return err
+		}
+		*result = v.Interface().(T)
+		return nil
+	}
+
+	return codec.Decode(payload, result)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package contract_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	fpccontract "github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/contract"
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/contract/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+type asset struct {
+	ID    string `json:"id"`
+	Value int    `json:"value"`
+}
+
+func TestEvaluateJSON(t *testing.T) {
+	expected := asset{ID: "a1", Value: 42}
+	expectedBytes, _ := json.Marshal(expected)
+
+	mockContract := &fakes.Contract{}
+	mockContract.EvaluateTransactionReturns(expectedBytes, nil)
+
+	result, err := fpccontract.Evaluate[asset](mockContract, "getAsset", "a1", 5, asset{ID: "a2"}, []byte("raw"))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	fn, args := mockContract.EvaluateTransactionArgsForCall(0)
+	assert.Equal(t, "getAsset", fn)
+	assert.Equal(t, []string{"a1", "5", `{"id":"a2","value":0}`, "raw"}, args)
+
+	// pointer results are allocated
+	resultPtr, err := fpccontract.Evaluate[*asset](mockContract, "getAsset")
+	assert.NoError(t, err)
+	assert.Equal(t, &expected, resultPtr)
+
+	// string and bytes results are returned as is
+	s, err := fpccontract.Evaluate[string](mockContract, "getAsset")
+	assert.NoError(t, err)
+	assert.Equal(t, string(expectedBytes), s)
+
+	// empty response results in zero value
+	mockContract.EvaluateTransactionReturns(nil, nil)
+	result, err = fpccontract.Evaluate[asset](mockContract, "getAsset")
+	assert.NoError(t, err)
+	assert.Equal(t, asset{}, result)
+
+	// invalid response
+	mockContract.EvaluateTransactionReturns([]byte("not json"), nil)
+	_, err = fpccontract.Evaluate[asset](mockContract, "getAsset")
+	assert.ErrorContains(t, err, "cannot decode response")
+
+	// invalid argument
+	_, err = fpccontract.Evaluate[asset](mockContract, "getAsset", func() {})
+	assert.ErrorContains(t, err, "cannot encode argument 0")
+}
+
+func TestSubmitProto(t *testing.T) {
+	expected := &protos.CCParameters{ChaincodeId: "myChaincode", Sequence: 2}
+	expectedBytes, _ := proto.Marshal(expected)
+
+	mockContract := &fakes.Contract{}
+	mockContract.SubmitTransactionReturns(expectedBytes, nil)
+
+	arg := &peer.ChaincodeInput{Args: [][]byte{[]byte("some arg")}}
+	result, err := fpccontract.SubmitWithCodec[*protos.CCParameters](mockContract, fpccontract.ProtoCodec, "update", arg)
+	assert.NoError(t, err)
+	assert.True(t, proto.Equal(expected, result))
+
+	_, args := mockContract.SubmitTransactionArgsForCall(0)
+	decodedArg := &peer.ChaincodeInput{}
+	assert.NoError(t, fpccontract.ProtoCodec.Decode([]byte(args[0]), decodedArg))
+	assert.Equal(t, arg.Args, decodedArg.Args)
+
+	// non-proto argument
+	_, err = fpccontract.SubmitWithCodec[*protos.CCParameters](mockContract, fpccontract.ProtoCodec, "update", 5)
+	assert.Error(t, err)
+
+	// non-proto result
+	_, err = fpccontract.SubmitWithCodec[asset](mockContract, fpccontract.ProtoCodec, "update")
+	assert.Error(t, err)
+}
+
+func TestChaincodeError(t *testing.T) {
+	txn := &fakes.Transaction{}
+	txn.EvaluateReturns([]byte("encrypted response"), nil)
+
+	mockContract := &fakes.Contract{}
+	mockContract.CreateTransactionReturns(txn, nil)
+
+	mockEncryptionContext := &fakes.EncryptionContext{}
+	mockEncryptionContext.RevealReturns(protoutil.MarshalOrPanic(&peer.Response{Status: 403, Message: "access denied"}), nil)
+	mockEncryptionProvider := &fakes.EncryptionProvider{}
+	mockEncryptionProvider.NewEncryptionContextReturns(mockEncryptionContext, nil)
+
+	contract := fpccontract.New(mockContract, nil, []string{"peer1:7051"}, mockEncryptionProvider)
+
+	_, err := fpccontract.Evaluate[asset](contract, "getAsset", "a1")
+	assert.EqualError(t, err, "access denied")
+
+	var ccErr *fpccontract.ChaincodeError
+	assert.True(t, errors.As(err, &ccErr))
+	assert.Equal(t, int32(403), ccErr.Status)
+	assert.Equal(t, "access denied", ccErr.Message)
+
+	// other errors are not mapped
+	txn.EvaluateReturns(nil, fmt.Errorf("some transport error"))
+	_, err = fpccontract.Evaluate[asset](contract, "getAsset", "a1")
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &ccErr))
+}
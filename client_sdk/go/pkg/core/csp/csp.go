@@ -0,0 +1,40 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package csp provides the cryptographic service providers (CSPs) of the FPC Client SDK, which are set with
+// contract.WithCSP and recipient.WithCSP. Besides the default CSP, which performs all operations in Go, the pkcs11
+// package provides a CSP that keeps the private keys it creates in a PKCS#11 token such as an HSM.
+// The package also defines the cipher suites that can be chosen with contract.WithCipherSuites and
+// recipient.WithCipherSuite.
+package csp
+
+import (
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+)
+
+// CipherSuite identifies the key transport and symmetric encryption used by a client
+type CipherSuite = protos.CipherSuite
+
+const (
+	// CipherSuiteRSAOAEP is the key transport with RSA-OAEP and the encryption with AES-128-GCM
+	CipherSuiteRSAOAEP = protos.CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM
+	// CipherSuiteECIESP256 is the key transport with ECIES on P-256 and the encryption with AES-256-GCM
+	CipherSuiteECIESP256 = protos.CipherSuite_CIPHER_SUITE_ECIES_P256_HKDF_SHA256_AES256_GCM
+	// CipherSuiteECIESX25519 is the key transport with ECIES on X25519 and the encryption with AES-256-GCM
+	CipherSuiteECIESX25519 = protos.CipherSuite_CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM
+	// CipherSuiteHybridMLKEM768X25519 is the hybrid post-quantum key transport with ML-KEM-768 and X25519 and the
+	// encryption with AES-256-GCM
+	CipherSuiteHybridMLKEM768X25519 = protos.CipherSuite_CIPHER_SUITE_HYBRID_MLKEM768_X25519_HKDF_SHA256_AES256_GCM
+)
+
+// CSP performs the cryptographic operations of the FPC Client SDK
+type CSP = crypto.CSP
+
+// Default returns the CSP that performs all cryptographic operations in Go
+func Default() CSP {
+	return crypto.GetDefaultCSP()
+}
@@ -0,0 +1,386 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package pkcs11 implements a crypto.CSP that keeps private keys in a PKCS#11 token (e.g., an HSM or SoftHSM).
+//
+// Private keys created by the CSP never leave the token; instead, NewRSAKeys and NewECDSAKeys return a reference
+// to the key (a PEM block of type "PKCS11 PRIVATE KEY" holding the CKA_ID of the key object), which is accepted
+// wherever the CSP expects a private key, i.e., by SignMessage and PkDecryptMessage. Public keys are returned in the
+// same format as by crypto.GoCrypto. Operations that only involve public keys or (ephemeral) symmetric keys, as well
+// as private keys that are not references, are performed by crypto.GoCrypto.
+//
+// Hence, the CSP only protects keys that the client creates with it, such as the recipient keys of sealed arguments
+// with the RSA-OAEP cipher suite (see recipient.NewKeys). Note that the encryption of FPC requests and responses (see
+// contract.WithCSP) only involves the public key of the chaincode and ephemeral symmetric keys, so that no key is
+// kept in the token in this case.
+//
+//	hsm, err := pkcs11.New(pkcs11.Config{Library: "/usr/lib/softhsm/libsofthsm2.so", Label: "ForFabric", Pin: "98765432"})
+//	defer hsm.Close()
+//	publicKey, privateKey, err := recipient.NewKeys(recipient.WithCSP(hsm), recipient.WithCipherSuite(csp.CipherSuiteRSAOAEP))
+package pkcs11
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric/common/flogging"
+	p11 "github.com/miekg/pkcs11"
+	"github.com/pkg/errors"
+)
+
+var logger = flogging.MustGetLogger("fpc-crypto-pkcs11")
+
+const (
+	// PrivateKeyPEMType is the PEM block type of references to private keys in the token
+	PrivateKeyPEMType = "PKCS11 PRIVATE KEY"
+
+	keyIDLength = 16
+)
+
+// oidNamedCurveP256 is the CKA_EC_PARAMS of secp256r1 (prime256v1), the curve used by crypto.GoCrypto
+var oidNamedCurveP256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+
+// Config identifies the PKCS#11 token used by the CSP
+type Config struct {
+	// Library is the path of the PKCS#11 module, e.g., /usr/lib/softhsm/libsofthsm2.so
+	Library string
+	// Label is the label of the token
+	Label string
+	// Pin is the user pin of the token
+	Pin string
+}
+
+// CSP implements crypto.CSP with a PKCS#11 token
+type CSP struct {
+	crypto.GoCrypto
+
+	ctx *p11.Ctx
+
+	// the session is shared by all operations; PKCS#11 does not allow concurrent operations within a session
+	mu      sync.Mutex
+	session p11.SessionHandle
+}
+
+var _ crypto.CSP = (*CSP)(nil)
+
+// New loads the PKCS#11 module and logs in to the token with the given label
+func New(config Config) (*CSP, error) {
+	ctx := p11.New(config.Library)
+	if ctx == nil {
+		return nil, fmt.Errorf("cannot load PKCS#11 module '%s'", config.Library)
+	}
+
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, errors.Wrap(err, "cannot initialize PKCS#11 module")
+	}
+
+	session, err := openSession(ctx, config.Label, config.Pin)
+	if err != nil {
+		_ = ctx.Finalize()
+		ctx.Destroy()
+		return nil, err
+	}
+
+	return &CSP{ctx: ctx, session: session}, nil
+}
+
+func openSession(ctx *p11.Ctx, label, pin string) (p11.SessionHandle, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot get slots")
+	}
+
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil || info.Label != label {
+			continue
+		}
+
+		session, err := ctx.OpenSession(slot, p11.CKF_SERIAL_SESSION|p11.CKF_RW_SESSION)
+		if err != nil {
+			return 0, errors.Wrapf(err, "cannot open session with token '%s'", label)
+		}
+
+		if err := ctx.Login(session, p11.CKU_USER, pin); err != nil && !isAlreadyLoggedIn(err) {
+			_ = ctx.CloseSession(session)
+			return 0, errors.Wrapf(err, "cannot login to token '%s'", label)
+		}
+
+		logger.Debugf("opened session with token '%s' in slot %d", label, slot)
+		return session, nil
+	}
+
+	return 0, fmt.Errorf("no token with label '%s' found", label)
+}
+
+func isAlreadyLoggedIn(err error) bool {
+	e, ok := err.(p11.Error)
+	return ok && e == p11.CKR_USER_ALREADY_LOGGED_IN
+}
+
+// Close logs out from the token and unloads the PKCS#11 module
+func (c *CSP) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_ = c.ctx.Logout(c.session)
+	_ = c.ctx.CloseSession(c.session)
+	err := c.ctx.Finalize()
+	c.ctx.Destroy()
+	return err
+}
+
+// NewRSAKeys generates a new RSA key pair in the token; the returned private key is a reference to the key
+func (c *CSP) NewRSAKeys() (publicKey []byte, privateKey []byte, e error) {
+	id, err := newKeyID()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	publicTemplate := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, p11.CKO_PUBLIC_KEY),
+		p11.NewAttribute(p11.CKA_KEY_TYPE, p11.CKK_RSA),
+		p11.NewAttribute(p11.CKA_TOKEN, true),
+		p11.NewAttribute(p11.CKA_ENCRYPT, true),
+		p11.NewAttribute(p11.CKA_MODULUS_BITS, crypto.RSAKeyLength),
+		p11.NewAttribute(p11.CKA_PUBLIC_EXPONENT, []byte{1, 0, 1}),
+		p11.NewAttribute(p11.CKA_ID, id),
+	}
+	privateTemplate := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, p11.CKO_PRIVATE_KEY),
+		p11.NewAttribute(p11.CKA_KEY_TYPE, p11.CKK_RSA),
+		p11.NewAttribute(p11.CKA_TOKEN, true),
+		p11.NewAttribute(p11.CKA_PRIVATE, true),
+		p11.NewAttribute(p11.CKA_SENSITIVE, true),
+		p11.NewAttribute(p11.CKA_EXTRACTABLE, false),
+		p11.NewAttribute(p11.CKA_DECRYPT, true),
+		p11.NewAttribute(p11.CKA_ID, id),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pub, _, err := c.ctx.GenerateKeyPair(c.session, []*p11.Mechanism{p11.NewMechanism(p11.CKM_RSA_PKCS_KEY_PAIR_GEN, nil)}, publicTemplate, privateTemplate)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot generate rsa key")
+	}
+
+	attrs, err := c.ctx.GetAttributeValue(c.session, pub, []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_MODULUS, nil),
+		p11.NewAttribute(p11.CKA_PUBLIC_EXPONENT, nil),
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot get rsa public key")
+	}
+
+	rsaPub := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(attrs[0].Value),
+		E: int(new(big.Int).SetBytes(attrs[1].Value).Int64()),
+	}
+
+	publicKey = pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PUBLIC KEY",
+		Bytes: x509.MarshalPKCS1PublicKey(rsaPub),
+	})
+
+	return publicKey, encodeKeyReference(id), nil
+}
+
+// NewECDSAKeys generates a new ECDSA key pair (secp256r1) in the token; the returned private key is a reference to
+// the key
+func (c *CSP) NewECDSAKeys() (publicKey []byte, privateKey []byte, e error) {
+	id, err := newKeyID()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ecParams, err := asn1.Marshal(oidNamedCurveP256)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	publicTemplate := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, p11.CKO_PUBLIC_KEY),
+		p11.NewAttribute(p11.CKA_KEY_TYPE, p11.CKK_EC),
+		p11.NewAttribute(p11.CKA_TOKEN, true),
+		p11.NewAttribute(p11.CKA_VERIFY, true),
+		p11.NewAttribute(p11.CKA_EC_PARAMS, ecParams),
+		p11.NewAttribute(p11.CKA_ID, id),
+	}
+	privateTemplate := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, p11.CKO_PRIVATE_KEY),
+		p11.NewAttribute(p11.CKA_KEY_TYPE, p11.CKK_EC),
+		p11.NewAttribute(p11.CKA_TOKEN, true),
+		p11.NewAttribute(p11.CKA_PRIVATE, true),
+		p11.NewAttribute(p11.CKA_SENSITIVE, true),
+		p11.NewAttribute(p11.CKA_EXTRACTABLE, false),
+		p11.NewAttribute(p11.CKA_SIGN, true),
+		p11.NewAttribute(p11.CKA_ID, id),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pub, _, err := c.ctx.GenerateKeyPair(c.session, []*p11.Mechanism{p11.NewMechanism(p11.CKM_EC_KEY_PAIR_GEN, nil)}, publicTemplate, privateTemplate)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot generate ecdsa key")
+	}
+
+	attrs, err := c.ctx.GetAttributeValue(c.session, pub, []*p11.Attribute{p11.NewAttribute(p11.CKA_EC_POINT, nil)})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot get ecdsa public key")
+	}
+
+	ecdsaPub, err := parseECPoint(attrs[0].Value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	x509encodedPub, err := x509.MarshalPKIXPublicKey(ecdsaPub)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	publicKey = pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: x509encodedPub,
+	})
+
+	return publicKey, encodeKeyReference(id), nil
+}
+
+// SignMessage signs the SHA-256 hash of the message with ECDSA; the signature is ASN.1 encoded as by crypto.GoCrypto
+func (c *CSP) SignMessage(privateKey []byte, message []byte) (signature []byte, e error) {
+	id, ok := decodeKeyReference(privateKey)
+	if !ok {
+		return c.GoCrypto.SignMessage(privateKey, message)
+	}
+
+	hash := sha256.Sum256(message)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key, err := c.findPrivateKey(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.ctx.SignInit(c.session, []*p11.Mechanism{p11.NewMechanism(p11.CKM_ECDSA, nil)}, key); err != nil {
+		return nil, errors.Wrap(err, "cannot initialize signing")
+	}
+	sig, err := c.ctx.Sign(c.session, hash[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot sign message")
+	}
+
+	// PKCS#11 returns r || s
+	if len(sig)%2 != 0 {
+		return nil, fmt.Errorf("unexpected signature length %d", len(sig))
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{
+		R: new(big.Int).SetBytes(sig[:len(sig)/2]),
+		S: new(big.Int).SetBytes(sig[len(sig)/2:]),
+	})
+}
+
+// PkDecryptMessage decrypts a message encrypted with RSA-OAEP (SHA-1), as crypto.GoCrypto
+func (c *CSP) PkDecryptMessage(privateKey []byte, encryptedMessage []byte) (message []byte, e error) {
+	id, ok := decodeKeyReference(privateKey)
+	if !ok {
+		return c.GoCrypto.PkDecryptMessage(privateKey, encryptedMessage)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key, err := c.findPrivateKey(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// using sha1 as used by default with Openssl RSA_PKCS1_OAEP_PADDING
+	params := p11.NewOAEPParams(p11.CKM_SHA_1, p11.CKG_MGF1_SHA1, p11.CKZ_DATA_SPECIFIED, nil)
+	if err := c.ctx.DecryptInit(c.session, []*p11.Mechanism{p11.NewMechanism(p11.CKM_RSA_PKCS_OAEP, params)}, key); err != nil {
+		return nil, errors.Wrap(err, "cannot initialize decryption")
+	}
+	message, err = c.ctx.Decrypt(c.session, encryptedMessage)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decrypt message")
+	}
+
+	return message, nil
+}
+
+// findPrivateKey returns the handle of the private key with the given CKA_ID; the caller must hold c.mu
+func (c *CSP) findPrivateKey(id []byte) (p11.ObjectHandle, error) {
+	template := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, p11.CKO_PRIVATE_KEY),
+		p11.NewAttribute(p11.CKA_ID, id),
+	}
+	if err := c.ctx.FindObjectsInit(c.session, template); err != nil {
+		return 0, errors.Wrap(err, "cannot find private key")
+	}
+	defer func() { _ = c.ctx.FindObjectsFinal(c.session) }()
+
+	objects, _, err := c.ctx.FindObjects(c.session, 1)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot find private key")
+	}
+	if len(objects) == 0 {
+		return 0, fmt.Errorf("private key %x not found", id)
+	}
+	return objects[0], nil
+}
+
+func newKeyID() ([]byte, error) {
+	id := make([]byte, keyIDLength)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+func encodeKeyReference(id []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  PrivateKeyPEMType,
+		Bytes: id,
+	})
+}
+
+func decodeKeyReference(privateKey []byte) ([]byte, bool) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil || block.Type != PrivateKeyPEMType {
+		return nil, false
+	}
+	return block.Bytes, true
+}
+
+// parseECPoint parses the CKA_EC_POINT of a secp256r1 public key, which is a DER-encoded octet string holding the
+// uncompressed point; some modules return the point without encoding
+func parseECPoint(ecPoint []byte) (*ecdsa.PublicKey, error) {
+	var point []byte
+	if rest, err := asn1.Unmarshal(ecPoint, &point); err != nil || len(rest) != 0 {
+		point = ecPoint
+	}
+
+	pub, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse ecdsa public key")
+	}
+	return pub, nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pkcs11
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// findPKCS11Lib returns the SoftHSM configuration used for testing; it can be overridden with the PKCS11_LIB,
+// PKCS11_PIN and PKCS11_LABEL environment variables (as for the Fabric PKCS#11 BCCSP). To run the tests locally:
+//
+//	softhsm2-util --init-token --slot 0 --label ForFabric --so-pin 1234 --pin 98765432
+func findPKCS11Lib() Config {
+	config := Config{
+		Library: os.Getenv("PKCS11_LIB"),
+		Pin:     os.Getenv("PKCS11_PIN"),
+		Label:   os.Getenv("PKCS11_LABEL"),
+	}
+	if config.Library == "" {
+		for _, path := range []string{
+			"/usr/lib/softhsm/libsofthsm2.so",                  // Debian
+			"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so", // Ubuntu
+		} {
+			if _, err := os.Stat(path); err == nil {
+				config.Library = path
+				break
+			}
+		}
+	}
+	if config.Pin == "" {
+		config.Pin = "98765432"
+	}
+	if config.Label == "" {
+		config.Label = "ForFabric"
+	}
+	return config
+}
+
+func newTestCSP(t *testing.T) *CSP {
+	config := findPKCS11Lib()
+	if config.Library == "" {
+		t.Skip("no PKCS#11 module found, set PKCS11_LIB to run this test")
+	}
+
+	csp, err := New(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, csp.Close()) })
+	return csp
+}
+
+func TestPKCS11CSP(t *testing.T) {
+	csp := newTestCSP(t)
+	goCrypto := crypto.NewGoCrypto()
+	msg := []byte("some message")
+
+	// signatures created in the token verify with GoCrypto
+	pubKey, privKey, err := csp.NewECDSAKeys()
+	require.NoError(t, err)
+	assert.Contains(t, string(privKey), PrivateKeyPEMType)
+
+	sig, err := csp.SignMessage(privKey, msg)
+	require.NoError(t, err)
+	assert.NoError(t, goCrypto.VerifyMessage(pubKey, msg, sig))
+	assert.Error(t, goCrypto.VerifyMessage(pubKey, []byte("another message"), sig))
+
+	// messages encrypted with GoCrypto decrypt in the token
+	pubKey, privKey, err = csp.NewRSAKeys()
+	require.NoError(t, err)
+
+	cipher, err := goCrypto.PkEncryptMessage(pubKey, msg)
+	require.NoError(t, err)
+	plain, err := csp.PkDecryptMessage(privKey, cipher)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, plain)
+
+	// unknown keys
+	_, err = csp.SignMessage(encodeKeyReference([]byte("unknown")), msg)
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestKeysOutsideToken(t *testing.T) {
+	// keys that are no references to keys in the token are handled by GoCrypto
+	csp := &CSP{}
+	msg := []byte("some message")
+
+	pubKey, privKey, err := csp.GoCrypto.NewECDSAKeys()
+	require.NoError(t, err)
+	sig, err := csp.SignMessage(privKey, msg)
+	require.NoError(t, err)
+	assert.NoError(t, csp.VerifyMessage(pubKey, msg, sig))
+
+	pubKey, privKey, err = csp.GoCrypto.NewRSAKeys()
+	require.NoError(t, err)
+	cipher, err := csp.PkEncryptMessage(pubKey, msg)
+	require.NoError(t, err)
+	plain, err := csp.PkDecryptMessage(privKey, cipher)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, plain)
+}
+
+func TestParseECPoint(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	point, err := key.PublicKey.Bytes()
+	require.NoError(t, err)
+
+	encoded, err := asn1.Marshal(point)
+	require.NoError(t, err)
+
+	for _, ecPoint := range [][]byte{encoded, point} {
+		pub, err := parseECPoint(ecPoint)
+		assert.NoError(t, err)
+		assert.True(t, key.PublicKey.Equal(pub))
+	}
+
+	_, err = parseECPoint([]byte("invalid"))
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{Library: "/does/not/exist.so"})
+	assert.EqualError(t, err, "cannot load PKCS#11 module '/does/not/exist.so'")
+}
@@ -49,9 +49,12 @@ import (
 )
 
 const (
-	ERCC               = "ercc"
-	InitEnclaveCMD     = "__initEnclave"
-	RegisterEnclaveCMD = "registerEnclave"
+	ERCC                       = "ercc"
+	InitEnclaveCMD             = "__initEnclave"
+	RenewCredentialsCMD        = "__renewCredentials"
+	RegisterEnclaveCMD         = "registerEnclave"
+	RenewEnclaveCredentialsCMD = "renewEnclaveCredentials"
+	IssueRegistrationNonceCMD  = "issueRegistrationNonce"
 )
 
 var logger = flogging.MustGetLogger("fpc-client-lifecycle")
@@ -99,6 +102,18 @@ func New(getChannelClient GetChannelClientFunction) (*Client, error) {
 
 // LifecycleInitEnclave initializes and registers an enclave for a particular FPC chaincode.
 func (rc *Client) LifecycleInitEnclave(channelID string, req LifecycleInitEnclaveRequest) (string, error) {
+	return rc.attestEnclave(channelID, req, InitEnclaveCMD, RegisterEnclaveCMD)
+}
+
+// LifecycleRenewEnclave re-attests the enclave of a particular FPC chaincode hosted at the given peer and renews its
+// registered credentials before they expire. Enclaves with expired credentials are not used for endorsement.
+func (rc *Client) LifecycleRenewEnclave(channelID string, req LifecycleInitEnclaveRequest) (string, error) {
+	return rc.attestEnclave(channelID, req, RenewCredentialsCMD, RenewEnclaveCredentialsCMD)
+}
+
+// attestEnclave requests fresh credentials from the enclave using enclaveCMD and submits them to the enclave registry
+// using registryCMD
+func (rc *Client) attestEnclave(channelID string, req LifecycleInitEnclaveRequest, enclaveCMD string, registryCMD string) (string, error) {
 	err := rc.verifyInitEnclaveRequest(req)
 	if err != nil {
 		return "", err
@@ -116,23 +131,31 @@ func (rc *Client) LifecycleInitEnclave(channelID string, req LifecycleInitEnclav
 	}
 	logger.Debugf("using attestation params: '%v'", req.AttestationParams)
 
+	logger.Debugf("calling issueRegistrationNonce")
+	// the registration nonce is the id of the issuing transaction at the enclave registry
+	nonce, err := channelClient.Execute(ERCC, IssueRegistrationNonceCMD, [][]byte{[]byte(req.ChaincodeID)})
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to execute issue registration nonce")
+	}
+
 	initMsg := &protos.InitEnclaveMessage{
 		PeerEndpoint:      req.EnclavePeerEndpoint,
 		AttestationParams: serializedJSONParams,
+		Nonce:             nonce,
 	}
 
 	// var initOpts []channel.RequestOption
 	// initOpts = append(initOpts, channel.WithRetry(retry.Opts{Attempts: 0}))
 	// initOpts = append(initOpts, channel.WithTargetEndpoints(req.EnclavePeerEndpoint))
 
-	logger.Debugf("calling __initEnclave (%v)", initMsg)
-	// send query to create (init) enclave at the target peer
+	logger.Debugf("calling %s (%v)", enclaveCMD, initMsg)
+	// send query to create (init) enclave, or to renew its credentials, at the target peer
 	payload, err := channelClient.Query(
-		req.ChaincodeID, InitEnclaveCMD, [][]byte{[]byte(utils.MarshallProtoBase64(initMsg))},
+		req.ChaincodeID, enclaveCMD, [][]byte{[]byte(utils.MarshallProtoBase64(initMsg))},
 		req.EnclavePeerEndpoint,
 	)
 	if err != nil {
-		return "", errors.Wrap(err, "Failed to query init enclave")
+		return "", errors.Wrapf(err, "Failed to query %s", enclaveCMD)
 	}
 
 	// convert credentials received from enclave
@@ -141,11 +164,11 @@ func (rc *Client) LifecycleInitEnclave(channelID string, req LifecycleInitEnclav
 		return "", errors.Wrap(err, "credentials conversion error")
 	}
 
-	logger.Debugf("calling registerEnclave")
-	// invoke registerEnclave at enclave registry
-	txID, err := channelClient.Execute(ERCC, RegisterEnclaveCMD, [][]byte{[]byte(convertedCredentials)})
+	logger.Debugf("calling %s", registryCMD)
+	// invoke registerEnclave (or renewEnclaveCredentials) at enclave registry
+	txID, err := channelClient.Execute(ERCC, registryCMD, [][]byte{[]byte(convertedCredentials)})
 	if err != nil {
-		return "", errors.Wrap(err, "Failed to execute register enclave")
+		return "", errors.Wrapf(err, "Failed to execute %s", registryCMD)
 	}
 
 	return txID, nil
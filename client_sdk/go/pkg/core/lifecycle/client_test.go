@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package lifecycle_test
 
 import (
+	"encoding/base64"
 	"fmt"
 	"testing"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/lifecycle"
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/lifecycle/fakes"
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/sgx"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 )
 
 //go:generate counterfeiter -o fakes/channelclient.go -fake-name ChannelClient . chClient
@@ -33,8 +35,9 @@ const (
 	channelID           = "mychannel"
 	chaincodeId         = "my-fpc-chaincode"
 	enclavePeerEndpoint = "mypeer.myorg.example.com"
-	attestationType     = "simulation"
+	attestationType     = "simulated"
 	expectedTxID        = "someTxID"
+	expectedNonce       = "someNonceTxID"
 )
 
 func setupClient(client lifecycle.ChannelClient, converter lifecycle.CredentialConverter) *lifecycle.Client {
@@ -75,12 +78,18 @@ func TestLifecycleInitEnclaveFailedWithInvalidRequest(t *testing.T) {
 	assert.Error(t, err)
 
 	// invalid AttestationParams
-	// TODO implement me once
-	//request = LifecycleInitEnclaveRequest{ChaincodeID: chaincodeId, EnclavePeerEndpoint: enclavePeerEndpoint, AttestationParams: &sgx.AttestationParams{
-	//	AttestationType: "InvalidType",
-	//}}
-	//_, err = client.LifecycleInitEnclave(channelID, request)
-	//assert.Error(t, err)
+	request = lifecycle.LifecycleInitEnclaveRequest{ChaincodeID: chaincodeId, EnclavePeerEndpoint: enclavePeerEndpoint, AttestationParams: &sgx.AttestationParams{
+		AttestationType: "InvalidType",
+	}}
+	_, err = client.LifecycleInitEnclave(channelID, request)
+	assert.ErrorContains(t, err, "attestation type 'InvalidType' is not supported")
+
+	request = lifecycle.LifecycleInitEnclaveRequest{ChaincodeID: chaincodeId, EnclavePeerEndpoint: enclavePeerEndpoint, AttestationParams: &sgx.AttestationParams{
+		AttestationType: "epid-linkable",
+		HexSpid:         "not a spid",
+	}}
+	_, err = client.LifecycleInitEnclave(channelID, request)
+	assert.ErrorContains(t, err, "hex_spid: Does not match pattern")
 }
 
 func TestLifecycleInitEnclaveFailedToCreateChannelClient(t *testing.T) {
@@ -143,10 +152,31 @@ func TestLifecycleInitEnclaveFailedToConvertCredentials(t *testing.T) {
 	assert.ErrorIs(t, err, expectedError)
 }
 
+func TestLifecycleInitEnclaveFailedToIssueNonce(t *testing.T) {
+	expectedError := fmt.Errorf("someNonceError")
+	fakeChannelClient := &fakes.ChannelClient{}
+	fakeChannelClient.ExecuteReturns("", expectedError)
+	fakeConverter := &fakes.CredentialConverter{}
+	client := setupClient(fakeChannelClient, fakeConverter)
+
+	initReq := lifecycle.LifecycleInitEnclaveRequest{
+		ChaincodeID:         chaincodeId,
+		EnclavePeerEndpoint: enclavePeerEndpoint,
+		AttestationParams: &sgx.AttestationParams{
+			AttestationType: attestationType,
+		},
+	}
+
+	_, err := client.LifecycleInitEnclave(channelID, initReq)
+	assert.ErrorIs(t, err, expectedError)
+	assert.Equal(t, 0, fakeChannelClient.QueryCallCount())
+}
+
 func TestLifecycleInitEnclaveFailedToRegisterEnclave(t *testing.T) {
 	expectedError := fmt.Errorf("someRegisterError")
 	fakeChannelClient := &fakes.ChannelClient{}
-	fakeChannelClient.ExecuteReturns("", expectedError)
+	fakeChannelClient.ExecuteReturnsOnCall(0, expectedNonce, nil)
+	fakeChannelClient.ExecuteReturnsOnCall(1, "", expectedError)
 	fakeConverter := &fakes.CredentialConverter{}
 	client := setupClient(fakeChannelClient, fakeConverter)
 
@@ -165,7 +195,8 @@ func TestLifecycleInitEnclaveFailedToRegisterEnclave(t *testing.T) {
 func TestLifecycleInitEnclaveSuccess(t *testing.T) {
 	fakeChannelClient := &fakes.ChannelClient{}
 	fakeChannelClient.QueryReturns(nil, nil)
-	fakeChannelClient.ExecuteReturns(expectedTxID, nil)
+	fakeChannelClient.ExecuteReturnsOnCall(0, expectedNonce, nil)
+	fakeChannelClient.ExecuteReturnsOnCall(1, expectedTxID, nil)
 	fakeConverter := &fakes.CredentialConverter{}
 
 	client := setupClient(fakeChannelClient, fakeConverter)
@@ -183,15 +214,73 @@ func TestLifecycleInitEnclaveSuccess(t *testing.T) {
 	assert.Equal(t, expectedTxID, txId)
 
 	assert.Equal(t, 1, fakeChannelClient.QueryCallCount())
-	assert.Equal(t, 1, fakeChannelClient.ExecuteCallCount())
+	assert.Equal(t, 2, fakeChannelClient.ExecuteCallCount())
 
-	chaincodeID, Fcn, Args, _ := fakeChannelClient.QueryArgsForCall(0)
+	chaincodeID, Fcn, Args := fakeChannelClient.ExecuteArgsForCall(0)
+	assert.Equal(t, lifecycle.ERCC, chaincodeID)
+	assert.Equal(t, lifecycle.IssueRegistrationNonceCMD, Fcn)
+	assert.Equal(t, [][]byte{[]byte(chaincodeId)}, Args)
+
+	chaincodeID, Fcn, Args, _ = fakeChannelClient.QueryArgsForCall(0)
 	assert.Equal(t, chaincodeId, chaincodeID)
 	assert.Equal(t, lifecycle.InitEnclaveCMD, Fcn)
 	assert.Len(t, Args, 1)
+	initMsgBytes, err := base64.StdEncoding.DecodeString(string(Args[0]))
+	assert.NoError(t, err)
+	initMsg, err := utils.UnmarshalInitEnclaveMessage(initMsgBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedNonce, initMsg.Nonce)
 
-	chaincodeID, Fcn, Args = fakeChannelClient.ExecuteArgsForCall(0)
+	chaincodeID, Fcn, Args = fakeChannelClient.ExecuteArgsForCall(1)
 	assert.Equal(t, lifecycle.ERCC, chaincodeID)
 	assert.Equal(t, lifecycle.RegisterEnclaveCMD, Fcn)
 	assert.Len(t, Args, 1)
 }
+
+func TestLifecycleRenewEnclaveSuccess(t *testing.T) {
+	fakeChannelClient := &fakes.ChannelClient{}
+	fakeChannelClient.QueryReturns([]byte("someCredentials"), nil)
+	fakeChannelClient.ExecuteReturnsOnCall(0, expectedNonce, nil)
+	fakeChannelClient.ExecuteReturnsOnCall(1, expectedTxID, nil)
+	fakeConverter := &fakes.CredentialConverter{}
+	fakeConverter.ConvertCredentialsReturns("someConvertedCredentials", nil)
+
+	client := setupClient(fakeChannelClient, fakeConverter)
+
+	renewReq := lifecycle.LifecycleInitEnclaveRequest{
+		ChaincodeID:         chaincodeId,
+		EnclavePeerEndpoint: enclavePeerEndpoint,
+		AttestationParams: &sgx.AttestationParams{
+			AttestationType: attestationType,
+		},
+	}
+
+	txId, err := client.LifecycleRenewEnclave(channelID, renewReq)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedTxID, txId)
+
+	_, Fcn, _ := fakeChannelClient.ExecuteArgsForCall(0)
+	assert.Equal(t, lifecycle.IssueRegistrationNonceCMD, Fcn)
+
+	chaincodeID, Fcn, Args, targets := fakeChannelClient.QueryArgsForCall(0)
+	assert.Equal(t, chaincodeId, chaincodeID)
+	assert.Equal(t, lifecycle.RenewCredentialsCMD, Fcn)
+	assert.Equal(t, []string{enclavePeerEndpoint}, targets)
+	initMsgBytes, err := base64.StdEncoding.DecodeString(string(Args[0]))
+	assert.NoError(t, err)
+	initMsg, err := utils.UnmarshalInitEnclaveMessage(initMsgBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedNonce, initMsg.Nonce)
+	assert.Equal(t, "someCredentials", fakeConverter.ConvertCredentialsArgsForCall(0))
+
+	chaincodeID, Fcn, Args = fakeChannelClient.ExecuteArgsForCall(1)
+	assert.Equal(t, lifecycle.ERCC, chaincodeID)
+	assert.Equal(t, lifecycle.RenewEnclaveCredentialsCMD, Fcn)
+	assert.Equal(t, [][]byte{[]byte("someConvertedCredentials")}, Args)
+
+	// renewal errors are reported
+	fakeChannelClient.ExecuteReturnsOnCall(2, expectedNonce, nil)
+	fakeChannelClient.ExecuteReturnsOnCall(3, "", fmt.Errorf("enclave is not registered"))
+	_, err = client.LifecycleRenewEnclave(channelID, renewReq)
+	assert.EqualError(t, err, "Failed to execute renewEnclaveCredentials: enclave is not registered")
+}
@@ -0,0 +1,128 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package recipient enables clients to seal invocation arguments end-to-end to a specific recipient, e.g., a document
+// for a counterparty. Only the recipient can read a sealed argument; the FPC chaincode only sees and attests its
+// metadata (see chaincode.AttestSealedArgument in ecc_go/chaincode), which the recipient verifies with VerifyMetadata.
+//
+//	// the recipient creates its keys and shares the public key with the sender
+//	publicKey, privateKey, err := recipient.NewKeys()
+//
+//	// the sender seals the document and passes it as argument of a FPC transaction
+//	sealedDocument, err := recipient.Seal(publicKey, document)
+//	signedMetadata, err := contract.SubmitTransaction("sendDocument", sealedDocument)
+//
+//	// the recipient verifies the metadata signed by the enclave and opens the document
+//	metadata, err := recipient.VerifyMetadata(ercc, "my-chaincode", string(signedMetadata), sealedDocument)
+//	document, err := recipient.Open(privateKey, sealedDocument)
+package recipient
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/csp"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/pkg/errors"
+)
+
+// Evaluator queries ERCC, e.g., a Contract of the Fabric Gateway
+type Evaluator interface {
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+}
+
+type options struct {
+	csp         csp.CSP
+	cipherSuite protos.CipherSuite
+}
+
+// Option configures the cryptography used for sealed arguments
+type Option func(*options)
+
+// WithCSP sets the CSP (see package csp), e.g., a PKCS#11 CSP that keeps the recipient private key in an HSM if used
+// with the RSA-OAEP cipher suite. If not set, csp.Default() is used.
+func WithCSP(provider csp.CSP) Option {
+	return func(o *options) {
+		o.csp = provider
+	}
+}
+
+// WithCipherSuite sets the cipher suite of the recipient keys created by NewKeys and used by Seal. If not set,
+// csp.CipherSuiteECIESX25519 is used.
+func WithCipherSuite(id csp.CipherSuite) Option {
+	return func(o *options) {
+		o.cipherSuite = id
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		csp:         crypto.GetDefaultCSP(),
+		cipherSuite: crypto.DefaultSealedArgumentCipherSuite,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// NewKeys creates the keys of a recipient; senders seal arguments with the public key
+func NewKeys(opts ...Option) (publicKey []byte, privateKey []byte, err error) {
+	o := newOptions(opts)
+	suite, err := crypto.NewCipherSuite(o.csp, o.cipherSuite)
+	if err != nil {
+		return nil, nil, err
+	}
+	return suite.NewKeyTransportKeys()
+}
+
+// Seal seals the content to the public key of the recipient; the returned sealed argument is passed as (string)
+// argument of a FPC transaction
+func Seal(recipientPublicKey []byte, content []byte, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	return crypto.SealArgument(o.csp, o.cipherSuite, recipientPublicKey, content)
+}
+
+// Open returns the content of a sealed argument with the private key of the recipient
+func Open(privateKey []byte, sealedArgument string, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	return crypto.OpenSealedArgument(o.csp, privateKey, sealedArgument)
+}
+
+// VerifyMetadata verifies that the signed metadata returned by chaincode.AttestSealedArgument attests the sealed
+// argument and is signed by an enclave of the given chaincode registered at ERCC; it returns the verified metadata.
+func VerifyMetadata(ercc Evaluator, chaincodeID string, signedMetadata string, sealedArgument string, opts ...Option) (*protos.SealedArgumentMetadata, error) {
+	o := newOptions(opts)
+
+	// the (not yet verified) enclave id determines the enclave verification key to use
+	_, metadata, err := crypto.UnmarshalSignedSealedArgumentMetadata(signedMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialsBytes, err := ercc.EvaluateTransaction("queryEnclaveCredentials", chaincodeID, metadata.GetEnclaveId())
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot query enclave credentials")
+	}
+	if len(credentialsBytes) == 0 {
+		return nil, fmt.Errorf("enclave %s is not registered for chaincode %s", metadata.GetEnclaveId(), chaincodeID)
+	}
+
+	credentials, err := utils.UnmarshalCredentials(string(credentialsBytes))
+	if err != nil {
+		return nil, err
+	}
+	attestedData, err := utils.UnmarshalAttestedData(credentials.GetSerializedAttestedData())
+	if err != nil {
+		return nil, err
+	}
+	if attestedData.GetCcParams().GetChaincodeId() != chaincodeID {
+		return nil, fmt.Errorf("enclave %s does not belong to chaincode %s", metadata.GetEnclaveId(), chaincodeID)
+	}
+
+	return crypto.VerifySealedArgumentMetadata(o.csp, attestedData.GetEnclaveVk(), signedMetadata, sealedArgument)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package recipient
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+type erccFunc func(name string, args ...string) ([]byte, error)
+
+func (f erccFunc) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	return f(name, args...)
+}
+
+func TestSealAndOpen(t *testing.T) {
+	for _, id := range []protos.CipherSuite{crypto.DefaultSealedArgumentCipherSuite, protos.CipherSuite_CIPHER_SUITE_HYBRID_MLKEM768_X25519_HKDF_SHA256_AES256_GCM} {
+		pk, sk, err := NewKeys(WithCipherSuite(id))
+		require.NoError(t, err)
+
+		sealedArgument, err := Seal(pk, []byte("some document"), WithCipherSuite(id))
+		require.NoError(t, err)
+
+		content, err := Open(sk, sealedArgument)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("some document"), content)
+	}
+
+	// the public key must match the cipher suite
+	pk, _, err := NewKeys()
+	require.NoError(t, err)
+	_, err = Seal(pk, []byte("some document"), WithCipherSuite(protos.CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM))
+	assert.Error(t, err)
+}
+
+func TestVerifyMetadata(t *testing.T) {
+	csp := crypto.GetDefaultCSP()
+	enclaveVk, enclaveSk, err := csp.NewECDSAKeys()
+	require.NoError(t, err)
+	attestedData, err := anypb.New(&protos.AttestedData{
+		CcParams:  &protos.CCParameters{ChaincodeId: "my-chaincode"},
+		EnclaveVk: enclaveVk,
+	})
+	require.NoError(t, err)
+	enclaveId := utils.GetEnclaveId(&protos.AttestedData{EnclaveVk: enclaveVk})
+
+	ercc := erccFunc(func(name string, args ...string) ([]byte, error) {
+		if name != "queryEnclaveCredentials" {
+			return nil, fmt.Errorf("unexpected function %s", name)
+		}
+		if args[1] != enclaveId {
+			return nil, nil
+		}
+		return []byte(utils.MarshallProtoBase64(&protos.Credentials{SerializedAttestedData: attestedData})), nil
+	})
+
+	pk, _, err := NewKeys()
+	require.NoError(t, err)
+	sealedArgument, err := Seal(pk, []byte("some document"))
+	require.NoError(t, err)
+	sealed, sealedArgumentHash, err := crypto.UnmarshalSealedArgument(sealedArgument)
+	require.NoError(t, err)
+
+	sign := func(enclaveId string) string {
+		metadataBytes, err := proto.Marshal(&protos.SealedArgumentMetadata{
+			SealedArgumentHash: sealedArgumentHash,
+			RecipientPkHash:    sealed.GetRecipientPkHash(),
+			EnclaveId:          enclaveId,
+			Metadata:           []byte("sent by alice"),
+		})
+		require.NoError(t, err)
+		sig, err := csp.SignMessage(enclaveSk, crypto.SealedArgumentMetadataToSign(metadataBytes))
+		require.NoError(t, err)
+		signedMetadataBytes, err := proto.Marshal(&protos.SignedSealedArgumentMetadata{SealedArgumentMetadata: metadataBytes, Signature: sig})
+		require.NoError(t, err)
+		return base64.StdEncoding.EncodeToString(signedMetadataBytes)
+	}
+
+	metadata, err := VerifyMetadata(ercc, "my-chaincode", sign(enclaveId), sealedArgument)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("sent by alice"), metadata.GetMetadata())
+
+	_, err = VerifyMetadata(ercc, "other-chaincode", sign(enclaveId), sealedArgument)
+	assert.EqualError(t, err, fmt.Sprintf("enclave %s does not belong to chaincode other-chaincode", enclaveId))
+
+	_, err = VerifyMetadata(ercc, "my-chaincode", sign("unknown-enclave"), sealedArgument)
+	assert.EqualError(t, err, "enclave unknown-enclave is not registered for chaincode my-chaincode")
+}
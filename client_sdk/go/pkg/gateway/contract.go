@@ -46,6 +46,64 @@ type Contract interface {
 	//  Returns:
 	//  The return value of the transaction function in the smart contract.
 	SubmitTransaction(name string, args ...string) ([]byte, error)
+
+	// SubmitBatch will submit multiple transactions to the ledger within a single Fabric transaction.
+	// The transaction functions are evaluated in the given order by the same enclave, where each transaction
+	// function observes the writes of the previous ones, and are committed atomically.
+	//  Parameters:
+	//  requests are the names and arguments of the transaction functions to be invoked in the smart contract.
+	//
+	//  Returns:
+	//  The return values of the transaction functions in the order of the requests.
+	SubmitBatch(requests ...contract.BatchRequest) ([][]byte, error)
+
+	// WithTargetEndpoints returns a copy of this contract that sends the FPC requests only to the given enclave peers.
+	// The copy shares the peer selection strategy and peer health information with this contract.
+	//  Parameters:
+	//  peerEndpoints are the endpoints of the enclave peers in the format `host:port`.
+	//
+	//  Returns:
+	//  The targeted contract object
+	WithTargetEndpoints(peerEndpoints ...string) Contract
+}
+
+// fpcContractImpl is implemented by the FPC contract of the contract package (see contract.GetContract), whose
+// WithTargetEndpoints returns its own type T
+type fpcContractImpl[T any] interface {
+	Name() string
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+	SubmitTransaction(name string, args ...string) ([]byte, error)
+	SubmitBatch(requests ...contract.BatchRequest) ([][]byte, error)
+	WithTargetEndpoints(peerEndpoints ...string) T
+}
+
+// fpcContract implements Contract by means of the FPC contract of the contract package
+type fpcContract[T fpcContractImpl[T]] struct {
+	impl T
+}
+
+func newFPCContract[T fpcContractImpl[T]](impl T) *fpcContract[T] {
+	return &fpcContract[T]{impl: impl}
+}
+
+func (c *fpcContract[T]) Name() string {
+	return c.impl.Name()
+}
+
+func (c *fpcContract[T]) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	return c.impl.EvaluateTransaction(name, args...)
+}
+
+func (c *fpcContract[T]) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	return c.impl.SubmitTransaction(name, args...)
+}
+
+func (c *fpcContract[T]) SubmitBatch(requests ...contract.BatchRequest) ([][]byte, error) {
+	return c.impl.SubmitBatch(requests...)
+}
+
+func (c *fpcContract[T]) WithTargetEndpoints(peerEndpoints ...string) Contract {
+	return newFPCContract(c.impl.WithTargetEndpoints(peerEndpoints...))
 }
 
 // Network interface that is needed by the FPC contract implementation
@@ -86,9 +144,17 @@ func (cp *contractProvider) GetContract(id string) contract.Contract {
 //	Parameters:
 //	network is an initialized Fabric network object
 //	chaincodeID is the ID of the target chaincode
+//	opts are optional settings such as the peer selection strategy used to fail over across FPC endorsing peers
 //
 //	Returns:
 //	The contract object
-func GetContract(network Network, chaincodeID string) Contract {
-	return contract.GetContract(&contractProvider{network: network}, chaincodeID)
+//
+// Example:
+//
+//	contract := GetContract(network, "my-fpc-chaincode",
+//		contract.WithPeerSelectionStrategy(contract.NewRoundRobinStrategy()),
+//		contract.WithMaxAttempts(2),
+//	)
+func GetContract(network Network, chaincodeID string, opts ...contract.Option) Contract {
+	return newFPCContract(contract.GetContract(&contractProvider{network: network}, chaincodeID, opts...))
 }
@@ -8,13 +8,20 @@ SPDX-License-Identifier: Apache-2.0
 package sgx
 
 import (
+	"embed"
 	"encoding/base64"
 	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/dcap"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/epid"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/sevsnp"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
 	"github.com/pkg/errors"
+	"github.com/xeipuuv/gojsonschema"
 )
 
 const (
@@ -24,6 +31,19 @@ const (
 	SGXCredentialsPathKey = "SGX_CREDENTIALS_PATH"
 )
 
+//go:embed schemas/*.schema.json
+var schemas embed.FS
+
+// paramsSchemas maps the attestation types to the JSON schema of their attestation params (see schemas/)
+var paramsSchemas = map[string]string{
+	simulation.SimulationType: "schemas/simulated.schema.json",
+	epid.LinkableType:         "schemas/epid.schema.json",
+	epid.UnlinkableType:       "schemas/epid.schema.json",
+	dcap.DCAPType:             "schemas/dcap.schema.json",
+	dcap.TDXType:              "schemas/tdx.schema.json",
+	sevsnp.SEVSNPType:         "schemas/sev-snp.schema.json",
+}
+
 // AttestationParams holds additional attestation information that is required to perform LifecycleInitEnclave.
 type AttestationParams struct {
 	AttestationType string `json:"attestation_type"`
@@ -41,11 +61,38 @@ func (p *AttestationParams) ToBase64EncodedJSON() ([]byte, error) {
 	return []byte(base64.StdEncoding.EncodeToString(serializedParams)), nil
 }
 
-// Validate checks that the attestation information are correct.
+// Validate checks that the attestation information are correct, i.e., that there is a converter for the attestation
+// type and that the params match the JSON schema of the attestation type (see schemas/).
 func (p *AttestationParams) Validate() error {
-	// TODO implement me
-	// check that attestation type is correct (epid, cap, simulated)
-	// check that hexspid has right format? etc ...
+	if len(p.AttestationType) == 0 {
+		return errors.New("attestation type is required")
+	}
+
+	supportedTypes := attestation.NewDefaultCredentialConverter().SupportedAttestationTypes()
+	if !contains(supportedTypes, p.AttestationType) {
+		return errors.Errorf("attestation type '%s' is not supported (supported types: %s)", p.AttestationType, strings.Join(supportedTypes, ", "))
+	}
+
+	schemaPath, ok := paramsSchemas[p.AttestationType]
+	if !ok {
+		return errors.Errorf("no attestation params schema for attestation type '%s'", p.AttestationType)
+	}
+	schema, err := schemas.ReadFile(schemaPath)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read attestation params schema for attestation type '%s'", p.AttestationType)
+	}
+
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schema), gojsonschema.NewGoLoader(p))
+	if err != nil {
+		return errors.Wrap(err, "cannot validate attestation params")
+	}
+	if !result.Valid() {
+		var violations []string
+		for _, e := range result.Errors() {
+			violations = append(violations, e.String())
+		}
+		return errors.Errorf("invalid params for attestation type '%s': %s", p.AttestationType, strings.Join(violations, "; "))
+	}
 
 	return nil
 }
@@ -127,3 +174,12 @@ func readFile(path string) (string, error) {
 
 	return strings.TrimSuffix(string(content), "\n"), nil
 }
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -28,7 +28,36 @@ func TestAttestationParamsToBase64EncodedJSON(t *testing.T) {
 }
 
 func TestAttestationParamsValidate(t *testing.T) {
-	// TODO implement with validate
+	validSpid := "00112233445566778899AABBCCDDEEFF"
+
+	for _, p := range []*sgx.AttestationParams{
+		{AttestationType: "simulated"},
+		{AttestationType: "simulated", HexSpid: "ignored", SigRL: "ignored"},
+		{AttestationType: "epid-linkable", HexSpid: validSpid},
+		{AttestationType: "epid-unlinkable", HexSpid: validSpid, SigRL: "c29tZSBzaWdybA=="},
+		{AttestationType: "dcap"},
+		{AttestationType: "tdx"},
+		{AttestationType: "sev-snp"},
+	} {
+		assert.NoError(t, p.Validate(), "attestation type %s", p.AttestationType)
+	}
+
+	for _, tc := range []struct {
+		params        *sgx.AttestationParams
+		expectedError string
+	}{
+		{&sgx.AttestationParams{}, "attestation type is required"},
+		{&sgx.AttestationParams{AttestationType: "simulation"}, "attestation type 'simulation' is not supported (supported types: dcap, epid-linkable, epid-unlinkable, sev-snp, simulated, tdx)"},
+		{&sgx.AttestationParams{AttestationType: "epid-linkable"}, "invalid params for attestation type 'epid-linkable': hex_spid: Does not match pattern '^[0-9a-fA-F]{32}$'"},
+		{&sgx.AttestationParams{AttestationType: "epid-linkable", HexSpid: validSpid[1:]}, "hex_spid: Does not match pattern"},
+		{&sgx.AttestationParams{AttestationType: "epid-linkable", HexSpid: "XX" + validSpid[2:]}, "hex_spid: Does not match pattern"},
+		{&sgx.AttestationParams{AttestationType: "epid-unlinkable", HexSpid: validSpid, SigRL: "not base64!"}, "sig_rl: Does not match pattern"},
+		{&sgx.AttestationParams{AttestationType: "dcap", HexSpid: validSpid}, "invalid params for attestation type 'dcap': hex_spid: String length must be less than or equal to 0"},
+		{&sgx.AttestationParams{AttestationType: "tdx", SigRL: "c29tZSBzaWdybA=="}, "sig_rl: String length must be less than or equal to 0"},
+		{&sgx.AttestationParams{AttestationType: "sev-snp", HexSpid: validSpid}, "hex_spid: String length must be less than or equal to 0"},
+	} {
+		assert.ErrorContains(t, tc.params.Validate(), tc.expectedError)
+	}
 }
 
 func TestCreateAttestationParamsFromEnvironment(t *testing.T) {
@@ -0,0 +1,45 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// this tool runs a mock attestation service with IAS- and PCCS-compatible endpoints for hermetic attestation tests.
+// Point the converters to it using `IAS_URL=http://<addr>/sgx/dev/attestation/v4/report` and
+// `PCCS_URL=http://<addr>/sgx/certification/v4`, and the verifiers to the root certificate written to `-root-ca`.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/mockservice"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8081", "listen address")
+	rootCAPath := flag.String("root-ca", "mock_attestation_root_ca.pem", "path to write the PEM-encoded root CA certificate to")
+	quoteStatus := flag.String("quote-status", "OK", "quote status reported by the IAS endpoint")
+	tcbStatus := flag.String("tcb-status", "UpToDate", "TCB status of the mock platform served by the PCCS endpoints")
+	flag.Parse()
+
+	service, err := mockservice.New(mockservice.WithQuoteStatus(*quoteStatus), mockservice.WithTcbStatus(*tcbStatus))
+	exitIfError(err)
+
+	exitIfError(os.WriteFile(*rootCAPath, service.RootCertificatePEM(), 0644))
+
+	fmt.Printf("mock attestation service listening on %s\n", *addr)
+	fmt.Printf("- IAS:  http://%s%s\n", *addr, mockservice.IASPath)
+	fmt.Printf("- PCCS: http://%s%s\n", *addr, mockservice.PCCSPath)
+	fmt.Printf("- root CA certificate: %s\n", *rootCAPath)
+	exitIfError(http.ListenAndServe(*addr, service))
+}
+
+func exitIfError(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -32,8 +32,13 @@ func main() {
 	expectedMrenclave, err := readFile("code_id.txt")
 	exitIfError(err)
 
+	// evidence is produced by the C++ attestation api, which does not issue simulated quotes; the test script
+	// enables legacy attestations explicitly (see simulation.LegacyAttestationsEnv)
+	simulationOpts, err := simulation.LegacyAttestationsFromEnv()
+	exitIfError(err)
+
 	verifier := attestation.NewCredentialVerifier(
-		simulation.NewSimulationVerifier(),
+		simulation.NewSimulationVerifier(simulationOpts...),
 		pdo.NewEpidLinkableVerifier(),
 		pdo.NewEpidUnlinkableVerifier(),
 	)
@@ -9,6 +9,7 @@ package chaincode
 import (
 	"encoding/base64"
 	"fmt"
+	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode/ercc"
@@ -43,8 +44,12 @@ func (t *EnclaveChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response
 	switch function {
 	case "__initEnclave":
 		return t.initEnclave(stub)
+	case "__renewCredentials":
+		return t.renewCredentials(stub)
 	case "__invoke":
 		return t.invoke(stub)
+	case "__invokeBatch":
+		return t.invokeBatch(stub)
 	case "__endorse":
 		return t.endorse(stub)
 	default:
@@ -96,6 +101,44 @@ func (t *EnclaveChaincode) initEnclave(stub shim.ChaincodeStubInterface) pb.Resp
 	return shim.Success([]byte(base64.StdEncoding.EncodeToString(credentialsBytes)))
 }
 
+// renewCredentials creates credentials with a fresh attestation for the already initialized enclave.
+// The credentials are returned like the ones of `__initEnclave` and can be passed to ERCC `renewEnclaveCredentials`.
+func (t *EnclaveChaincode) renewCredentials(stub shim.ChaincodeStubInterface) pb.Response {
+	renewableEnclave, ok := t.Enclave.(RenewableEnclave)
+	if !ok {
+		return shim.Error("credential renewal not supported by enclave")
+	}
+
+	// note that the renewal uses the same input as `__initEnclave`, in particular, a fresh registration nonce
+	initMsg, err := t.Extractor.GetInitEnclaveMessage(stub)
+	if err != nil {
+		errMsg := fmt.Sprintf("getting initEnclave msg failed: %s", err.Error())
+		logger.Error(errMsg)
+		return shim.Error(errMsg)
+	}
+
+	hostParams, err := t.Extractor.GetHostParams(stub)
+	if err != nil {
+		errMsg := fmt.Sprintf("getting host params failed: %s", err.Error())
+		logger.Error(errMsg)
+		return shim.Error(errMsg)
+	}
+
+	serializedHostParams, err := protoutil.Marshal(hostParams)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	credentialsBytes, err := renewableEnclave.RenewCredentials(serializedHostParams, initMsg.AttestationParams)
+	if err != nil {
+		errMsg := fmt.Sprintf("Enclave RenewCredentials function failed: %s", err.Error())
+		logger.Error(errMsg)
+		return shim.Error(errMsg)
+	}
+
+	return shim.Success([]byte(base64.StdEncoding.EncodeToString(credentialsBytes)))
+}
+
 func (t *EnclaveChaincode) invoke(stub shim.ChaincodeStubInterface) pb.Response {
 	var errMsg string
 
@@ -113,6 +156,37 @@ func (t *EnclaveChaincode) invoke(stub shim.ChaincodeStubInterface) pb.Response
 		// likely a chaincode error, so we still want response go back ...
 	}
 
+	return invokeResponse(signedChaincodeResponseMessage, errInvoke, errMsg)
+}
+
+// invokeBatch executes all requests of a batch within a single transaction.
+// The resulting response can be passed to `__endorse` as a response returned by `__invoke`.
+func (t *EnclaveChaincode) invokeBatch(stub shim.ChaincodeStubInterface) pb.Response {
+	var errMsg string
+
+	batchEnclave, ok := t.Enclave.(BatchEnclave)
+	if !ok {
+		return shim.Error("batch invocations not supported by enclave")
+	}
+
+	// note that the batch request message is passed like a normal chaincode request message
+	serializedChaincodeBatchRequest, err := t.Extractor.GetSerializedChaincodeRequest(stub)
+	if err != nil {
+		errMsg = fmt.Sprintf("cannot get chaincode batch request message from input: %s", err.Error())
+		logger.Error(errMsg)
+		return shim.Error(errMsg)
+	}
+
+	signedChaincodeResponseMessage, errInvoke := batchEnclave.ChaincodeInvokeBatch(stub, serializedChaincodeBatchRequest)
+	if errInvoke != nil {
+		errMsg = fmt.Sprintf("t.Enclave.InvokeBatch failed: %s", errInvoke)
+		logger.Error(errMsg)
+	}
+
+	return invokeResponse(signedChaincodeResponseMessage, errInvoke, errMsg)
+}
+
+func invokeResponse(signedChaincodeResponseMessage []byte, errInvoke error, errMsg string) pb.Response {
 	signedChaincodeResponseMessageB64 := []byte(base64.StdEncoding.EncodeToString(signedChaincodeResponseMessage))
 	logger.Debugf("base64-encoded response message: '%s'", signedChaincodeResponseMessageB64)
 
@@ -161,6 +235,15 @@ func (t *EnclaveChaincode) endorse(stub shim.ChaincodeStubInterface) pb.Response
 		return shim.Error(fmt.Sprintf("no credentials found for enclaveId = %s", responseMsg.EnclaveId))
 	}
 
+	// enclaves with expired credentials are unavailable until they are re-attested
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if utils.CredentialsExpired(credentials, txTimestamp.AsTime()) {
+		return shim.Error(fmt.Sprintf("credentials of enclaveId = %s expired at %s", responseMsg.EnclaveId, credentials.GetExpiresAt().AsTime().UTC().Format(time.RFC3339)))
+	}
+
 	attestedData, err := utils.UnmarshalAttestedData(credentials.SerializedAttestedData)
 	if err != nil {
 		return shim.Error(err.Error())
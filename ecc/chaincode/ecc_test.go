@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode/ercc"
@@ -13,6 +14,7 @@ import (
 	"github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
@@ -23,6 +25,18 @@ type enclaveStub interface {
 	Enclave
 }
 
+//counterfeiter:generate -o fakes/batch_enclave.go -fake-name BatchEnclaveStub . batchEnclaveStub
+//lint:ignore U1000 This is just used to generate fake
+type batchEnclaveStub interface {
+	BatchEnclave
+}
+
+//counterfeiter:generate -o fakes/renewable_enclave.go -fake-name RenewableEnclaveStub . renewableEnclaveStub
+//lint:ignore U1000 This is just used to generate fake
+type renewableEnclaveStub interface {
+	RenewableEnclave
+}
+
 //counterfeiter:generate -o fakes/utils.go -fake-name Extractors . extractors
 //lint:ignore U1000 This is just used to generate fake
 type extractors interface {
@@ -158,6 +172,45 @@ func TestInvokeEnclave(t *testing.T) {
 	assert.Equal(t, []byte("someChaincodeRequest"), scr)
 }
 
+func TestInvokeBatch(t *testing.T) {
+	stub := &fakes.ChaincodeStub{}
+	stub.GetFunctionAndParametersReturns("__invokeBatch", nil)
+	_, _, ex, _ := newFakes()
+	expectedErr := fmt.Errorf("some error")
+	expectedResp := []byte("someResponse")
+
+	// enclave does not support batches
+	ecc := newECC(&fakes.EnclaveStub{}, nil, ex, nil)
+	r := ecc.Invoke(stub)
+	expectError(t, "batch invocations not supported by enclave", r)
+
+	ec := &fakes.BatchEnclaveStub{}
+	ecc = &EnclaveChaincode{Enclave: ec, Extractor: ex}
+
+	// error getting chaincode batch request
+	ex.GetSerializedChaincodeRequestReturns(nil, expectedErr)
+	r = ecc.Invoke(stub)
+	expectError(t, fmt.Sprintf("cannot get chaincode batch request message from input: %s", expectedErr), r)
+
+	// error when invoking enclave
+	ex.GetSerializedChaincodeRequestReturns([]byte("someChaincodeBatchRequest"), nil)
+	ec.ChaincodeInvokeBatchReturns(nil, expectedErr)
+	r = ecc.Invoke(stub)
+	expectError(t, fmt.Sprintf("t.Enclave.InvokeBatch failed: %s", expectedErr), r)
+
+	// no error
+	ec.ChaincodeInvokeBatchReturns(expectedResp, nil)
+	r = ecc.Invoke(stub)
+	assert.EqualValues(t, shim.OK, r.Status)
+	p, err := base64.StdEncoding.DecodeString(string(r.Payload))
+	assert.NoError(t, err)
+	assert.EqualValues(t, expectedResp, p)
+	s, scr := ec.ChaincodeInvokeBatchArgsForCall(1)
+	assert.Equal(t, stub, s)
+	assert.Equal(t, []byte("someChaincodeBatchRequest"), scr)
+	assert.Equal(t, 0, ec.ChaincodeInvokeCallCount())
+}
+
 func TestEndorse(t *testing.T) {
 	stub := &fakes.ChaincodeStub{}
 	stub.GetFunctionAndParametersReturns("__endorse", nil)
@@ -260,6 +313,60 @@ func TestEndorse(t *testing.T) {
 	r = ecc.Invoke(stub)
 	assert.EqualValues(t, shim.OK, r.Status)
 	assert.EqualValues(t, []byte("OK"), r.Payload)
+
+	// credentials expired
+	expiresAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	expectedCred.ExpiresAt = timestamppb.New(expiresAt)
+	stub.GetTxTimestampReturns(timestamppb.New(expiresAt.Add(-time.Second)), nil)
+	r = ecc.Invoke(stub)
+	assert.EqualValues(t, shim.OK, r.Status)
+	stub.GetTxTimestampReturns(timestamppb.New(expiresAt.Add(time.Second)), nil)
+	r = ecc.Invoke(stub)
+	expectError(t, "credentials of enclaveId = someEnclaveId expired at 2026-01-01T12:00:00Z", r)
+}
+
+func TestRenewCredentials(t *testing.T) {
+	stub := &fakes.ChaincodeStub{}
+	stub.GetFunctionAndParametersReturns("__renewCredentials", nil)
+	_, _, ex, _ := newFakes()
+	expectedErr := fmt.Errorf("some error")
+
+	// enclave does not support renewal
+	ecc := newECC(&fakes.EnclaveStub{}, nil, ex, nil)
+	r := ecc.Invoke(stub)
+	expectError(t, "credential renewal not supported by enclave", r)
+
+	ec := &fakes.RenewableEnclaveStub{}
+	ecc = &EnclaveChaincode{Enclave: ec, Extractor: ex}
+
+	// error getting init enclave message
+	ex.GetInitEnclaveMessageReturns(nil, expectedErr)
+	r = ecc.Invoke(stub)
+	expectError(t, fmt.Sprintf("getting initEnclave msg failed: %s", expectedErr), r)
+
+	// error getting host params
+	ex.GetInitEnclaveMessageReturns(&protos.InitEnclaveMessage{AttestationParams: []byte("someAttestationParams")}, nil)
+	ex.GetHostParamsReturns(nil, expectedErr)
+	r = ecc.Invoke(stub)
+	expectError(t, fmt.Sprintf("getting host params failed: %s", expectedErr), r)
+
+	// error when renewing
+	ex.GetHostParamsReturns(&protos.HostParameters{Nonce: "someNonce"}, nil)
+	ec.RenewCredentialsReturns(nil, expectedErr)
+	r = ecc.Invoke(stub)
+	expectError(t, fmt.Sprintf("Enclave RenewCredentials function failed: %s", expectedErr), r)
+
+	// no error
+	expectedCreds := []byte("someCredentials")
+	ec.RenewCredentialsReturns(expectedCreds, nil)
+	r = ecc.Invoke(stub)
+	assert.EqualValues(t, shim.OK, r.Status)
+	p, err := base64.StdEncoding.DecodeString(string(r.Payload))
+	assert.NoError(t, err)
+	assert.EqualValues(t, expectedCreds, p)
+	_, attestationParams := ec.RenewCredentialsArgsForCall(1)
+	assert.Equal(t, []byte("someAttestationParams"), attestationParams)
+	assert.Equal(t, 0, ec.InitCallCount())
 }
 
 func expectError(t *testing.T, errorMsg string, r peer.Response) {
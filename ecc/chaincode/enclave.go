@@ -39,3 +39,21 @@ type Enclave interface {
 	// chaincodeRequestMessage and chaincodeResponseMessage are serialized protobuf
 	ChaincodeInvoke(stub shim.ChaincodeStubInterface, chaincodeRequestMessage []byte) (chaincodeResponseMessage []byte, err error)
 }
+
+// BatchEnclave is implemented by enclaves that support batch invocations (i.e., `__invokeBatch`)
+type BatchEnclave interface {
+	Enclave
+
+	// ChaincodeInvokeBatch invokes fpc chaincode inside enclave for each request of the batch within a single transaction
+	// chaincodeBatchRequestMessage and chaincodeResponseMessage are serialized protobuf
+	ChaincodeInvokeBatch(stub shim.ChaincodeStubInterface, chaincodeBatchRequestMessage []byte) (chaincodeResponseMessage []byte, err error)
+}
+
+// RenewableEnclave is implemented by enclaves that support the renewal of their credentials (i.e., `__renewCredentials`)
+type RenewableEnclave interface {
+	Enclave
+
+	// RenewCredentials returns credentials with a fresh attestation for the existing enclave identity and chaincode keys.
+	// The input and output parameters are serialized protobufs
+	RenewCredentials(hostParams, attestationParams []byte) (credentials []byte, err error)
+}
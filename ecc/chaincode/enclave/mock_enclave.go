@@ -18,6 +18,7 @@ import (
 	"strings"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
 	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
 	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
@@ -83,8 +84,14 @@ func (m *MockEnclaveStub) Init(serializedChaincodeParams, serializedHostParamsBy
 		HostParams:  hostParams,
 		ChaincodeEk: ccPublicKey,
 	})
+	issuer := simulation.NewSimulationIssuer(simulation.WithMrenclave(chaincodeParams.GetVersion()))
+	attestation, err := issuer.Issue(serializedAttestedData.GetValue())
+	if err != nil {
+		return nil, err
+	}
+
 	credentials := &protos.Credentials{
-		Attestation:            []byte("{\"attestation_type\":\"simulated\",\"attestation\":\"MA==\"}"),
+		Attestation:            attestation,
 		SerializedAttestedData: serializedAttestedData,
 	}
 	logger.Infof("Create credentials: %s", credentials)
@@ -137,5 +137,6 @@ func (s *ExtractorImpl) GetHostParams(stub shim.ChaincodeStubInterface) (*protos
 		PeerMspId:    mspid,
 		PeerEndpoint: initMsg.PeerEndpoint,
 		Certificate:  nil, // todo
+		Nonce:        initMsg.Nonce,
 	}, nil
 }
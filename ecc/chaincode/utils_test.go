@@ -189,13 +189,15 @@ func TestGetHostParams(t *testing.T) {
 	// no errors
 	stub = &fakes.ChaincodeStub{}
 	stub.GetCreatorReturns(protoutil.MarshalOrPanic(sid), nil)
-	initMsg := &protos.InitEnclaveMessage{PeerEndpoint: PeerEndpoint}
+	initMsg := &protos.InitEnclaveMessage{PeerEndpoint: PeerEndpoint, Nonce: "someNonce"}
 	stub.GetStringArgsReturns([]string{"someFunction", utils.MarshallProtoBase64(initMsg)})
 	hp, err = ex.GetHostParams(stub)
 	assert.NotNil(t, hp)
 	assert.NoError(t, err)
 	assert.EqualValues(t, Mspid, hp.GetPeerMspId())
 	assert.EqualValues(t, PeerEndpoint, hp.GetPeerEndpoint())
+	// the registration nonce is bound into the attested data through the host params
+	assert.EqualValues(t, "someNonce", hp.GetNonce())
 	// Note that currently no certs are implemented
 	assert.Nil(t, hp.GetCertificate())
 }
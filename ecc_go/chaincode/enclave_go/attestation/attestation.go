@@ -7,13 +7,93 @@ SPDX-License-Identifier: Apache-2.0
 package attestation
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/dcap"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/sevsnp"
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/tsm"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
 	"github.com/pkg/errors"
 	"google.golang.org/protobuf/types/known/anypb"
 )
 
-func Issue(attestedData *anypb.Any) ([]byte, error) {
-	issuer := simulation.NewSimulationIssuer()
+// Params are the attestation params passed to the enclave, i.e., a base64-encoded json string as created by the
+// client sdk (see `sgx.AttestationParams`)
+type Params struct {
+	AttestationType string `json:"attestation_type"`
+	HexSpid         string `json:"hex_spid,omitempty"`
+	SigRL           string `json:"sig_rl,omitempty"`
+}
+
+// IssuerFactory returns the issuer for the given attestation params. The mrenclave is the chaincode version, which
+// simulated attestations report as (fake) mrenclave; hardware-backed issuers report the actual measurement instead.
+// A factory returns an error if the issuer is not available on this platform.
+type IssuerFactory func(params *Params, mrenclave string) (*types.Issuer, error)
+
+var (
+	issuersMutex sync.RWMutex
+	issuers      = map[string]IssuerFactory{
+		simulation.SimulationType: newSimulationIssuer,
+		dcap.DCAPType:             newGramineIssuer,
+		dcap.TDXType:              newTDXIssuer,
+		sevsnp.SEVSNPType:         newSEVSNPIssuer,
+	}
+)
+
+// RegisterIssuer makes an issuer available for the given attestation type, replacing any issuer registered before.
+// This allows enclave runtimes to plug in hardware-backed issuers, e.g., for EGo:
+//
+//	attestation.RegisterIssuer(dcap.DCAPType, func(*attestation.Params, string) (*types.Issuer, error) {
+//		return dcap.NewDCAPIssuer(egoQuoteProvider), nil
+//	})
+func RegisterIssuer(attestationType string, factory IssuerFactory) {
+	issuersMutex.Lock()
+	defer issuersMutex.Unlock()
+	issuers[attestationType] = factory
+}
+
+// ParseParams parses the (serialized) attestation params; without params or attestation type, simulated attestations
+// are issued
+func ParseParams(serializedAttestationParams []byte) (*Params, error) {
+	params := &Params{AttestationType: simulation.SimulationType}
+	if len(serializedAttestationParams) == 0 {
+		return params, nil
+	}
+
+	paramsJson, err := base64.StdEncoding.DecodeString(string(serializedAttestationParams))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode attestation params")
+	}
+	if err := json.Unmarshal(paramsJson, params); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal attestation params")
+	}
+
+	if len(params.AttestationType) == 0 {
+		params.AttestationType = simulation.SimulationType
+	}
+	return params, nil
+}
+
+// Issue returns an attestation of the type requested by the attestation params binding the attested data; the
+// mrenclave is only used by simulated attestations (see IssuerFactory)
+func Issue(attestedData *anypb.Any, serializedAttestationParams []byte, mrenclave string) ([]byte, error) {
+	params, err := ParseParams(serializedAttestationParams)
+	if err != nil {
+		return nil, err
+	}
+
+	issuer, err := getIssuer(params, mrenclave)
+	if err != nil {
+		return nil, err
+	}
+
 	att, err := issuer.Issue(attestedData.Value)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot get attestation")
@@ -21,3 +101,49 @@ func Issue(attestedData *anypb.Any) ([]byte, error) {
 
 	return att, nil
 }
+
+func getIssuer(params *Params, mrenclave string) (*types.Issuer, error) {
+	issuersMutex.RLock()
+	factory, ok := issuers[params.AttestationType]
+	available := make([]string, 0, len(issuers))
+	for t := range issuers {
+		available = append(available, t)
+	}
+	issuersMutex.RUnlock()
+
+	if !ok {
+		sort.Strings(available)
+		return nil, fmt.Errorf("attestation type '%s' is not supported by this enclave (supported types: %s)", params.AttestationType, strings.Join(available, ", "))
+	}
+
+	issuer, err := factory(params, mrenclave)
+	if err != nil {
+		return nil, errors.Wrapf(err, "attestation type '%s' is not available", params.AttestationType)
+	}
+	return issuer, nil
+}
+
+func newSimulationIssuer(_ *Params, mrenclave string) (*types.Issuer, error) {
+	return simulation.NewSimulationIssuer(simulation.WithMrenclave(mrenclave)), nil
+}
+
+func newGramineIssuer(_ *Params, _ string) (*types.Issuer, error) {
+	if _, err := os.Stat(dcap.GramineAttestationPath); err != nil {
+		return nil, errors.Wrap(err, "no Gramine attestation interface")
+	}
+	return dcap.NewDCAPIssuer(dcap.NewGramineQuoteProvider(dcap.GramineAttestationPath)), nil
+}
+
+func newTDXIssuer(_ *Params, _ string) (*types.Issuer, error) {
+	if _, err := os.Stat(tsm.DefaultPath); err != nil {
+		return nil, errors.Wrap(err, "no configfs-tsm interface")
+	}
+	return dcap.NewTDXIssuer(), nil
+}
+
+func newSEVSNPIssuer(_ *Params, _ string) (*types.Issuer, error) {
+	if _, err := os.Stat(tsm.DefaultPath); err != nil {
+		return nil, errors.Wrap(err, "no configfs-tsm interface")
+	}
+	return sevsnp.NewSEVSNPIssuer(), nil
+}
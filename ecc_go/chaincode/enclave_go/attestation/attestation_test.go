@@ -0,0 +1,88 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func serializeParams(params string) []byte {
+	return []byte(base64.StdEncoding.EncodeToString([]byte(params)))
+}
+
+func TestParseParams(t *testing.T) {
+	// simulation is the fallback
+	params, err := ParseParams(nil)
+	require.NoError(t, err)
+	assert.Equal(t, simulation.SimulationType, params.AttestationType)
+
+	params, err = ParseParams(serializeParams(`{"hex_spid": ""}`))
+	require.NoError(t, err)
+	assert.Equal(t, simulation.SimulationType, params.AttestationType)
+
+	params, err = ParseParams(serializeParams(`{"attestation_type": "epid-linkable", "hex_spid": "1234", "sig_rl": ""}`))
+	require.NoError(t, err)
+	assert.Equal(t, &Params{AttestationType: "epid-linkable", HexSpid: "1234"}, params)
+
+	_, err = ParseParams([]byte("not base64"))
+	assert.ErrorContains(t, err, "cannot decode attestation params")
+
+	_, err = ParseParams(serializeParams("not json"))
+	assert.ErrorContains(t, err, "cannot unmarshal attestation params")
+}
+
+func TestIssue(t *testing.T) {
+	attestedData := &anypb.Any{Value: []byte("attested data")}
+
+	// simulated attestation reporting the chaincode version as mrenclave
+	att, err := Issue(attestedData, serializeParams(`{"attestation_type": "simulated"}`), "some-mrenclave")
+	require.NoError(t, err)
+	attestation := &types.Attestation{}
+	require.NoError(t, json.Unmarshal(att, attestation))
+	assert.Equal(t, simulation.SimulationType, attestation.Type)
+	evidence := &types.Evidence{Type: attestation.Type, Data: attestation.Data}
+	err = simulation.NewSimulationVerifier().Verify(evidence, &types.ValidationValues{Statement: attestedData.Value, Mrenclave: "some-mrenclave"})
+	assert.NoError(t, err)
+
+	// unknown attestation type
+	_, err = Issue(attestedData, serializeParams(`{"attestation_type": "epid-linkable"}`), "some-mrenclave")
+	assert.EqualError(t, err, "attestation type 'epid-linkable' is not supported by this enclave (supported types: dcap, sev-snp, simulated, tdx)")
+
+	// hardware not available
+	_, err = Issue(attestedData, serializeParams(`{"attestation_type": "sev-snp"}`), "some-mrenclave")
+	assert.ErrorContains(t, err, "attestation type 'sev-snp' is not available: no configfs-tsm interface")
+
+	// plugged in issuer
+	RegisterIssuer("epid-linkable", func(params *Params, mrenclave string) (*types.Issuer, error) {
+		return &types.Issuer{Type: params.AttestationType, Issue: func(customData []byte) ([]byte, error) {
+			return []byte(fmt.Sprintf("%s:%s", params.HexSpid, customData)), nil
+		}}, nil
+	})
+	defer func() {
+		issuersMutex.Lock()
+		delete(issuers, "epid-linkable")
+		issuersMutex.Unlock()
+	}()
+	att, err = Issue(attestedData, serializeParams(`{"attestation_type": "epid-linkable", "hex_spid": "1234"}`), "some-mrenclave")
+	require.NoError(t, err)
+	assert.Equal(t, "1234:attested data", string(att))
+
+	RegisterIssuer("epid-linkable", func(*Params, string) (*types.Issuer, error) {
+		return nil, fmt.Errorf("no SGX device")
+	})
+	_, err = Issue(attestedData, serializeParams(`{"attestation_type": "epid-linkable"}`), "some-mrenclave")
+	assert.EqualError(t, err, "attestation type 'epid-linkable' is not available: no SGX device")
+}
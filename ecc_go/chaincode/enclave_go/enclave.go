@@ -18,6 +18,7 @@ import (
 	"github.com/hyperledger/fabric-private-chaincode/ecc_go/chaincode/enclave_go/attestation"
 	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/bccsp"
 	"github.com/hyperledger/fabric/bccsp/factory"
@@ -38,7 +39,18 @@ type EnclaveStub struct {
 	hostParams           *protos.HostParameters
 	chaincodeParams      *protos.CCParameters
 	fabricCryptoProvider bccsp.BCCSP
-	stubProvider         func(shim.ChaincodeStubInterface, *pb.ChaincodeInput, *readWriteSet, StateEncryptionFunctions) shim.ChaincodeStubInterface
+	stubProvider         func(shim.ChaincodeStubInterface, *pb.ChaincodeInput, *readWriteSet, StateEncryptionFunctions, StateKeyFunctions, EnclaveSigner) shim.ChaincodeStubInterface
+
+	// if set, state keys are mapped with a keyed PRF before they reach the ledger (see EnableHashedStateKeys)
+	hashedStateKeys bool
+	stateKeys       StateKeyFunctions
+
+	// if set, state values and responses are padded before encryption (see SetStatePadding and SetResponsePadding)
+	statePadding    PaddingPolicy
+	responsePadding PaddingPolicy
+
+	// the key prefixes with their own state key (see SetStatePrefixes)
+	statePrefixes []string
 }
 
 func NewEnclaveStub(cc shim.Chaincode) *EnclaveStub {
@@ -51,8 +63,8 @@ func NewEnclaveStub(cc shim.Chaincode) *EnclaveStub {
 		csp:                  crypto.GetDefaultCSP(),
 		ccRef:                cc,
 		fabricCryptoProvider: cryptoProvider,
-		stubProvider: func(stub shim.ChaincodeStubInterface, input *pb.ChaincodeInput, rwset *readWriteSet, sep StateEncryptionFunctions) shim.ChaincodeStubInterface {
-			return NewFpcStubInterface(stub, input, rwset, sep)
+		stubProvider: func(stub shim.ChaincodeStubInterface, input *pb.ChaincodeInput, rwset *readWriteSet, sep StateEncryptionFunctions, skf StateKeyFunctions, signer EnclaveSigner) shim.ChaincodeStubInterface {
+			return NewFpcStubInterface(stub, input, rwset, sep, skf, signer)
 		},
 	}
 }
@@ -68,31 +80,98 @@ func (e *EnclaveStub) Init(serializedChaincodeParams, serializedHostParamsBytes,
 		return nil, errors.Wrap(err, "cannot create new enclave identity")
 	}
 
+	e.hostParams = &protos.HostParameters{}
+	if err := proto.Unmarshal(serializedHostParamsBytes, e.hostParams); err != nil {
+		return nil, err
+	}
+
+	e.chaincodeParams = &protos.CCParameters{}
+	if err := proto.Unmarshal(serializedChaincodeParams, e.chaincodeParams); err != nil {
+		return nil, err
+	}
+
 	// as we currently support a single enclave instance per chaincode, we also generate a new chaincode identity here
 	// this needs to be refactored once multi enclave support will be integrated
-	e.ccKeys, err = NewChaincodeKeys(e.csp)
+	e.ccKeys, err = NewChaincodeKeys(e.csp, e.chaincodeParams.GetChaincodeId())
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot create new enclave identity")
 	}
 
-	e.hostParams = &protos.HostParameters{}
-	if err := proto.Unmarshal(serializedHostParamsBytes, e.hostParams); err != nil {
-		return nil, err
+	e.ccKeys.statePadding = e.statePadding
+	if err := e.ccKeys.SetStatePrefixes(e.statePrefixes...); err != nil {
+		return nil, errors.Wrap(err, "cannot derive state prefix keys")
 	}
 
-	e.chaincodeParams = &protos.CCParameters{}
-	if err := proto.Unmarshal(serializedChaincodeParams, e.chaincodeParams); err != nil {
+	e.stateKeys = cleartextStateKeys{}
+	if e.hashedStateKeys {
+		e.stateKeys, err = e.ccKeys.hashedStateKeys()
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot derive state key secret")
+		}
+	}
+
+	return e.createCredentials(serializedAttestationParams)
+}
+
+// EnableHashedStateKeys enables the hashed state keys mode, in which the keys of the (encrypted) state, including each
+// attribute of composite keys, are mapped with HMAC-SHA256 under a secret derived from the chaincode master secret
+// before they reach the ledger. Hence, the ledger does not reveal the keys, e.g., user names used as composite key
+// attributes, while partial composite key queries keep working. Note that public state (see PutPublicState) is not
+// affected, and that the mode must be enabled before the enclave is initialized.
+func (e *EnclaveStub) EnableHashedStateKeys() {
+	e.hashedStateKeys = true
+}
+
+// SetStatePadding sets the padding policy applied to state values before encryption, where the name passed to the
+// policy is the key used by the chaincode, also with hashed state keys (in which case the padded plaintext includes
+// this key). Padded state remains readable without policy, and state written without policy remains readable with.
+// The policy must be set before the enclave is initialized.
+func (e *EnclaveStub) SetStatePadding(policy PaddingPolicy) {
+	e.statePadding = policy
+}
+
+// SetResponsePadding sets the padding policy applied to chaincode responses before encryption, where the name passed
+// to the policy is the invoked function. Clients remove the padding as indicated by the response message.
+func (e *EnclaveStub) SetResponsePadding(policy PaddingPolicy) {
+	e.responsePadding = policy
+}
+
+// SetStatePrefixes sets the key prefixes with their own state key derived from the chaincode master secret (see
+// ChaincodeKeys.SetStatePrefixes). The prefixes apply to the keys used by the chaincode, also with hashed state keys,
+// and must be set before the enclave is initialized.
+func (e *EnclaveStub) SetStatePrefixes(prefixes ...string) {
+	e.statePrefixes = prefixes
+}
+
+// RenewCredentials returns credentials with a fresh attestation for the existing enclave identity and chaincode keys.
+// The host params are replaced, in particular, to bind the attestation to a new registration nonce.
+func (e *EnclaveStub) RenewCredentials(serializedHostParamsBytes, serializedAttestationParams []byte) ([]byte, error) {
+	logger.Debug("Renew enclave credentials")
+
+	if e.identity == nil {
+		return nil, fmt.Errorf("enclave not yet initliazed")
+	}
+
+	hostParams := &protos.HostParameters{}
+	if err := proto.Unmarshal(serializedHostParamsBytes, hostParams); err != nil {
 		return nil, err
 	}
+	e.hostParams = hostParams
 
+	return e.createCredentials(serializedAttestationParams)
+}
+
+func (e *EnclaveStub) createCredentials(serializedAttestationParams []byte) ([]byte, error) {
 	serializedAttestedData, _ := anypb.New(&protos.AttestedData{
-		EnclaveVk:   e.identity.GetPublicKey(),
-		CcParams:    e.chaincodeParams,
-		HostParams:  e.hostParams,
-		ChaincodeEk: e.ccKeys.GetPublicKey(),
+		EnclaveVk:    e.identity.GetPublicKey(),
+		CcParams:     e.chaincodeParams,
+		HostParams:   e.hostParams,
+		ChaincodeEk:  e.ccKeys.GetPublicKey(),
+		ChaincodeEks: e.ccKeys.GetEncryptionKeys(),
 	})
 
-	att, err := attestation.Issue(serializedAttestedData)
+	// in simulation mode, the enclave reports the chaincode version as its mrenclave
+	att, err := attestation.Issue(serializedAttestedData, serializedAttestationParams, e.chaincodeParams.GetVersion())
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot create attestation")
 	}
@@ -142,54 +221,153 @@ func (e *EnclaveStub) ChaincodeInvoke(stub shim.ChaincodeStubInterface, chaincod
 		return nil, errors.Wrap(err, "signed proposal verification failed")
 	}
 
+	// create a new instance of a FPC RWSet that we pass to the stub and later return with the response
+	rwset := NewReadWriteSet()
+
+	encryptedResponse, _, err := e.invokeRequest(stub, chaincodeRequestMessageBytes, rwset)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &protos.ChaincodeResponseMessage{
+		EncryptedResponse: encryptedResponse,
+		FpcRwSet:          rwset.ToFPCKVSet(),
+		PaddedResponses:   e.responsePadding != nil,
+	}
+
+	return e.signResponse(response, signedProposal, chaincodeRequestMessageBytes)
+}
+
+// ChaincodeInvokeBatch invokes the chaincode for every request of the given (serialized) ChaincodeBatchRequestMessage
+// in order. All requests share a single FPC RWSet, where each request observes the writes of the previous requests,
+// such that the batch is endorsed and committed atomically. If any request fails, the whole batch is aborted.
+func (e *EnclaveStub) ChaincodeInvokeBatch(stub shim.ChaincodeStubInterface, chaincodeBatchRequestMessageBytes []byte) ([]byte, error) {
+	logger.Debug("ChaincodeInvokeBatch")
+
+	signedProposal, err := stub.GetSignedProposal()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.verifySignedProposal(stub, chaincodeBatchRequestMessageBytes); err != nil {
+		return nil, errors.Wrap(err, "signed proposal verification failed")
+	}
+
+	batch, err := utils.UnmarshalChaincodeBatchRequestMessage(chaincodeBatchRequestMessageBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := batch.GetChaincodeRequestMessages()
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("batch contains no requests")
+	}
+
+	rwset := newBatchReadWriteSet()
+	encryptedResponses := make([][]byte, 0, len(requests))
+	for i, chaincodeRequestMessageBytes := range requests {
+		encryptedResponse, status, err := e.invokeRequest(stub, chaincodeRequestMessageBytes, rwset)
+		if err != nil {
+			return nil, errors.Wrapf(err, "batch request %d failed", i)
+		}
+
+		// note that we must not reveal the (confidential) error message of the chaincode here
+		if status >= shim.ERRORTHRESHOLD {
+			return nil, fmt.Errorf("batch aborted as request %d returned status %d", i, status)
+		}
+
+		encryptedResponses = append(encryptedResponses, encryptedResponse)
+	}
+
+	response := &protos.ChaincodeResponseMessage{
+		EncryptedBatchResponses: encryptedResponses,
+		FpcRwSet:                rwset.ToFPCKVSet(),
+		PaddedResponses:         e.responsePadding != nil,
+	}
+
+	return e.signResponse(response, signedProposal, chaincodeBatchRequestMessageBytes)
+}
+
+// writeBuffer is implemented by stubs that buffer the writes of an invocation and write them to the rwset once the
+// chaincode returns
+type writeBuffer interface {
+	flush() error
+}
+
+// invokeRequest decrypts the given (serialized) ChaincodeRequestMessage, invokes the chaincode, and returns the
+// encrypted chaincode response together with its status. All reads and writes are recorded in rwset.
+func (e *EnclaveStub) invokeRequest(stub shim.ChaincodeStubInterface, chaincodeRequestMessageBytes []byte, rwset *readWriteSet) ([]byte, int32, error) {
 	// unmarshal chaincodeRequest
 	chaincodeRequestMessage := &protos.ChaincodeRequestMessage{}
-	err = proto.Unmarshal(chaincodeRequestMessageBytes, chaincodeRequestMessage)
+	err := proto.Unmarshal(chaincodeRequestMessageBytes, chaincodeRequestMessage)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	// the cipher suite chosen by the client for the key transport and the request and response encryption
+	suite, err := e.ccKeys.CipherSuite(chaincodeRequestMessage.GetCipherSuite())
+	if err != nil {
+		return nil, 0, err
 	}
 
 	// get key transport message including the encryption keys for request and response
 	keyTransportMessage, err := e.extractKeyTransportMessage(chaincodeRequestMessage)
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot extract keyTransportMessage")
+		return nil, 0, errors.Wrap(err, "cannot extract keyTransportMessage")
 	}
 
 	// decrypt request
-	cleartextChaincodeRequest, err := e.extractCleartextChaincodeRequest(chaincodeRequestMessage, keyTransportMessage)
+	cleartextChaincodeRequest, err := e.extractCleartextChaincodeRequest(suite, chaincodeRequestMessage, keyTransportMessage)
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot decrypt chaincode request")
+		return nil, 0, errors.Wrap(err, "cannot decrypt chaincode request")
 	}
 
-	// create a new instance of a FPC RWSet that we pass to the stub and later return with the response
-	rwset := NewReadWriteSet()
-
 	// Invoke chaincode
 	// we wrap the stub with our FpcStubInterface
-	fpcStub := e.stubProvider(stub, cleartextChaincodeRequest.GetInput(), rwset, e.ccKeys)
+	fpcStub := e.stubProvider(stub, cleartextChaincodeRequest.GetInput(), rwset, e.ccKeys, e.stateKeys, e.identity)
 	ccResponse := e.ccRef.Invoke(fpcStub)
 
+	// write the state buffered by the stub, e.g., the SKVS
+	if b, ok := fpcStub.(writeBuffer); ok {
+		if err := b.flush(); err != nil {
+			return nil, 0, errors.Wrap(err, "cannot flush state")
+		}
+	}
+
 	// marshal chaincode response
 	ccResponseBytes, err := protoutil.Marshal(&ccResponse)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	if e.responsePadding != nil {
+		function := ""
+		if args := cleartextChaincodeRequest.GetInput().GetArgs(); len(args) > 0 {
+			function = string(args[0])
+		}
+		ccResponseBytes, err = pad(e.responsePadding, function, ccResponseBytes)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "cannot pad response")
+		}
 	}
 
 	//encrypt response
-	encryptedResponse, err := e.csp.EncryptMessage(keyTransportMessage.GetResponseEncryptionKey(), ccResponseBytes)
+	encryptedResponse, err := suite.EncryptMessage(keyTransportMessage.GetResponseEncryptionKey(), ccResponseBytes)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	chaincodeRequestMessageHash := sha256.Sum256(chaincodeRequestMessageBytes)
+	return encryptedResponse, ccResponse.GetStatus(), nil
+}
 
-	response := &protos.ChaincodeResponseMessage{
-		EncryptedResponse:           encryptedResponse,
-		FpcRwSet:                    rwset.ToFPCKVSet(),
-		EnclaveId:                   e.identity.GetEnclaveId(),
-		Proposal:                    signedProposal,
-		ChaincodeRequestMessageHash: chaincodeRequestMessageHash[:],
-	}
+// signResponse completes the given response message with the enclave id, the signed proposal, and the request hash,
+// and returns the serialized SignedChaincodeResponseMessage
+func (e *EnclaveStub) signResponse(response *protos.ChaincodeResponseMessage, signedProposal *pb.SignedProposal, requestMessageBytes []byte) ([]byte, error) {
+	requestMessageHash := sha256.Sum256(requestMessageBytes)
+
+	response.EnclaveId = e.identity.GetEnclaveId()
+	response.Proposal = signedProposal
+	response.ChaincodeRequestMessageHash = requestMessageHash[:]
 
 	responseBytes, err := proto.Marshal(response)
 	if err != nil {
@@ -304,7 +482,7 @@ func (e *EnclaveStub) extractKeyTransportMessage(chaincodeRequestMessage *protos
 	}
 
 	// decrypt key transport message with chaincode decryption key
-	keyTransportMessageBytes, err := e.ccKeys.PkDecryptMessage(chaincodeRequestMessage.GetEncryptedKeyTransportMessage())
+	keyTransportMessageBytes, err := e.ccKeys.PkDecryptMessage(chaincodeRequestMessage.GetCipherSuite(), chaincodeRequestMessage.GetEncryptedKeyTransportMessage())
 	if err != nil {
 		return nil, errors.Wrap(err, "decryption of key transport message failed")
 	}
@@ -325,7 +503,7 @@ func (e *EnclaveStub) extractKeyTransportMessage(chaincodeRequestMessage *protos
 	return keyTransportMessage, err
 }
 
-func (e *EnclaveStub) extractCleartextChaincodeRequest(chaincodeRequestMessage *protos.ChaincodeRequestMessage, keyTransportMessage *protos.KeyTransportMessage) (*protos.CleartextChaincodeRequest, error) {
+func (e *EnclaveStub) extractCleartextChaincodeRequest(suite crypto.CipherSuite, chaincodeRequestMessage *protos.ChaincodeRequestMessage, keyTransportMessage *protos.KeyTransportMessage) (*protos.CleartextChaincodeRequest, error) {
 	if chaincodeRequestMessage.GetEncryptedRequest() == nil {
 		return nil, fmt.Errorf("no encrypted request")
 	}
@@ -335,7 +513,7 @@ func (e *EnclaveStub) extractCleartextChaincodeRequest(chaincodeRequestMessage *
 	}
 
 	// decrypt request
-	clearChaincodeRequestBytes, err := e.csp.DecryptMessage(keyTransportMessage.GetRequestEncryptionKey(), chaincodeRequestMessage.GetEncryptedRequest())
+	clearChaincodeRequestBytes, err := suite.DecryptMessage(keyTransportMessage.GetRequestEncryptionKey(), chaincodeRequestMessage.GetEncryptedRequest())
 	if err != nil {
 		return nil, errors.Wrap(err, "decryption of request failed")
 	}
@@ -7,11 +7,24 @@ SPDX-License-Identifier: Apache-2.0
 package enclave_go
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
 	"strings"
 
 	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/pkg/errors"
+)
+
+const (
+	// stateFormatV1 is the version byte prefixing state ciphertexts that bind the chaincode id and the key as AES-GCM
+	// additional authenticated data. State without version byte is rejected.
+	stateFormatV1 byte = 0x01
+
+	// stateFormatV2 is like stateFormatV1 for padded plaintexts (see PaddingPolicy)
+	stateFormatV2 byte = 0x02
 )
 
 type EnclaveIdentity struct {
@@ -60,35 +73,85 @@ func (e *EnclaveIdentity) GetEnclaveId() string {
 
 type ChaincodeKeys struct {
 	csp          crypto.CSP
+	chaincodeId  string
 	ccPrivateKey []byte
 	ccPublicKey  []byte
-	stateKey     []byte
+
+	// the master secret from which the symmetric keys are derived (see deriveKey)
+	masterSecret []byte
+	stateKeys    *stateKeyTree
+
+	// if set, state is padded before encryption to hide the length of the values
+	statePadding PaddingPolicy
+
+	// the supported cipher suites in order of preference, each with its own key transport keys
+	cipherSuites []*cipherSuiteKeys
+}
+
+type cipherSuiteKeys struct {
+	suite      crypto.CipherSuite
+	publicKey  []byte
+	privateKey []byte
 }
 
 type ChaincodeIdentityFunctions interface {
 	GetPublicKey() []byte
-	PkDecryptMessage(ciphertext []byte) (plaintext []byte, err error)
+	GetEncryptionKeys() []*protos.ChaincodeEncryptionKey
+	CipherSuite(id protos.CipherSuite) (crypto.CipherSuite, error)
+	PkDecryptMessage(id protos.CipherSuite, ciphertext []byte) (plaintext []byte, err error)
 	StateEncryptionFunctions
 }
 
+// StateEncryptionFunctions encrypt and decrypt the value of a key in the world state. The key chosen by the chaincode
+// selects the state key, while the ciphertext is bound to the key on the ledger (see StateKeyFunctions.LedgerKey),
+// i.e., decryption fails if the value is moved to another key.
 type StateEncryptionFunctions interface {
-	EncryptState(plaintext []byte) (ciphertext []byte, err error)
-	DecryptState(ciphertext []byte) (plaintext []byte, err error)
+	// EncryptState encrypts the value of key stored under ledgerKey
+	EncryptState(key string, ledgerKey string, plaintext []byte) (ciphertext []byte, err error)
+	// DecryptState decrypts the value stored under ledgerKey, where keyPrefix is the key of the value or, if the key
+	// is not known before decryption (e.g., for queries on hashed state keys), a prefix of it
+	DecryptState(keyPrefix string, ledgerKey string, ciphertext []byte) (plaintext []byte, err error)
 }
 
-func NewChaincodeKeys(csp crypto.CSP) (*ChaincodeKeys, error) {
+func NewChaincodeKeys(csp crypto.CSP, chaincodeId string) (*ChaincodeKeys, error) {
 	var err error
 	c := &ChaincodeKeys{}
 	c.csp = csp
+	c.chaincodeId = chaincodeId
 
-	// create chaincode encryption keys
+	// create chaincode encryption keys; the RSA keys (i.e., chaincode_ek) serve CIPHER_SUITE_RSA_OAEP_AES128_GCM
 	c.ccPublicKey, c.ccPrivateKey, err = csp.NewRSAKeys()
 	if err != nil {
 		return nil, err
 	}
 
-	// create state key
-	c.stateKey, err = csp.NewSymmetricKey()
+	for _, id := range crypto.SupportedCipherSuites {
+		suite, err := crypto.NewCipherSuite(csp, id)
+		if err != nil {
+			return nil, err
+		}
+
+		keys := &cipherSuiteKeys{suite: suite, publicKey: c.ccPublicKey, privateKey: c.ccPrivateKey}
+		if id != protos.CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM {
+			keys.publicKey, keys.privateKey, err = suite.NewKeyTransportKeys()
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot create keys for cipher suite %v", id)
+			}
+		}
+		c.cipherSuites = append(c.cipherSuites, keys)
+	}
+
+	// create master secret and derive the symmetric keys
+	c.masterSecret = make([]byte, masterSecretLength)
+	if _, err := rand.Read(c.masterSecret); err != nil {
+		return nil, errors.Wrap(err, "cannot create master secret")
+	}
+
+	rootStateKey, err := c.deriveKey(stateKeyLabel, "", crypto.SymKeyLength)
+	if err != nil {
+		return nil, err
+	}
+	c.stateKeys, err = newStateKeyTree(chaincodeId, "", rootStateKey, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -96,19 +159,171 @@ func NewChaincodeKeys(csp crypto.CSP) (*ChaincodeKeys, error) {
 	return c, nil
 }
 
+// deriveKey derives a key from the master secret for the given purpose (label) and context
+func (c *ChaincodeKeys) deriveKey(label string, context string, length int) ([]byte, error) {
+	return deriveKey(c.masterSecret, label, c.chaincodeId, context, length)
+}
+
+// SetStatePrefixes sets the key prefixes with their own state key, e.g., to disclose the state below a prefix only
+// (see StatePrefixKey). The state of a key is encrypted with the state key of its longest prefix, or with the root
+// state key if no prefix matches; the prefixes must be set before any state is written.
+func (c *ChaincodeKeys) SetStatePrefixes(prefixes ...string) error {
+	rootStateKey, err := c.stateKeys.prefixKey("")
+	if err != nil {
+		return err
+	}
+
+	stateKeys, err := newStateKeyTree(c.chaincodeId, "", rootStateKey, prefixes)
+	if err != nil {
+		return err
+	}
+	c.stateKeys = stateKeys
+	return nil
+}
+
+// StatePrefixKey returns the state key of a prefix set with SetStatePrefixes (or of the root prefix ""); with this
+// key, NewDisclosedStateKeys decrypts the state below the prefix.
+func (c *ChaincodeKeys) StatePrefixKey(prefix string) ([]byte, error) {
+	return c.stateKeys.prefixKey(prefix)
+}
+
+// hashedStateKeys returns the hashed state keys (see hmacStateKeys) under a secret derived from the master secret
+func (c *ChaincodeKeys) hashedStateKeys() (*hmacStateKeys, error) {
+	secret, err := c.deriveKey(hmacStateKeysLabel, "", sha256.Size)
+	if err != nil {
+		return nil, err
+	}
+	return newHMACStateKeys(secret), nil
+}
+
 func (c *ChaincodeKeys) GetPublicKey() []byte {
 	return c.ccPublicKey
 }
 
-func (c *ChaincodeKeys) PkDecryptMessage(ciphertext []byte) (plaintext []byte, err error) {
-	return c.csp.PkDecryptMessage(c.ccPrivateKey, ciphertext)
+// GetEncryptionKeys returns the supported cipher suites with their chaincode encryption keys in order of preference
+func (c *ChaincodeKeys) GetEncryptionKeys() []*protos.ChaincodeEncryptionKey {
+	eks := make([]*protos.ChaincodeEncryptionKey, 0, len(c.cipherSuites))
+	for _, keys := range c.cipherSuites {
+		eks = append(eks, &protos.ChaincodeEncryptionKey{CipherSuite: keys.suite.ID(), ChaincodeEk: keys.publicKey})
+	}
+	return eks
+}
+
+// CipherSuite returns the implementation of a supported cipher suite
+func (c *ChaincodeKeys) CipherSuite(id protos.CipherSuite) (crypto.CipherSuite, error) {
+	keys, err := c.cipherSuiteKeys(id)
+	if err != nil {
+		return nil, err
+	}
+	return keys.suite, nil
+}
+
+// PkDecryptMessage decrypts a message encrypted with the chaincode encryption key of the cipher suite
+func (c *ChaincodeKeys) PkDecryptMessage(id protos.CipherSuite, ciphertext []byte) (plaintext []byte, err error) {
+	keys, err := c.cipherSuiteKeys(id)
+	if err != nil {
+		return nil, err
+	}
+	return keys.suite.PkDecryptMessage(keys.privateKey, ciphertext)
+}
+
+func (c *ChaincodeKeys) cipherSuiteKeys(id protos.CipherSuite) (*cipherSuiteKeys, error) {
+	for _, keys := range c.cipherSuites {
+		if keys.suite.ID() == id {
+			return keys, nil
+		}
+	}
+	return nil, errors.Errorf("unsupported cipher suite %v", id)
+}
+
+// EncryptState encrypts the value of a key with the state key of its longest prefix (see SetStatePrefixes); the
+// ciphertext is prefixed with the format version and binds the version, the chaincode id and the ledger key as
+// additional authenticated data. If a padding policy is set, the value is padded before encryption. Note that this
+// does not prevent a peer from presenting an older value of the same key.
+func (c *ChaincodeKeys) EncryptState(key string, ledgerKey string, plaintext []byte) (ciphertext []byte, err error) {
+	stateKey, err := c.stateKeys.stateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	version := stateFormatV1
+	if c.statePadding != nil {
+		version = stateFormatV2
+		plaintext, err = pad(c.statePadding, key, plaintext)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot pad state of key '%s'", key)
+		}
+	}
+
+	ciphertext, err = c.csp.EncryptWithAAD(stateKey, plaintext, stateAAD(version, c.chaincodeId, ledgerKey))
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{version}, ciphertext...), nil
 }
 
-func (c *ChaincodeKeys) EncryptState(plaintext []byte) (ciphertext []byte, err error) {
-	return c.csp.EncryptMessage(c.stateKey, plaintext)
+// DecryptState decrypts the value of a key encrypted with EncryptState and removes the padding, if any
+func (c *ChaincodeKeys) DecryptState(keyPrefix string, ledgerKey string, ciphertext []byte) (plaintext []byte, err error) {
+	return decryptState(c.csp, c.chaincodeId, c.stateKeys, keyPrefix, ledgerKey, ciphertext)
 }
 
-func (c *ChaincodeKeys) DecryptState(ciphertext []byte) (plaintext []byte, err error) {
-	return c.csp.DecryptMessage(c.stateKey, ciphertext)
+// DisclosedStateKeys decrypt the state below a prefix with the state key of this prefix (see
+// ChaincodeKeys.StatePrefixKey), e.g., in an auditor enclave; the state of other keys remains confidential.
+type DisclosedStateKeys struct {
+	csp       crypto.CSP
+	stateKeys *stateKeyTree
+}
+
+// NewDisclosedStateKeys returns DisclosedStateKeys for the disclosed state key of a prefix, where prefixes are the
+// prefixes below this prefix set with ChaincodeKeys.SetStatePrefixes
+func NewDisclosedStateKeys(csp crypto.CSP, chaincodeId string, prefix string, prefixKey []byte, prefixes ...string) (*DisclosedStateKeys, error) {
+	stateKeys, err := newStateKeyTree(chaincodeId, prefix, prefixKey, prefixes)
+	if err != nil {
+		return nil, err
+	}
+	return &DisclosedStateKeys{csp: csp, stateKeys: stateKeys}, nil
+}
+
+// DecryptState decrypts the value of a key below the disclosed prefix (see ChaincodeKeys.DecryptState)
+func (d *DisclosedStateKeys) DecryptState(keyPrefix string, ledgerKey string, ciphertext []byte) (plaintext []byte, err error) {
+	return decryptState(d.csp, d.stateKeys.chaincodeId, d.stateKeys, keyPrefix, ledgerKey, ciphertext)
+}
+
+func decryptState(csp crypto.CSP, chaincodeId string, stateKeys *stateKeyTree, keyPrefix string, ledgerKey string, ciphertext []byte) (plaintext []byte, err error) {
+	candidates := stateKeys.candidateKeys(keyPrefix)
+	if len(candidates) == 0 {
+		return nil, errors.Errorf("no state key for key '%s'", keyPrefix)
+	}
+
+	if len(ciphertext) == 0 || (ciphertext[0] != stateFormatV1 && ciphertext[0] != stateFormatV2) {
+		return nil, errors.Errorf("cannot decrypt state of key '%s': no format version", ledgerKey)
+	}
+
+	version := ciphertext[0]
+	for _, stateKey := range candidates {
+		if plaintext, err = csp.DecryptWithAAD(stateKey, ciphertext[1:], stateAAD(version, chaincodeId, ledgerKey)); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot decrypt state of key '%s'", ledgerKey)
+	}
+
+	if version == stateFormatV2 {
+		plaintext, err = crypto.Unpad(plaintext)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot decrypt state of key '%s'", ledgerKey)
+		}
+	}
+	return plaintext, nil
+}
 
+// stateAAD returns the additional authenticated data of a key, i.e., version || len(chaincode id) || chaincode id || key
+func stateAAD(version byte, chaincodeId string, key string) []byte {
+	aad := make([]byte, 0, 1+4+len(chaincodeId)+len(key))
+	aad = append(aad, version)
+	aad = binary.BigEndian.AppendUint32(aad, uint32(len(chaincodeId)))
+	aad = append(aad, chaincodeId...)
+	aad = append(aad, key...)
+	return aad
 }
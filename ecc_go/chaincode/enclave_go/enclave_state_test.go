@@ -0,0 +1,137 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateEncryption(t *testing.T) {
+	csp := crypto.GetDefaultCSP()
+	keys, err := NewChaincodeKeys(csp, "some-chaincode")
+	require.NoError(t, err)
+
+	value := []byte("some value")
+	ciphertext, err := keys.EncryptState("key-a", "key-a", value)
+	require.NoError(t, err)
+	assert.Equal(t, stateFormatV1, ciphertext[0])
+
+	plaintext, err := keys.DecryptState("key-a", "key-a", ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, value, plaintext)
+
+	// moving the value to another key must fail
+	_, err = keys.DecryptState("key-b", "key-b", ciphertext)
+	assert.ErrorContains(t, err, "cannot decrypt state of key 'key-b'")
+
+	// as must decrypting it with the same state key but another chaincode id
+	otherKeys := *keys
+	otherKeys.chaincodeId = "other-chaincode"
+	_, err = otherKeys.DecryptState("key-a", "key-a", ciphertext)
+	assert.Error(t, err)
+
+	// state without format version, as written by earlier versions, is rejected
+	stateKey, err := keys.StatePrefixKey("")
+	require.NoError(t, err)
+	legacyCiphertext, err := csp.EncryptMessage(stateKey, value)
+	require.NoError(t, err)
+	_, err = keys.DecryptState("key-a", "key-a", legacyCiphertext)
+	assert.ErrorContains(t, err, "cannot decrypt state of key 'key-a'")
+
+	disclosed, err := NewDisclosedStateKeys(csp, "some-chaincode", "", stateKey)
+	require.NoError(t, err)
+	_, err = disclosed.DecryptState("key-a", "key-a", legacyCiphertext)
+	assert.Error(t, err)
+}
+
+func TestStateEncryptionWithPadding(t *testing.T) {
+	keys, err := NewChaincodeKeys(crypto.GetDefaultCSP(), "some-chaincode")
+	require.NoError(t, err)
+	keys.statePadding, err = NewBucketPadding(64)
+	require.NoError(t, err)
+
+	// values of different length result in ciphertexts of the same length
+	short, err := keys.EncryptState("key-a", "key-a", []byte("1"))
+	require.NoError(t, err)
+	assert.Equal(t, stateFormatV2, short[0])
+	long, err := keys.EncryptState("key-a", "key-a", []byte("1000000"))
+	require.NoError(t, err)
+	assert.Len(t, long, len(short))
+
+	plaintext, err := keys.DecryptState("key-a", "key-a", long)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1000000"), plaintext)
+
+	_, err = keys.DecryptState("key-b", "key-b", long)
+	assert.ErrorContains(t, err, "cannot decrypt state of key 'key-b'")
+
+	// state written without padding stays readable and vice versa
+	unpaddedKeys := *keys
+	unpaddedKeys.statePadding = nil
+	unpadded, err := unpaddedKeys.EncryptState("key-a", "key-a", []byte("1"))
+	require.NoError(t, err)
+	plaintext, err = keys.DecryptState("key-a", "key-a", unpadded)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), plaintext)
+	plaintext, err = unpaddedKeys.DecryptState("key-a", "key-a", short)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), plaintext)
+
+	// values exceeding a fixed size are rejected
+	keys.statePadding, err = NewFixedSizePadding(map[string]int{"key-": 4}, nil)
+	require.NoError(t, err)
+	_, err = keys.EncryptState("key-a", "key-a", []byte("1000000"))
+	assert.EqualError(t, err, "cannot pad state of key 'key-a': plaintext of 7 bytes exceeds the fixed size 4 of prefix 'key-'")
+
+	// the policy applies to the key used by the chaincode, also if the ledger key differs (e.g., hashed state keys)
+	keys.statePadding, err = NewFixedSizePadding(map[string]int{"key-": 32}, nil)
+	require.NoError(t, err)
+	skf, err := keys.hashedStateKeys()
+	require.NoError(t, err)
+	ledgerKey := skf.LedgerKey("key-a")
+	short, err = keys.EncryptState("key-a", ledgerKey, skf.WrapValue("key-a", []byte("1")))
+	require.NoError(t, err)
+	long, err = keys.EncryptState("key-a", ledgerKey, skf.WrapValue("key-a", []byte("1000000")))
+	require.NoError(t, err)
+	assert.Equal(t, len(short), len(long))
+	plaintext, err = keys.DecryptState("key-a", ledgerKey, long)
+	require.NoError(t, err)
+	_, value, err := skf.UnwrapValue(plaintext)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1000000"), value)
+}
+
+func TestChaincodeEncryptionKeys(t *testing.T) {
+	keys, err := NewChaincodeKeys(crypto.GetDefaultCSP(), "some-chaincode")
+	require.NoError(t, err)
+
+	eks := keys.GetEncryptionKeys()
+	require.Len(t, eks, len(crypto.SupportedCipherSuites))
+	for i, ek := range eks {
+		assert.Equal(t, crypto.SupportedCipherSuites[i], ek.GetCipherSuite())
+
+		// a message encrypted for the advertised key can be decrypted with the suite
+		suite, err := keys.CipherSuite(ek.GetCipherSuite())
+		require.NoError(t, err)
+		ciphertext, err := suite.PkEncryptMessage(ek.GetChaincodeEk(), []byte("some key transport message"))
+		require.NoError(t, err)
+		plaintext, err := keys.PkDecryptMessage(ek.GetCipherSuite(), ciphertext)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("some key transport message"), plaintext)
+	}
+
+	// the RSA suite uses chaincode_ek
+	assert.Equal(t, keys.GetPublicKey(), eks[len(eks)-1].GetChaincodeEk())
+
+	_, err = keys.CipherSuite(protos.CipherSuite(42))
+	assert.EqualError(t, err, "unsupported cipher suite 42")
+}
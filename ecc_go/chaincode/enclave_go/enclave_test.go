@@ -0,0 +1,259 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/endorsement/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	bccsputils "github.com/hyperledger/fabric/bccsp/utils"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// testChaincode stores the given values and lists the values of composite keys
+type testChaincode struct{}
+
+func (testChaincode) Init(shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (testChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	function, args := stub.GetFunctionAndParameters()
+	switch function {
+	case "put":
+		if err := stub.PutState(args[0], []byte(args[1])); err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(nil)
+	case "del":
+		if err := stub.DelState(args[0]); err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(nil)
+	case "get":
+		value, err := stub.GetState(args[0])
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(value)
+	case "list":
+		it, err := stub.GetStateByPartialCompositeKey(args[0], args[1:])
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		defer it.Close()
+		var entries []string
+		for it.HasNext() {
+			kv, err := it.Next()
+			if err != nil {
+				return shim.Error(err.Error())
+			}
+			entries = append(entries, fmt.Sprintf("%s=%s", kv.GetKey(), kv.GetValue()))
+		}
+		return shim.Success([]byte(strings.Join(entries, ",")))
+	default:
+		return shim.Error(fmt.Sprintf("unknown function %s", function))
+	}
+}
+
+// newSignedProposal returns a proposal for the given channel signed by a (self-signed) client
+func newSignedProposal(t *testing.T, channelId string) *pb.SignedProposal {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &sk.PublicKey, sk)
+	require.NoError(t, err)
+
+	creator := protoutil.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1MSP", IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})})
+	proposalBytes := protoutil.MarshalOrPanic(&pb.Proposal{
+		Header: protoutil.MarshalOrPanic(&common.Header{
+			ChannelHeader:   protoutil.MarshalOrPanic(&common.ChannelHeader{ChannelId: channelId, TxId: "some-tx"}),
+			SignatureHeader: protoutil.MarshalOrPanic(&common.SignatureHeader{Creator: creator}),
+		}),
+	})
+
+	digest := sha256.Sum256(proposalBytes)
+	sig, err := ecdsa.SignASN1(rand.Reader, sk, digest[:])
+	require.NoError(t, err)
+	sig, err = bccsputils.SignatureToLowS(&sk.PublicKey, sig)
+	require.NoError(t, err)
+	return &pb.SignedProposal{ProposalBytes: proposalBytes, Signature: sig}
+}
+
+// batchClient conceals requests for an enclave and reveals the responses
+type batchClient struct {
+	t        *testing.T
+	provider crypto.EncryptionProvider
+	contexts []crypto.EncryptionContext
+	requests [][]byte
+}
+
+func newBatchClient(t *testing.T, enclave *EnclaveStub) *batchClient {
+	return &batchClient{t: t, provider: &crypto.EncryptionProviderImpl{
+		GetCcEncryptionKey: func() ([]byte, error) {
+			return []byte(base64.StdEncoding.EncodeToString(enclave.ccKeys.GetPublicKey())), nil
+		},
+	}}
+}
+
+func (c *batchClient) add(function string, args ...string) {
+	ctx, err := c.provider.NewEncryptionContext()
+	require.NoError(c.t, err)
+	request, err := ctx.Conceal(function, args)
+	require.NoError(c.t, err)
+	requestBytes, err := base64.StdEncoding.DecodeString(request)
+	require.NoError(c.t, err)
+	c.contexts = append(c.contexts, ctx)
+	c.requests = append(c.requests, requestBytes)
+}
+
+func (c *batchClient) marshal() []byte {
+	batch, err := proto.Marshal(&protos.ChaincodeBatchRequestMessage{ChaincodeRequestMessages: c.requests})
+	require.NoError(c.t, err)
+	return batch
+}
+
+// reveal returns the payload of the response to the request at index
+func (c *batchClient) reveal(signedResponse []byte, index int) string {
+	responseBytes, err := c.contexts[index].RevealBatchResponse([]byte(base64.StdEncoding.EncodeToString(signedResponse)), index)
+	require.NoError(c.t, err)
+	payload, err := utils.UnwrapResponse(responseBytes)
+	require.NoError(c.t, err)
+	return string(payload)
+}
+
+// commitResponse commits the writes of a signed chaincode response message on the ledger
+func (l *memLedger) commitResponse(t *testing.T, signedResponse []byte) {
+	signedResponseMessage, err := utils.UnmarshalSignedChaincodeResponseMessage(signedResponse)
+	require.NoError(t, err)
+	response, err := utils.UnmarshalChaincodeResponseMessage(signedResponseMessage.GetChaincodeResponseMessage())
+	require.NoError(t, err)
+	for _, w := range response.GetFpcRwSet().GetRwSet().GetWrites() {
+		if w.GetIsDelete() {
+			delete(l.state, w.GetKey())
+		} else {
+			l.state[w.GetKey()] = w.GetValue()
+		}
+	}
+}
+
+func newTestEnclave(t *testing.T, hashedStateKeys bool) *EnclaveStub {
+	enclave := NewEnclaveStub(testChaincode{})
+	if hashedStateKeys {
+		enclave.EnableHashedStateKeys()
+	}
+	ccParams, err := proto.Marshal(&protos.CCParameters{ChaincodeId: "some-chaincode", Version: "1.0", ChannelId: "mychannel"})
+	require.NoError(t, err)
+	hostParams, err := proto.Marshal(&protos.HostParameters{})
+	require.NoError(t, err)
+	_, err = enclave.Init(ccParams, hostParams, nil)
+	require.NoError(t, err)
+	return enclave
+}
+
+func TestChaincodeInvokeBatch(t *testing.T) {
+	for _, hashedStateKeys := range []bool{false, true} {
+		t.Run(fmt.Sprintf("hashedStateKeys=%v", hashedStateKeys), func(t *testing.T) {
+			enclave := newTestEnclave(t, hashedStateKeys)
+			ledger := newMemLedger()
+			newStub := func() *fakes.ChaincodeStub {
+				stub := ledger.stub()
+				stub.GetSignedProposalReturns(newSignedProposal(t, "mychannel"), nil)
+				return stub
+			}
+			// with hashed state keys, composite keys are ordered by their hashed attributes
+			assertEntries := func(expected, actual string) {
+				if hashedStateKeys {
+					assert.ElementsMatch(t, strings.Split(expected, ","), strings.Split(actual, ","))
+				} else {
+					assert.Equal(t, expected, actual)
+				}
+			}
+
+			// requests observe the writes of the previous requests of the batch
+			client := newBatchClient(t, enclave)
+			client.add("put", ".account.alice.usd.", "1")
+			client.add("put", ".account.alice.eur.", "2")
+			client.add("put", ".account.bob.usd.", "3")
+			client.add("get", ".account.alice.usd.")
+			client.add("list", "account", "alice")
+			response, err := enclave.ChaincodeInvokeBatch(newStub(), client.marshal())
+			require.NoError(t, err)
+			assert.Equal(t, "1", client.reveal(response, 3))
+			assertEntries(".account.alice.eur.=2,.account.alice.usd.=1", client.reveal(response, 4))
+			ledger.commitResponse(t, response)
+			assert.Len(t, ledger.state, 3)
+
+			// range queries merge the pending writes and deletes with the committed state
+			client = newBatchClient(t, enclave)
+			client.add("del", ".account.alice.usd.")
+			client.add("put", ".account.alice.chf.", "4")
+			client.add("put", ".account.alice.eur.", "20")
+			client.add("get", ".account.alice.usd.")
+			client.add("list", "account", "alice")
+			client.add("list", "account")
+			response, err = enclave.ChaincodeInvokeBatch(newStub(), client.marshal())
+			require.NoError(t, err)
+			assert.Empty(t, client.reveal(response, 3))
+			assertEntries(".account.alice.chf.=4,.account.alice.eur.=20", client.reveal(response, 4))
+			assertEntries(".account.alice.chf.=4,.account.alice.eur.=20,.account.bob.usd.=3", client.reveal(response, 5))
+			ledger.commitResponse(t, response)
+
+			// a single invocation reads the committed state
+			client = newBatchClient(t, enclave)
+			client.add("list", "account")
+			response, err = enclave.ChaincodeInvokeBatch(newStub(), client.marshal())
+			require.NoError(t, err)
+			assertEntries(".account.alice.chf.=4,.account.alice.eur.=20,.account.bob.usd.=3", client.reveal(response, 0))
+
+			// a failing request aborts the batch
+			client = newBatchClient(t, enclave)
+			client.add("put", "alice", "1")
+			client.add("unknown")
+			_, err = enclave.ChaincodeInvokeBatch(newStub(), client.marshal())
+			assert.EqualError(t, err, "batch aborted as request 1 returned status 500")
+
+			// as does a proposal for another channel
+			client = newBatchClient(t, enclave)
+			client.add("get", "alice")
+			stub := ledger.stub()
+			stub.GetSignedProposalReturns(newSignedProposal(t, "otherchannel"), nil)
+			_, err = enclave.ChaincodeInvokeBatch(stub, client.marshal())
+			assert.ErrorContains(t, err, "signed proposal verification failed")
+
+			_, err = enclave.ChaincodeInvokeBatch(newStub(), (&batchClient{t: t}).marshal())
+			assert.Error(t, err)
+		})
+	}
+}
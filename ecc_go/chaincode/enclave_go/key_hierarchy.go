@@ -0,0 +1,130 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+)
+
+// The symmetric chaincode keys are derived from a random chaincode master secret with HKDF-SHA256, where the info
+// binds the purpose of the key (label), the chaincode id, and the context (e.g., the key prefix):
+//
+//	master secret
+//	├── state key (root prefix "")
+//	│   └── state key of a configured prefix, derived from the key of its closest configured ancestor prefix
+//	└── hashed state keys secret (see hmacStateKeys)
+const (
+	masterSecretLength = 32
+
+	stateKeyLabel       = "FPC state key v1"
+	statePrefixKeyLabel = "FPC state prefix key v1"
+)
+
+// deriveKey derives a key of the given length from the secret, where the HKDF info is
+// label || len(chaincode id) || chaincode id || context
+func deriveKey(secret []byte, label string, chaincodeId string, context string, length int) ([]byte, error) {
+	info := make([]byte, 0, len(label)+4+len(chaincodeId)+len(context))
+	info = append(info, label...)
+	info = binary.BigEndian.AppendUint32(info, uint32(len(chaincodeId)))
+	info = append(info, chaincodeId...)
+	info = append(info, context...)
+	return hkdf.Key(sha256.New, secret, nil, string(info), length)
+}
+
+// stateKeyTree holds the state keys of a root prefix and of the configured prefixes below. The key of a prefix is
+// derived from the key of its closest configured ancestor, such that the key of a prefix discloses the state below
+// this prefix only. The state of a key is encrypted with the key of its longest configured prefix.
+type stateKeyTree struct {
+	chaincodeId string
+	root        string
+	keys        map[string][]byte
+}
+
+func newStateKeyTree(chaincodeId string, root string, rootKey []byte, prefixes []string) (*stateKeyTree, error) {
+	t := &stateKeyTree{
+		chaincodeId: chaincodeId,
+		root:        root,
+		keys:        map[string][]byte{root: rootKey},
+	}
+
+	// derive the keys of shorter prefixes first, such that the ancestors of a prefix are known
+	sorted := append([]string(nil), prefixes...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) < len(sorted[j]) })
+
+	for _, prefix := range sorted {
+		if !strings.HasPrefix(prefix, root) {
+			return nil, fmt.Errorf("prefix '%s' is not below '%s'", prefix, root)
+		}
+		if _, exists := t.keys[prefix]; exists {
+			continue
+		}
+
+		ancestor := t.longestPrefix(prefix)
+		key, err := deriveKey(t.keys[ancestor], statePrefixKeyLabel, chaincodeId, prefix, crypto.SymKeyLength)
+		if err != nil {
+			return nil, err
+		}
+		t.keys[prefix] = key
+	}
+	return t, nil
+}
+
+// longestPrefix returns the longest configured prefix of the key
+func (t *stateKeyTree) longestPrefix(key string) string {
+	longest := t.root
+	for prefix := range t.keys {
+		if len(prefix) > len(longest) && strings.HasPrefix(key, prefix) {
+			longest = prefix
+		}
+	}
+	return longest
+}
+
+// stateKey returns the key to encrypt the state of the key
+func (t *stateKeyTree) stateKey(key string) ([]byte, error) {
+	if !strings.HasPrefix(key, t.root) {
+		return nil, fmt.Errorf("no state key for key '%s'", key)
+	}
+	return t.keys[t.longestPrefix(key)], nil
+}
+
+// candidateKeys returns the state keys of the keys with the given prefix, i.e., the key of the longest configured
+// prefix of keyPrefix followed by the keys of the configured prefixes that extend keyPrefix
+func (t *stateKeyTree) candidateKeys(keyPrefix string) [][]byte {
+	var keys [][]byte
+	if strings.HasPrefix(keyPrefix, t.root) {
+		keys = append(keys, t.keys[t.longestPrefix(keyPrefix)])
+	}
+
+	var extensions []string
+	for prefix := range t.keys {
+		if len(prefix) > len(keyPrefix) && strings.HasPrefix(prefix, keyPrefix) {
+			extensions = append(extensions, prefix)
+		}
+	}
+	sort.Strings(extensions)
+	for _, prefix := range extensions {
+		keys = append(keys, t.keys[prefix])
+	}
+	return keys
+}
+
+// prefixKey returns the key of a configured prefix
+func (t *stateKeyTree) prefixKey(prefix string) ([]byte, error) {
+	key, ok := t.keys[prefix]
+	if !ok {
+		return nil, fmt.Errorf("no state key for prefix '%s'", prefix)
+	}
+	return key, nil
+}
@@ -0,0 +1,178 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveKey(t *testing.T) {
+	secret := []byte("some master secret")
+
+	key, err := deriveKey(secret, stateKeyLabel, "some-chaincode", "", crypto.SymKeyLength)
+	require.NoError(t, err)
+	assert.Len(t, key, crypto.SymKeyLength)
+
+	sameKey, err := deriveKey(secret, stateKeyLabel, "some-chaincode", "", crypto.SymKeyLength)
+	require.NoError(t, err)
+	assert.Equal(t, key, sameKey)
+
+	// the key is bound to the label, the chaincode and the context
+	for _, other := range [][]string{
+		{statePrefixKeyLabel, "some-chaincode", ""},
+		{stateKeyLabel, "other-chaincode", ""},
+		{stateKeyLabel, "some-chaincode", "prefix"},
+		{stateKeyLabel, "some-chaincod", "eprefix"},
+	} {
+		otherKey, err := deriveKey(secret, other[0], other[1], other[2], crypto.SymKeyLength)
+		require.NoError(t, err)
+		assert.NotEqual(t, key, otherKey, other)
+	}
+}
+
+func TestChaincodeKeyHierarchy(t *testing.T) {
+	keys, err := NewChaincodeKeys(crypto.GetDefaultCSP(), "some-chaincode")
+	require.NoError(t, err)
+
+	rootStateKey, err := keys.StatePrefixKey("")
+	require.NoError(t, err)
+	assert.Len(t, rootStateKey, crypto.SymKeyLength)
+	hashedStateKeysSecret, err := keys.deriveKey(hmacStateKeysLabel, "", crypto.SymKeyLength)
+	require.NoError(t, err)
+	assert.NotEqual(t, rootStateKey, hashedStateKeysSecret)
+
+	require.NoError(t, keys.SetStatePrefixes("balance.", "balance.usd.", "name."))
+	balanceKey, err := keys.StatePrefixKey("balance.")
+	require.NoError(t, err)
+	_, err = keys.StatePrefixKey("other.")
+	assert.EqualError(t, err, "no state key for prefix 'other.'")
+
+	// the root state key does not change with the prefixes
+	sameRootStateKey, err := keys.StatePrefixKey("")
+	require.NoError(t, err)
+	assert.Equal(t, rootStateKey, sameRootStateKey)
+
+	ciphertexts := make(map[string][]byte)
+	for _, key := range []string{"balance.alice", "balance.usd.alice", "name.alice", "alice"} {
+		ciphertexts[key], err = keys.EncryptState(key, key, []byte("some value of "+key))
+		require.NoError(t, err)
+
+		plaintext, err := keys.DecryptState(key, key, ciphertexts[key])
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("some value of "+key), plaintext)
+	}
+
+	// the state below a prefix is encrypted with the key of the prefix
+	_, err = crypto.GetDefaultCSP().DecryptWithAAD(rootStateKey, ciphertexts["balance.alice"][1:], stateAAD(stateFormatV1, "some-chaincode", "balance.alice"))
+	assert.Error(t, err)
+	plaintext, err := crypto.GetDefaultCSP().DecryptWithAAD(balanceKey, ciphertexts["balance.alice"][1:], stateAAD(stateFormatV1, "some-chaincode", "balance.alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("some value of balance.alice"), plaintext)
+
+	// an auditor with the key of a prefix decrypts the state below this prefix only
+	disclosed, err := NewDisclosedStateKeys(crypto.GetDefaultCSP(), "some-chaincode", "balance.", balanceKey, "balance.usd.")
+	require.NoError(t, err)
+	for _, key := range []string{"balance.alice", "balance.usd.alice"} {
+		plaintext, err := disclosed.DecryptState(key, key, ciphertexts[key])
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("some value of "+key), plaintext)
+	}
+	_, err = disclosed.DecryptState("name.alice", "name.alice", ciphertexts["name.alice"])
+	assert.EqualError(t, err, "no state key for key 'name.alice'")
+
+	// a disclosed key does not decrypt the state of other keys
+	_, err = disclosed.DecryptState("balance.bob", "balance.bob", ciphertexts["alice"])
+	assert.Error(t, err)
+
+	_, err = NewDisclosedStateKeys(crypto.GetDefaultCSP(), "some-chaincode", "balance.", balanceKey, "name.")
+	assert.EqualError(t, err, "prefix 'name.' is not below 'balance.'")
+
+	// as the master secret is random, the keys of other chaincode instances differ
+	otherKeys, err := NewChaincodeKeys(crypto.GetDefaultCSP(), "some-chaincode")
+	require.NoError(t, err)
+	otherRootStateKey, err := otherKeys.StatePrefixKey("")
+	require.NoError(t, err)
+	assert.NotEqual(t, rootStateKey, otherRootStateKey)
+}
+
+func TestStatePrefixesWithHashedStateKeys(t *testing.T) {
+	keys, err := NewChaincodeKeys(crypto.GetDefaultCSP(), "some-chaincode")
+	require.NoError(t, err)
+	require.NoError(t, keys.SetStatePrefixes("balance.", ".account."))
+	skf, err := keys.hashedStateKeys()
+	require.NoError(t, err)
+	rootStateKey, err := keys.StatePrefixKey("")
+	require.NoError(t, err)
+	balanceKey, err := keys.StatePrefixKey("balance.")
+	require.NoError(t, err)
+	accountKey, err := keys.StatePrefixKey(".account.")
+	require.NoError(t, err)
+
+	ledger := newMemLedger()
+	ledger.invoke(t, func(stub shim.ChaincodeStubInterface, rwset *readWriteSet) shim.ChaincodeStubInterface {
+		return NewFpcStubInterface(stub, &pb.ChaincodeInput{}, rwset, keys, skf, nil)
+	}, func(stub shim.ChaincodeStubInterface) {
+		require.NoError(t, stub.PutState("balance.alice", []byte("100")))
+		require.NoError(t, stub.PutState(".account.alice.usd.", []byte("50")))
+		require.NoError(t, stub.PutState("name.alice", []byte("Alice")))
+	})
+
+	// the state key is chosen by the key of the chaincode, not by the hashed ledger key
+	balanceLedgerKey := skf.LedgerKey("balance.alice")
+	ciphertext := ledger.state[balanceLedgerKey]
+	require.NotEmpty(t, ciphertext)
+	_, err = crypto.GetDefaultCSP().DecryptWithAAD(rootStateKey, ciphertext[1:], stateAAD(stateFormatV1, "some-chaincode", balanceLedgerKey))
+	assert.Error(t, err)
+
+	// an auditor with the key of a prefix decrypts the state below this prefix only
+	disclosed, err := NewDisclosedStateKeys(crypto.GetDefaultCSP(), "some-chaincode", "balance.", balanceKey)
+	require.NoError(t, err)
+	plaintext, err := disclosed.DecryptState("balance.", balanceLedgerKey, ciphertext)
+	require.NoError(t, err)
+	key, value, err := skf.UnwrapValue(plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, "balance.alice", key)
+	assert.Equal(t, []byte("100"), value)
+
+	nameLedgerKey := skf.LedgerKey("name.alice")
+	_, err = disclosed.DecryptState("balance.", nameLedgerKey, ledger.state[nameLedgerKey])
+	assert.Error(t, err)
+
+	// partial composite key queries decrypt values under the key of their prefix
+	accountLedgerKey := skf.LedgerKey(".account.alice.usd.")
+	stub := ledger.stub()
+	stub.GetStateByPartialCompositeKeyCalls(func(objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+		return &sliceIterator{kvs: []*queryresult.KV{{
+			Key:   strings.ReplaceAll(accountLedgerKey, compositeKeySep, "\x00"),
+			Value: ledger.state[accountLedgerKey],
+		}}}, nil
+	})
+	fpcStub := NewFpcStubInterface(stub, &pb.ChaincodeInput{}, NewReadWriteSet(), keys, skf, nil)
+	iterator, err := fpcStub.GetStateByPartialCompositeKey("account", nil)
+	require.NoError(t, err)
+	kv, err := iterator.Next()
+	require.NoError(t, err)
+	assert.Equal(t, ".account.alice.usd.", kv.GetKey())
+	assert.Equal(t, []byte("50"), kv.GetValue())
+
+	accountDisclosed, err := NewDisclosedStateKeys(crypto.GetDefaultCSP(), "some-chaincode", ".account.", accountKey)
+	require.NoError(t, err)
+	_, err = accountDisclosed.DecryptState(".account.", accountLedgerKey, ledger.state[accountLedgerKey])
+	assert.NoError(t, err)
+
+	value, err = fpcStub.GetState("balance.alice")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("100"), value)
+}
@@ -0,0 +1,292 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ORAMOption configures the oblivious SKVS (see NewObliviousSkvsStub)
+type ORAMOption func(*oramConfig)
+
+type oramConfig struct {
+	// the tree of buckets has 2^height leaves and 2^(height+1)-1 buckets
+	height int
+	// the number of blocks per bucket (Z)
+	bucketSize int
+	// the maximum length of key and value of a block
+	blockSize int
+}
+
+var defaultORAMConfig = oramConfig{
+	height:     10,
+	bucketSize: 4,
+	blockSize:  1024,
+}
+
+// WithORAMHeight sets the height of the tree of buckets; the ORAM holds about 2^height keys (default 10)
+func WithORAMHeight(height int) ORAMOption {
+	return func(c *oramConfig) {
+		c.height = height
+	}
+}
+
+// WithORAMBucketSize sets the number of blocks per bucket (default 4)
+func WithORAMBucketSize(bucketSize int) ORAMOption {
+	return func(c *oramConfig) {
+		c.bucketSize = bucketSize
+	}
+}
+
+// WithORAMBlockSize sets the maximum length of key and value of a block, i.e., of a single state entry; all buckets
+// are padded to the same size, which is proportional to the block size (default 1024)
+func WithORAMBlockSize(blockSize int) ORAMOption {
+	return func(c *oramConfig) {
+		c.blockSize = blockSize
+	}
+}
+
+func newORAMConfig(opts []ORAMOption) (oramConfig, error) {
+	config := defaultORAMConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if config.height < 0 || config.height > 24 {
+		return config, fmt.Errorf("invalid ORAM height %d", config.height)
+	}
+	if config.bucketSize <= 0 {
+		return config, fmt.Errorf("invalid ORAM bucket size %d", config.bucketSize)
+	}
+	if config.blockSize <= 0 {
+		return config, fmt.Errorf("invalid ORAM block size %d", config.blockSize)
+	}
+	return config, nil
+}
+
+// bucketPlaintextLength returns the (padded) length of a serialized bucket
+func (c oramConfig) bucketPlaintextLength() int {
+	return binary.MaxVarintLen64 + c.bucketSize*(c.blockSize+2*binary.MaxVarintLen64) + 1
+}
+
+type oramOp int
+
+const (
+	oramRead oramOp = iota
+	oramWrite
+	oramDelete
+)
+
+type oramBlock struct {
+	key   string
+	value []byte
+}
+
+// oramBucketStore reads and writes the buckets of the tree, where the root has index 0 and the children of bucket i
+// have the indices 2i+1 and 2i+2
+type oramBucketStore interface {
+	readBucket(index int) ([]oramBlock, error)
+	writeBucket(index int, blocks []oramBlock) error
+}
+
+// pathORAM implements Path ORAM (Stefanov et al., CCS 2013): each key is mapped to a random leaf and stored in a
+// bucket on the path from the root to its leaf or in the stash. An access reads all buckets on the path of the key,
+// maps the key to a new random leaf, and writes the path back with as many stash blocks as possible. Hence, every
+// access (read, write, or delete) touches a uniformly random path, independent of the key.
+type pathORAM struct {
+	config    oramConfig
+	positions map[string]uint32
+	stash     map[string][]byte
+	store     oramBucketStore
+}
+
+func newPathORAM(config oramConfig, store oramBucketStore) *pathORAM {
+	return &pathORAM{
+		config:    config,
+		positions: make(map[string]uint32),
+		stash:     make(map[string][]byte),
+		store:     store,
+	}
+}
+
+// access performs the operation on the key and returns the value of the key before the operation
+func (o *pathORAM) access(op oramOp, key string, value []byte) ([]byte, error) {
+	if op == oramWrite && len(key)+len(value) > o.config.blockSize {
+		return nil, fmt.Errorf("key and value of '%s' exceed the ORAM block size %d", key, o.config.blockSize)
+	}
+
+	leaf, found := o.positions[key]
+	if !found {
+		// the key does not exist; we still read a random path
+		leaf = o.randomLeaf()
+	}
+
+	switch {
+	case op == oramDelete:
+		delete(o.positions, key)
+	case op == oramWrite || found:
+		o.positions[key] = o.randomLeaf()
+	}
+
+	// read the path into the stash
+	for level := 0; level <= o.config.height; level++ {
+		blocks, err := o.store.readBucket(o.bucketIndex(leaf, level))
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range blocks {
+			o.stash[block.key] = block.value
+		}
+	}
+
+	current := o.stash[key]
+	switch op {
+	case oramWrite:
+		o.stash[key] = value
+	case oramDelete:
+		delete(o.stash, key)
+	}
+
+	// write the path back, from the leaf to the root, with the blocks whose path shares the bucket
+	for level := o.config.height; level >= 0; level-- {
+		index := o.bucketIndex(leaf, level)
+		blocks := make([]oramBlock, 0, o.config.bucketSize)
+		for k, v := range o.stash {
+			if len(blocks) == o.config.bucketSize {
+				break
+			}
+			if o.bucketIndex(o.positions[k], level) == index {
+				blocks = append(blocks, oramBlock{key: k, value: v})
+			}
+		}
+		for _, block := range blocks {
+			delete(o.stash, block.key)
+		}
+
+		if err := o.store.writeBucket(index, blocks); err != nil {
+			return nil, err
+		}
+	}
+
+	return current, nil
+}
+
+// bucketIndex returns the index of the bucket at the given level on the path to the leaf
+func (o *pathORAM) bucketIndex(leaf uint32, level int) int {
+	return (1 << level) - 1 + int(leaf>>(o.config.height-level))
+}
+
+func (o *pathORAM) randomLeaf() uint32 {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("cannot read randomness: %v", err))
+	}
+	return binary.BigEndian.Uint32(b[:]) & (1<<o.config.height - 1)
+}
+
+// marshalMetadata serializes the position map and the stash
+func (o *pathORAM) marshalMetadata() []byte {
+	buf := binary.AppendUvarint(nil, uint64(len(o.positions)))
+	for key, leaf := range o.positions {
+		buf = appendBytes(buf, []byte(key))
+		buf = binary.AppendUvarint(buf, uint64(leaf))
+	}
+	return appendBlocks(buf, stashBlocks(o.stash))
+}
+
+// unmarshalMetadata restores the position map and the stash serialized with marshalMetadata
+func (o *pathORAM) unmarshalMetadata(buf []byte) error {
+	r := &entryReader{buf: buf}
+	n := r.uvarint()
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		key := string(r.bytes())
+		leaf := r.uvarint()
+		if leaf >= 1<<o.config.height {
+			return fmt.Errorf("invalid ORAM position %d", leaf)
+		}
+		o.positions[key] = uint32(leaf)
+	}
+
+	blocks, err := r.blocks()
+	if err != nil {
+		return errors.Wrap(err, "invalid ORAM metadata")
+	}
+	for _, block := range blocks {
+		o.stash[block.key] = block.value
+	}
+	return nil
+}
+
+func stashBlocks(stash map[string][]byte) []oramBlock {
+	blocks := make([]oramBlock, 0, len(stash))
+	for k, v := range stash {
+		blocks = append(blocks, oramBlock{key: k, value: v})
+	}
+	return blocks
+}
+
+func appendBytes(buf []byte, b []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+// appendBlocks serializes blocks as count || (len(key) || key || len(value) || value)*
+func appendBlocks(buf []byte, blocks []oramBlock) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(blocks)))
+	for _, block := range blocks {
+		buf = appendBytes(buf, []byte(block.key))
+		buf = appendBytes(buf, block.value)
+	}
+	return buf
+}
+
+type entryReader struct {
+	buf []byte
+	err error
+}
+
+func (r *entryReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.err = fmt.Errorf("invalid encoding")
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *entryReader) bytes() []byte {
+	l := r.uvarint()
+	if r.err != nil {
+		return nil
+	}
+	if l > uint64(len(r.buf)) {
+		r.err = fmt.Errorf("invalid encoding")
+		return nil
+	}
+	b := r.buf[:l]
+	r.buf = r.buf[l:]
+	return b
+}
+
+func (r *entryReader) blocks() ([]oramBlock, error) {
+	n := r.uvarint()
+	var blocks []oramBlock
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		key := string(r.bytes())
+		value := r.bytes()
+		blocks = append(blocks, oramBlock{key: key, value: value})
+	}
+	return blocks, r.err
+}
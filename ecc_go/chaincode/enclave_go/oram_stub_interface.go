@@ -0,0 +1,188 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ORAMKeyPrefix prefixes the ledger keys of the oblivious SKVS, i.e., the buckets and the metadata
+	ORAMKeyPrefix = "SKVS.ORAM."
+
+	oramMetadataKey = ORAMKeyPrefix + "meta"
+
+	// the serialized metadata is padded to a multiple of this length
+	oramMetadataPadding = 4096
+)
+
+// ObliviousSkvsStubInterface stores the state in a Path ORAM (see pathORAM) over a fixed set of ledger keys, such
+// that the ledger reveals neither which keys are read or written nor the length of values. Each GetState, PutState
+// and DelState reads and rewrites a random path of buckets; the buckets and the (encrypted and padded) metadata, i.e.,
+// the position map and the stash, are written once the chaincode returns (see flush). Hence, every invocation also
+// writes the state, and concurrent invocations conflict. Note that the accesses of invocations that are not committed
+// (e.g., queries) may be repeated and are thus linkable.
+type ObliviousSkvsStubInterface struct {
+	*FpcStubInterface
+	config oramConfig
+	oram   *pathORAM
+
+	// the buckets read by this invocation
+	buckets map[int][]oramBlock
+	// the buckets written by this invocation
+	dirty map[int]bool
+}
+
+func NewObliviousSkvsStubInterface(stub shim.ChaincodeStubInterface, input *pb.ChaincodeInput, rwset *readWriteSet, sep StateEncryptionFunctions, skf StateKeyFunctions, signer EnclaveSigner, config oramConfig) *ObliviousSkvsStubInterface {
+	s := &ObliviousSkvsStubInterface{
+		FpcStubInterface: NewFpcStubInterface(stub, input, rwset, sep, skf, signer),
+		config:           config,
+		buckets:          make(map[int][]oramBlock),
+		dirty:            make(map[int]bool),
+	}
+	s.oram = newPathORAM(config, s)
+
+	if err := s.loadMetadata(); err != nil {
+		panic(fmt.Sprintf("Initializing oblivious SKVS failed, err: %v", err))
+	}
+	return s
+}
+
+func (s *ObliviousSkvsStubInterface) GetState(key string) ([]byte, error) {
+	value, err := s.oram.access(oramRead, key, nil)
+	// the chaincode must not modify the stash through the returned slice
+	return bytes.Clone(value), err
+}
+
+func (s *ObliviousSkvsStubInterface) PutState(key string, value []byte) error {
+	// the chaincode may reuse the slice after the call
+	_, err := s.oram.access(oramWrite, key, bytes.Clone(value))
+	return err
+}
+
+func (s *ObliviousSkvsStubInterface) DelState(key string) error {
+	_, err := s.oram.access(oramDelete, key, nil)
+	return err
+}
+
+func (s *ObliviousSkvsStubInterface) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	return nil, fmt.Errorf("partial composite key queries are not supported by the oblivious SKVS")
+}
+
+func (s *ObliviousSkvsStubInterface) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	return nil, nil, fmt.Errorf("partial composite key queries are not supported by the oblivious SKVS")
+}
+
+func (s *ObliviousSkvsStubInterface) GetStateByRange(startKey string, endKey string) (shim.StateQueryIteratorInterface, error) {
+	return nil, fmt.Errorf("range queries are not supported by the oblivious SKVS")
+}
+
+func (s *ObliviousSkvsStubInterface) GetStateByRangeWithPagination(startKey string, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	return nil, nil, fmt.Errorf("range queries are not supported by the oblivious SKVS")
+}
+
+// flush encrypts and writes the buckets written by the invocation and the metadata
+func (s *ObliviousSkvsStubInterface) flush() error {
+	if len(s.dirty) == 0 {
+		return nil
+	}
+
+	indices := make([]int, 0, len(s.dirty))
+	for index := range s.dirty {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+
+	for _, index := range indices {
+		plaintext, err := crypto.Pad(appendBlocks(nil, s.buckets[index]), s.config.bucketPlaintextLength())
+		if err != nil {
+			return err
+		}
+		if err := s.putEncryptedState(oramBucketKey(index), plaintext); err != nil {
+			return err
+		}
+	}
+	s.dirty = make(map[int]bool)
+
+	return s.storeMetadata()
+}
+
+func (s *ObliviousSkvsStubInterface) readBucket(index int) ([]oramBlock, error) {
+	if blocks, ok := s.buckets[index]; ok {
+		return blocks, nil
+	}
+
+	plaintext, err := s.getEncryptedState(oramBucketKey(index))
+	if err != nil || plaintext == nil {
+		return nil, err
+	}
+
+	blocks, err := (&entryReader{buf: plaintext}).blocks()
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid ORAM bucket %d", index)
+	}
+	s.buckets[index] = blocks
+	return blocks, nil
+}
+
+func (s *ObliviousSkvsStubInterface) writeBucket(index int, blocks []oramBlock) error {
+	s.buckets[index] = blocks
+	s.dirty[index] = true
+	return nil
+}
+
+func (s *ObliviousSkvsStubInterface) loadMetadata() error {
+	plaintext, err := s.getEncryptedState(oramMetadataKey)
+	if err != nil || plaintext == nil {
+		return err
+	}
+	return s.oram.unmarshalMetadata(plaintext)
+}
+
+func (s *ObliviousSkvsStubInterface) storeMetadata() error {
+	metadata := s.oram.marshalMetadata()
+	length := (len(metadata)/oramMetadataPadding + 1) * oramMetadataPadding
+	plaintext, err := crypto.Pad(metadata, length)
+	if err != nil {
+		return err
+	}
+	return s.putEncryptedState(oramMetadataKey, plaintext)
+}
+
+// getEncryptedState returns the decrypted and unpadded value of a ledger key of the ORAM, or nil if not set
+func (s *ObliviousSkvsStubInterface) getEncryptedState(key string) ([]byte, error) {
+	encValue, err := s.GetPublicState(key)
+	if err != nil || len(encValue) == 0 {
+		return nil, err
+	}
+
+	plaintext, err := s.sep.DecryptState(key, key, encValue)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Unpad(plaintext)
+}
+
+func (s *ObliviousSkvsStubInterface) putEncryptedState(key string, plaintext []byte) error {
+	encValue, err := s.sep.EncryptState(key, key, plaintext)
+	if err != nil {
+		return err
+	}
+	return s.PutPublicState(key, encValue)
+}
+
+func oramBucketKey(index int) string {
+	return ORAMKeyPrefix + strconv.Itoa(index)
+}
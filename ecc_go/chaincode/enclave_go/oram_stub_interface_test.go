@@ -0,0 +1,185 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/endorsement/fakes"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memLedger is an in-memory ledger on which invocations are committed
+type memLedger struct {
+	state map[string][]byte
+}
+
+func newMemLedger() *memLedger {
+	return &memLedger{state: make(map[string][]byte)}
+}
+
+func (l *memLedger) stub() *fakes.ChaincodeStub {
+	stub := &fakes.ChaincodeStub{}
+	stub.GetStateCalls(func(key string) ([]byte, error) {
+		return l.state[key], nil
+	})
+	stub.GetStateByPartialCompositeKeyCalls(func(objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+		// as fabric, return the matching composite keys in order with the separator \x00
+		prefix := compositeKeyPrefix(objectType, attributes)
+		var keys []string
+		for key := range l.state {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool { return ledgerOrder(keys[i]) < ledgerOrder(keys[j]) })
+		it := &sliceIterator{}
+		for _, key := range keys {
+			it.kvs = append(it.kvs, &queryresult.KV{Key: ledgerOrder(key), Value: l.state[key]})
+		}
+		return it, nil
+	})
+	return stub
+}
+
+func (l *memLedger) commit(rwset *readWriteSet) {
+	for _, w := range rwset.ToFPCKVSet().GetRwSet().GetWrites() {
+		if w.GetIsDelete() {
+			delete(l.state, w.GetKey())
+		} else {
+			l.state[w.GetKey()] = w.GetValue()
+		}
+	}
+}
+
+// invoke runs f as a committed invocation on the ledger with the given stub provider
+func (l *memLedger) invoke(t testing.TB, newStub func(shim.ChaincodeStubInterface, *readWriteSet) shim.ChaincodeStubInterface, f func(stub shim.ChaincodeStubInterface)) {
+	rwset := NewReadWriteSet()
+	stub := newStub(l.stub(), rwset)
+	f(stub)
+	if b, ok := stub.(writeBuffer); ok {
+		require.NoError(t, b.flush())
+	}
+	l.commit(rwset)
+}
+
+func newObliviousStubProvider(t testing.TB, opts ...ORAMOption) func(shim.ChaincodeStubInterface, *readWriteSet) shim.ChaincodeStubInterface {
+	keys, err := NewChaincodeKeys(crypto.GetDefaultCSP(), "some-chaincode")
+	require.NoError(t, err)
+	config, err := newORAMConfig(opts)
+	require.NoError(t, err)
+	return func(stub shim.ChaincodeStubInterface, rwset *readWriteSet) shim.ChaincodeStubInterface {
+		return NewObliviousSkvsStubInterface(stub, &pb.ChaincodeInput{}, rwset, keys, nil, nil, config)
+	}
+}
+
+func newSkvsStubProvider(t testing.TB) func(shim.ChaincodeStubInterface, *readWriteSet) shim.ChaincodeStubInterface {
+	keys, err := NewChaincodeKeys(crypto.GetDefaultCSP(), "some-chaincode")
+	require.NoError(t, err)
+	return func(stub shim.ChaincodeStubInterface, rwset *readWriteSet) shim.ChaincodeStubInterface {
+		return NewSkvsStubInterface(stub, &pb.ChaincodeInput{}, rwset, keys, nil, nil)
+	}
+}
+
+func TestObliviousSkvsStubInterface(t *testing.T) {
+	ledger := newMemLedger()
+	newStub := newObliviousStubProvider(t, WithORAMHeight(4), WithORAMBlockSize(128))
+
+	ledger.invoke(t, newStub, func(stub shim.ChaincodeStubInterface) {
+		require.NoError(t, stub.PutState("alice", []byte("100")))
+		require.NoError(t, stub.PutState("bob", []byte("a much longer value than the one of alice")))
+
+		// read your writes
+		value, err := stub.GetState("alice")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("100"), value)
+	})
+
+	ledger.invoke(t, newStub, func(stub shim.ChaincodeStubInterface) {
+		value, err := stub.GetState("bob")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("a much longer value than the one of alice"), value)
+
+		value, err = stub.GetState("charlie")
+		assert.NoError(t, err)
+		assert.Nil(t, value)
+
+		require.NoError(t, stub.DelState("alice"))
+
+		err = stub.PutState("charlie", make([]byte, 128))
+		assert.EqualError(t, err, "key and value of 'charlie' exceed the ORAM block size 128")
+
+		// the ORAM does not support queries
+		_, err = stub.GetStateByPartialCompositeKey("account", nil)
+		assert.Error(t, err)
+		_, _, err = stub.GetStateByPartialCompositeKeyWithPagination("account", nil, 1, "")
+		assert.Error(t, err)
+		_, err = stub.GetStateByRange("", "")
+		assert.EqualError(t, err, "range queries are not supported by the oblivious SKVS")
+		_, _, err = stub.GetStateByRangeWithPagination("", "", 1, "")
+		assert.EqualError(t, err, "range queries are not supported by the oblivious SKVS")
+	})
+
+	ledger.invoke(t, newStub, func(stub shim.ChaincodeStubInterface) {
+		value, err := stub.GetState("alice")
+		assert.NoError(t, err)
+		assert.Nil(t, value)
+	})
+
+	// the ledger holds buckets of the same length and the metadata only
+	bucketLength := -1
+	for key, value := range ledger.state {
+		require.True(t, strings.HasPrefix(key, ORAMKeyPrefix), key)
+		if key == oramMetadataKey {
+			continue
+		}
+		if bucketLength < 0 {
+			bucketLength = len(value)
+		}
+		assert.Len(t, value, bucketLength, key)
+	}
+}
+
+func benchmarkSKVS(b *testing.B, newStub func(shim.ChaincodeStubInterface, *readWriteSet) shim.ChaincodeStubInterface) {
+	for _, n := range []int{100, 1000} {
+		b.Run(fmt.Sprintf("keys=%d", n), func(b *testing.B) {
+			ledger := newMemLedger()
+			ledger.invoke(b, newStub, func(stub shim.ChaincodeStubInterface) {
+				for i := 0; i < n; i++ {
+					require.NoError(b, stub.PutState(fmt.Sprintf("key-%d", i), []byte(fmt.Sprintf("value-%d", i))))
+				}
+			})
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// an invocation that reads and updates a key
+				ledger.invoke(b, newStub, func(stub shim.ChaincodeStubInterface) {
+					key := fmt.Sprintf("key-%d", i%n)
+					_, err := stub.GetState(key)
+					require.NoError(b, err)
+					require.NoError(b, stub.PutState(key, []byte(fmt.Sprintf("value-%d", i))))
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkSKVS(b *testing.B) {
+	benchmarkSKVS(b, newSkvsStubProvider(b))
+}
+
+func BenchmarkObliviousSKVS(b *testing.B) {
+	benchmarkSKVS(b, newObliviousStubProvider(b, WithORAMHeight(10), WithORAMBlockSize(64)))
+}
@@ -0,0 +1,104 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"fmt"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memBuckets is an in-memory oramBucketStore that records the indices of the buckets read
+type memBuckets struct {
+	buckets map[int][]oramBlock
+	reads   []int
+}
+
+func (m *memBuckets) readBucket(index int) ([]oramBlock, error) {
+	m.reads = append(m.reads, index)
+	return m.buckets[index], nil
+}
+
+func (m *memBuckets) writeBucket(index int, blocks []oramBlock) error {
+	m.buckets[index] = blocks
+	return nil
+}
+
+func TestPathORAM(t *testing.T) {
+	config, err := newORAMConfig([]ORAMOption{WithORAMHeight(5), WithORAMBucketSize(4), WithORAMBlockSize(64)})
+	require.NoError(t, err)
+	store := &memBuckets{buckets: make(map[int][]oramBlock)}
+	oram := newPathORAM(config, store)
+
+	model := make(map[string][]byte)
+	rnd := mrand.New(mrand.NewSource(42))
+	for i := 0; i < 2000; i++ {
+		key := fmt.Sprintf("key-%d", rnd.Intn(40))
+		store.reads = nil
+
+		var op oramOp
+		var value []byte
+		switch rnd.Intn(3) {
+		case 0:
+			op = oramRead
+		case 1:
+			op, value = oramWrite, []byte(fmt.Sprintf("value-%d", i))
+		case 2:
+			op = oramDelete
+		}
+
+		current, err := oram.access(op, key, value)
+		require.NoError(t, err)
+		require.Equal(t, model[key], current, "access %d of %s", i, key)
+
+		switch op {
+		case oramWrite:
+			model[key] = value
+		case oramDelete:
+			delete(model, key)
+		}
+
+		// every access reads a path from the root to a leaf
+		require.Len(t, store.reads, config.height+1)
+		assert.Equal(t, 0, store.reads[0])
+		for level := 1; level <= config.height; level++ {
+			parent := store.reads[level-1]
+			assert.Contains(t, []int{2*parent + 1, 2*parent + 2}, store.reads[level])
+		}
+
+		// the stash stays small
+		assert.LessOrEqual(t, len(oram.stash), 20)
+	}
+
+	// the metadata restores the ORAM
+	restored := newPathORAM(config, store)
+	require.NoError(t, restored.unmarshalMetadata(oram.marshalMetadata()))
+	for key, value := range model {
+		current, err := restored.access(oramRead, key, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, value, current)
+	}
+
+	_, err = oram.access(oramWrite, "key", make([]byte, 64))
+	assert.EqualError(t, err, "key and value of 'key' exceed the ORAM block size 64")
+}
+
+func TestORAMConfig(t *testing.T) {
+	config, err := newORAMConfig(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultORAMConfig, config)
+
+	_, err = newORAMConfig([]ORAMOption{WithORAMHeight(25)})
+	assert.EqualError(t, err, "invalid ORAM height 25")
+	_, err = newORAMConfig([]ORAMOption{WithORAMBucketSize(0)})
+	assert.EqualError(t, err, "invalid ORAM bucket size 0")
+	_, err = newORAMConfig([]ORAMOption{WithORAMBlockSize(-1)})
+	assert.EqualError(t, err, "invalid ORAM block size -1")
+}
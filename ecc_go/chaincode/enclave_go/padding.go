@@ -0,0 +1,107 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+)
+
+// PaddingPolicy determines the length to which a plaintext is padded before encryption, such that the ciphertext
+// hides the exact length of the plaintext, e.g., of a balance. The name identifies the plaintext, i.e., the key for
+// state and the function name for responses.
+type PaddingPolicy interface {
+	// PaddedLength returns the padded length of a plaintext of the given length, which must exceed the length by
+	// at least one byte (see crypto.Pad)
+	PaddedLength(name string, length int) (int, error)
+}
+
+// pad pads the plaintext according to the policy
+func pad(policy PaddingPolicy, name string, plaintext []byte) ([]byte, error) {
+	length, err := policy.PaddedLength(name, len(plaintext))
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Pad(plaintext, length)
+}
+
+type bucketPadding struct {
+	buckets []int
+}
+
+// NewBucketPadding returns a policy that pads plaintexts to the smallest bucket size that fits; plaintexts that
+// exceed the largest bucket are padded to a multiple of the largest bucket.
+func NewBucketPadding(buckets ...int) (PaddingPolicy, error) {
+	if len(buckets) == 0 {
+		return nil, fmt.Errorf("no buckets given")
+	}
+
+	sorted := append([]int(nil), buckets...)
+	sort.Ints(sorted)
+	if sorted[0] <= 0 {
+		return nil, fmt.Errorf("invalid bucket size %d", sorted[0])
+	}
+	return &bucketPadding{buckets: sorted}, nil
+}
+
+func (p *bucketPadding) PaddedLength(_ string, length int) (int, error) {
+	// the padding takes at least one byte
+	length++
+
+	for _, bucket := range p.buckets {
+		if length <= bucket {
+			return bucket, nil
+		}
+	}
+
+	largest := p.buckets[len(p.buckets)-1]
+	return (length + largest - 1) / largest * largest, nil
+}
+
+type fixedSizePadding struct {
+	sizes    map[string]int
+	fallback PaddingPolicy
+}
+
+// NewFixedSizePadding returns a policy that pads plaintexts to the fixed size of the longest prefix of the name, i.e.,
+// all values with a given key prefix have the same length. Plaintexts that exceed the fixed size (minus one byte for
+// the padding) are rejected. Plaintexts without matching prefix are padded with the fallback policy, if given, and
+// otherwise padded by one byte only.
+func NewFixedSizePadding(sizes map[string]int, fallback PaddingPolicy) (PaddingPolicy, error) {
+	copied := make(map[string]int, len(sizes))
+	for prefix, size := range sizes {
+		if size <= 0 {
+			return nil, fmt.Errorf("invalid size %d for prefix '%s'", size, prefix)
+		}
+		copied[prefix] = size
+	}
+	return &fixedSizePadding{sizes: copied, fallback: fallback}, nil
+}
+
+func (p *fixedSizePadding) PaddedLength(name string, length int) (int, error) {
+	prefix, size, found := "", 0, false
+	for candidate, candidateSize := range p.sizes {
+		if strings.HasPrefix(name, candidate) && (!found || len(candidate) > len(prefix)) {
+			prefix, size, found = candidate, candidateSize, true
+		}
+	}
+
+	if !found {
+		if p.fallback != nil {
+			return p.fallback.PaddedLength(name, length)
+		}
+		return length + 1, nil
+	}
+
+	if length >= size {
+		return 0, fmt.Errorf("plaintext of %d bytes exceeds the fixed size %d of prefix '%s'", length, size, prefix)
+	}
+	return size, nil
+}
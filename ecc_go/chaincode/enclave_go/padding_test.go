@@ -0,0 +1,63 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketPadding(t *testing.T) {
+	policy, err := NewBucketPadding(256, 32, 64)
+	require.NoError(t, err)
+
+	for length, expected := range map[int]int{0: 32, 31: 32, 32: 64, 100: 256, 255: 256, 256: 512, 600: 768} {
+		padded, err := policy.PaddedLength("some-key", length)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, padded, length)
+	}
+
+	_, err = NewBucketPadding()
+	assert.EqualError(t, err, "no buckets given")
+	_, err = NewBucketPadding(32, 0)
+	assert.EqualError(t, err, "invalid bucket size 0")
+}
+
+func TestFixedSizePadding(t *testing.T) {
+	buckets, err := NewBucketPadding(128)
+	require.NoError(t, err)
+	policy, err := NewFixedSizePadding(map[string]int{"balance": 16, "balance.usd": 32}, buckets)
+	require.NoError(t, err)
+
+	// the longest prefix determines the size
+	padded, err := policy.PaddedLength("balance.eur", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 16, padded)
+	padded, err = policy.PaddedLength("balance.usd", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 32, padded)
+
+	_, err = policy.PaddedLength("balance.eur", 16)
+	assert.EqualError(t, err, "plaintext of 16 bytes exceeds the fixed size 16 of prefix 'balance'")
+
+	// other keys are padded with the fallback
+	padded, err = policy.PaddedLength("name", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 128, padded)
+
+	// or by a single byte without fallback
+	policy, err = NewFixedSizePadding(map[string]int{"balance": 16}, nil)
+	require.NoError(t, err)
+	padded, err = policy.PaddedLength("name", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, padded)
+
+	_, err = NewFixedSizePadding(map[string]int{"balance": -1}, nil)
+	assert.EqualError(t, err, "invalid size -1 for prefix 'balance'")
+}
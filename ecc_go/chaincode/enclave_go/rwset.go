@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package enclave_go
 
 import (
+	"strings"
 	"sync"
 
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
@@ -33,6 +34,9 @@ type readWriteSet struct {
 	mu     sync.Mutex
 	reads  map[string]read
 	writes map[string]write
+
+	// if set, reads observe previous writes to this set (see pendingWrite)
+	readYourWrites bool
 }
 
 func NewReadWriteSet() *readWriteSet {
@@ -42,6 +46,48 @@ func NewReadWriteSet() *readWriteSet {
 	}
 }
 
+// newBatchReadWriteSet returns a readWriteSet shared by all requests of a batch invocation,
+// where each request observes the writes of the previous requests
+func newBatchReadWriteSet() *readWriteSet {
+	rwset := NewReadWriteSet()
+	rwset.readYourWrites = true
+	return rwset
+}
+
+// pendingWrite returns the value written to key if this set supports read-your-writes and key was written before;
+// for deleted keys, a nil value is returned
+func (rwset *readWriteSet) pendingWrite(key string) ([]byte, bool) {
+	if !rwset.readYourWrites {
+		return nil, false
+	}
+
+	rwset.mu.Lock()
+	defer rwset.mu.Unlock()
+	w, ok := rwset.writes[key]
+	if !ok {
+		return nil, false
+	}
+	return w.kvwrite.GetValue(), true
+}
+
+// pendingWrites returns the writes (including deletes) to keys with the given prefix if this set supports
+// read-your-writes
+func (rwset *readWriteSet) pendingWrites(prefix string) []*kvrwset.KVWrite {
+	if !rwset.readYourWrites {
+		return nil
+	}
+
+	rwset.mu.Lock()
+	defer rwset.mu.Unlock()
+	var writes []*kvrwset.KVWrite
+	for key, w := range rwset.writes {
+		if strings.HasPrefix(key, prefix) {
+			writes = append(writes, w.kvwrite)
+		}
+	}
+	return writes
+}
+
 func (rwset *readWriteSet) AddRead(key string, hash []byte) {
 	rwset.mu.Lock()
 	defer rwset.mu.Unlock()
@@ -0,0 +1,74 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"encoding/base64"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// EnclaveSigner signs on behalf of the enclave, i.e., with the enclave signing key registered at ERCC
+type EnclaveSigner interface {
+	Sign(msg []byte) (signature []byte, err error)
+	GetEnclaveId() string
+}
+
+// SealedArgumentAttester is implemented by the stub passed to FPC chaincodes. Chaincodes use it (e.g., with
+// chaincode.AttestSealedArgument) to attest metadata about arguments that clients sealed to a specific recipient
+// (see crypto.SealArgument), without being able to read their content.
+type SealedArgumentAttester interface {
+	// AttestSealedArgument returns a (base64-encoded) SignedSealedArgumentMetadata for the given (base64-encoded)
+	// SealedArgument, which binds the sealed argument, the current transaction and the chaincode-defined metadata;
+	// the recipient verifies it with crypto.VerifySealedArgumentMetadata.
+	AttestSealedArgument(sealedArgument string, metadata []byte) (signedMetadata string, err error)
+}
+
+var _ SealedArgumentAttester = (*FpcStubInterface)(nil)
+
+func (f *FpcStubInterface) AttestSealedArgument(sealedArgument string, metadata []byte) (string, error) {
+	sealed, sealedArgumentHash, err := crypto.UnmarshalSealedArgument(sealedArgument)
+	if err != nil {
+		return "", err
+	}
+
+	timestamp, err := f.GetTxTimestamp()
+	if err != nil {
+		return "", err
+	}
+
+	metadataBytes, err := proto.Marshal(&protos.SealedArgumentMetadata{
+		SealedArgumentHash: sealedArgumentHash,
+		RecipientPkHash:    sealed.GetRecipientPkHash(),
+		ChannelId:          f.GetChannelID(),
+		TxId:               f.GetTxID(),
+		Timestamp:          timestamp,
+		EnclaveId:          f.signer.GetEnclaveId(),
+		Metadata:           metadata,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sig, err := f.signer.Sign(crypto.SealedArgumentMetadataToSign(metadataBytes))
+	if err != nil {
+		return "", errors.Wrap(err, "cannot sign sealed argument metadata")
+	}
+
+	signedMetadataBytes, err := proto.Marshal(&protos.SignedSealedArgumentMetadata{
+		SealedArgumentMetadata: metadataBytes,
+		Signature:              sig,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(signedMetadataBytes), nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/endorsement/fakes"
+	"github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestAttestSealedArgument(t *testing.T) {
+	csp := crypto.GetDefaultCSP()
+	identity, err := NewEnclaveIdentity(csp)
+	require.NoError(t, err)
+
+	txTimestamp := timestamppb.New(time.Unix(1700000000, 0))
+	proposal := &pb.Proposal{
+		Header: protoutil.MarshalOrPanic(&common.Header{
+			ChannelHeader: protoutil.MarshalOrPanic(&common.ChannelHeader{ChannelId: "mychannel", TxId: "some-tx", Timestamp: txTimestamp}),
+		}),
+	}
+	stub := &fakes.ChaincodeStub{}
+	stub.GetChannelIDReturns("mychannel")
+	stub.GetTxIDReturns("some-tx")
+	stub.GetSignedProposalReturns(&pb.SignedProposal{ProposalBytes: protoutil.MarshalOrPanic(proposal)}, nil)
+
+	fpcStub := NewFpcStubInterface(stub, &pb.ChaincodeInput{}, nil, nil, nil, identity)
+
+	// a client seals a document to a recipient
+	suite, err := crypto.NewCipherSuite(csp, crypto.DefaultSealedArgumentCipherSuite)
+	require.NoError(t, err)
+	recipientPk, recipientSk, err := suite.NewKeyTransportKeys()
+	require.NoError(t, err)
+	sealedArgument, err := crypto.SealArgument(csp, crypto.DefaultSealedArgumentCipherSuite, recipientPk, []byte("some document"))
+	require.NoError(t, err)
+
+	// the chaincode attests the sealed document
+	signedMetadata, err := fpcStub.AttestSealedArgument(sealedArgument, []byte("sent by alice"))
+	require.NoError(t, err)
+
+	// which the recipient verifies and opens
+	metadata, err := crypto.VerifySealedArgumentMetadata(csp, identity.GetPublicKey(), signedMetadata, sealedArgument)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("sent by alice"), metadata.GetMetadata())
+	assert.Equal(t, "mychannel", metadata.GetChannelId())
+	assert.Equal(t, "some-tx", metadata.GetTxId())
+	assert.Equal(t, txTimestamp.AsTime(), metadata.GetTimestamp().AsTime())
+	assert.Equal(t, identity.GetEnclaveId(), metadata.GetEnclaveId())
+
+	content, err := crypto.OpenSealedArgument(csp, recipientSk, sealedArgument)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("some document"), content)
+
+	// only sealed arguments are attested
+	_, err = fpcStub.AttestSealedArgument("not a sealed argument", nil)
+	assert.Error(t, err)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 	common "github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 
 	"google.golang.org/protobuf/proto"
@@ -21,18 +22,25 @@ import (
 )
 
 type FpcStubInterface struct {
-	stub  shim.ChaincodeStubInterface
-	input *pb.ChaincodeInput
-	rwset ReadWriteSet
-	sep   StateEncryptionFunctions
+	stub   shim.ChaincodeStubInterface
+	input  *pb.ChaincodeInput
+	rwset  ReadWriteSet
+	sep    StateEncryptionFunctions
+	skf    StateKeyFunctions
+	signer EnclaveSigner
 }
 
-func NewFpcStubInterface(stub shim.ChaincodeStubInterface, input *pb.ChaincodeInput, rwset *readWriteSet, sep StateEncryptionFunctions) *FpcStubInterface {
+func NewFpcStubInterface(stub shim.ChaincodeStubInterface, input *pb.ChaincodeInput, rwset *readWriteSet, sep StateEncryptionFunctions, skf StateKeyFunctions, signer EnclaveSigner) *FpcStubInterface {
+	if skf == nil {
+		skf = cleartextStateKeys{}
+	}
 	return &FpcStubInterface{
-		stub:  stub,
-		input: input,
-		sep:   sep,
-		rwset: rwset,
+		stub:   stub,
+		input:  input,
+		sep:    sep,
+		skf:    skf,
+		rwset:  rwset,
+		signer: signer,
 	}
 }
 
@@ -83,7 +91,8 @@ func (f *FpcStubInterface) InvokeChaincode(chaincodeName string, args [][]byte,
 }
 
 func (f *FpcStubInterface) GetState(key string) ([]byte, error) {
-	encValue, err := f.GetPublicState(key)
+	ledgerKey := f.skf.LedgerKey(key)
+	encValue, err := f.GetPublicState(ledgerKey)
 	if err != nil {
 		return nil, err
 	}
@@ -93,10 +102,29 @@ func (f *FpcStubInterface) GetState(key string) ([]byte, error) {
 		return nil, nil
 	}
 
-	return f.sep.DecryptState(encValue)
+	plaintext, err := f.sep.DecryptState(key, ledgerKey, encValue)
+	if err != nil {
+		return nil, err
+	}
+
+	storedKey, value, err := f.skf.UnwrapValue(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	if storedKey != "" && storedKey != key {
+		return nil, fmt.Errorf("state of key '%s' belongs to another key", key)
+	}
+	return value, nil
 }
 
 func (f *FpcStubInterface) GetPublicState(key string) ([]byte, error) {
+	// within a batch invocation we return the values written by previous requests of the batch
+	if rwset, ok := f.rwset.(*readWriteSet); ok {
+		if value, found := rwset.pendingWrite(key); found {
+			return value, nil
+		}
+	}
+
 	value, err := f.stub.GetState(key)
 	if err != nil {
 		return nil, err
@@ -108,11 +136,12 @@ func (f *FpcStubInterface) GetPublicState(key string) ([]byte, error) {
 }
 
 func (f *FpcStubInterface) PutState(key string, value []byte) error {
-	encValue, err := f.sep.EncryptState(value)
+	ledgerKey := f.skf.LedgerKey(key)
+	encValue, err := f.sep.EncryptState(key, ledgerKey, f.skf.WrapValue(key, value))
 	if err != nil {
 		return err
 	}
-	return f.PutPublicState(key, encValue)
+	return f.PutPublicState(ledgerKey, encValue)
 }
 
 func (f *FpcStubInterface) PutPublicState(key string, value []byte) error {
@@ -124,7 +153,7 @@ func (f *FpcStubInterface) PutPublicState(key string, value []byte) error {
 }
 
 func (f *FpcStubInterface) DelState(key string) error {
-	f.rwset.AddDelete(key)
+	f.rwset.AddDelete(f.skf.LedgerKey(key))
 
 	// note that since we are not using the fabric proposal response  we can skip the delState call
 	//return f.stub.DelState(key)
@@ -148,12 +177,31 @@ func (f *FpcStubInterface) GetStateByRangeWithPagination(startKey string, endKey
 }
 
 func (f *FpcStubInterface) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
-	iterator, err := f.stub.GetStateByPartialCompositeKey(objectType, keys)
+	ledgerObjectType, ledgerKeys := f.skf.LedgerCompositeKeyPrefix(objectType, keys)
+	iterator, err := f.stub.GetStateByPartialCompositeKey(ledgerObjectType, ledgerKeys)
 	if err != nil {
 		return nil, err
 	}
 
-	return newFpcIterator(iterator, f.rwset.AddRead, f.sep.DecryptState), nil
+	// with hashed state keys, the key of a value is only known after decryption
+	keyPrefix := compositeKeyPrefix(objectType, keys)
+	return newFpcIterator(iterator, f.rwset.AddRead, func(key string, ciphertext []byte) (string, []byte, error) {
+		plaintext, err := f.sep.DecryptState(keyPrefix, key, ciphertext)
+		if err != nil {
+			return "", nil, err
+		}
+
+		storedKey, value, err := f.skf.UnwrapValue(plaintext)
+		if err != nil {
+			return "", nil, err
+		}
+		if storedKey == "" {
+			storedKey = key
+		} else if f.skf.LedgerKey(storedKey) != key {
+			return "", nil, fmt.Errorf("state of key '%s' belongs to another key", key)
+		}
+		return storedKey, value, nil
+	}).withPendingWrites(f.pendingWrites(compositeKeyPrefix(ledgerObjectType, ledgerKeys)))
 }
 
 func (f *FpcStubInterface) GetPublicStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
@@ -163,7 +211,16 @@ func (f *FpcStubInterface) GetPublicStateByPartialCompositeKey(objectType string
 	}
 
 	// note that we do not pass the state decryption function here
-	return newFpcIterator(iterator, f.rwset.AddRead, nil), nil
+	return newFpcIterator(iterator, f.rwset.AddRead, nil).withPendingWrites(f.pendingWrites(compositeKeyPrefix(objectType, keys)))
+}
+
+// pendingWrites returns the writes of previous requests of a batch to keys with the given prefix (see
+// readWriteSet.pendingWrites)
+func (f *FpcStubInterface) pendingWrites(prefix string) []*kvrwset.KVWrite {
+	if rwset, ok := f.rwset.(*readWriteSet); ok {
+		return rwset.pendingWrites(prefix)
+	}
+	return nil
 }
 
 func (f *FpcStubInterface) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
@@ -255,12 +312,18 @@ func (f *FpcStubInterface) GetSignedProposal() (*pb.SignedProposal, error) {
 }
 
 func (f *FpcStubInterface) GetTxTimestamp() (*timestamp.Timestamp, error) {
-	hdr := &common.Header{}
 	proposal, Proposalerr := f.GetSignedProposal()
 	if Proposalerr != nil {
 		return nil, fmt.Errorf("error retrieving the proposal from the FPC Stub")
 	}
-	if err := proto.Unmarshal(proposal.ProposalBytes, protoV1.MessageV2(hdr)); err != nil {
+
+	prop := &pb.Proposal{}
+	if err := proto.Unmarshal(proposal.ProposalBytes, protoV1.MessageV2(prop)); err != nil {
+		return nil, fmt.Errorf("error unmarshaling Proposal: %s", err)
+	}
+
+	hdr := &common.Header{}
+	if err := proto.Unmarshal(prop.Header, protoV1.MessageV2(hdr)); err != nil {
 		return nil, fmt.Errorf("error unmarshaling Header: %s", err)
 	}
 
@@ -7,14 +7,46 @@ SPDX-License-Identifier: Apache-2.0
 package enclave_go
 
 import (
+	"fmt"
+
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 )
 
 func NewSkvsStub(cc shim.Chaincode) *EnclaveStub {
 	enclaveStub := NewEnclaveStub(cc)
-	enclaveStub.stubProvider = func(stub shim.ChaincodeStubInterface, input *pb.ChaincodeInput, rwset *readWriteSet, sep StateEncryptionFunctions) shim.ChaincodeStubInterface {
-		return NewSkvsStubInterface(stub, input, rwset, sep)
+	enclaveStub.UseSKVS()
+	return enclaveStub
+}
+
+// NewObliviousSkvsStub returns an EnclaveStub that stores the state in a Path ORAM over a fixed set of ledger keys
+// (see ObliviousSkvsStubInterface); it panics if the options are invalid.
+func NewObliviousSkvsStub(cc shim.Chaincode, opts ...ORAMOption) *EnclaveStub {
+	enclaveStub := NewEnclaveStub(cc)
+	if err := enclaveStub.UseObliviousSKVS(opts...); err != nil {
+		panic(err)
 	}
 	return enclaveStub
 }
+
+// UseSKVS stores the state of the chaincode as a single encrypted value (see SkvsStubInterface); other settings of
+// the enclave are kept. It must be called before the enclave is initialized.
+func (e *EnclaveStub) UseSKVS() {
+	e.stubProvider = func(stub shim.ChaincodeStubInterface, input *pb.ChaincodeInput, rwset *readWriteSet, sep StateEncryptionFunctions, skf StateKeyFunctions, signer EnclaveSigner) shim.ChaincodeStubInterface {
+		return NewSkvsStubInterface(stub, input, rwset, sep, skf, signer)
+	}
+}
+
+// UseObliviousSKVS stores the state of the chaincode in a Path ORAM (see ObliviousSkvsStubInterface); other settings
+// of the enclave are kept. It must be called before the enclave is initialized.
+func (e *EnclaveStub) UseObliviousSKVS(opts ...ORAMOption) error {
+	config, err := newORAMConfig(opts)
+	if err != nil {
+		return fmt.Errorf("invalid oblivious SKVS options: %v", err)
+	}
+
+	e.stubProvider = func(stub shim.ChaincodeStubInterface, input *pb.ChaincodeInput, rwset *readWriteSet, sep StateEncryptionFunctions, skf StateKeyFunctions, signer EnclaveSigner) shim.ChaincodeStubInterface {
+		return NewObliviousSkvsStubInterface(stub, input, rwset, sep, skf, signer, config)
+	}
+	return nil
+}
@@ -7,28 +7,42 @@ SPDX-License-Identifier: Apache-2.0
 package enclave_go
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
 )
 
 const SKVSKey = "SKVS"
 
+// skvsFormatV1 is the version byte of the binary encoding of the SKVS, i.e.,
+// version || count || (len(key) || key || len(value) || value)* with keys in ascending order.
+// SKVS written before as JSON map stays readable.
+const skvsFormatV1 byte = 0x01
+
+// SkvsStubInterface stores the whole state as a single encrypted value under SKVSKey. The state is loaded once per
+// invocation; reads and writes operate on the in-memory state (i.e., reads observe the writes of the invocation), and
+// the state is encrypted and written once the chaincode returns (see flush).
 type SkvsStubInterface struct {
 	*FpcStubInterface
-	allDataOld map[string][]byte
-	allDataNew map[string][]byte
-	key        string
+	data  map[string][]byte
+	dirty bool
+	key   string
 }
 
-func NewSkvsStubInterface(stub shim.ChaincodeStubInterface, input *pb.ChaincodeInput, rwset *readWriteSet, sep StateEncryptionFunctions) *SkvsStubInterface {
-	fpcStub := NewFpcStubInterface(stub, input, rwset, sep)
+func NewSkvsStubInterface(stub shim.ChaincodeStubInterface, input *pb.ChaincodeInput, rwset *readWriteSet, sep StateEncryptionFunctions, skf StateKeyFunctions, signer EnclaveSigner) *SkvsStubInterface {
+	fpcStub := NewFpcStubInterface(stub, input, rwset, sep, skf, signer)
 	skvsStub := &SkvsStubInterface{
 		FpcStubInterface: fpcStub,
-		allDataOld:       make(map[string][]byte),
-		allDataNew:       make(map[string][]byte),
+		data:             make(map[string][]byte),
 		key:              SKVSKey,
 	}
 	err := skvsStub.initSKVS()
@@ -48,70 +62,212 @@ func (s *SkvsStubInterface) initSKVS() error {
 
 	// return if the key initially does not exist
 	if len(encValue) == 0 {
-		logger.Warningf("SKVS is empty, Initiating.")
+		logger.Debugf("SKVS is empty, Initiating.")
 		return nil
 	}
 
-	value, err := s.sep.DecryptState(encValue)
+	value, err := s.sep.DecryptState(s.key, s.key, encValue)
 	if err != nil {
 		return err
 	}
 	logger.Debug("SKVS has default value, loading current value.")
 
-	err = json.Unmarshal(value, &s.allDataOld)
+	s.data, err = unmarshalSKVS(value)
 	if err != nil {
-		logger.Errorf("SKVS Json unmarshal error: %s", err)
+		logger.Errorf("SKVS unmarshal error: %s", err)
 		return err
 	}
-	err = json.Unmarshal(value, &s.allDataNew)
+	return nil
+}
+
+// flush encrypts and writes the state if it was modified by the invocation
+func (s *SkvsStubInterface) flush() error {
+	if !s.dirty {
+		return nil
+	}
+
+	encValue, err := s.sep.EncryptState(s.key, s.key, marshalSKVS(s.data))
 	if err != nil {
-		logger.Errorf("SKVS Json unmarshal error: %s", err)
 		return err
 	}
+	if err := s.PutPublicState(s.key, encValue); err != nil {
+		return err
+	}
+	s.dirty = false
 	return nil
 }
 
 func (s *SkvsStubInterface) GetState(key string) ([]byte, error) {
-	value, found := s.allDataOld[key]
+	value, found := s.data[key]
 	if !found {
-		logger.Errorf("skvs allDataOld key: %s, not found", key)
+		logger.Debugf("skvs key: %s, not found", key)
 		return nil, nil
 	}
-	return value, nil
+	// the chaincode must not modify the state through the returned slice
+	return bytes.Clone(value), nil
 }
 
 func (s *SkvsStubInterface) PutState(key string, value []byte) error {
+	// the chaincode may reuse the slice after the call
+	s.data[key] = bytes.Clone(value)
+	s.dirty = true
+	return nil
+}
 
-	s.allDataNew[key] = value
-	byteAllData, err := json.Marshal(s.allDataNew)
-	if err != nil {
-		return err
+func (s *SkvsStubInterface) DelState(key string) error {
+	if _, found := s.data[key]; found {
+		delete(s.data, key)
+		s.dirty = true
 	}
-	encValue, err := s.sep.EncryptState(byteAllData)
-	if err != nil {
-		return err
+	return nil
+}
+
+// GetStateByRange returns the keys in [startKey, endKey) in ascending order, where an empty endKey is unbounded;
+// as in Fabric, composite keys are not included.
+func (s *SkvsStubInterface) GetStateByRange(startKey string, endKey string) (shim.StateQueryIteratorInterface, error) {
+	return s.iterator(s.rangeKeys(startKey, endKey)), nil
+}
+
+func (s *SkvsStubInterface) GetStateByRangeWithPagination(startKey string, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if bookmark != "" {
+		startKey = bookmark
 	}
+	keys := s.rangeKeys(startKey, endKey)
+	return s.paginate(keys, pageSize)
+}
 
-	return s.PutPublicState(s.key, encValue)
+func (s *SkvsStubInterface) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	return s.iterator(s.prefixKeys(compositeKeyPrefix(objectType, keys), "")), nil
 }
 
-func (s *SkvsStubInterface) DelState(key string) error {
-	delete(s.allDataNew, key)
-	byteAllData, err := json.Marshal(s.allDataNew)
-	if err != nil {
-		return err
+func (s *SkvsStubInterface) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	return s.paginate(s.prefixKeys(compositeKeyPrefix(objectType, keys), bookmark), pageSize)
+}
+
+// rangeKeys returns the sorted (non-composite) keys in [startKey, endKey)
+func (s *SkvsStubInterface) rangeKeys(startKey string, endKey string) []string {
+	keys := make([]string, 0)
+	for key := range s.data {
+		if utils.IsFPCCompositeKey(key) || key < startKey || (endKey != "" && key >= endKey) {
+			continue
+		}
+		keys = append(keys, key)
 	}
-	encValue, err := s.sep.EncryptState(byteAllData)
-	if err != nil {
-		return err
+	sort.Strings(keys)
+	return keys
+}
+
+// prefixKeys returns the sorted keys with the given prefix, starting at bookmark (if given)
+func (s *SkvsStubInterface) prefixKeys(prefix string, bookmark string) []string {
+	keys := make([]string, 0)
+	for key := range s.data {
+		if strings.HasPrefix(key, prefix) && key >= bookmark {
+			keys = append(keys, key)
+		}
 	}
-	return s.PutPublicState(s.key, encValue)
+	sort.Strings(keys)
+	return keys
 }
 
-func (s *SkvsStubInterface) GetStateByRange(startKey string, endKey string) (shim.StateQueryIteratorInterface, error) {
-	panic("not implemented") // TODO: Implement
+// paginate returns an iterator over the first pageSize keys; the bookmark of the metadata is the next key, if any
+func (s *SkvsStubInterface) paginate(keys []string, pageSize int32) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if pageSize <= 0 {
+		return nil, nil, fmt.Errorf("invalid page size %d", pageSize)
+	}
+
+	metadata := &pb.QueryResponseMetadata{}
+	if len(keys) > int(pageSize) {
+		metadata.Bookmark = keys[pageSize]
+		keys = keys[:pageSize]
+	}
+	metadata.FetchedRecordsCount = int32(len(keys))
+	return s.iterator(keys), metadata, nil
 }
 
-func (s *SkvsStubInterface) GetStateByRangeWithPagination(startKey string, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
-	panic("not implemented") // TODO: Implement
+// iterator returns an iterator over a snapshot of the given keys and their values
+func (s *SkvsStubInterface) iterator(keys []string) *skvsIterator {
+	kvs := make([]*queryresult.KV, 0, len(keys))
+	for _, key := range keys {
+		kvs = append(kvs, &queryresult.KV{Key: key, Value: bytes.Clone(s.data[key])})
+	}
+	return &skvsIterator{kvs: kvs}
+}
+
+// compositeKeyPrefix returns the prefix of the FPC composite keys (see CreateCompositeKey) of the object type with
+// the given leading attributes
+func compositeKeyPrefix(objectType string, attributes []string) string {
+	var b strings.Builder
+	b.WriteString(compositeKeySep)
+	b.WriteString(objectType)
+	b.WriteString(compositeKeySep)
+	for _, attribute := range attributes {
+		b.WriteString(attribute)
+		b.WriteString(compositeKeySep)
+	}
+	return b.String()
+}
+
+// skvsIterator iterates over a snapshot of the SKVS
+type skvsIterator struct {
+	kvs []*queryresult.KV
+}
+
+func (i *skvsIterator) HasNext() bool {
+	return len(i.kvs) > 0
+}
+
+func (i *skvsIterator) Next() (*queryresult.KV, error) {
+	if len(i.kvs) == 0 {
+		return nil, fmt.Errorf("no more results")
+	}
+	kv := i.kvs[0]
+	i.kvs = i.kvs[1:]
+	return kv, nil
+}
+
+func (i *skvsIterator) Close() error {
+	return nil
+}
+
+// marshalSKVS serializes the state with the binary encoding (see skvsFormatV1)
+func marshalSKVS(data map[string][]byte) []byte {
+	keys := make([]string, 0, len(data))
+	size := 1 + binary.MaxVarintLen64
+	for key, value := range data {
+		keys = append(keys, key)
+		size += 2*binary.MaxVarintLen64 + len(key) + len(value)
+	}
+	sort.Strings(keys)
+
+	buf := make([]byte, 0, size)
+	buf = append(buf, skvsFormatV1)
+	buf = binary.AppendUvarint(buf, uint64(len(keys)))
+	for _, key := range keys {
+		buf = appendBytes(buf, []byte(key))
+		buf = appendBytes(buf, data[key])
+	}
+	return buf
+}
+
+// unmarshalSKVS restores the state serialized with marshalSKVS or as JSON map
+func unmarshalSKVS(buf []byte) (map[string][]byte, error) {
+	data := make(map[string][]byte)
+	if len(buf) == 0 {
+		return data, nil
+	}
+	if buf[0] != skvsFormatV1 {
+		if err := json.Unmarshal(buf, &data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+
+	blocks, err := (&entryReader{buf: buf[1:]}).blocks()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid SKVS encoding")
+	}
+	for _, block := range blocks {
+		data[block.key] = block.value
+	}
+	return data, nil
 }
@@ -0,0 +1,196 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collectKeys(t *testing.T, it shim.StateQueryIteratorInterface) []string {
+	var keys []string
+	for it.HasNext() {
+		kv, err := it.Next()
+		require.NoError(t, err)
+		keys = append(keys, kv.GetKey())
+	}
+	require.NoError(t, it.Close())
+	return keys
+}
+
+func TestSkvsStubInterface(t *testing.T) {
+	ledger := newMemLedger()
+	newStub := newSkvsStubProvider(t)
+
+	ledger.invoke(t, newStub, func(stub shim.ChaincodeStubInterface) {
+		require.NoError(t, stub.PutState("alice", []byte("100")))
+		require.NoError(t, stub.PutState("bob", []byte("50")))
+		require.NoError(t, stub.PutState("charlie", []byte("10")))
+		require.NoError(t, stub.PutState(".account.alice.usd.", []byte("1")))
+		require.NoError(t, stub.PutState(".account.alice.eur.", []byte("2")))
+		require.NoError(t, stub.PutState(".account.bob.usd.", []byte("3")))
+
+		// read your writes
+		value, err := stub.GetState("alice")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("100"), value)
+
+		require.NoError(t, stub.PutState("alice", []byte("90")))
+		value, err = stub.GetState("alice")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("90"), value)
+	})
+
+	// the whole state is written once
+	require.Len(t, ledger.state, 1)
+	require.Contains(t, ledger.state, SKVSKey)
+
+	ledger.invoke(t, newStub, func(stub shim.ChaincodeStubInterface) {
+		value, err := stub.GetState("alice")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("90"), value)
+
+		require.NoError(t, stub.DelState("charlie"))
+		value, err = stub.GetState("charlie")
+		assert.NoError(t, err)
+		assert.Nil(t, value)
+	})
+
+	ledger.invoke(t, newStub, func(stub shim.ChaincodeStubInterface) {
+		// range queries exclude composite keys
+		it, err := stub.GetStateByRange("", "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice", "bob"}, collectKeys(t, it))
+
+		it, err = stub.GetStateByRange("b", "c")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bob"}, collectKeys(t, it))
+
+		it, metadata, err := stub.GetStateByRangeWithPagination("", "", 1, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice"}, collectKeys(t, it))
+		assert.Equal(t, "bob", metadata.GetBookmark())
+
+		it, metadata, err = stub.GetStateByRangeWithPagination("", "", 1, metadata.GetBookmark())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bob"}, collectKeys(t, it))
+		assert.Empty(t, metadata.GetBookmark())
+
+		it, err = stub.GetStateByPartialCompositeKey("account", []string{"alice"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{".account.alice.eur.", ".account.alice.usd."}, collectKeys(t, it))
+
+		it, metadata, err = stub.GetStateByPartialCompositeKeyWithPagination("account", nil, 2, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{".account.alice.eur.", ".account.alice.usd."}, collectKeys(t, it))
+		assert.Equal(t, int32(2), metadata.GetFetchedRecordsCount())
+
+		it, _, err = stub.GetStateByPartialCompositeKeyWithPagination("account", nil, 2, metadata.GetBookmark())
+		require.NoError(t, err)
+		assert.Equal(t, []string{".account.bob.usd."}, collectKeys(t, it))
+
+		_, _, err = stub.GetStateByRangeWithPagination("", "", 0, "")
+		assert.EqualError(t, err, "invalid page size 0")
+	})
+}
+
+func TestSkvsStubInterfaceCopiesValues(t *testing.T) {
+	ledger := newMemLedger()
+	newStub := newSkvsStubProvider(t)
+
+	ledger.invoke(t, newStub, func(stub shim.ChaincodeStubInterface) {
+		// the chaincode reuses its buffer after PutState
+		buf := []byte("100")
+		require.NoError(t, stub.PutState("alice", buf))
+		copy(buf, "999")
+		value, err := stub.GetState("alice")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("100"), value)
+
+		// and modifies the values it reads
+		copy(value, "999")
+		value, err = stub.GetState("alice")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("100"), value)
+
+		it, err := stub.GetStateByRange("", "")
+		require.NoError(t, err)
+		kv, err := it.Next()
+		require.NoError(t, err)
+		copy(kv.Value, "999")
+		value, err = stub.GetState("alice")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("100"), value)
+	})
+
+	ledger.invoke(t, newStub, func(stub shim.ChaincodeStubInterface) {
+		value, err := stub.GetState("alice")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("100"), value)
+	})
+}
+
+func TestSkvsStubInterfaceFlush(t *testing.T) {
+	keys, err := NewChaincodeKeys(crypto.GetDefaultCSP(), "some-chaincode")
+	require.NoError(t, err)
+	ledger := newMemLedger()
+
+	// an invocation that only reads does not write the state
+	rwset := NewReadWriteSet()
+	stub := NewSkvsStubInterface(ledger.stub(), &pb.ChaincodeInput{}, rwset, keys, nil, nil)
+	_, err = stub.GetState("alice")
+	require.NoError(t, err)
+	require.NoError(t, stub.flush())
+	assert.Empty(t, rwset.ToFPCKVSet().GetRwSet().GetWrites())
+
+	// writes are buffered until flush
+	rwset = NewReadWriteSet()
+	stub = NewSkvsStubInterface(ledger.stub(), &pb.ChaincodeInput{}, rwset, keys, nil, nil)
+	require.NoError(t, stub.PutState("alice", []byte("100")))
+	require.NoError(t, stub.PutState("bob", []byte("50")))
+	assert.Empty(t, rwset.ToFPCKVSet().GetRwSet().GetWrites())
+	require.NoError(t, stub.flush())
+	writes := rwset.ToFPCKVSet().GetRwSet().GetWrites()
+	require.Len(t, writes, 1)
+	assert.Equal(t, SKVSKey, writes[0].GetKey())
+}
+
+func TestSkvsEncoding(t *testing.T) {
+	data := map[string][]byte{
+		"alice":               []byte("100"),
+		"bob":                 {},
+		".account.alice.usd.": {0x00, 0x01, 0x02},
+	}
+
+	buf := marshalSKVS(data)
+	assert.Equal(t, skvsFormatV1, buf[0])
+	decoded, err := unmarshalSKVS(buf)
+	require.NoError(t, err)
+	assert.Len(t, decoded, len(data))
+	for key, value := range data {
+		assert.Equal(t, value, decoded[key], key)
+	}
+
+	// the encoding is deterministic
+	assert.Equal(t, buf, marshalSKVS(decoded))
+
+	// SKVS written as JSON map remains readable
+	legacy, err := json.Marshal(data)
+	require.NoError(t, err)
+	decoded, err = unmarshalSKVS(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("100"), decoded["alice"])
+
+	_, err = unmarshalSKVS(buf[:len(buf)-1])
+	assert.Error(t, err)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+)
+
+const (
+	// hmacStateKeysLabel is the HKDF label to derive the secret of the hashed state keys (see deriveKey)
+	hmacStateKeysLabel = "FPC hashed state keys v1"
+
+	compositeKeySep = "."
+)
+
+// StateKeyFunctions map the (plain or FPC composite) keys used by the chaincode to the keys stored on the ledger.
+// As the mapping may not be invertible, the key is also stored with the (encrypted) value (see WrapValue).
+type StateKeyFunctions interface {
+	// LedgerKey returns the ledger key of a plain or FPC composite key
+	LedgerKey(key string) string
+	// LedgerCompositeKeyPrefix returns the object type and attributes of the ledger key prefix for a partial
+	// composite key query; it maps each attribute separately, such that prefix queries keep working
+	LedgerCompositeKeyPrefix(objectType string, attributes []string) (string, []string)
+	// WrapValue returns the plaintext to encrypt for a value of the given key
+	WrapValue(key string, value []byte) []byte
+	// UnwrapValue returns the key and the value of a (decrypted) plaintext created by WrapValue
+	UnwrapValue(plaintext []byte) (key string, value []byte, err error)
+}
+
+// cleartextStateKeys store keys as they are; this is the default
+type cleartextStateKeys struct{}
+
+func (cleartextStateKeys) LedgerKey(key string) string {
+	return key
+}
+
+func (cleartextStateKeys) LedgerCompositeKeyPrefix(objectType string, attributes []string) (string, []string) {
+	return objectType, attributes
+}
+
+func (cleartextStateKeys) WrapValue(key string, value []byte) []byte {
+	return value
+}
+
+func (cleartextStateKeys) UnwrapValue(plaintext []byte) (string, []byte, error) {
+	return "", plaintext, nil
+}
+
+// hmacStateKeys map keys with HMAC-SHA256 under a secret derived from the chaincode master secret (i.e., a keyed
+// PRF), such that the ledger reveals neither the keys nor the attributes of composite keys, but only whether two keys
+// are equal. The object type and each attribute of a composite key are mapped separately (the attributes bound to the
+// object type and their position); the mapped components are hex-encoded and thus never contain the composite key
+// separator.
+type hmacStateKeys struct {
+	secret []byte
+}
+
+func newHMACStateKeys(secret []byte) *hmacStateKeys {
+	return &hmacStateKeys{secret: secret}
+}
+
+func (h *hmacStateKeys) LedgerKey(key string) string {
+	if !utils.IsFPCCompositeKey(key) {
+		return h.mac("key", key)
+	}
+
+	comp := utils.SplitFPCCompositeKey(key)
+	objectType, attributes := h.LedgerCompositeKeyPrefix(comp[0], comp[1:])
+	return compositeKeySep + strings.Join(append([]string{objectType}, attributes...), compositeKeySep) + compositeKeySep
+}
+
+func (h *hmacStateKeys) LedgerCompositeKeyPrefix(objectType string, attributes []string) (string, []string) {
+	mapped := make([]string, 0, len(attributes))
+	for i, attribute := range attributes {
+		mapped = append(mapped, h.mac("attribute", objectType, fmt.Sprint(i), attribute))
+	}
+	return h.mac("type", objectType), mapped
+}
+
+func (h *hmacStateKeys) WrapValue(key string, value []byte) []byte {
+	plaintext := binary.AppendUvarint(nil, uint64(len(key)))
+	plaintext = append(plaintext, key...)
+	return append(plaintext, value...)
+}
+
+func (h *hmacStateKeys) UnwrapValue(plaintext []byte) (string, []byte, error) {
+	keyLen, n := binary.Uvarint(plaintext)
+	if n <= 0 || keyLen > uint64(len(plaintext)-n) {
+		return "", nil, fmt.Errorf("invalid state value")
+	}
+	plaintext = plaintext[n:]
+	return string(plaintext[:keyLen]), plaintext[keyLen:], nil
+}
+
+// mac returns the hex-encoded HMAC of the (length-prefixed) fields
+func (h *hmacStateKeys) mac(fields ...string) string {
+	m := hmac.New(sha256.New, h.secret)
+	for _, field := range fields {
+		writeField(m, field)
+	}
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+func writeField(w io.Writer, field string) {
+	var l [4]byte
+	binary.BigEndian.PutUint32(l[:], uint32(len(field)))
+	_, _ = w.Write(l[:])
+	_, _ = io.WriteString(w, field)
+}
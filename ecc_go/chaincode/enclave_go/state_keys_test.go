@@ -0,0 +1,139 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/endorsement/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sliceIterator iterates over a fixed list of ledger entries
+type sliceIterator struct {
+	kvs []*queryresult.KV
+}
+
+func (i *sliceIterator) HasNext() bool {
+	return len(i.kvs) > 0
+}
+
+func (i *sliceIterator) Next() (*queryresult.KV, error) {
+	kv := i.kvs[0]
+	i.kvs = i.kvs[1:]
+	return kv, nil
+}
+
+func (i *sliceIterator) Close() error {
+	return nil
+}
+
+func TestHMACStateKeys(t *testing.T) {
+	skf := newHMACStateKeys([]byte("some secret"))
+
+	// keys are mapped deterministically and do not reveal the key
+	ledgerKey := skf.LedgerKey("alice")
+	assert.Equal(t, ledgerKey, skf.LedgerKey("alice"))
+	assert.NotEqual(t, ledgerKey, skf.LedgerKey("bob"))
+	assert.NotContains(t, ledgerKey, "alice")
+	assert.False(t, utils.IsFPCCompositeKey(ledgerKey))
+
+	// with another secret, keys are mapped differently
+	otherSkf := newHMACStateKeys([]byte("other secret"))
+	assert.NotEqual(t, ledgerKey, otherSkf.LedgerKey("alice"))
+
+	// composite keys stay composite keys and start with the mapped prefix
+	compositeKey := skf.LedgerKey(".account.alice.usd.")
+	require.True(t, utils.IsFPCCompositeKey(compositeKey))
+	assert.NotContains(t, compositeKey, "alice")
+	assert.Len(t, utils.SplitFPCCompositeKey(compositeKey), 3)
+
+	objectType, attributes := skf.LedgerCompositeKeyPrefix("account", []string{"alice"})
+	prefix := compositeKeySep + strings.Join(append([]string{objectType}, attributes...), compositeKeySep) + compositeKeySep
+	assert.True(t, strings.HasPrefix(compositeKey, prefix))
+
+	// attributes are bound to the object type and their position
+	assert.NotEqual(t, utils.SplitFPCCompositeKey(skf.LedgerKey(".account.alice."))[1], utils.SplitFPCCompositeKey(skf.LedgerKey(".asset.alice."))[1])
+	assert.NotEqual(t, utils.SplitFPCCompositeKey(skf.LedgerKey(".account.alice.alice."))[1], utils.SplitFPCCompositeKey(skf.LedgerKey(".account.alice.alice."))[2])
+
+	// values carry the original key
+	key, value, err := skf.UnwrapValue(skf.WrapValue("alice", []byte("some value")))
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", key)
+	assert.Equal(t, []byte("some value"), value)
+
+	_, _, err = skf.UnwrapValue([]byte{0x10, 'a'})
+	assert.EqualError(t, err, "invalid state value")
+}
+
+func TestFpcStubInterfaceHashedStateKeys(t *testing.T) {
+	ccKeys, err := NewChaincodeKeys(crypto.GetDefaultCSP(), "some-chaincode")
+	require.NoError(t, err)
+	skf, err := ccKeys.hashedStateKeys()
+	require.NoError(t, err)
+
+	// write a plain and a composite key
+	rwset := NewReadWriteSet()
+	fpcStub := NewFpcStubInterface(&fakes.ChaincodeStub{}, &pb.ChaincodeInput{}, rwset, ccKeys, skf, nil)
+	require.NoError(t, fpcStub.PutState("alice", []byte("some value")))
+	compositeKey := ".account.alice.usd."
+	require.NoError(t, fpcStub.PutState(compositeKey, []byte("100")))
+
+	// only mapped keys reach the rwset
+	writes := rwset.ToFPCKVSet().GetRwSet().GetWrites()
+	require.Len(t, writes, 2)
+	ledger := make(map[string][]byte)
+	for _, w := range writes {
+		assert.NotContains(t, w.GetKey(), "alice")
+		ledger[w.GetKey()] = w.GetValue()
+	}
+
+	// and read back with the original keys
+	stub := &fakes.ChaincodeStub{}
+	stub.GetStateCalls(func(key string) ([]byte, error) {
+		return ledger[key], nil
+	})
+	fpcStub = NewFpcStubInterface(stub, &pb.ChaincodeInput{}, NewReadWriteSet(), ccKeys, skf, nil)
+	value, err := fpcStub.GetState("alice")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("some value"), value)
+	value, err = fpcStub.GetState(compositeKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("100"), value)
+
+	// a value cannot be moved to another key
+	ledger[skf.LedgerKey("bob")] = ledger[skf.LedgerKey("alice")]
+	_, err = fpcStub.GetState("bob")
+	assert.Error(t, err)
+
+	// partial composite key queries work on the mapped attributes and return the original keys
+	ledgerCompositeKey := skf.LedgerKey(compositeKey)
+	stub.GetStateByPartialCompositeKeyCalls(func(objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+		mappedObjectType, mappedAttributes := skf.LedgerCompositeKeyPrefix("account", []string{"alice"})
+		assert.Equal(t, mappedObjectType, objectType)
+		assert.Equal(t, mappedAttributes, attributes)
+		return &sliceIterator{kvs: []*queryresult.KV{{
+			Key:   strings.ReplaceAll(ledgerCompositeKey, compositeKeySep, "\x00"),
+			Value: ledger[ledgerCompositeKey],
+		}}}, nil
+	})
+	iterator, err := fpcStub.GetStateByPartialCompositeKey("account", []string{"alice"})
+	require.NoError(t, err)
+	require.True(t, iterator.HasNext())
+	kv, err := iterator.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, compositeKey, kv.GetKey())
+	assert.Equal(t, []byte("100"), kv.GetValue())
+	assert.False(t, iterator.HasNext())
+}
@@ -8,10 +8,14 @@ package enclave_go
 
 import (
 	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
 )
 
 func hash(value []byte) []byte {
@@ -20,13 +24,27 @@ func hash(value []byte) []byte {
 	return h.Sum(nil)
 }
 
+// decryptFunction decrypts the value of the ledger key and returns the key used by the chaincode together with the
+// plaintext value (see StateKeyFunctions)
+type decryptFunction func(ledgerKey string, ciphertext []byte) (key string, plaintext []byte, err error)
+
 type fpcIterator struct {
 	iterator        shim.StateQueryIteratorInterface
 	addReadFunction func(key string, hash []byte)
-	decryptFunction func(ciphertext []byte) (plaintext []byte, err error)
+	decryptFunction decryptFunction
+
+	// if merged, the results are served from results instead of iterator (see withPendingWrites)
+	merged  bool
+	results []iteratorResult
+}
+
+type iteratorResult struct {
+	kv *queryresult.KV
+	// pending results are written by previous requests of a batch and thus not read from the ledger
+	pending bool
 }
 
-func newFpcIterator(iterator shim.StateQueryIteratorInterface, addReadFunction func(key string, hash []byte), decryptFunction func(ciphertext []byte) (plaintext []byte, err error)) *fpcIterator {
+func newFpcIterator(iterator shim.StateQueryIteratorInterface, addReadFunction func(key string, hash []byte), decryptFunction decryptFunction) *fpcIterator {
 	return &fpcIterator{
 		iterator:        iterator,
 		addReadFunction: addReadFunction,
@@ -34,7 +52,55 @@ func newFpcIterator(iterator shim.StateQueryIteratorInterface, addReadFunction f
 	}
 }
 
+// withPendingWrites merges the writes of previous requests of a batch (see readWriteSet.pendingWrites) into the
+// results, i.e., deleted keys are omitted and written keys carry the written value. As on the ledger, the results
+// are ordered by key.
+func (i *fpcIterator) withPendingWrites(writes []*kvrwset.KVWrite) (*fpcIterator, error) {
+	if len(writes) == 0 {
+		return i, nil
+	}
+
+	pending := make(map[string]bool, len(writes))
+	for _, w := range writes {
+		pending[w.GetKey()] = true
+	}
+
+	var results []iteratorResult
+	for i.iterator.HasNext() {
+		q, err := i.iterator.Next()
+		if err != nil {
+			_ = i.iterator.Close()
+			return nil, err
+		}
+		if q == nil || pending[utils.TransformToFPCKey(q.Key)] {
+			continue
+		}
+		results = append(results, iteratorResult{kv: q})
+	}
+	for _, w := range writes {
+		if !w.GetIsDelete() {
+			results = append(results, iteratorResult{kv: &queryresult.KV{Key: w.GetKey(), Value: w.GetValue()}, pending: true})
+		}
+	}
+
+	// the ledger orders composite keys by their attributes, i.e., with the separator \x00
+	sort.Slice(results, func(a, b int) bool {
+		return ledgerOrder(results[a].kv.Key) < ledgerOrder(results[b].kv.Key)
+	})
+
+	i.merged = true
+	i.results = results
+	return i, nil
+}
+
+func ledgerOrder(key string) string {
+	return strings.ReplaceAll(utils.TransformToFPCKey(key), compositeKeySep, "\x00")
+}
+
 func (i *fpcIterator) HasNext() bool {
+	if i.merged {
+		return len(i.results) > 0
+	}
 	return i.iterator.HasNext()
 }
 
@@ -43,6 +109,15 @@ func (i *fpcIterator) Close() error {
 }
 
 func (i *fpcIterator) Next() (*queryresult.KV, error) {
+	if i.merged {
+		if len(i.results) == 0 {
+			return nil, fmt.Errorf("no more results")
+		}
+		r := i.results[0]
+		i.results = i.results[1:]
+		return i.result(r.kv, !r.pending)
+	}
+
 	q, err := i.iterator.Next()
 	if err != nil {
 		return nil, err
@@ -52,22 +127,30 @@ func (i *fpcIterator) Next() (*queryresult.KV, error) {
 		return q, nil
 	}
 
+	return i.result(q, true)
+}
+
+// result records the read of a ledger entry, if read from the ledger, and decrypts its value
+func (i *fpcIterator) result(q *queryresult.KV, read bool) (*queryresult.KV, error) {
 	// add to rwset
-	i.addReadFunction(utils.TransformToFPCKey(q.Key), hash(q.Value))
+	key := utils.TransformToFPCKey(q.Key)
+	if read {
+		i.addReadFunction(key, hash(q.Value))
+	}
 
 	if i.decryptFunction == nil {
 		return q, nil
 	}
 
 	// decrypt if state decryption function set
-	decValue, err := i.decryptFunction(q.Value)
+	key, decValue, err := i.decryptFunction(key, q.Value)
 	if err != nil {
 		return nil, err
 	}
 
 	return &queryresult.KV{
 		Namespace: q.Namespace,
-		Key:       utils.TransformToFPCKey(q.Key),
+		Key:       key,
 		Value:     decValue,
 	}, nil
 }
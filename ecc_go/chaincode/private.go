@@ -30,8 +30,78 @@ func NewPrivateChaincode(cc shim.Chaincode, options ...BuildOption) *chaincode.E
 	return ecc
 }
 
+// WithSKVS stores the whole state under a single key (see enclave_go.SkvsStubInterface). The other options apply
+// regardless of whether they are given before or after it.
 func WithSKVS() BuildOption {
 	return func(ecc *chaincode.EnclaveChaincode, cc shim.Chaincode) {
+		if e, ok := ecc.Enclave.(*enclave_go.EnclaveStub); ok {
+			e.UseSKVS()
+			return
+		}
 		ecc.Enclave = enclave_go.NewSkvsStub(cc)
 	}
 }
+
+// WithObliviousSKVS stores the state in a Path ORAM over a fixed set of ledger keys (see
+// enclave_go.ObliviousSkvsStubInterface), such that the ledger does not reveal which keys are read or written. In
+// contrast to WithSKVS, which re-encrypts all values on every invocation that writes, an access re-encrypts O(log n)
+// buckets and the position map. The other options apply regardless of whether they are given before or after it. It
+// panics if the ORAM options are invalid.
+func WithObliviousSKVS(opts ...enclave_go.ORAMOption) BuildOption {
+	return func(ecc *chaincode.EnclaveChaincode, cc shim.Chaincode) {
+		if e, ok := ecc.Enclave.(*enclave_go.EnclaveStub); ok {
+			if err := e.UseObliviousSKVS(opts...); err != nil {
+				panic(err)
+			}
+			return
+		}
+		ecc.Enclave = enclave_go.NewObliviousSkvsStub(cc, opts...)
+	}
+}
+
+// WithHashedStateKeys enables the hashed state keys mode (see enclave_go.EnclaveStub.EnableHashedStateKeys), in which
+// the ledger does not reveal the keys of the chaincode state. Note that it has no effect with WithSKVS and
+// WithObliviousSKVS, which do not store the state under the keys of the chaincode.
+func WithHashedStateKeys() BuildOption {
+	return func(ecc *chaincode.EnclaveChaincode, cc shim.Chaincode) {
+		if e, ok := ecc.Enclave.(*enclave_go.EnclaveStub); ok {
+			e.EnableHashedStateKeys()
+		}
+	}
+}
+
+// WithStatePadding pads state values before encryption according to the policy (see enclave_go.NewBucketPadding and
+// enclave_go.NewFixedSizePadding), such that the ledger does not reveal the exact length of the values.
+func WithStatePadding(policy enclave_go.PaddingPolicy) BuildOption {
+	return func(ecc *chaincode.EnclaveChaincode, cc shim.Chaincode) {
+		if e, ok := ecc.Enclave.(*enclave_go.EnclaveStub); ok {
+			e.SetStatePadding(policy)
+		}
+	}
+}
+
+// WithResponsePadding pads chaincode responses before encryption according to the policy, where fixed sizes apply to
+// function name prefixes, such that the encrypted response does not reveal the exact length of the response.
+func WithResponsePadding(policy enclave_go.PaddingPolicy) BuildOption {
+	return func(ecc *chaincode.EnclaveChaincode, cc shim.Chaincode) {
+		if e, ok := ecc.Enclave.(*enclave_go.EnclaveStub); ok {
+			e.SetResponsePadding(policy)
+		}
+	}
+}
+
+// WithStatePrefixes encrypts the state below each of the given key prefixes with its own state key derived from the
+// chaincode master secret (see enclave_go.ChaincodeKeys.SetStatePrefixes).
+func WithStatePrefixes(prefixes ...string) BuildOption {
+	return func(ecc *chaincode.EnclaveChaincode, cc shim.Chaincode) {
+		if e, ok := ecc.Enclave.(*enclave_go.EnclaveStub); ok {
+			e.SetStatePrefixes(prefixes...)
+		}
+	}
+}
+
+// enclave_go supports batch invocations
+var _ chaincode.BatchEnclave = &enclave_go.EnclaveStub{}
+
+// enclave_go supports the renewal of enclave credentials
+var _ chaincode.RenewableEnclave = &enclave_go.EnclaveStub{}
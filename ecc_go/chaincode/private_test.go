@@ -0,0 +1,153 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package chaincode
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode"
+	"github.com/hyperledger/fabric-private-chaincode/ecc_go/chaincode/enclave_go"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/endorsement/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	bccsputils "github.com/hyperledger/fabric/bccsp/utils"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// putChaincode stores the value of its single argument under the key "k"
+type putChaincode struct{}
+
+func (putChaincode) Init(shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (putChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	_, args := stub.GetFunctionAndParameters()
+	if err := stub.PutState("k", []byte(args[0])); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+// newSignedProposal returns a proposal for mychannel signed by a (self-signed) client
+func newSignedProposal(t *testing.T) *pb.SignedProposal {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &sk.PublicKey, sk)
+	require.NoError(t, err)
+
+	creator := protoutil.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1MSP", IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})})
+	proposalBytes := protoutil.MarshalOrPanic(&pb.Proposal{
+		Header: protoutil.MarshalOrPanic(&common.Header{
+			ChannelHeader:   protoutil.MarshalOrPanic(&common.ChannelHeader{ChannelId: "mychannel", TxId: "some-tx"}),
+			SignatureHeader: protoutil.MarshalOrPanic(&common.SignatureHeader{Creator: creator}),
+		}),
+	})
+
+	digest := sha256.Sum256(proposalBytes)
+	sig, err := ecdsa.SignASN1(rand.Reader, sk, digest[:])
+	require.NoError(t, err)
+	sig, err = bccsputils.SignatureToLowS(&sk.PublicKey, sig)
+	require.NoError(t, err)
+	return &pb.SignedProposal{ProposalBytes: proposalBytes, Signature: sig}
+}
+
+// invokePut initializes the enclave of ecc and invokes putChaincode with the given value; it returns the response
+// message of the enclave
+func invokePut(t *testing.T, ecc *chaincode.EnclaveChaincode, value string) *protos.ChaincodeResponseMessage {
+	ccParams, err := proto.Marshal(&protos.CCParameters{ChaincodeId: "some-chaincode", Version: "1.0", ChannelId: "mychannel"})
+	require.NoError(t, err)
+	hostParams, err := proto.Marshal(&protos.HostParameters{})
+	require.NoError(t, err)
+	credentialsBytes, err := ecc.Enclave.Init(ccParams, hostParams, nil)
+	require.NoError(t, err)
+	credentials := &protos.Credentials{}
+	require.NoError(t, proto.Unmarshal(credentialsBytes, credentials))
+	attestedData := &protos.AttestedData{}
+	require.NoError(t, credentials.GetSerializedAttestedData().UnmarshalTo(attestedData))
+
+	provider := &crypto.EncryptionProviderImpl{
+		GetCcEncryptionKey: func() ([]byte, error) {
+			return []byte(base64.StdEncoding.EncodeToString(attestedData.GetChaincodeEk())), nil
+		},
+	}
+	ctx, err := provider.NewEncryptionContext()
+	require.NoError(t, err)
+	request, err := ctx.Conceal("put", []string{value})
+	require.NoError(t, err)
+	requestBytes, err := base64.StdEncoding.DecodeString(request)
+	require.NoError(t, err)
+
+	stub := &fakes.ChaincodeStub{}
+	stub.GetSignedProposalReturns(newSignedProposal(t), nil)
+	signedResponse, err := ecc.Enclave.ChaincodeInvoke(stub, requestBytes)
+	require.NoError(t, err)
+	signedResponseMessage, err := utils.UnmarshalSignedChaincodeResponseMessage(signedResponse)
+	require.NoError(t, err)
+	response, err := utils.UnmarshalChaincodeResponseMessage(signedResponseMessage.GetChaincodeResponseMessage())
+	require.NoError(t, err)
+	return response
+}
+
+func TestBuildOptionsOrder(t *testing.T) {
+	statePadding, err := enclave_go.NewBucketPadding(1024)
+	require.NoError(t, err)
+	responsePadding, err := enclave_go.NewBucketPadding(256)
+	require.NoError(t, err)
+	padding := []BuildOption{WithStatePadding(statePadding), WithResponsePadding(responsePadding)}
+
+	for name, backend := range map[string]BuildOption{
+		"SKVS":          WithSKVS(),
+		"ObliviousSKVS": WithObliviousSKVS(enclave_go.WithORAMHeight(2)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			// the padding applies whether it is given before or after the backend
+			before := invokePut(t, NewPrivateChaincode(putChaincode{}, append(padding, backend)...), "v")
+			after := invokePut(t, NewPrivateChaincode(putChaincode{}, append([]BuildOption{backend}, padding...)...), "v")
+			unpadded := invokePut(t, NewPrivateChaincode(putChaincode{}, backend), "v")
+
+			assert.True(t, before.GetPaddedResponses())
+			assert.True(t, after.GetPaddedResponses())
+			assert.False(t, unpadded.GetPaddedResponses())
+
+			valueLengths := func(response *protos.ChaincodeResponseMessage) []int {
+				var lengths []int
+				for _, w := range response.GetFpcRwSet().GetRwSet().GetWrites() {
+					lengths = append(lengths, len(w.GetValue()))
+				}
+				return lengths
+			}
+			require.NotEmpty(t, valueLengths(before))
+			assert.Equal(t, valueLengths(before), valueLengths(after))
+			assert.NotEqual(t, valueLengths(unpadded), valueLengths(before))
+		})
+	}
+}
@@ -0,0 +1,26 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/ecc_go/chaincode/enclave_go"
+)
+
+// AttestSealedArgument lets a FPC chaincode attest metadata about an argument that the client sealed to a specific
+// recipient, e.g., the sender or the type of a document for a counterparty. The chaincode cannot read the content of
+// the sealed argument; the returned (base64-encoded) SignedSealedArgumentMetadata is signed by the enclave and can
+// be verified by the recipient together with the sealed argument.
+func AttestSealedArgument(stub shim.ChaincodeStubInterface, sealedArgument string, metadata []byte) (string, error) {
+	attester, ok := stub.(enclave_go.SealedArgumentAttester)
+	if !ok {
+		return "", fmt.Errorf("stub does not support sealed arguments, chaincode must run as FPC chaincode")
+	}
+	return attester.AttestSealedArgument(sealedArgument, metadata)
+}
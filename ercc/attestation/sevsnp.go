@@ -0,0 +1,27 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"os"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/sevsnp"
+)
+
+func init() {
+	// without the AMD certificate chain (ASK and ARK), SEV-SNP evidence is rejected
+	var opts []sevsnp.VerifierOption
+	if path := os.Getenv("SEV_SNP_CERT_CHAIN_PATH"); len(path) != 0 {
+		certs, err := sevsnp.LoadCertificates(path)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, sevsnp.WithCertificates(certs...))
+	}
+
+	registry.add(sevsnp.NewSEVSNPVerifier(opts...))
+}
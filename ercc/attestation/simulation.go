@@ -9,5 +9,12 @@ package attestation
 import "github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
 
 func init() {
-	registry.add(simulation.NewSimulationVerifier())
+	// the C++ enclave in SGX simulation mode does not issue simulated quotes yet; its unbound attestations are only
+	// accepted if explicitly enabled (see simulation.LegacyAttestationsEnv)
+	opts, err := simulation.LegacyAttestationsFromEnv()
+	if err != nil {
+		panic(err)
+	}
+
+	registry.add(simulation.NewSimulationVerifier(opts...))
 }
@@ -0,0 +1,27 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"os"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/dcap"
+)
+
+func init() {
+	// TDX quotes are rooted in the Intel SGX Root CA unless a different root CA is configured
+	var opts []dcap.VerifierOption
+	if path := os.Getenv("TDX_ROOT_CA_PATH"); len(path) != 0 {
+		roots, err := dcap.LoadTrustedRoots(path)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, dcap.WithTrustedRoots(roots))
+	}
+
+	registry.add(dcap.NewTDXVerifier(opts...))
+}
@@ -3,7 +3,9 @@ package fakes
 
 import (
 	"sync"
+	"time"
 
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
 )
 
@@ -20,6 +22,20 @@ type CredentialVerifier struct {
 	verifyCredentialsReturnsOnCall map[int]struct {
 		result1 error
 	}
+	VerifyCredentialsWithPolicyStub        func(*protos.Credentials, string, *types.AppraisalPolicy, time.Time) error
+	verifyCredentialsWithPolicyMutex       sync.RWMutex
+	verifyCredentialsWithPolicyArgsForCall []struct {
+		arg1 *protos.Credentials
+		arg2 string
+		arg3 *types.AppraisalPolicy
+		arg4 time.Time
+	}
+	verifyCredentialsWithPolicyReturns struct {
+		result1 error
+	}
+	verifyCredentialsWithPolicyReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -86,11 +102,73 @@ func (fake *CredentialVerifier) VerifyCredentialsReturnsOnCall(i int, result1 er
 	}{result1}
 }
 
+func (fake *CredentialVerifier) VerifyCredentialsWithPolicy(arg1 *protos.Credentials, arg2 string, arg3 *types.AppraisalPolicy, arg4 time.Time) error {
+	fake.verifyCredentialsWithPolicyMutex.Lock()
+	ret, specificReturn := fake.verifyCredentialsWithPolicyReturnsOnCall[len(fake.verifyCredentialsWithPolicyArgsForCall)]
+	fake.verifyCredentialsWithPolicyArgsForCall = append(fake.verifyCredentialsWithPolicyArgsForCall, struct {
+		arg1 *protos.Credentials
+		arg2 string
+		arg3 *types.AppraisalPolicy
+		arg4 time.Time
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.VerifyCredentialsWithPolicyStub
+	fakeReturns := fake.verifyCredentialsWithPolicyReturns
+	fake.recordInvocation("VerifyCredentialsWithPolicy", []interface{}{arg1, arg2, arg3, arg4})
+	fake.verifyCredentialsWithPolicyMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *CredentialVerifier) VerifyCredentialsWithPolicyCallCount() int {
+	fake.verifyCredentialsWithPolicyMutex.RLock()
+	defer fake.verifyCredentialsWithPolicyMutex.RUnlock()
+	return len(fake.verifyCredentialsWithPolicyArgsForCall)
+}
+
+func (fake *CredentialVerifier) VerifyCredentialsWithPolicyCalls(stub func(*protos.Credentials, string, *types.AppraisalPolicy, time.Time) error) {
+	fake.verifyCredentialsWithPolicyMutex.Lock()
+	defer fake.verifyCredentialsWithPolicyMutex.Unlock()
+	fake.VerifyCredentialsWithPolicyStub = stub
+}
+
+func (fake *CredentialVerifier) VerifyCredentialsWithPolicyArgsForCall(i int) (*protos.Credentials, string, *types.AppraisalPolicy, time.Time) {
+	fake.verifyCredentialsWithPolicyMutex.RLock()
+	defer fake.verifyCredentialsWithPolicyMutex.RUnlock()
+	argsForCall := fake.verifyCredentialsWithPolicyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *CredentialVerifier) VerifyCredentialsWithPolicyReturns(result1 error) {
+	fake.verifyCredentialsWithPolicyMutex.Lock()
+	defer fake.verifyCredentialsWithPolicyMutex.Unlock()
+	fake.VerifyCredentialsWithPolicyStub = nil
+	fake.verifyCredentialsWithPolicyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *CredentialVerifier) VerifyCredentialsWithPolicyReturnsOnCall(i int, result1 error) {
+	fake.verifyCredentialsWithPolicyMutex.Lock()
+	defer fake.verifyCredentialsWithPolicyMutex.Unlock()
+	fake.VerifyCredentialsWithPolicyStub = nil
+	if fake.verifyCredentialsWithPolicyReturnsOnCall == nil {
+		fake.verifyCredentialsWithPolicyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.verifyCredentialsWithPolicyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *CredentialVerifier) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.verifyCredentialsMutex.RLock()
-	defer fake.verifyCredentialsMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
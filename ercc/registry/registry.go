@@ -10,19 +10,35 @@ SPDX-License-Identifier: Apache-2.0
 package registry
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
 	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var logger = flogging.MustGetLogger("ercc")
 
+// registrationNonceValidity is the maximum time between issuing a registration nonce and registering an enclave with it
+const registrationNonceValidity = 10 * time.Minute
+
+// defaultCredentialValidity is the validity of registered enclave credentials if the attestation policy of the
+// chaincode does not define one (see types.AppraisalPolicy)
+const defaultCredentialValidity = 30 * 24 * time.Hour
+
 type Contract struct {
 	contractapi.Contract
 
@@ -143,7 +159,8 @@ func (rs *Contract) QueryListProvisionedEnclaves(ctx contractapi.TransactionCont
 }
 
 // QueryChaincodeEndPoints returns the chaincode endpoints for given chaincode id
-// (if more than one, they are concatenated with a ",")
+// (if more than one, they are concatenated with a ",").
+// Endpoints of enclaves with expired credentials are omitted.
 func (rs *Contract) QueryChaincodeEndPoints(ctx contractapi.TransactionContextInterface, chaincodeId string) (string, error) {
 	iter, err := ctx.GetStub().GetStateByPartialCompositeKey("namespaces/credentials", []string{chaincodeId})
 	if iter != nil {
@@ -157,6 +174,11 @@ func (rs *Contract) QueryChaincodeEndPoints(ctx contractapi.TransactionContextIn
 		return "", nil
 	}
 
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return "", fmt.Errorf("cannot get transaction timestamp: %s", err)
+	}
+
 	peerEndpoints := ""
 	for iter.HasNext() {
 		q, err := iter.Next()
@@ -169,6 +191,11 @@ func (rs *Contract) QueryChaincodeEndPoints(ctx contractapi.TransactionContextIn
 			return "", err
 		}
 
+		// enclaves with expired credentials are not available until renewed
+		if utils.CredentialsExpired(credentials, txTimestamp.AsTime()) {
+			continue
+		}
+
 		endpoint, err := utils.ExtractEndpoint(credentials)
 		if err != nil {
 			return "", err
@@ -188,57 +215,96 @@ func (rs *Contract) QueryChaincodeEncryptionKey(ctx contractapi.TransactionConte
 	// NOTE: This is a (momentary) short-cut over the FPC and FPC Lite specification in `docs/design/fabric-v2+/fpc-registration.puml` and `docs/design/fabric-v2+/fpc-key-dist.puml`.  See also `common/enclave/cc_data.cpp` and `protos/fpc/fpc.proto`
 	// TODO: remove short cut (see also RegisterEnclave and RegisterCCKeys (Post-MVP)
 
-	// retrieve the enclave id
-	iter, err := ctx.GetStub().GetStateByPartialCompositeKey("namespaces/credentials", []string{chaincodeId})
-	if iter != nil {
-		defer iter.Close()
-	}
+	attestedData, err := rs.attestedDataOfValidEnclave(ctx, chaincodeId)
 	if err != nil {
 		return "", err
 	}
 
-	// pick the first one from the list
-	q, err := iter.Next()
+	chaincodeEKBytes := attestedData.GetChaincodeEk()
+
+	// b64 encoded chaincode key
+	b64ChaincodeEK := base64.StdEncoding.EncodeToString(chaincodeEKBytes)
+	logger.Debugf("QueryChaincodeEncryptionKey: EK: '%s' / EK b64: '%s'", string(chaincodeEKBytes), b64ChaincodeEK)
+
+	return b64ChaincodeEK, nil
+}
+
+// QueryChaincodeEncryptionKeys returns the (base64-encoded) ChaincodeEncryptionKeys for a given chaincode id, i.e.,
+// the cipher suites supported by the chaincode with their chaincode encryption keys in order of preference.
+// For chaincodes that do not advertise cipher suites, only CIPHER_SUITE_RSA_OAEP_AES128_GCM with chaincode_ek is returned.
+func (rs *Contract) QueryChaincodeEncryptionKeys(ctx contractapi.TransactionContextInterface, chaincodeId string) (string, error) {
+	attestedData, err := rs.attestedDataOfValidEnclave(ctx, chaincodeId)
 	if err != nil {
 		return "", err
 	}
-	_, res, err := ctx.GetStub().SplitCompositeKey(q.Key)
-	if err != nil {
-		logger.Debugf("no split")
-		return "", err
+
+	chaincodeEks := attestedData.GetChaincodeEks()
+	if len(chaincodeEks) == 0 {
+		chaincodeEks = []*protos.ChaincodeEncryptionKey{{
+			CipherSuite: protos.CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM,
+			ChaincodeEk: attestedData.GetChaincodeEk(),
+		}}
 	}
-	enclaveId := res[1]
 
-	// recreate composite key of credentials
-	k, err := ctx.GetStub().CreateCompositeKey("namespaces/credentials", []string{chaincodeId, enclaveId})
-	if err != nil {
-		return "", err
+	return utils.MarshallProtoBase64(&protos.ChaincodeEncryptionKeys{ChaincodeEks: chaincodeEks}), nil
+}
+
+func equalChaincodeEks(a, b []*protos.ChaincodeEncryptionKey) bool {
+	if len(a) != len(b) {
+		return false
 	}
+	for i := range a {
+		if !proto.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
 
-	// get credentials from state
-	credentialsBase64, err := ctx.GetStub().GetState(k)
+// attestedDataOfValidEnclave returns the attested data of the first enclave of the chaincode whose credentials are not expired
+func (rs *Contract) attestedDataOfValidEnclave(ctx contractapi.TransactionContextInterface, chaincodeId string) (*protos.AttestedData, error) {
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("cannot get transaction timestamp: %s", err)
 	}
 
-	// retrieve chaincode ek from credentials
-	credentials, err := utils.UnmarshalCredentials(string(credentialsBase64))
+	// retrieve the enclave credentials
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey("namespaces/credentials", []string{chaincodeId})
+	if iter != nil {
+		defer iter.Close()
+	}
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	// pick the first one from the list whose credentials are not expired
+	var credentials *protos.Credentials
+	for iter != nil && iter.HasNext() {
+		q, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		c, err := utils.UnmarshalCredentials(string(q.Value))
+		if err != nil {
+			return nil, err
+		}
+
+		if !utils.CredentialsExpired(c, txTimestamp.AsTime()) {
+			credentials = c
+			break
+		}
+	}
+	if credentials == nil {
+		return nil, fmt.Errorf("no enclave with valid credentials registered for chaincode %s", chaincodeId)
 	}
 
 	var attestedData protos.AttestedData
 	if err := credentials.SerializedAttestedData.UnmarshalTo(&attestedData); err != nil {
-		return "", err
+		return nil, err
 	}
 
-	chaincodeEKBytes := attestedData.GetChaincodeEk()
-
-	// b64 encoded chaincode key
-	b64ChaincodeEK := base64.StdEncoding.EncodeToString(chaincodeEKBytes)
-	logger.Debugf("QueryChaincodeEncryptionKey: EK: '%s' / EK b64: '%s'", string(chaincodeEKBytes), b64ChaincodeEK)
-
-	return b64ChaincodeEK, nil
+	return &attestedData, nil
 }
 
 // RegisterEnclave register a new FPC chaincode enclave instance
@@ -287,8 +353,8 @@ func (rs *Contract) RegisterEnclave(ctx contractapi.TransactionContextInterface,
 	// All check passed, now register enclave
 	logger.Debugf("Registering credentials at key %s", key)
 
-	if err := ctx.GetStub().PutState(key, []byte(credentialsBase64)); err != nil {
-		return fmt.Errorf("cannot store credentials: %s", err)
+	if err := putCredentials(ctx, key, chaincodeId, credentials); err != nil {
+		return err
 	}
 
 	// Due to MVP short-cut (see QueryChaincodeEncryptionKey) we already declare chaincode/enclave as provisioned
@@ -306,6 +372,96 @@ func (rs *Contract) RegisterEnclave(ctx contractapi.TransactionContextInterface,
 	return nil
 }
 
+// RenewEnclaveCredentials replaces the credentials of a registered enclave with credentials based on fresh attestation
+// evidence for the same enclave (i.e., the same enclave_vk and chaincode_ek) and extends their expiry.
+// The evidence is checked as in RegisterEnclave, including the registration nonce and the current attestation policy.
+func (rs *Contract) RenewEnclaveCredentials(ctx contractapi.TransactionContextInterface, credentialsBase64 string) error {
+	logger.Debugf("RenewEnclaveCredentials")
+
+	credentials, err := utils.UnmarshalCredentials(credentialsBase64)
+	if err != nil {
+		return errors.Wrap(err, "invalid credential bytes")
+	}
+
+	if len(credentials.Evidence) == 0 {
+		return errors.New("evidence is empty")
+	}
+
+	attestedData, err := utils.UnmarshalAttestedData(credentials.SerializedAttestedData)
+	if err != nil {
+		return errors.Wrap(err, "invalid attested data message")
+	}
+
+	if err := checkAttestedData(ctx, rs.Verifier, rs.IEvaluator, attestedData, credentials); err != nil {
+		return err
+	}
+
+	chaincodeId := attestedData.CcParams.ChaincodeId
+	enclaveId := utils.GetEnclaveId(attestedData)
+
+	key, err := ctx.GetStub().CreateCompositeKey("namespaces/credentials", []string{chaincodeId, enclaveId})
+	if err != nil {
+		return err
+	}
+
+	registeredCredentialsBase64, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("cannot get credentials: %s", err)
+	}
+	if len(registeredCredentialsBase64) == 0 {
+		return fmt.Errorf("enclave %s is not registered for chaincode %s", enclaveId, chaincodeId)
+	}
+
+	registeredCredentials, err := utils.UnmarshalCredentials(string(registeredCredentialsBase64))
+	if err != nil {
+		return errors.Wrap(err, "invalid registered credentials")
+	}
+	registeredAttestedData, err := utils.UnmarshalAttestedData(registeredCredentials.SerializedAttestedData)
+	if err != nil {
+		return errors.Wrap(err, "invalid registered attested data")
+	}
+
+	// the enclave id is derived from enclave_vk, still, we double check the keys
+	if !bytes.Equal(attestedData.EnclaveVk, registeredAttestedData.EnclaveVk) {
+		return errors.New("enclave_vk does not match registered enclave")
+	}
+	if !bytes.Equal(attestedData.ChaincodeEk, registeredAttestedData.ChaincodeEk) {
+		return errors.New("chaincode_ek does not match registered enclave")
+	}
+	if !equalChaincodeEks(attestedData.ChaincodeEks, registeredAttestedData.ChaincodeEks) {
+		return errors.New("chaincode_eks do not match registered enclave")
+	}
+
+	logger.Debugf("Renewing credentials at key %s", key)
+
+	return putCredentials(ctx, key, chaincodeId, credentials)
+}
+
+// putCredentials stores the credentials with an expiry according to the attestation policy of the chaincode
+func putCredentials(ctx contractapi.TransactionContextInterface, key string, chaincodeId string, credentials *protos.Credentials) error {
+	policy, err := getAttestationPolicy(ctx, chaincodeId)
+	if err != nil {
+		return err
+	}
+
+	validity := defaultCredentialValidity
+	if policy != nil && policy.CredentialValiditySeconds > 0 {
+		validity = time.Duration(policy.CredentialValiditySeconds) * time.Second
+	}
+
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("cannot get transaction timestamp: %s", err)
+	}
+	credentials.ExpiresAt = timestamppb.New(txTimestamp.AsTime().Add(validity))
+
+	if err := ctx.GetStub().PutState(key, []byte(utils.MarshallProtoBase64(credentials))); err != nil {
+		return fmt.Errorf("cannot store credentials: %s", err)
+	}
+
+	return nil
+}
+
 func checkAttestedData(ctx contractapi.TransactionContextInterface, v attestation.Verifier, ie utils.IdentityEvaluatorInterface, attestedData *protos.AttestedData, credentials *protos.Credentials) error {
 
 	// check that the enclave channelId matches ERCC channelId
@@ -330,8 +486,23 @@ func checkAttestedData(ctx contractapi.TransactionContextInterface, v attestatio
 		return fmt.Errorf("sequence does not match chaincode definition")
 	}
 
+	policy, err := getAttestationPolicy(ctx, attestedData.CcParams.ChaincodeId)
+	if err != nil {
+		return err
+	}
+
 	// check that attestation evidence contains expectedMrEnclave as defined in chaincode definition
-	if err := v.VerifyCredentials(credentials, expectedMrEnclave); err != nil {
+	// and, if an appraisal policy is set for the chaincode, that the attestation report satisfies the policy
+	if policy == nil {
+		err = v.VerifyCredentials(credentials, expectedMrEnclave)
+	} else {
+		txTimestamp, tsErr := ctx.GetStub().GetTxTimestamp()
+		if tsErr != nil {
+			return fmt.Errorf("cannot get transaction timestamp: %s", tsErr)
+		}
+		err = v.VerifyCredentialsWithPolicy(credentials, expectedMrEnclave, policy, txTimestamp.AsTime())
+	}
+	if err != nil {
 		return fmt.Errorf("evidence verification failed: %s", err)
 	}
 
@@ -350,6 +521,11 @@ func checkAttestedData(ctx contractapi.TransactionContextInterface, v attestatio
 		return fmt.Errorf("creator identity evaluation failed: %s", err)
 	}
 
+	// check that the attestation is fresh, i.e., bound to a registration nonce issued recently by ERCC
+	if err := consumeRegistrationNonce(ctx, attestedData.CcParams.ChaincodeId, attestedData.HostParams.PeerMspId, attestedData.HostParams.Nonce); err != nil {
+		return err
+	}
+
 	// TODO add more checks (POST-MVP)
 	// - channel_hash should correspond to peers view of channel id
 	// - TLCC_MRENCLAVE matches the version baked into ERCC
@@ -358,6 +534,294 @@ func checkAttestedData(ctx contractapi.TransactionContextInterface, v attestatio
 	return nil
 }
 
+// IssueRegistrationNonce issues a new registration nonce for the given chaincode id. The nonce is the id of this
+// transaction, hence, clients submitting this transaction know the nonce without evaluating the response.
+// The nonce must be passed to `__initEnclave` and can be used once for `RegisterEnclave` within registrationNonceValidity
+// by the org that requested it. Only members of the orgs that approved the chaincode definition can request nonces.
+// Expired nonces of the requesting org are removed.
+func (rs *Contract) IssueRegistrationNonce(ctx contractapi.TransactionContextInterface, chaincodeId string) (string, error) {
+	ccDef, err := utils.GetChaincodeDefinition(chaincodeId, ctx.GetStub())
+	if err != nil {
+		return "", fmt.Errorf("cannot get chaincode definition: %s", err)
+	}
+
+	creatorIdentityBytes, err := ctx.GetStub().GetCreator()
+	if err != nil {
+		return "", err
+	}
+
+	mspId, err := utils.ExtractMSPID(creatorIdentityBytes)
+	if err != nil {
+		return "", fmt.Errorf("cannot get creator msp: %s", err)
+	}
+
+	if !ccDef.Approvals[mspId] {
+		return "", fmt.Errorf("creator msp %s has not approved the chaincode definition", mspId)
+	}
+
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return "", fmt.Errorf("cannot get transaction timestamp: %s", err)
+	}
+
+	if err := purgeExpiredRegistrationNonces(ctx, chaincodeId, mspId, txTimestamp.AsTime()); err != nil {
+		return "", err
+	}
+
+	nonce := ctx.GetStub().GetTxID()
+	key, err := ctx.GetStub().CreateCompositeKey("namespaces/nonce", []string{chaincodeId, mspId, nonce})
+	if err != nil {
+		return "", err
+	}
+
+	if err := ctx.GetStub().PutState(key, []byte(txTimestamp.AsTime().UTC().Format(time.RFC3339Nano))); err != nil {
+		return "", fmt.Errorf("cannot store registration nonce: %s", err)
+	}
+
+	return nonce, nil
+}
+
+// consumeRegistrationNonce checks that the nonce was issued for the chaincode to the given org within
+// registrationNonceValidity and removes it, along with the expired nonces of the org, so that it cannot be used again
+func consumeRegistrationNonce(ctx contractapi.TransactionContextInterface, chaincodeId string, mspId string, nonce string) error {
+	if len(nonce) == 0 {
+		// credentials of enclaves that were not initialized with a nonce from IssueRegistrationNonce (see ercc/README.md)
+		return errors.New("registration nonce is empty")
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey("namespaces/nonce", []string{chaincodeId, mspId, nonce})
+	if err != nil {
+		return err
+	}
+
+	issuedAtBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("cannot get registration nonce: %s", err)
+	}
+	if len(issuedAtBytes) == 0 {
+		return fmt.Errorf("registration nonce %s is unknown or already used", nonce)
+	}
+
+	issuedAt, err := time.Parse(time.RFC3339Nano, string(issuedAtBytes))
+	if err != nil {
+		return fmt.Errorf("invalid registration nonce: %s", err)
+	}
+
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("cannot get transaction timestamp: %s", err)
+	}
+	if txTimestamp.AsTime().Sub(issuedAt) > registrationNonceValidity {
+		// note that the nonce cannot be removed here as this transaction fails; it is purged with the next
+		// nonce issued to, or successful registration of, the org
+		return fmt.Errorf("registration nonce %s expired", nonce)
+	}
+
+	if err := ctx.GetStub().DelState(key); err != nil {
+		return fmt.Errorf("cannot remove registration nonce: %s", err)
+	}
+
+	return purgeExpiredRegistrationNonces(ctx, chaincodeId, mspId, txTimestamp.AsTime())
+}
+
+// purgeExpiredRegistrationNonces removes the nonces issued for the chaincode to the given org that expired at now.
+// Nonces are kept per org so that the range read does not conflict with the registrations of other orgs.
+func purgeExpiredRegistrationNonces(ctx contractapi.TransactionContextInterface, chaincodeId string, mspId string, now time.Time) error {
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey("namespaces/nonce", []string{chaincodeId, mspId})
+	if iter != nil {
+		defer iter.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("cannot get registration nonces: %s", err)
+	}
+	if iter == nil {
+		return nil
+	}
+
+	for iter.HasNext() {
+		q, err := iter.Next()
+		if err != nil {
+			return fmt.Errorf("cannot get registration nonces: %s", err)
+		}
+
+		// unparsable nonces cannot be used and are removed as well
+		issuedAt, err := time.Parse(time.RFC3339Nano, string(q.Value))
+		if err == nil && now.Sub(issuedAt) <= registrationNonceValidity {
+			continue
+		}
+
+		if err := ctx.GetStub().DelState(q.Key); err != nil {
+			return fmt.Errorf("cannot remove registration nonce: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// SetAttestationPolicy sets the appraisal policy (see types.AppraisalPolicy) applied to the attestation reports of
+// all enclaves registered for the given chaincode id. Enclaves that are already registered are not re-evaluated.
+// The policy is given as json, e.g., `{"allowed_statuses": ["OK"], "min_isv_svn": 1, "max_report_age_seconds": 86400}`.
+// Only admins of the orgs that approved the chaincode definition can approve a policy, and the policy is set once
+// admins of a majority of these orgs approved it, such that a single org cannot weaken the policy on its own; until
+// then, the approvals are stored per policy (see QueryAttestationPolicyApprovals). With a single approving org, the
+// approval of its admin sets the policy immediately.
+func (rs *Contract) SetAttestationPolicy(ctx contractapi.TransactionContextInterface, chaincodeId string, policyJson string) error {
+	ccDef, err := utils.GetChaincodeDefinition(chaincodeId, ctx.GetStub())
+	if err != nil {
+		return fmt.Errorf("cannot get chaincode definition: %s", err)
+	}
+
+	creatorIdentityBytes, err := ctx.GetStub().GetCreator()
+	if err != nil {
+		return err
+	}
+
+	var approvingMSPs []string
+	for mspId, approved := range ccDef.Approvals {
+		if approved {
+			approvingMSPs = append(approvingMSPs, mspId)
+		}
+	}
+	sort.Strings(approvingMSPs)
+
+	if err := rs.IEvaluator.EvaluateAdminIdentity(creatorIdentityBytes, approvingMSPs); err != nil {
+		return fmt.Errorf("creator is not authorized to set the attestation policy: %s", err)
+	}
+	creatorMSP, err := utils.ExtractMSPID(creatorIdentityBytes)
+	if err != nil {
+		return err
+	}
+
+	if _, err := types.ParseAppraisalPolicy([]byte(policyJson)); err != nil {
+		return err
+	}
+
+	approvalsKey, err := policyApprovalsKey(ctx, chaincodeId, policyJson)
+	if err != nil {
+		return err
+	}
+	approvals, err := getPolicyApprovals(ctx, approvalsKey)
+	if err != nil {
+		return err
+	}
+
+	// only approvals of orgs that (still) approve the chaincode definition count
+	approvedBy := []string{creatorMSP}
+	for _, mspId := range approvals {
+		if mspId != creatorMSP && contains(approvingMSPs, mspId) {
+			approvedBy = append(approvedBy, mspId)
+		}
+	}
+	sort.Strings(approvedBy)
+
+	if 2*len(approvedBy) <= len(approvingMSPs) {
+		logger.Infof("attestation policy for %s approved by %v; %d of %d approving orgs required", chaincodeId, approvedBy, len(approvingMSPs)/2+1, len(approvingMSPs))
+		approvalsJson, err := json.Marshal(approvedBy)
+		if err != nil {
+			return err
+		}
+		if err := ctx.GetStub().PutState(approvalsKey, approvalsJson); err != nil {
+			return fmt.Errorf("cannot store attestation policy approvals: %s", err)
+		}
+		return nil
+	}
+
+	if err := ctx.GetStub().DelState(approvalsKey); err != nil {
+		return fmt.Errorf("cannot remove attestation policy approvals: %s", err)
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey("namespaces/policy", []string{chaincodeId})
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.GetStub().PutState(key, []byte(policyJson)); err != nil {
+		return fmt.Errorf("cannot store attestation policy: %s", err)
+	}
+
+	return nil
+}
+
+// QueryAttestationPolicyApprovals returns the MSP IDs of the orgs that approved the given (json-encoded) attestation
+// policy for the chaincode id so far (see SetAttestationPolicy); it is empty once the policy is set
+func (rs *Contract) QueryAttestationPolicyApprovals(ctx contractapi.TransactionContextInterface, chaincodeId string, policyJson string) ([]string, error) {
+	approvalsKey, err := policyApprovalsKey(ctx, chaincodeId, policyJson)
+	if err != nil {
+		return nil, err
+	}
+	return getPolicyApprovals(ctx, approvalsKey)
+}
+
+// policyApprovalsKey returns the key of the approvals of a policy, which are stored by the hash of the policy
+func policyApprovalsKey(ctx contractapi.TransactionContextInterface, chaincodeId string, policyJson string) (string, error) {
+	policyHash := sha256.Sum256([]byte(policyJson))
+	return ctx.GetStub().CreateCompositeKey("namespaces/policyApprovals", []string{chaincodeId, hex.EncodeToString(policyHash[:])})
+}
+
+func getPolicyApprovals(ctx contractapi.TransactionContextInterface, approvalsKey string) ([]string, error) {
+	approvalsJson, err := ctx.GetStub().GetState(approvalsKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get attestation policy approvals: %s", err)
+	}
+	if len(approvalsJson) == 0 {
+		return nil, nil
+	}
+
+	var approvals []string
+	if err := json.Unmarshal(approvalsJson, &approvals); err != nil {
+		return nil, fmt.Errorf("invalid attestation policy approvals: %s", err)
+	}
+	return approvals, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// QueryAttestationPolicy returns the json-encoded appraisal policy for the given chaincode id or an empty string
+// if no policy is set
+func (rs *Contract) QueryAttestationPolicy(ctx contractapi.TransactionContextInterface, chaincodeId string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey("namespaces/policy", []string{chaincodeId})
+	if err != nil {
+		return "", err
+	}
+
+	policyJson, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(policyJson), nil
+}
+
+func getAttestationPolicy(ctx contractapi.TransactionContextInterface, chaincodeId string) (*types.AppraisalPolicy, error) {
+	key, err := ctx.GetStub().CreateCompositeKey("namespaces/policy", []string{chaincodeId})
+	if err != nil {
+		return nil, err
+	}
+
+	policyJson, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get attestation policy: %s", err)
+	}
+
+	if len(policyJson) == 0 {
+		return nil, nil
+	}
+
+	policy, err := types.ParseAppraisalPolicy(policyJson)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestation policy: %s", err)
+	}
+
+	return policy, nil
+}
+
 // RegisterCCKeys  registers a CCKeyRegistration message that confirms that an enclave is provisioned with the chaincode encryption key.
 // This method is used during the key generation and key distribution protocol. In particular, during key generation,
 // this call sets the chaincode_ek for a chaincode if no chaincode_ek is set yet.
@@ -10,20 +10,25 @@ package registry_test
 import (
 	"encoding/base64"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/hyperledger/fabric-private-chaincode/ercc/registry"
 	"github.com/hyperledger/fabric-private-chaincode/ercc/registry/fakes"
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
 	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/msp"
 	"github.com/hyperledger/fabric-protos-go/peer/lifecycle"
 	"github.com/hyperledger/fabric/protoutil"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
@@ -64,8 +69,25 @@ var (
 	chaincodeId = "SOME_CHAINCODE_PKG_ID"
 	enclaveId   = "some enclave id"
 	someMspId   = "some org"
+	nonce       = "a8b2de1c0e4d55b65b8c0c9bcd7c8f3e6f3d20ad9e4e2b0b5cf12fbf0f3f5d11"
+	issuedAt    = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
 )
 
+// compositeKey mimics ChaincodeStub.CreateCompositeKey with readable keys
+func compositeKey(objectType string, attributes []string) (string, error) {
+	return objectType + "/" + strings.Join(attributes, "/"), nil
+}
+
+// stateWithNonce returns a GetState function for a state that only contains the given registration nonce
+func stateWithNonce(nonce string) func(key string) ([]byte, error) {
+	return func(key string) ([]byte, error) {
+		if key == "namespaces/nonce/"+chaincodeId+"/"+someMspId+"/"+nonce {
+			return []byte(issuedAt.Format(time.RFC3339Nano)), nil
+		}
+		return nil, nil
+	}
+}
+
 func toBase64(credentials *protos.Credentials) string {
 	credentialBytes := protoutil.MarshalOrPanic(credentials)
 	return base64.StdEncoding.EncodeToString(credentialBytes)
@@ -225,6 +247,7 @@ func TestRegisterEnclave(t *testing.T) {
 			},
 			HostParams: &protos.HostParameters{
 				PeerMspId: someMspId,
+				Nonce:     nonce,
 			},
 		})
 	credentialBase64 = toBase64(&protos.Credentials{
@@ -250,7 +273,12 @@ func TestRegisterEnclave(t *testing.T) {
 	err = ercc.RegisterEnclave(transactionContext, credentialBase64)
 	require.EqualError(t, err, "cannot create composite key")
 
-	chaincodeStub.CreateCompositeKeyReturns("someKey", nil)
+	chaincodeStub.CreateCompositeKeyStub = compositeKey
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(issuedAt.Add(time.Minute)), nil)
+	err = ercc.RegisterEnclave(transactionContext, credentialBase64)
+	require.EqualError(t, err, "registration nonce "+nonce+" is unknown or already used")
+
+	chaincodeStub.GetStateStub = stateWithNonce(nonce)
 	chaincodeStub.PutStateReturns(fmt.Errorf("some put state error"))
 	err = ercc.RegisterEnclave(transactionContext, credentialBase64)
 	require.EqualError(t, err, "cannot store credentials: some put state error")
@@ -258,6 +286,266 @@ func TestRegisterEnclave(t *testing.T) {
 	chaincodeStub.PutStateReturns(nil)
 	err = ercc.RegisterEnclave(transactionContext, credentialBase64)
 	require.NoError(t, err)
+	key := chaincodeStub.DelStateArgsForCall(chaincodeStub.DelStateCallCount() - 1)
+	require.Equal(t, "namespaces/nonce/"+chaincodeId+"/"+someMspId+"/"+nonce, key)
+	// along with the expired nonces of the org
+	var purged []string
+	for i := 0; i < chaincodeStub.GetStateByPartialCompositeKeyCallCount(); i++ {
+		if objectType, attributes := chaincodeStub.GetStateByPartialCompositeKeyArgsForCall(i); objectType == "namespaces/nonce" {
+			purged = attributes
+		}
+	}
+	require.Equal(t, []string{chaincodeId, someMspId}, purged)
+
+	// credentials are stored with the default expiry
+	key, value := chaincodeStub.PutStateArgsForCall(chaincodeStub.PutStateCallCount() - 2)
+	require.Equal(t, "namespaces/credentials/"+chaincodeId+"/"+utils.GetEnclaveId(&protos.AttestedData{EnclaveVk: []byte("enclaveVKString")}), key)
+	storedCredentials, err := utils.UnmarshalCredentials(string(value))
+	require.NoError(t, err)
+	require.Equal(t, issuedAt.Add(time.Minute).Add(30*24*time.Hour), storedCredentials.GetExpiresAt().AsTime())
+	require.Equal(t, []byte("some mock evidence"), storedCredentials.GetEvidence())
+
+	// nonce expired
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(issuedAt.Add(time.Hour)), nil)
+	err = ercc.RegisterEnclave(transactionContext, credentialBase64)
+	require.EqualError(t, err, "registration nonce "+nonce+" expired")
+}
+
+// credentialsWithExpiry returns base64-encoded credentials of an enclave at the given endpoint
+func credentialsWithExpiry(enclaveVk string, endpoint string, expiresAt *timestamppb.Timestamp) string {
+	serializedAttestedData, _ := anypb.New(
+		&protos.AttestedData{
+			EnclaveVk: []byte(enclaveVk),
+			CcParams: &protos.CCParameters{
+				ChaincodeId: chaincodeId,
+				Version:     mrenclave,
+				ChannelId:   channelId,
+				Sequence:    1,
+			},
+			HostParams: &protos.HostParameters{
+				PeerMspId:    someMspId,
+				PeerEndpoint: endpoint,
+				Nonce:        nonce,
+			},
+			ChaincodeEk: []byte("chaincodeEK"),
+		})
+	return toBase64(&protos.Credentials{
+		Evidence:               []byte("some mock evidence"),
+		SerializedAttestedData: serializedAttestedData,
+		ExpiresAt:              expiresAt,
+	})
+}
+
+func TestRenewEnclaveCredentials(t *testing.T) {
+	chaincodeStub := &fakes.ChaincodeStub{}
+	transactionContext := &fakes.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	verifier := &fakes.CredentialVerifier{}
+	id := &fakes.IdentityEvaluator{}
+
+	ercc := registry.Contract{}
+	ercc.Verifier = verifier
+	ercc.IEvaluator = id
+
+	chaincodeStub.GetChannelIDReturns(channelId)
+	chaincodeStub.InvokeChaincodeReturns(shim.Success(protoutil.MarshalOrPanic(
+		&lifecycle.QueryChaincodeDefinitionResult{
+			Version:  mrenclave,
+			Sequence: 1,
+		})))
+	chaincodeStub.CreateCompositeKeyStub = compositeKey
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(issuedAt.Add(time.Minute)), nil)
+
+	credentialsKey := "namespaces/credentials/" + chaincodeId + "/" + utils.GetEnclaveId(&protos.AttestedData{EnclaveVk: []byte("enclaveVKString")})
+	registered := credentialsWithExpiry("enclaveVKString", "peer0:7051", timestamppb.New(issuedAt.Add(-time.Hour)))
+	state := map[string][]byte{}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		if v, ok := state[key]; ok {
+			return v, nil
+		}
+		return stateWithNonce(nonce)(key)
+	}
+
+	renewed := credentialsWithExpiry("enclaveVKString", "peer0:7051", nil)
+
+	err := ercc.RenewEnclaveCredentials(transactionContext, "")
+	require.EqualError(t, err, "invalid credential bytes: credential input empty")
+
+	err = ercc.RenewEnclaveCredentials(transactionContext, toBase64(&protos.Credentials{SerializedAttestedData: &anypb.Any{}}))
+	require.EqualError(t, err, "evidence is empty")
+
+	// fresh evidence must be valid
+	verifier.VerifyCredentialsReturns(fmt.Errorf("evidence invalid"))
+	err = ercc.RenewEnclaveCredentials(transactionContext, renewed)
+	require.EqualError(t, err, "evidence verification failed: evidence invalid")
+	verifier.VerifyCredentialsReturns(nil)
+
+	// enclave must be registered
+	err = ercc.RenewEnclaveCredentials(transactionContext, renewed)
+	require.EqualError(t, err, "enclave "+utils.GetEnclaveId(&protos.AttestedData{EnclaveVk: []byte("enclaveVKString")})+" is not registered for chaincode "+chaincodeId)
+
+	// chaincode ek must not change
+	other, _ := anypb.New(&protos.AttestedData{EnclaveVk: []byte("enclaveVKString"), ChaincodeEk: []byte("other chaincodeEK")})
+	state[credentialsKey] = []byte(toBase64(&protos.Credentials{SerializedAttestedData: other}))
+	err = ercc.RenewEnclaveCredentials(transactionContext, renewed)
+	require.EqualError(t, err, "chaincode_ek does not match registered enclave")
+
+	other, _ = anypb.New(&protos.AttestedData{EnclaveVk: []byte("enclaveVKString"), ChaincodeEk: []byte("chaincodeEK"), ChaincodeEks: []*protos.ChaincodeEncryptionKey{
+		{CipherSuite: protos.CipherSuite_CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM, ChaincodeEk: []byte("x25519 EK")},
+	}})
+	state[credentialsKey] = []byte(toBase64(&protos.Credentials{SerializedAttestedData: other}))
+	err = ercc.RenewEnclaveCredentials(transactionContext, renewed)
+	require.EqualError(t, err, "chaincode_eks do not match registered enclave")
+
+	// expired credentials are renewed with the validity defined by the attestation policy
+	state[credentialsKey] = []byte(registered)
+	state["namespaces/policy/"+chaincodeId] = []byte(`{"credential_validity_seconds": 3600}`)
+	err = ercc.RenewEnclaveCredentials(transactionContext, renewed)
+	require.NoError(t, err)
+	require.Equal(t, 1, verifier.VerifyCredentialsWithPolicyCallCount())
+	require.Equal(t, 1, chaincodeStub.PutStateCallCount())
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, credentialsKey, key)
+	storedCredentials, err := utils.UnmarshalCredentials(string(value))
+	require.NoError(t, err)
+	require.Equal(t, issuedAt.Add(time.Minute).Add(time.Hour), storedCredentials.GetExpiresAt().AsTime())
+	require.False(t, utils.CredentialsExpired(storedCredentials, issuedAt.Add(time.Hour)))
+	require.True(t, utils.CredentialsExpired(storedCredentials, issuedAt.Add(2*time.Hour)))
+}
+
+func TestQueryChaincodeEndPointsExpiry(t *testing.T) {
+	chaincodeStub := &fakes.ChaincodeStub{}
+	transactionContext := &fakes.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(issuedAt), nil)
+
+	ercc := registry.Contract{}
+
+	iteratorOver := func(values ...string) *fakes.StateQueryIterator {
+		stateQueryIterator := &fakes.StateQueryIterator{}
+		for i, v := range values {
+			stateQueryIterator.HasNextReturnsOnCall(i, true)
+			stateQueryIterator.NextReturnsOnCall(i, &queryresult.KV{Value: []byte(v)}, nil)
+		}
+		stateQueryIterator.HasNextReturnsOnCall(len(values), false)
+		return stateQueryIterator
+	}
+
+	expired := credentialsWithExpiry("vk1", "peer0:7051", timestamppb.New(issuedAt.Add(-time.Second)))
+	valid := credentialsWithExpiry("vk2", "peer1:7051", timestamppb.New(issuedAt.Add(time.Second)))
+	legacy := credentialsWithExpiry("vk3", "peer2:7051", nil)
+
+	chaincodeStub.GetStateByPartialCompositeKeyReturns(iteratorOver(expired, valid, legacy), nil)
+	endpoints, err := ercc.QueryChaincodeEndPoints(transactionContext, chaincodeId)
+	require.NoError(t, err)
+	require.Equal(t, "peer1:7051,peer2:7051", endpoints)
+
+	chaincodeStub.GetStateByPartialCompositeKeyReturns(iteratorOver(expired, valid), nil)
+	ek, err := ercc.QueryChaincodeEncryptionKey(transactionContext, chaincodeId)
+	require.NoError(t, err)
+	require.Equal(t, base64.StdEncoding.EncodeToString([]byte("chaincodeEK")), ek)
+
+	chaincodeStub.GetStateByPartialCompositeKeyReturns(iteratorOver(expired), nil)
+	_, err = ercc.QueryChaincodeEncryptionKey(transactionContext, chaincodeId)
+	require.EqualError(t, err, "no enclave with valid credentials registered for chaincode "+chaincodeId)
+
+	// without advertised cipher suites, only the RSA suite with the chaincode ek is available
+	chaincodeStub.GetStateByPartialCompositeKeyReturns(iteratorOver(expired, valid), nil)
+	eks, err := ercc.QueryChaincodeEncryptionKeys(transactionContext, chaincodeId)
+	require.NoError(t, err)
+	chaincodeEks, err := utils.UnmarshalChaincodeEncryptionKeys(eks)
+	require.NoError(t, err)
+	require.Len(t, chaincodeEks.GetChaincodeEks(), 1)
+	require.Equal(t, protos.CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM, chaincodeEks.GetChaincodeEks()[0].GetCipherSuite())
+	require.Equal(t, []byte("chaincodeEK"), chaincodeEks.GetChaincodeEks()[0].GetChaincodeEk())
+
+	chaincodeStub.GetStateByPartialCompositeKeyReturns(iteratorOver(expired), nil)
+	_, err = ercc.QueryChaincodeEncryptionKeys(transactionContext, chaincodeId)
+	require.EqualError(t, err, "no enclave with valid credentials registered for chaincode "+chaincodeId)
+}
+
+func TestQueryChaincodeEncryptionKeys(t *testing.T) {
+	chaincodeStub := &fakes.ChaincodeStub{}
+	transactionContext := &fakes.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(issuedAt), nil)
+
+	advertised := []*protos.ChaincodeEncryptionKey{
+		{CipherSuite: protos.CipherSuite_CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM, ChaincodeEk: []byte("x25519 EK")},
+		{CipherSuite: protos.CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM, ChaincodeEk: []byte("chaincodeEK")},
+	}
+	attestedData, _ := anypb.New(&protos.AttestedData{ChaincodeEk: []byte("chaincodeEK"), ChaincodeEks: advertised})
+	stateQueryIterator := &fakes.StateQueryIterator{}
+	stateQueryIterator.HasNextReturnsOnCall(0, true)
+	stateQueryIterator.NextReturnsOnCall(0, &queryresult.KV{Value: []byte(toBase64(&protos.Credentials{SerializedAttestedData: attestedData}))}, nil)
+	chaincodeStub.GetStateByPartialCompositeKeyReturns(stateQueryIterator, nil)
+
+	ercc := registry.Contract{}
+	eks, err := ercc.QueryChaincodeEncryptionKeys(transactionContext, chaincodeId)
+	require.NoError(t, err)
+	chaincodeEks, err := utils.UnmarshalChaincodeEncryptionKeys(eks)
+	require.NoError(t, err)
+	require.Len(t, chaincodeEks.GetChaincodeEks(), 2)
+	require.Equal(t, advertised[0].GetCipherSuite(), chaincodeEks.GetChaincodeEks()[0].GetCipherSuite())
+	require.Equal(t, advertised[0].GetChaincodeEk(), chaincodeEks.GetChaincodeEks()[0].GetChaincodeEk())
+}
+
+func TestIssueRegistrationNonce(t *testing.T) {
+	chaincodeStub := &fakes.ChaincodeStub{}
+	transactionContext := &fakes.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	chaincodeStub.CreateCompositeKeyStub = compositeKey
+	chaincodeStub.GetTxIDReturns(nonce)
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(issuedAt), nil)
+
+	ercc := registry.Contract{}
+
+	chaincodeStub.InvokeChaincodeReturns(shim.Error("no chaincode definition exists"))
+	_, err := ercc.IssueRegistrationNonce(transactionContext, chaincodeId)
+	require.Contains(t, err.Error(), "cannot get chaincode definition")
+
+	chaincodeStub.InvokeChaincodeReturns(shim.Success(protoutil.MarshalOrPanic(
+		&lifecycle.QueryChaincodeDefinitionResult{
+			Version:   mrenclave,
+			Sequence:  1,
+			Approvals: map[string]bool{someMspId: true, "Org3MSP": false},
+		})))
+
+	// only members of the approving orgs can request nonces
+	chaincodeStub.GetCreatorReturns(protoutil.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org3MSP"}), nil)
+	_, err = ercc.IssueRegistrationNonce(transactionContext, chaincodeId)
+	require.EqualError(t, err, "creator msp Org3MSP has not approved the chaincode definition")
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+
+	chaincodeStub.GetCreatorReturns(protoutil.MarshalOrPanic(&msp.SerializedIdentity{Mspid: someMspId}), nil)
+	issued, err := ercc.IssueRegistrationNonce(transactionContext, chaincodeId)
+	require.NoError(t, err)
+	require.Equal(t, nonce, issued)
+
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "namespaces/nonce/"+chaincodeId+"/"+someMspId+"/"+nonce, key)
+	require.Equal(t, issuedAt.Format(time.RFC3339Nano), string(value))
+
+	// expired nonces of the org are removed
+	stateQueryIterator := &fakes.StateQueryIterator{}
+	stateQueryIterator.HasNextReturnsOnCall(0, true)
+	stateQueryIterator.HasNextReturnsOnCall(1, true)
+	stateQueryIterator.HasNextReturnsOnCall(2, false)
+	stateQueryIterator.NextReturnsOnCall(0, &queryresult.KV{Key: "expired", Value: []byte(issuedAt.Add(-time.Hour).Format(time.RFC3339Nano))}, nil)
+	stateQueryIterator.NextReturnsOnCall(1, &queryresult.KV{Key: "fresh", Value: []byte(issuedAt.Add(-time.Minute).Format(time.RFC3339Nano))}, nil)
+	chaincodeStub.GetStateByPartialCompositeKeyReturns(stateQueryIterator, nil)
+	_, err = ercc.IssueRegistrationNonce(transactionContext, chaincodeId)
+	require.NoError(t, err)
+	objectType, attributes := chaincodeStub.GetStateByPartialCompositeKeyArgsForCall(chaincodeStub.GetStateByPartialCompositeKeyCallCount() - 1)
+	require.Equal(t, "namespaces/nonce", objectType)
+	require.Equal(t, []string{chaincodeId, someMspId}, attributes)
+	require.Equal(t, 1, chaincodeStub.DelStateCallCount())
+	require.Equal(t, "expired", chaincodeStub.DelStateArgsForCall(0))
+	require.Equal(t, 1, stateQueryIterator.CloseCallCount())
+
+	chaincodeStub.GetStateByPartialCompositeKeyReturns(nil, fmt.Errorf("some query error"))
+	_, err = ercc.IssueRegistrationNonce(transactionContext, chaincodeId)
+	require.EqualError(t, err, "cannot get registration nonces: some query error")
 }
 
 func TestQueryListEnclaveCredentials(t *testing.T) {
@@ -347,3 +635,134 @@ func TestQueryEnclaveCredentials(t *testing.T) {
 	require.Empty(t, resp)
 	require.NoError(t, err)
 }
+
+func TestAttestationPolicy(t *testing.T) {
+	chaincodeStub := &fakes.ChaincodeStub{}
+	transactionContext := &fakes.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	verifier := &fakes.CredentialVerifier{}
+	id := &fakes.IdentityEvaluator{}
+
+	ercc := registry.Contract{}
+	ercc.Verifier = verifier
+	ercc.IEvaluator = id
+
+	policyJson := `{"allowed_statuses": ["OK"], "min_isv_svn": 1}`
+
+	chaincodeStub.InvokeChaincodeReturns(shim.Error("no chaincode definition exists"))
+	err := ercc.SetAttestationPolicy(transactionContext, chaincodeId, policyJson)
+	require.Contains(t, err.Error(), "cannot get chaincode definition")
+
+	chaincodeStub.InvokeChaincodeReturns(shim.Success(protoutil.MarshalOrPanic(
+		&lifecycle.QueryChaincodeDefinitionResult{
+			Version:   mrenclave,
+			Sequence:  1,
+			Approvals: map[string]bool{"Org2MSP": true, "Org1MSP": true, "Org3MSP": false},
+		})))
+	chaincodeStub.GetCreatorReturns([]byte("some creator"), nil)
+
+	// only admins of the approving orgs can set the policy
+	id.EvaluateAdminIdentityReturns(fmt.Errorf("creator msp Org3MSP is not authorized"))
+	err = ercc.SetAttestationPolicy(transactionContext, chaincodeId, policyJson)
+	require.EqualError(t, err, "creator is not authorized to set the attestation policy: creator msp Org3MSP is not authorized")
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+	creator, adminMSPs := id.EvaluateAdminIdentityArgsForCall(0)
+	require.Equal(t, []byte("some creator"), creator)
+	require.Equal(t, []string{"Org1MSP", "Org2MSP"}, adminMSPs)
+	id.EvaluateAdminIdentityReturns(nil)
+
+	// the stub keeps the state in memory
+	state := make(map[string][]byte)
+	chaincodeStub.CreateCompositeKeyCalls(shim.CreateCompositeKey)
+	chaincodeStub.GetStateCalls(func(key string) ([]byte, error) { return state[key], nil })
+	chaincodeStub.PutStateCalls(func(key string, value []byte) error { state[key] = value; return nil })
+	chaincodeStub.DelStateCalls(func(key string) error { delete(state, key); return nil })
+	asAdmin := func(mspId string) {
+		chaincodeStub.GetCreatorReturns(protoutil.MarshalOrPanic(&msp.SerializedIdentity{Mspid: mspId}), nil)
+	}
+
+	asAdmin("Org1MSP")
+	err = ercc.SetAttestationPolicy(transactionContext, chaincodeId, `{"unknown": 1}`)
+	require.Contains(t, err.Error(), "cannot parse appraisal policy")
+
+	// the policy is set once admins of a majority of the approving orgs approved it
+	for i := 0; i < 2; i++ {
+		err = ercc.SetAttestationPolicy(transactionContext, chaincodeId, policyJson)
+		require.NoError(t, err)
+		storedPolicy, err := ercc.QueryAttestationPolicy(transactionContext, chaincodeId)
+		require.NoError(t, err)
+		require.Empty(t, storedPolicy)
+		approvals, err := ercc.QueryAttestationPolicyApprovals(transactionContext, chaincodeId, policyJson)
+		require.NoError(t, err)
+		require.Equal(t, []string{"Org1MSP"}, approvals)
+	}
+
+	// approvals of other policies do not count
+	asAdmin("Org2MSP")
+	err = ercc.SetAttestationPolicy(transactionContext, chaincodeId, `{"allowed_statuses": ["OK"]}`)
+	require.NoError(t, err)
+	storedPolicy, err := ercc.QueryAttestationPolicy(transactionContext, chaincodeId)
+	require.NoError(t, err)
+	require.Empty(t, storedPolicy)
+
+	err = ercc.SetAttestationPolicy(transactionContext, chaincodeId, policyJson)
+	require.NoError(t, err)
+	storedPolicy, err = ercc.QueryAttestationPolicy(transactionContext, chaincodeId)
+	require.NoError(t, err)
+	require.Equal(t, policyJson, storedPolicy)
+	approvals, err := ercc.QueryAttestationPolicyApprovals(transactionContext, chaincodeId, policyJson)
+	require.NoError(t, err)
+	require.Empty(t, approvals)
+	policyKey, _ := shim.CreateCompositeKey("namespaces/policy", []string{chaincodeId})
+	require.Equal(t, policyJson, string(state[policyKey]))
+
+	// with a single approving org, its admin sets the policy on its own
+	chaincodeStub.InvokeChaincodeReturns(shim.Success(protoutil.MarshalOrPanic(
+		&lifecycle.QueryChaincodeDefinitionResult{
+			Version:   mrenclave,
+			Sequence:  1,
+			Approvals: map[string]bool{"Org1MSP": true},
+		})))
+	asAdmin("Org1MSP")
+	err = ercc.SetAttestationPolicy(transactionContext, chaincodeId, `{"allowed_statuses": ["OK"]}`)
+	require.NoError(t, err)
+	storedPolicy, err = ercc.QueryAttestationPolicy(transactionContext, chaincodeId)
+	require.NoError(t, err)
+	require.Equal(t, `{"allowed_statuses": ["OK"]}`, storedPolicy)
+
+	chaincodeStub.GetStateReturns([]byte(policyJson), nil)
+
+	// registration applies the stored policy
+	serializedAttestedData, _ := anypb.New(
+		&protos.AttestedData{
+			EnclaveVk: []byte("enclaveVKString"),
+			CcParams: &protos.CCParameters{
+				ChaincodeId: chaincodeId,
+				Version:     mrenclave,
+				ChannelId:   channelId,
+				Sequence:    1,
+			},
+			HostParams: &protos.HostParameters{
+				PeerMspId: someMspId,
+			},
+		})
+	credentialBase64 := toBase64(&protos.Credentials{
+		Evidence:               []byte("some mock evidence"),
+		SerializedAttestedData: serializedAttestedData,
+	})
+	chaincodeStub.GetChannelIDReturns(channelId)
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Unix(1000, 0)), nil)
+	verifier.VerifyCredentialsWithPolicyReturns(fmt.Errorf("appraisal policy not satisfied: debug enclaves are not allowed"))
+	err = ercc.RegisterEnclave(transactionContext, credentialBase64)
+	require.EqualError(t, err, "evidence verification failed: appraisal policy not satisfied: debug enclaves are not allowed")
+	require.Equal(t, 0, verifier.VerifyCredentialsCallCount())
+	_, expectedMrenclave, policy, timestamp := verifier.VerifyCredentialsWithPolicyArgsForCall(0)
+	require.Equal(t, mrenclave, expectedMrenclave)
+	require.Equal(t, &types.AppraisalPolicy{AllowedStatuses: []string{"OK"}, MinIsvSvn: 1}, policy)
+	require.Equal(t, int64(1000), timestamp.Unix())
+
+	// a corrupted policy blocks registration
+	chaincodeStub.GetStateReturns([]byte("not json"), nil)
+	err = ercc.RegisterEnclave(transactionContext, credentialBase64)
+	require.Contains(t, err.Error(), "invalid attestation policy")
+}
@@ -51,6 +51,13 @@ define_common_vars() {
     : ${ERCC_VERSION:="0"}
     : ${ERCC_SEQUENCE:="1"}
 
+    # C++ chaincode does not issue simulated quotes yet, hence, in SGX simulation mode ercc (started by the peer)
+    # must accept legacy simulated attestations to register its enclaves (see ercc/README.md)
+    if [ "${SGX_MODE:-SIM}" = "SIM" ]; then
+        : ${FPC_ACCEPT_LEGACY_SIMULATED_ATTESTATIONS:="true"}
+        export FPC_ACCEPT_LEGACY_SIMULATED_ATTESTATIONS
+    fi
+
     ORDERER_PID_FILE="${FABRIC_STATE_DIR}/orderer.pid"
     ORDERER_LOG_OUT="${FABRIC_STATE_DIR}/orderer.out"
     ORDERER_LOG_ERR="${FABRIC_STATE_DIR}/orderer.err"
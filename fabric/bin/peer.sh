@@ -494,8 +494,15 @@ EOF
     # create host params
     PEER_ENDPOINT="${PEER_ADDRESS}"
 
+    # get a fresh registration nonce from the enclave registry
+    echo "Requesting registration nonce from Enclave Registry"
+    try_r $RUN ${FABRIC_BIN_DIR}/peer chaincode invoke -o ${ORDERER_ADDR} -C ${CHAN_ID} -n ${ERCC_ID} -c '{"Args":["IssueRegistrationNonce", "'${CC_ID}'"]}' --waitForEvent
+    REGISTRATION_NONCE=$(parse_invoke_result_from_log "${RESPONSE}")
+    [ -z ${REGISTRATION_NONCE} ] && die "could not get registration nonce"
+    [ -z ${DEBUG+x} ] || say "registration nonce: ${REGISTRATION_NONCE}"
+
     # create init enclave message
-    INIT_ENCLAVE_PROTO=$( (echo "peer_endpoint: \"${PEER_ENDPOINT}\""; echo "attestation_params: \"${ATTESTATION_PARAMS}\"") | protoc --encode fpc.InitEnclaveMessage --proto_path=${FPC_PATH}/protos/fpc --proto_path=${FPC_PATH}/protos/fabric ${FPC_PATH}/protos/fpc/fpc.proto | base64 --wrap=0)
+    INIT_ENCLAVE_PROTO=$( (echo "peer_endpoint: \"${PEER_ENDPOINT}\""; echo "attestation_params: \"${ATTESTATION_PARAMS}\""; echo "nonce: \"${REGISTRATION_NONCE}\"") | protoc --encode fpc.InitEnclaveMessage --proto_path=${FPC_PATH}/protos/fpc --proto_path=${FPC_PATH}/protos/fabric ${FPC_PATH}/protos/fpc/fpc.proto | base64 --wrap=0)
     [ -z ${INIT_ENCLAVE_PROTO} ] && die "init enclave proto is empty"
 
     # trigger initEnclave
@@ -10,8 +10,11 @@ package attestation
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/dcap"
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/epid"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/sevsnp"
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
@@ -44,6 +47,16 @@ func (d *converterDispatcher) Register(converter ...*types.Converter) error {
 	return nil
 }
 
+// Types returns the (sorted) types of the registered converters
+func (d *converterDispatcher) Types() []string {
+	registered := make([]string, 0, len(d.converters))
+	for t := range d.converters {
+		registered = append(registered, t)
+	}
+	sort.Strings(registered)
+	return registered
+}
+
 // Convert performs the attestation to evidence conversion with help of the registered Converter.
 // If there is no matching Converter registered for the input attestation, an error is returned;
 // If the invoked ConverterFunction fails, an error is returned; Otherwise an evidence struct is returned.
@@ -76,6 +89,9 @@ func NewDefaultCredentialConverter() *CredentialConverter {
 		simulation.NewSimulationConverter(),
 		epid.NewEpidLinkableConverter(),
 		epid.NewEpidUnlinkableConverter(),
+		dcap.NewDCAPConverter(),
+		dcap.NewTDXConverter(),
+		sevsnp.NewSEVSNPConverter(),
 	)
 }
 
@@ -90,6 +106,11 @@ func NewCredentialConverter(converter ...*types.Converter) *CredentialConverter
 	return &CredentialConverter{dispatcher: dispatcher}
 }
 
+// SupportedAttestationTypes returns the attestation types for which the CredentialConverter has a registered converter
+func (c *CredentialConverter) SupportedAttestationTypes() []string {
+	return c.dispatcher.Types()
+}
+
 // ConvertCredentials perform attestation evidence conversion (transformation) for a given credentials message (encoded as base64 string)
 func (c *CredentialConverter) ConvertCredentials(credentialsOnlyAttestation string) (credentialsWithEvidence string, err error) {
 	logger.Debugf("Received Credential: '%s'", credentialsOnlyAttestation)
@@ -58,6 +58,12 @@ func TestDispatcher(t *testing.T) {
 	// but registering another converter should work fine
 	err = d.Register(simulation.NewSimulationConverter())
 	assert.NoError(t, err)
+	assert.Equal(t, []string{"dummy", "simulated"}, d.Types())
+}
+
+func TestSupportedAttestationTypes(t *testing.T) {
+	cv := NewDefaultCredentialConverter()
+	assert.Equal(t, []string{"dcap", "epid-linkable", "epid-unlinkable", "sev-snp", "simulated", "tdx"}, cv.SupportedAttestationTypes())
 }
 
 func TestCredentialConverterWithSimulation(t *testing.T) {
@@ -0,0 +1,281 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Collateral holds the verification collateral for a quote as provided by the Intel Provisioning Certification
+// Service (PCS) or a Provisioning Certificate Caching Service (PCCS). The issuer chains are PEM-encoded
+// (optionally URL-encoded as returned by the PCCS).
+type Collateral struct {
+	PckCrlIssuerChain     string `json:"pck_crl_issuer_chain"`
+	PckCrl                []byte `json:"pck_crl"`
+	TcbInfoIssuerChain    string `json:"tcb_info_issuer_chain"`
+	TcbInfo               string `json:"tcb_info"`
+	QeIdentityIssuerChain string `json:"qe_identity_issuer_chain"`
+	QeIdentity            string `json:"qe_identity"`
+}
+
+// TCB status values as defined by the Intel PCS TCB info and enclave identity
+const (
+	TcbStatusUpToDate                          = "UpToDate"
+	TcbStatusSWHardeningNeeded                 = "SWHardeningNeeded"
+	TcbStatusConfigurationNeeded               = "ConfigurationNeeded"
+	TcbStatusConfigurationAndSWHardeningNeeded = "ConfigurationAndSWHardeningNeeded"
+	TcbStatusOutOfDate                         = "OutOfDate"
+	TcbStatusOutOfDateConfigurationNeeded      = "OutOfDateConfigurationNeeded"
+	TcbStatusRevoked                           = "Revoked"
+)
+
+type tcbInfo struct {
+	ID         string     `json:"id"`
+	Version    int        `json:"version"`
+	IssueDate  time.Time  `json:"issueDate"`
+	NextUpdate time.Time  `json:"nextUpdate"`
+	Fmspc      string     `json:"fmspc"`
+	PceID      string     `json:"pceId"`
+	TcbLevels  []tcbLevel `json:"tcbLevels"`
+}
+
+type tcbLevel struct {
+	Tcb struct {
+		SgxTcbComponents []tcbComponent `json:"sgxtcbcomponents"`
+		PceSvn           int            `json:"pcesvn"`
+		TdxTcbComponents []tcbComponent `json:"tdxtcbcomponents"`
+	} `json:"tcb"`
+	TcbDate     string   `json:"tcbDate"`
+	TcbStatus   string   `json:"tcbStatus"`
+	AdvisoryIDs []string `json:"advisoryIDs"`
+}
+
+type tcbComponent struct {
+	Svn int `json:"svn"`
+}
+
+type enclaveIdentity struct {
+	ID             string    `json:"id"`
+	Version        int       `json:"version"`
+	IssueDate      time.Time `json:"issueDate"`
+	NextUpdate     time.Time `json:"nextUpdate"`
+	MiscSelect     string    `json:"miscselect"`
+	MiscSelectMask string    `json:"miscselectMask"`
+	Attributes     string    `json:"attributes"`
+	AttributesMask string    `json:"attributesMask"`
+	MrSigner       string    `json:"mrsigner"`
+	IsvProdID      uint16    `json:"isvprodid"`
+	TcbLevels      []struct {
+		Tcb struct {
+			IsvSvn uint16 `json:"isvsvn"`
+		} `json:"tcb"`
+		TcbStatus string `json:"tcbStatus"`
+	} `json:"tcbLevels"`
+}
+
+// verifyPckCrl verifies the PCK CRL against the trusted roots and checks that the PCK certificate is not revoked
+func verifyPckCrl(c *Collateral, pck *x509.Certificate, roots *x509.CertPool, now time.Time) error {
+	issuer, err := verifyCertChain(c.PckCrlIssuerChain, roots, now)
+	if err != nil {
+		return errors.Wrap(err, "invalid PCK CRL issuer chain")
+	}
+
+	raw := c.PckCrl
+	if block, _ := pem.Decode(raw); block != nil {
+		raw = block.Bytes
+	}
+	crl, err := x509.ParseRevocationList(raw)
+	if err != nil {
+		return errors.Wrap(err, "cannot parse PCK CRL")
+	}
+
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return errors.Wrap(err, "invalid PCK CRL signature")
+	}
+	if now.After(crl.NextUpdate) {
+		return fmt.Errorf("PCK CRL expired at %s", crl.NextUpdate)
+	}
+	if !bytes.Equal(pck.RawIssuer, crl.RawIssuer) {
+		return fmt.Errorf("PCK CRL issuer '%s' does not match PCK certificate issuer '%s'", crl.Issuer, pck.Issuer)
+	}
+
+	for _, revoked := range crl.RevokedCertificateEntries {
+		if revoked.SerialNumber.Cmp(pck.SerialNumber) == 0 {
+			return fmt.Errorf("PCK certificate revoked")
+		}
+	}
+	return nil
+}
+
+// verifySignedBody verifies a signed PCS response of the form {"<field>": {...}, "signature": "<hex r|s>"},
+// where the signature covers the raw JSON of the field, and unmarshals the field into v
+func verifySignedBody(body, field, issuerChain string, roots *x509.CertPool, now time.Time, v interface{}) error {
+	issuer, err := verifyCertChain(issuerChain, roots, now)
+	if err != nil {
+		return errors.Wrap(err, "invalid issuer chain")
+	}
+	pub, err := certificatePublicKey(issuer)
+	if err != nil {
+		return err
+	}
+
+	var signed map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &signed); err != nil {
+		return errors.Wrap(err, "cannot parse body")
+	}
+
+	var signatureHex string
+	if err := json.Unmarshal(signed["signature"], &signatureHex); err != nil {
+		return errors.Wrap(err, "cannot parse signature")
+	}
+	signature, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return errors.Wrap(err, "cannot decode signature")
+	}
+
+	data, ok := signed[field]
+	if !ok {
+		return fmt.Errorf("body has no '%s' field", field)
+	}
+	if err := verifyECDSASignature(pub, data, signature); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func checkValidityPeriod(issueDate, nextUpdate, now time.Time) error {
+	if now.Before(issueDate) {
+		return fmt.Errorf("not valid before %s", issueDate)
+	}
+	if now.After(nextUpdate) {
+		return fmt.Errorf("expired at %s", nextUpdate)
+	}
+	return nil
+}
+
+// tdxTcbInfoID is the id of TDX TCB info
+const tdxTcbInfoID = "TDX"
+
+// verifyTcbInfo verifies the TCB info and returns it together with the TCB level matching the platform described by
+// the PCK certificate extensions. For TDX quotes, teeTcbSvn is the TEE_TCB_SVN of the TD report, which must also
+// match the TDX TCB components of the level; it is nil for SGX quotes.
+// Note that the TDX module identities of TCB info version 3 are not evaluated.
+func verifyTcbInfo(c *Collateral, pck *pckExtensions, teeTcbSvn []byte, roots *x509.CertPool, now time.Time) (*tcbInfo, *tcbLevel, error) {
+	info := &tcbInfo{}
+	if err := verifySignedBody(c.TcbInfo, "tcbInfo", c.TcbInfoIssuerChain, roots, now, info); err != nil {
+		return nil, nil, errors.Wrap(err, "invalid TCB info")
+	}
+	if err := checkValidityPeriod(info.IssueDate, info.NextUpdate, now); err != nil {
+		return nil, nil, errors.Wrap(err, "TCB info")
+	}
+
+	if (teeTcbSvn != nil) != (info.ID == tdxTcbInfoID) {
+		return nil, nil, fmt.Errorf("TCB info id '%s' does not match quote tee type", info.ID)
+	}
+
+	if !strings.EqualFold(info.Fmspc, hex.EncodeToString(pck.fmspc)) {
+		return nil, nil, fmt.Errorf("TCB info fmspc %s does not match PCK certificate fmspc %x", info.Fmspc, pck.fmspc)
+	}
+	if !strings.EqualFold(info.PceID, hex.EncodeToString(pck.pceID)) {
+		return nil, nil, fmt.Errorf("TCB info pceId %s does not match PCK certificate pceId %x", info.PceID, pck.pceID)
+	}
+
+	// TCB levels are sorted descending; the first level not exceeding the platform TCB applies
+	for i := range info.TcbLevels {
+		level := &info.TcbLevels[i]
+		if len(level.Tcb.SgxTcbComponents) != tcbComponentCount {
+			return nil, nil, fmt.Errorf("TCB level with %d SGX TCB components", len(level.Tcb.SgxTcbComponents))
+		}
+
+		matches := pck.pceSvn >= level.Tcb.PceSvn
+		for j, component := range level.Tcb.SgxTcbComponents {
+			matches = matches && pck.tcbComponents[j] >= component.Svn
+		}
+		if teeTcbSvn != nil {
+			if len(level.Tcb.TdxTcbComponents) != len(teeTcbSvn) {
+				return nil, nil, fmt.Errorf("TCB level with %d TDX TCB components", len(level.Tcb.TdxTcbComponents))
+			}
+			for j, component := range level.Tcb.TdxTcbComponents {
+				matches = matches && int(teeTcbSvn[j]) >= component.Svn
+			}
+		}
+		if matches {
+			return info, level, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("TCB level not supported")
+}
+
+// verifyQeIdentity verifies the QE identity and checks that the QE report matches; it returns the TCB status of
+// the quoting enclave
+func verifyQeIdentity(c *Collateral, qe *ReportBody, roots *x509.CertPool, now time.Time) (string, error) {
+	identity := &enclaveIdentity{}
+	if err := verifySignedBody(c.QeIdentity, "enclaveIdentity", c.QeIdentityIssuerChain, roots, now, identity); err != nil {
+		return "", errors.Wrap(err, "invalid QE identity")
+	}
+	if err := checkValidityPeriod(identity.IssueDate, identity.NextUpdate, now); err != nil {
+		return "", errors.Wrap(err, "QE identity")
+	}
+
+	if !strings.EqualFold(identity.MrSigner, hex.EncodeToString(qe.MrSigner)) {
+		return "", fmt.Errorf("QE mrsigner %x does not match QE identity %s", qe.MrSigner, identity.MrSigner)
+	}
+	if identity.IsvProdID != qe.IsvProdID {
+		return "", fmt.Errorf("QE isvprodid %d does not match QE identity %d", qe.IsvProdID, identity.IsvProdID)
+	}
+
+	miscSelect := make([]byte, 4)
+	binary.BigEndian.PutUint32(miscSelect, qe.MiscSelect)
+	if err := checkMasked("miscselect", miscSelect, identity.MiscSelect, identity.MiscSelectMask); err != nil {
+		return "", err
+	}
+	if err := checkMasked("attributes", qe.Attributes, identity.Attributes, identity.AttributesMask); err != nil {
+		return "", err
+	}
+
+	// TCB levels are sorted descending by isvsvn
+	for _, level := range identity.TcbLevels {
+		if level.Tcb.IsvSvn <= qe.IsvSvn {
+			return level.TcbStatus, nil
+		}
+	}
+	return "", fmt.Errorf("QE isvsvn %d not supported", qe.IsvSvn)
+}
+
+// checkMasked checks that (value & mask) == expected, where mask and expected are hex-encoded
+func checkMasked(name string, value []byte, expectedHex, maskHex string) error {
+	expected, err := hex.DecodeString(expectedHex)
+	if err != nil {
+		return errors.Wrapf(err, "cannot decode QE identity %s", name)
+	}
+	mask, err := hex.DecodeString(maskHex)
+	if err != nil {
+		return errors.Wrapf(err, "cannot decode QE identity %s mask", name)
+	}
+	if len(expected) != len(value) || len(mask) != len(value) {
+		return fmt.Errorf("QE identity %s has invalid length", name)
+	}
+
+	for i := range value {
+		if value[i]&mask[i] != expected[i] {
+			return fmt.Errorf("QE %s %x does not match QE identity %s (mask %s)", name, value, expectedHex, maskHex)
+		}
+	}
+	return nil
+}
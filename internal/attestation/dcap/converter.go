@@ -0,0 +1,60 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/pkg/errors"
+)
+
+const DCAPType = "dcap"
+
+// NewDCAPConverter creates a new attestation converter for Intel SGX DCAP (ECDSA) attestation.
+// The converter fetches the collateral for the quote from the PCCS at $PCCS_URL or, if not set, from DefaultPCCSUrl.
+func NewDCAPConverter() *types.Converter {
+	return &types.Converter{
+		Type:      DCAPType,
+		Converter: newDCAPConverter(newPCCSClientFromEnv()),
+	}
+}
+
+func newPCCSClientFromEnv() *PCCSClient {
+	var opts []PCCSClientOption
+	if pccsUrl := os.Getenv("PCCS_URL"); len(pccsUrl) != 0 {
+		opts = append(opts, WithUrl(pccsUrl))
+	}
+	return NewPCCSClient(opts...)
+}
+
+func newDCAPConverter(pccs *PCCSClient) types.ConvertFunction {
+	return func(attestationBytes []byte) (evidenceBytes []byte, err error) {
+		// the attestation is the base64-encoded quote
+		rawQuote, err := base64.StdEncoding.DecodeString(string(attestationBytes))
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot decode quote")
+		}
+
+		quote, err := ParseQuote(rawQuote)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse quote")
+		}
+
+		collateral, err := pccs.GetCollateral(quote)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot convert dcap attestation")
+		}
+
+		return json.Marshal(&QuoteWithCollateral{
+			Quote:      string(attestationBytes),
+			Collateral: collateral,
+		})
+	}
+}
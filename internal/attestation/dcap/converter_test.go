@@ -0,0 +1,95 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePCCS returns a fake http client serving the collateral of the given test platform
+func fakePCCS(platform *testPlatform) *fakes.HTTPClient {
+	collateral := platform.collateral()
+	fakeHttpClient := &fakes.HTTPClient{}
+	fakeHttpClient.DoCalls(func(req *http.Request) (*http.Response, error) {
+		header := http.Header{}
+		var body []byte
+		switch req.URL.Path {
+		case "/sgx/certification/v4/pckcrl":
+			header.Set(pckCrlIssuerChainHeader, url.PathEscape(collateral.PckCrlIssuerChain))
+			body = collateral.PckCrl
+		case "/sgx/certification/v4/tcb", "/tdx/certification/v4/tcb":
+			// older PCCS versions use the prefixed header
+			header.Set("SGX-"+tcbInfoIssuerChainHeader, url.PathEscape(collateral.TcbInfoIssuerChain))
+			body = []byte(collateral.TcbInfo)
+		case "/sgx/certification/v4/qe/identity", "/tdx/certification/v4/qe/identity":
+			header.Set(qeIdentityIssuerChainHeader, url.PathEscape(collateral.QeIdentityIssuerChain))
+			body = []byte(collateral.QeIdentity)
+		default:
+			return &http.Response{StatusCode: 404, Status: "404 Not Found", Body: io.NopCloser(&bytes.Buffer{})}, nil
+		}
+		return &http.Response{StatusCode: 200, Header: header, Body: io.NopCloser(bytes.NewReader(body))}, nil
+	})
+	return fakeHttpClient
+}
+
+func TestDCAPConverter(t *testing.T) {
+	platform := newTestPlatform(t)
+	fakeHttpClient := fakePCCS(platform)
+	pccsUrl := "https://pccs.example.com/sgx/certification/v4"
+
+	reportData := sha256.Sum256(testStatement)
+	attestation := encodeQuote(platform.quote(testMrenclave, reportData[:]))
+
+	convert := newDCAPConverter(NewPCCSClient(WithUrl(pccsUrl), WithHttpClient(fakeHttpClient)))
+	evidence, err := convert([]byte(attestation))
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, fakeHttpClient.DoCallCount())
+	assert.Equal(t, pccsUrl+"/pckcrl?ca=platform&encoding=der", fakeHttpClient.DoArgsForCall(0).URL.String())
+	assert.Equal(t, pccsUrl+"/tcb?fmspc=00906ea10000", fakeHttpClient.DoArgsForCall(1).URL.String())
+	assert.Equal(t, pccsUrl+"/qe/identity", fakeHttpClient.DoArgsForCall(2).URL.String())
+
+	// the evidence verifies
+	verifier := NewDCAPVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow }))
+	err = verifier.Verify(&types.Evidence{Type: DCAPType, Data: string(evidence)}, &types.ValidationValues{
+		Statement: testStatement,
+		Mrenclave: testMrenclave,
+	})
+	assert.NoError(t, err)
+
+	// PCCS not available
+	convert = newDCAPConverter(NewPCCSClient(WithUrl("https://pccs.example.com/unknown"), WithHttpClient(fakeHttpClient)))
+	_, err = convert([]byte(attestation))
+	assert.ErrorContains(t, err, "cannot get PCK CRL: request failed! Reason: 404")
+
+	// invalid quote
+	_, err = convert([]byte("not base64"))
+	assert.ErrorContains(t, err, "cannot decode quote")
+	_, err = convert([]byte(encodeQuote([]byte("no quote"))))
+	assert.ErrorContains(t, err, "cannot parse quote")
+}
+
+func TestNewDCAPConverter(t *testing.T) {
+	converter := NewDCAPConverter()
+	assert.Equal(t, DCAPType, converter.Type)
+	assert.NotNil(t, converter.Converter)
+
+	client := NewPCCSClient()
+	assert.Equal(t, DefaultPCCSUrl, client.url)
+	assert.NotNil(t, client.httpClient)
+}
@@ -0,0 +1,370 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testNow is the time used to verify the test platform quotes and collateral
+var testNow = time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+var testMrSigner = "8c4f5775d796503e96137f77c68a829a0056ac8ded70140b081b094490c57bff"
+
+// testPlatform mimics an SGX (or TDX) platform with a quoting enclave and the Intel PKI; the root CA replaces the
+// Intel SGX Root CA and must be passed to the verifier using WithTrustedRoots
+type testPlatform struct {
+	t *testing.T
+
+	rootKey, platformCAKey, pckKey, tcbSigningKey, attestationKey *ecdsa.PrivateKey
+	root, platformCA, pck, tcbSigning                             *x509.Certificate
+
+	fmspc      []byte
+	pceSvn     int
+	tcbStatus  string
+	qeIsvSvn   uint16
+	qeMrSigner string
+	revoked    bool
+
+	// tdx switches to version 4 TDX quotes for a trust domain with tdxTcbSvn as TEE_TCB_SVN and TDX TCB info
+	tdx       bool
+	tdxTcbSvn byte
+	tdDebug   bool
+}
+
+func newTestPlatform(t *testing.T) *testPlatform {
+	p := &testPlatform{
+		t:          t,
+		fmspc:      []byte{0x00, 0x90, 0x6e, 0xa1, 0x00, 0x00},
+		pceSvn:     13,
+		tcbStatus:  TcbStatusUpToDate,
+		qeIsvSvn:   8,
+		qeMrSigner: testMrSigner,
+		tdxTcbSvn:  4,
+	}
+
+	p.rootKey = p.newKey()
+	p.root = p.newCert(1, "Test SGX Root CA", true, &p.rootKey.PublicKey, nil, nil, nil)
+	p.platformCAKey = p.newKey()
+	p.platformCA = p.newCert(2, "Intel SGX PCK Platform CA", true, &p.platformCAKey.PublicKey, p.root, p.rootKey, nil)
+	p.tcbSigningKey = p.newKey()
+	p.tcbSigning = p.newCert(3, "Test SGX TCB Signing", false, &p.tcbSigningKey.PublicKey, p.root, p.rootKey, nil)
+	p.pckKey = p.newKey()
+	p.pck = p.newCert(4, "Intel SGX PCK Certificate", false, &p.pckKey.PublicKey, p.platformCA, p.platformCAKey, p.sgxExtension())
+	p.attestationKey = p.newKey()
+
+	return p
+}
+
+func (p *testPlatform) roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(p.root)
+	return pool
+}
+
+func (p *testPlatform) newKey() *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(p.t, err)
+	return key
+}
+
+func (p *testPlatform) newCert(serial int64, cn string, isCA bool, pub *ecdsa.PublicKey, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, ext *pkix.Extension) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: cn, Organization: []string{"Intel Corporation"}},
+		NotBefore:             time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2040, 1, 1, 0, 0, 0, 0, time.UTC),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	}
+	if ext != nil {
+		template.ExtraExtensions = []pkix.Extension{*ext}
+	}
+
+	if parent == nil {
+		// self-signed
+		parent, parentKey = template, p.rootKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, parentKey)
+	require.NoError(p.t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(p.t, err)
+	return cert
+}
+
+func (p *testPlatform) marshal(v interface{}) asn1.RawValue {
+	b, err := asn1.Marshal(v)
+	require.NoError(p.t, err)
+	return asn1.RawValue{FullBytes: b}
+}
+
+func (p *testPlatform) sgxExtension() *pkix.Extension {
+	var tcb []sgxExtension
+	for i := 1; i <= tcbComponentCount; i++ {
+		tcb = append(tcb, sgxExtension{ID: append(append(asn1.ObjectIdentifier{}, oidTCB...), i), Value: p.marshal(3)})
+	}
+	tcb = append(tcb,
+		sgxExtension{ID: append(append(asn1.ObjectIdentifier{}, oidTCB...), oidTCBPceSvnSuffix), Value: p.marshal(p.pceSvn)},
+		sgxExtension{ID: append(append(asn1.ObjectIdentifier{}, oidTCB...), oidTCBCpuSvnSuffix), Value: p.marshal(make([]byte, 16))},
+	)
+
+	value, err := asn1.Marshal([]sgxExtension{
+		{ID: asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 1}, Value: p.marshal(make([]byte, 16))},
+		{ID: oidTCB, Value: p.marshal(tcb)},
+		{ID: oidPCEID, Value: p.marshal([]byte{0x00, 0x00})},
+		{ID: oidFMSPC, Value: p.marshal(p.fmspc)},
+	})
+	require.NoError(p.t, err)
+	return &pkix.Extension{Id: oidSGXExtensions, Value: value}
+}
+
+func (p *testPlatform) sign(key *ecdsa.PrivateKey, data []byte) []byte {
+	digest := sha256.Sum256(data)
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	require.NoError(p.t, err)
+	signature := make([]byte, ecdsaSignatureLength)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signature
+}
+
+func rawKey(key *ecdsa.PrivateKey) []byte {
+	raw := make([]byte, ecdsaPublicKeyLength)
+	key.X.FillBytes(raw[:32])
+	key.Y.FillBytes(raw[32:])
+	return raw
+}
+
+func pemChain(certs ...*x509.Certificate) []byte {
+	var chain []byte
+	for _, c := range certs {
+		chain = append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+	}
+	return chain
+}
+
+func reportBody(mrenclave, mrsigner []byte, isvProdID, isvSvn uint16, reportData []byte) []byte {
+	body := make([]byte, sgxReportBodyLength)
+	copy(body[64:], mrenclave)
+	copy(body[128:], mrsigner)
+	binary.LittleEndian.PutUint16(body[256:], isvProdID)
+	binary.LittleEndian.PutUint16(body[258:], isvSvn)
+	copy(body[320:], reportData)
+	return body
+}
+
+func (p *testPlatform) tdReportBody(mrtd, reportData []byte) []byte {
+	body := make([]byte, tdxReportBodyLength)
+	for i := 0; i < 16; i++ {
+		body[i] = p.tdxTcbSvn
+	}
+	if p.tdDebug {
+		body[120] = tdAttributeDebug
+	}
+	copy(body[136:], mrtd)
+	copy(body[520:], reportData)
+	return body
+}
+
+// quote returns a version 3 quote for an enclave (or, for TDX platforms, a version 4 quote for a trust domain) with
+// the given mrenclave (mrtd) and report data
+func (p *testPlatform) quote(mrenclave string, reportData []byte) []byte {
+	header := make([]byte, quoteHeaderLength)
+	binary.LittleEndian.PutUint16(header[0:], 3)
+	binary.LittleEndian.PutUint16(header[2:], AttestationKeyTypeECDSAP256)
+	copy(header[12:], intelQEVendorID)
+
+	mr, err := hex.DecodeString(mrenclave)
+	require.NoError(p.t, err)
+	body := reportBody(mr, nil, 0, 0, reportData)
+	if p.tdx {
+		binary.LittleEndian.PutUint16(header[0:], 4)
+		binary.LittleEndian.PutUint32(header[4:], TeeTypeTDX)
+		body = p.tdReportBody(mr, reportData)
+	}
+
+	attestationKey := rawKey(p.attestationKey)
+	authData := make([]byte, 32)
+	qeReportData := sha256.Sum256(append(append([]byte{}, attestationKey...), authData...))
+	qeMrSigner, err := hex.DecodeString(p.qeMrSigner)
+	require.NoError(p.t, err)
+	qeReport := reportBody(nil, qeMrSigner, 1, p.qeIsvSvn, append(qeReportData[:], make([]byte, 32)...))
+	qeReport[48] = 0x11 // attributes INIT and MODE64BIT
+
+	signedData := append(append([]byte{}, header...), body...)
+	chain := pemChain(p.pck, p.platformCA, p.root)
+
+	var qeData []byte
+	qeData = append(qeData, qeReport...)
+	qeData = append(qeData, p.sign(p.pckKey, qeReport)...)
+	qeData = binary.LittleEndian.AppendUint16(qeData, uint16(len(authData)))
+	qeData = append(qeData, authData...)
+	qeData = binary.LittleEndian.AppendUint16(qeData, certificationDataTypePCKCertChain)
+	qeData = binary.LittleEndian.AppendUint32(qeData, uint32(len(chain)))
+	qeData = append(qeData, chain...)
+
+	var sigData []byte
+	sigData = append(sigData, p.sign(p.attestationKey, signedData)...)
+	sigData = append(sigData, attestationKey...)
+	if p.tdx {
+		// version 4 quotes wrap the QE report certification data
+		sigData = binary.LittleEndian.AppendUint16(sigData, certificationDataTypeQEReport)
+		sigData = binary.LittleEndian.AppendUint32(sigData, uint32(len(qeData)))
+	}
+	sigData = append(sigData, qeData...)
+
+	quote := binary.LittleEndian.AppendUint32(signedData, uint32(len(sigData)))
+	return append(quote, sigData...)
+}
+
+func (p *testPlatform) signedBody(field string, v interface{}) string {
+	data, err := json.Marshal(v)
+	require.NoError(p.t, err)
+	return fmt.Sprintf(`{"%s":%s,"signature":"%s"}`, field, data, hex.EncodeToString(p.sign(p.tcbSigningKey, data)))
+}
+
+func (p *testPlatform) collateral() *Collateral {
+	issueDate := testNow.Add(-24 * time.Hour)
+	nextUpdate := testNow.Add(30 * 24 * time.Hour)
+
+	crlTemplate := &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: issueDate,
+		NextUpdate: nextUpdate,
+	}
+	if p.revoked {
+		crlTemplate.RevokedCertificateEntries = []x509.RevocationListEntry{{SerialNumber: p.pck.SerialNumber, RevocationTime: issueDate}}
+	}
+	crl, err := x509.CreateRevocationList(rand.Reader, crlTemplate, p.platformCA, p.platformCAKey)
+	require.NoError(p.t, err)
+
+	components := make([]map[string]int, tcbComponentCount)
+	for i := range components {
+		components[i] = map[string]int{"svn": 3}
+	}
+	tcbInfo := map[string]interface{}{
+		"id":         "SGX",
+		"version":    3,
+		"issueDate":  issueDate,
+		"nextUpdate": nextUpdate,
+		"fmspc":      hex.EncodeToString(p.fmspc),
+		"pceId":      "0000",
+		"tcbLevels": []map[string]interface{}{
+			{"tcb": map[string]interface{}{"sgxtcbcomponents": components, "pcesvn": 13}, "tcbStatus": p.tcbStatus},
+			{"tcb": map[string]interface{}{"sgxtcbcomponents": components, "pcesvn": 5}, "tcbStatus": TcbStatusOutOfDate},
+		},
+	}
+	if p.tdx {
+		tdxComponents := func(svn int) []map[string]int {
+			c := make([]map[string]int, 16)
+			for i := range c {
+				c[i] = map[string]int{"svn": svn}
+			}
+			return c
+		}
+		tcbInfo["id"] = tdxTcbInfoID
+		tcbInfo["tcbLevels"] = []map[string]interface{}{
+			{"tcb": map[string]interface{}{"sgxtcbcomponents": components, "pcesvn": 13, "tdxtcbcomponents": tdxComponents(4)}, "tcbStatus": p.tcbStatus},
+			{"tcb": map[string]interface{}{"sgxtcbcomponents": components, "pcesvn": 13, "tdxtcbcomponents": tdxComponents(2)}, "tcbStatus": TcbStatusOutOfDate},
+		}
+	}
+
+	qeIdentity := map[string]interface{}{
+		"id":             "QE",
+		"version":        2,
+		"issueDate":      issueDate,
+		"nextUpdate":     nextUpdate,
+		"miscselect":     "00000000",
+		"miscselectMask": "FFFFFFFF",
+		"attributes":     "11000000000000000000000000000000",
+		"attributesMask": "FBFFFFFFFFFFFFFF0000000000000000",
+		"mrsigner":       testMrSigner,
+		"isvprodid":      1,
+		"tcbLevels": []map[string]interface{}{
+			{"tcb": map[string]int{"isvsvn": 8}, "tcbStatus": TcbStatusUpToDate},
+			{"tcb": map[string]int{"isvsvn": 6}, "tcbStatus": TcbStatusOutOfDate},
+		},
+	}
+
+	tcbSigningChain := string(pemChain(p.tcbSigning, p.root))
+	return &Collateral{
+		PckCrlIssuerChain:     string(pemChain(p.platformCA, p.root)),
+		PckCrl:                crl,
+		TcbInfoIssuerChain:    tcbSigningChain,
+		TcbInfo:               p.signedBody("tcbInfo", tcbInfo),
+		QeIdentityIssuerChain: tcbSigningChain,
+		QeIdentity:            p.signedBody("enclaveIdentity", qeIdentity),
+	}
+}
+
+// evidence returns the DCAP evidence for an enclave with the given mrenclave and statement
+func (p *testPlatform) evidence(mrenclave string, statement []byte) string {
+	reportData := sha256.Sum256(statement)
+	qc := &QuoteWithCollateral{
+		Quote:      encodeQuote(p.quote(mrenclave, reportData[:])),
+		Collateral: p.collateral(),
+	}
+	evidence, err := json.Marshal(qc)
+	require.NoError(p.t, err)
+	return string(evidence)
+}
+
+// recordedTDXCollateral returns the collateral for the recorded TDX quote in testdata/tdx
+func recordedTDXCollateral(t *testing.T) *Collateral {
+	read := func(name string) []byte {
+		b, err := os.ReadFile(filepath.Join("testdata", "tdx", name))
+		require.NoError(t, err)
+		return b
+	}
+
+	return &Collateral{
+		PckCrlIssuerChain:     string(read("pck_crl_issuer_chain.pem")),
+		PckCrl:                read("pck_crl.der"),
+		TcbInfoIssuerChain:    string(read("tcb_info_issuer_chain.pem")),
+		TcbInfo:               string(read("tcb_info.json")),
+		QeIdentityIssuerChain: string(read("qe_identity_issuer_chain.pem")),
+		QeIdentity:            string(read("qe_identity.json")),
+	}
+}
+
+func encodeQuote(quote []byte) string {
+	return base64.StdEncoding.EncodeToString(quote)
+}
+
+func encodeHex(b []byte) string {
+	return hex.EncodeToString(b)
+}
+
+func replaceOnce(t *testing.T, s, old, new string) string {
+	require.Contains(t, s, old)
+	return strings.Replace(s, old, new, 1)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/tsm"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/pkg/errors"
+)
+
+// GramineAttestationPath is the attestation pseudo-filesystem of Gramine enclaves
+const GramineAttestationPath = "/dev/attestation"
+
+// QuoteProvider returns ECDSA quotes of the calling SGX enclave binding the given report data. It abstracts the
+// enclave runtime, e.g., Gramine (see NewGramineQuoteProvider) or EGo.
+type QuoteProvider interface {
+	GetQuote(reportData []byte) ([]byte, error)
+}
+
+// NewDCAPIssuer creates a new attestation issuer for Go enclaves running in an SGX enclave runtime that provides
+// ECDSA quotes through the given QuoteProvider
+func NewDCAPIssuer(provider QuoteProvider) *types.Issuer {
+	return &types.Issuer{
+		Type: DCAPType,
+		Issue: func(customData []byte) ([]byte, error) {
+			quote, err := provider.GetQuote(tsm.ReportData(customData))
+			if err != nil {
+				return nil, errors.Wrap(err, "cannot get quote")
+			}
+
+			// the attestation is the base64-encoded quote
+			return json.Marshal(&types.Attestation{
+				Type: DCAPType,
+				Data: base64.StdEncoding.EncodeToString(quote),
+			})
+		},
+	}
+}
+
+type gramineQuoteProvider struct {
+	path string
+}
+
+// NewGramineQuoteProvider returns a QuoteProvider using the attestation pseudo-filesystem of Gramine at the given path
+// (usually GramineAttestationPath). The enclave must be configured for DCAP (`sgx.remote_attestation = "dcap"`).
+func NewGramineQuoteProvider(path string) QuoteProvider {
+	return &gramineQuoteProvider{path: path}
+}
+
+func (p *gramineQuoteProvider) GetQuote(reportData []byte) ([]byte, error) {
+	if err := os.WriteFile(filepath.Join(p.path, "user_report_data"), reportData, 0600); err != nil {
+		return nil, errors.Wrap(err, "cannot write report data")
+	}
+
+	quote, err := os.ReadFile(filepath.Join(p.path, "quote"))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read quote")
+	}
+	return quote, nil
+}
@@ -0,0 +1,53 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/tsm"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDCAPIssuer(t *testing.T) {
+	platform := newTestPlatform(t)
+
+	// mimic the Gramine attestation pseudo-filesystem with a quote for the expected report data
+	path := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(path, "quote"), platform.quote(testMrenclave, tsm.ReportData(testStatement)), 0600))
+
+	issuer := NewDCAPIssuer(NewGramineQuoteProvider(path))
+	assert.Equal(t, DCAPType, issuer.Type)
+
+	attestationBytes, err := issuer.Issue(testStatement)
+	require.NoError(t, err)
+
+	reportData, err := os.ReadFile(filepath.Join(path, "user_report_data"))
+	require.NoError(t, err)
+	assert.Equal(t, tsm.ReportData(testStatement), reportData)
+
+	attestation := &types.Attestation{}
+	require.NoError(t, json.Unmarshal(attestationBytes, attestation))
+	assert.Equal(t, DCAPType, attestation.Type)
+	evidence, err := newDCAPConverter(NewPCCSClient(WithHttpClient(fakePCCS(platform))))([]byte(attestation.Data))
+	require.NoError(t, err)
+	verifier := NewDCAPVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow }))
+	assert.NoError(t, verifier.Verify(&types.Evidence{Type: DCAPType, Data: string(evidence)}, &types.ValidationValues{
+		Statement: testStatement,
+		Mrenclave: testMrenclave,
+	}))
+
+	// not a Gramine enclave
+	_, err = NewDCAPIssuer(NewGramineQuoteProvider(filepath.Join(path, "missing"))).Issue(testStatement)
+	assert.ErrorContains(t, err, "cannot get quote: cannot write report data")
+}
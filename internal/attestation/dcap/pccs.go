@@ -0,0 +1,158 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultPCCSUrl is the Intel Provisioning Certification Service (PCS); a local Provisioning Certificate Caching
+// Service (PCCS) exposes the same API
+const DefaultPCCSUrl = "https://api.trustedservices.intel.com/sgx/certification/v4"
+
+// issuer chain headers of the PCS v4 API
+const (
+	pckCrlIssuerChainHeader     = "SGX-PCK-CRL-Issuer-Chain"
+	tcbInfoIssuerChainHeader    = "TCB-Info-Issuer-Chain"
+	qeIdentityIssuerChainHeader = "SGX-Enclave-Identity-Issuer-Chain"
+)
+
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type PCCSClient struct {
+	url        string
+	httpClient HTTPClient
+}
+
+type PCCSClientOption func(*PCCSClient)
+
+// WithUrl option allows to override the default PCCS endpoint (DefaultPCCSUrl)
+func WithUrl(url string) PCCSClientOption {
+	return func(c *PCCSClient) {
+		c.url = url
+	}
+}
+
+// WithHttpClient option allows to use a custom http client. Mainly used for testing
+func WithHttpClient(client HTTPClient) PCCSClientOption {
+	return func(c *PCCSClient) {
+		c.httpClient = client
+	}
+}
+
+// NewPCCSClient returns a new PCCSClient instance using DefaultPCCSUrl as endpoint.
+// Optionally, PCCSClientOption can be provided to change the behavior of the PCCSClient.
+func NewPCCSClient(opts ...PCCSClientOption) *PCCSClient {
+	client := &PCCSClient{
+		url: DefaultPCCSUrl,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	// create default http client if not provided via options
+	if client.httpClient == nil {
+		client.httpClient = &http.Client{}
+	}
+
+	return client
+}
+
+// GetCollateral fetches the collateral required to verify the given quote. For TDX quotes, the TCB info and QE
+// identity are fetched from the TDX API (/tdx/certification/v4) of the same PCS or PCCS.
+func (c *PCCSClient) GetCollateral(quote *Quote) (*Collateral, error) {
+	pckCert, err := parseCertChain(string(quote.PCKCertChain))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid PCK certificate chain")
+	}
+	pck, err := parsePCKExtensions(pckCert[0])
+	if err != nil {
+		return nil, err
+	}
+
+	collateral := &Collateral{}
+
+	// the PCK certificate is either issued by the processor or the platform CA
+	ca := "processor"
+	if pckCert[0].Issuer.CommonName == "Intel SGX PCK Platform CA" {
+		ca = "platform"
+	}
+	collateral.PckCrl, collateral.PckCrlIssuerChain, err = c.get(c.url, "pckcrl", url.Values{"ca": {ca}, "encoding": {"der"}}, pckCrlIssuerChainHeader)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get PCK CRL")
+	}
+
+	// the PCK CRL is shared by SGX and TDX
+	teeUrl := c.url
+	if quote.Header.TeeType == TeeTypeTDX {
+		teeUrl = strings.Replace(c.url, "/sgx/certification/", "/tdx/certification/", 1)
+	}
+
+	tcbInfo, tcbInfoIssuerChain, err := c.get(teeUrl, "tcb", url.Values{"fmspc": {hex.EncodeToString(pck.fmspc)}}, tcbInfoIssuerChainHeader)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get TCB info")
+	}
+	collateral.TcbInfo, collateral.TcbInfoIssuerChain = string(tcbInfo), tcbInfoIssuerChain
+
+	qeIdentity, qeIdentityIssuerChain, err := c.get(teeUrl, "qe/identity", nil, qeIdentityIssuerChainHeader)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get QE identity")
+	}
+	collateral.QeIdentity, collateral.QeIdentityIssuerChain = string(qeIdentity), qeIdentityIssuerChain
+
+	return collateral, nil
+}
+
+func (c *PCCSClient) get(baseUrl, path string, query url.Values, issuerChainHeader string) ([]byte, string, error) {
+	u := fmt.Sprintf("%s/%s", baseUrl, path)
+	if len(query) > 0 {
+		u = fmt.Sprintf("%s?%s", u, query.Encode())
+	}
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "cannot create http request")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "cannot perform http request")
+	}
+	defer resp.Body.Close()
+
+	// check response status code
+	if resp.StatusCode != 200 {
+		return nil, "", fmt.Errorf("request failed! Reason: %d %s. Request ID: %s", resp.StatusCode, resp.Status, resp.Header.Get("Request-ID"))
+	}
+
+	issuerChain := resp.Header.Get(issuerChainHeader)
+	if len(issuerChain) == 0 {
+		// older PCCS versions prefix the TCB info issuer chain header
+		issuerChain = resp.Header.Get("SGX-" + issuerChainHeader)
+	}
+	if len(issuerChain) == 0 {
+		return nil, "", fmt.Errorf("response has no %s header", issuerChainHeader)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "cannot read response")
+	}
+
+	return body, issuerChain, nil
+}
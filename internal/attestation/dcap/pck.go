@@ -0,0 +1,240 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	_ "embed"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// trustedRootPEM is the Intel SGX Root CA certificate, the trust anchor for PCK certificates, TCB info and QE identity
+// (https://certificates.trustedservices.intel.com/Intel_SGX_Provisioning_Certification_RootCA.pem)
+//
+//go:embed trusted_root.pem
+var trustedRootPEM []byte
+
+// OIDs of the Intel SGX PCK certificate extensions, see Intel SGX PCK Certificate and Certificate Revocation List Profile
+var (
+	oidSGXExtensions = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1}
+	oidTCB           = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 2}
+	oidPCEID         = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 3}
+	oidFMSPC         = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 4}
+)
+
+const (
+	tcbComponentCount  = 16
+	oidTCBPceSvnSuffix = 17
+	oidTCBCpuSvnSuffix = 18
+)
+
+// pckExtensions holds the platform information encoded in the SGX extension of a PCK certificate
+type pckExtensions struct {
+	tcbComponents [tcbComponentCount]int
+	pceSvn        int
+	pceID         []byte
+	fmspc         []byte
+}
+
+type sgxExtension struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+// DefaultTrustedRoots returns a certificate pool with the Intel SGX Root CA
+func DefaultTrustedRoots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(trustedRootPEM) {
+		panic("cannot load embedded Intel SGX Root CA")
+	}
+	return pool
+}
+
+// LoadTrustedRoots returns a certificate pool with the PEM-encoded root CA certificates in the given file, e.g.,
+// to replace the Intel SGX Root CA as trust anchor using WithTrustedRoots
+func LoadTrustedRoots(path string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read trusted roots")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// parseCertChain parses a PEM-encoded certificate chain; issuer chains returned by the PCCS are URL-encoded
+func parseCertChain(chain string) ([]*x509.Certificate, error) {
+	if strings.Contains(chain, "%") {
+		decoded, err := url.PathUnescape(chain)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot decode certificate chain")
+		}
+		chain = decoded
+	}
+
+	var certs []*x509.Certificate
+	rest := []byte(chain)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse certificate")
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+	return certs, nil
+}
+
+// verifyCertChain parses the given PEM-encoded certificate chain and verifies it against the trusted roots at the
+// given time; it returns the leaf certificate
+func verifyCertChain(chain string, roots *x509.CertPool, now time.Time) (*x509.Certificate, error) {
+	certs, err := parseCertChain(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+
+	_, err = certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot verify certificate chain")
+	}
+
+	return certs[0], nil
+}
+
+// verifyECDSASignature verifies a raw (r|s) ECDSA P-256 signature over SHA-256 of the given data
+func verifyECDSASignature(pub *ecdsa.PublicKey, data, signature []byte) error {
+	if len(signature) != ecdsaSignatureLength {
+		return fmt.Errorf("invalid signature length %d", len(signature))
+	}
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	digest := sha256.Sum256(data)
+	if !ecdsa.Verify(pub, digest[:], r, s) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+func certificatePublicKey(cert *x509.Certificate) (*ecdsa.PublicKey, error) {
+	pub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok || pub.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("certificate '%s' has no ECDSA P-256 key", cert.Subject.CommonName)
+	}
+	return pub, nil
+}
+
+// rawPublicKey returns the ECDSA P-256 public key for the given raw (x|y) encoding
+func rawPublicKey(raw []byte) (*ecdsa.PublicKey, error) {
+	if len(raw) != ecdsaPublicKeyLength {
+		return nil, fmt.Errorf("invalid public key length %d", len(raw))
+	}
+	pub := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(raw[:32]),
+		Y:     new(big.Int).SetBytes(raw[32:]),
+	}
+	if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+		return nil, fmt.Errorf("public key not on curve")
+	}
+	return pub, nil
+}
+
+func parsePCKExtensions(cert *x509.Certificate) (*pckExtensions, error) {
+	var raw []byte
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidSGXExtensions) {
+			raw = ext.Value
+		}
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("PCK certificate has no SGX extension")
+	}
+
+	var extensions []sgxExtension
+	if _, err := asn1.Unmarshal(raw, &extensions); err != nil {
+		return nil, errors.Wrap(err, "cannot parse SGX extension")
+	}
+
+	pck := &pckExtensions{}
+	var hasTCB bool
+	for _, e := range extensions {
+		var err error
+		switch {
+		case e.ID.Equal(oidTCB):
+			hasTCB = true
+			err = pck.parseTCB(e.Value.FullBytes)
+		case e.ID.Equal(oidPCEID):
+			_, err = asn1.Unmarshal(e.Value.FullBytes, &pck.pceID)
+		case e.ID.Equal(oidFMSPC):
+			_, err = asn1.Unmarshal(e.Value.FullBytes, &pck.fmspc)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot parse SGX extension %s", e.ID)
+		}
+	}
+
+	if !hasTCB || pck.pceID == nil || pck.fmspc == nil {
+		return nil, fmt.Errorf("PCK certificate SGX extension lacks TCB, PCE-ID or FMSPC")
+	}
+	return pck, nil
+}
+
+func (pck *pckExtensions) parseTCB(raw []byte) error {
+	var components []sgxExtension
+	if _, err := asn1.Unmarshal(raw, &components); err != nil {
+		return err
+	}
+
+	for _, c := range components {
+		if len(c.ID) != len(oidTCB)+1 || !c.ID[:len(oidTCB)].Equal(oidTCB) {
+			continue
+		}
+		switch n := c.ID[len(oidTCB)]; {
+		case n >= 1 && n <= tcbComponentCount:
+			if _, err := asn1.Unmarshal(c.Value.FullBytes, &pck.tcbComponents[n-1]); err != nil {
+				return err
+			}
+		case n == oidTCBPceSvnSuffix:
+			if _, err := asn1.Unmarshal(c.Value.FullBytes, &pck.pceSvn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
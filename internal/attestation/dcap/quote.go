@@ -0,0 +1,297 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Quote format as defined in the Intel SGX ECDSA Quote Library API specification (quote version 3) and
+// the Intel TDX DCAP Quote Generation Library and Quote Verification Library specification (quote version 4).
+
+const (
+	quoteHeaderLength    = 48
+	sgxReportBodyLength  = 384
+	tdxReportBodyLength  = 584
+	ecdsaSignatureLength = 64
+	ecdsaPublicKeyLength = 64
+
+	// AttestationKeyTypeECDSAP256 denotes an ECDSA-256-with-P-256 attestation key
+	AttestationKeyTypeECDSAP256 = 2
+
+	// TeeTypeSGX and TeeTypeTDX denote the TEE type of a version 4 quote
+	TeeTypeSGX = 0x00000000
+	TeeTypeTDX = 0x00000081
+
+	// attributeDebug is the DEBUG flag in the first byte of the enclave attributes
+	attributeDebug = 0x02
+
+	certificationDataTypePCKCertChain = 5
+	certificationDataTypeQEReport     = 6
+)
+
+// intelQEVendorID is the vendor id of the Intel quoting enclave
+var intelQEVendorID = []byte{0x93, 0x9A, 0x72, 0x33, 0xF7, 0x9C, 0x4C, 0xA9, 0x94, 0x0A, 0x0D, 0xB3, 0x95, 0x7F, 0x06, 0x07}
+
+// QuoteHeader is the header of a version 3 or version 4 quote
+type QuoteHeader struct {
+	Version            uint16
+	AttestationKeyType uint16
+	// TeeType is always TeeTypeSGX for version 3 quotes
+	TeeType    uint32
+	QeVendorID []byte
+	UserData   []byte
+}
+
+// ReportBody is an SGX enclave report body (sgx_report_body_t)
+type ReportBody struct {
+	CPUSvn     []byte
+	MiscSelect uint32
+	Attributes []byte
+	MrEnclave  []byte
+	MrSigner   []byte
+	IsvProdID  uint16
+	IsvSvn     uint16
+	ReportData []byte
+}
+
+// TDReportBody is a TDX TD report body (TD quote body of a version 4 quote)
+type TDReportBody struct {
+	TeeTcbSvn      []byte
+	MrSeam         []byte
+	MrSignerSeam   []byte
+	SeamAttributes []byte
+	TdAttributes   []byte
+	Xfam           []byte
+	MrTd           []byte
+	MrConfigID     []byte
+	MrOwner        []byte
+	MrOwnerConfig  []byte
+	Rtmr           [4][]byte
+	ReportData     []byte
+}
+
+// Quote is a parsed ECDSA quote
+type Quote struct {
+	Header QuoteHeader
+	// Body is the raw report body of the attested TEE, i.e., an SGX report body (see EnclaveReport) for SGX quotes
+	// and a TD report body for TDX quotes
+	Body []byte
+
+	// Signature is the ECDSA signature (r|s) over the header and body with AttestationKey
+	Signature []byte
+	// AttestationKey is the raw ECDSA P-256 public key (x|y) of the quoting enclave
+	AttestationKey []byte
+
+	// QeReport is the report body of the quoting enclave binding the attestation key
+	QeReport *ReportBody
+	// QeReportSignature is the ECDSA signature (r|s) over the QE report with the PCK
+	QeReportSignature []byte
+	// QeAuthData is the authentication data included in the QE report data
+	QeAuthData []byte
+	// PCKCertChain is the PEM-encoded PCK certificate chain (PCK certificate, intermediate CA, root CA)
+	PCKCertChain []byte
+
+	signedData  []byte
+	rawQeReport []byte
+}
+
+// ParseQuote parses an ECDSA quote of version 3 (SGX) or version 4 (SGX or TDX)
+func ParseQuote(raw []byte) (*Quote, error) {
+	r := &reader{buf: raw}
+
+	header, err := parseQuoteHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	bodyLength := sgxReportBodyLength
+	if header.TeeType == TeeTypeTDX {
+		bodyLength = tdxReportBodyLength
+	}
+
+	q := &Quote{Header: *header}
+	q.Body = r.next(bodyLength)
+	if r.err != nil {
+		return nil, errors.Wrap(r.err, "quote too short")
+	}
+	q.signedData = raw[:quoteHeaderLength+bodyLength]
+
+	signatureDataLength := int(r.uint32())
+	signatureData := r.next(signatureDataLength)
+	if r.err != nil {
+		return nil, errors.Wrap(r.err, "quote too short")
+	}
+
+	if err := q.parseSignatureData(&reader{buf: signatureData}); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+func parseQuoteHeader(r *reader) (*QuoteHeader, error) {
+	h := &QuoteHeader{}
+	h.Version = r.uint16()
+	h.AttestationKeyType = r.uint16()
+	teeType := r.uint32()
+	r.next(4) // reserved (v4) or QE SVN and PCE SVN (v3)
+	h.QeVendorID = r.next(16)
+	h.UserData = r.next(20)
+	if r.err != nil {
+		return nil, errors.Wrap(r.err, "quote too short")
+	}
+
+	switch h.Version {
+	case 3:
+		// the tee type field is reserved in version 3 quotes
+		h.TeeType = TeeTypeSGX
+	case 4:
+		if teeType != TeeTypeSGX && teeType != TeeTypeTDX {
+			return nil, fmt.Errorf("unsupported tee type 0x%x", teeType)
+		}
+		h.TeeType = teeType
+	default:
+		return nil, fmt.Errorf("unsupported quote version %d", h.Version)
+	}
+
+	if h.AttestationKeyType != AttestationKeyTypeECDSAP256 {
+		return nil, fmt.Errorf("unsupported attestation key type %d", h.AttestationKeyType)
+	}
+
+	if !bytes.Equal(h.QeVendorID, intelQEVendorID) {
+		return nil, fmt.Errorf("unknown QE vendor id %x", h.QeVendorID)
+	}
+
+	return h, nil
+}
+
+func (q *Quote) parseSignatureData(r *reader) error {
+	q.Signature = r.next(ecdsaSignatureLength)
+	q.AttestationKey = r.next(ecdsaPublicKeyLength)
+
+	// version 4 quotes wrap the QE report and PCK certificate chain in a certification data structure
+	if q.Header.Version == 4 {
+		certificationDataType := r.uint16()
+		certificationData := r.next(int(r.uint32()))
+		if r.err != nil {
+			return errors.Wrap(r.err, "invalid signature data")
+		}
+		if certificationDataType != certificationDataTypeQEReport {
+			return fmt.Errorf("unsupported certification data type %d", certificationDataType)
+		}
+		r = &reader{buf: certificationData}
+	}
+
+	q.rawQeReport = r.next(sgxReportBodyLength)
+	q.QeReportSignature = r.next(ecdsaSignatureLength)
+	q.QeAuthData = r.next(int(r.uint16()))
+
+	certificationDataType := r.uint16()
+	q.PCKCertChain = r.next(int(r.uint32()))
+	if r.err != nil {
+		return errors.Wrap(r.err, "invalid signature data")
+	}
+
+	if certificationDataType != certificationDataTypePCKCertChain {
+		return fmt.Errorf("unsupported certification data type %d", certificationDataType)
+	}
+
+	q.QeReport = parseReportBody(q.rawQeReport)
+	return nil
+}
+
+// EnclaveReport returns the SGX report body of the attested enclave
+func (q *Quote) EnclaveReport() (*ReportBody, error) {
+	if q.Header.TeeType != TeeTypeSGX {
+		return nil, fmt.Errorf("not an SGX quote (tee type 0x%x)", q.Header.TeeType)
+	}
+	return parseReportBody(q.Body), nil
+}
+
+// TDReport returns the TD report body of the attested trust domain
+func (q *Quote) TDReport() (*TDReportBody, error) {
+	if q.Header.TeeType != TeeTypeTDX {
+		return nil, fmt.Errorf("not a TDX quote (tee type 0x%x)", q.Header.TeeType)
+	}
+	r := &reader{buf: q.Body}
+	b := &TDReportBody{}
+	b.TeeTcbSvn = r.next(16)
+	b.MrSeam = r.next(48)
+	b.MrSignerSeam = r.next(48)
+	b.SeamAttributes = r.next(8)
+	b.TdAttributes = r.next(8)
+	b.Xfam = r.next(8)
+	b.MrTd = r.next(48)
+	b.MrConfigID = r.next(48)
+	b.MrOwner = r.next(48)
+	b.MrOwnerConfig = r.next(48)
+	for i := range b.Rtmr {
+		b.Rtmr[i] = r.next(48)
+	}
+	b.ReportData = r.next(64)
+	return b, nil
+}
+
+func parseReportBody(raw []byte) *ReportBody {
+	r := &reader{buf: raw}
+	b := &ReportBody{}
+	b.CPUSvn = r.next(16)
+	b.MiscSelect = r.uint32()
+	r.next(28) // reserved
+	b.Attributes = r.next(16)
+	b.MrEnclave = r.next(32)
+	r.next(32) // reserved
+	b.MrSigner = r.next(32)
+	r.next(96) // reserved
+	b.IsvProdID = r.uint16()
+	b.IsvSvn = r.uint16()
+	r.next(60) // reserved
+	b.ReportData = r.next(64)
+	return b
+}
+
+// reader is a little endian byte reader; after the first out of bounds read, all subsequent reads return zero values
+// and err is set
+type reader struct {
+	buf []byte
+	off int
+	err error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.off+n > len(r.buf) {
+		r.err = fmt.Errorf("cannot read %d bytes at offset %d of %d", n, r.off, len(r.buf))
+		return nil
+	}
+	b := r.buf[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *reader) uint16() uint16 {
+	b := r.next(2)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(b)
+}
+
+func (r *reader) uint32() uint32 {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
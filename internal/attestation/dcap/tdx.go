@@ -0,0 +1,132 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/tsm"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/pkg/errors"
+)
+
+const TDXType = "tdx"
+
+// tdAttributeDebug is the DEBUG flag in the first byte of the TD attributes
+const tdAttributeDebug = 0x01
+
+// NewTDXConverter creates a new attestation converter for Intel TDX attestation.
+// As for DCAP, the collateral is fetched from the PCCS at $PCCS_URL or, if not set, from DefaultPCCSUrl.
+func NewTDXConverter() *types.Converter {
+	return &types.Converter{
+		Type:      TDXType,
+		Converter: newDCAPConverter(newPCCSClientFromEnv()),
+	}
+}
+
+// NewTDXVerifier creates a new attestation verifier for Intel TDX attestation. The TD measurement (MRTD) takes the
+// role of the mrenclave.
+func NewTDXVerifier(opts ...VerifierOption) *types.Verifier {
+	v := newVerifier(opts...)
+	return &types.Verifier{
+		Type:   TDXType,
+		Verify: v.verifyTDX,
+		Claims: v.claimsTDX,
+	}
+}
+
+func (v *verifier) verifyTDX(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) error {
+	quote, _, err := v.verifyEvidence(evidence)
+	if err != nil {
+		return err
+	}
+
+	report, err := quote.TDReport()
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(hex.EncodeToString(report.MrTd), expectedValidationValues.Mrenclave) {
+		return fmt.Errorf("mrtd does not match: expected %s but got %x", expectedValidationValues.Mrenclave, report.MrTd)
+	}
+
+	return checkReportData(report.ReportData, expectedValidationValues.Statement)
+}
+
+// claimsTDX returns the claims of the (verified) evidence for the appraisal policy. TDs have no signer identity and
+// security version, hence, policies restricting mrsigners or the isv svn reject TDX evidence.
+func (v *verifier) claimsTDX(evidence *types.Evidence) (*types.Claims, error) {
+	quote, status, err := v.verifyEvidence(evidence)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := quote.TDReport()
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Claims{
+		Status:      status.tcbStatus,
+		AdvisoryIDs: status.advisoryIDs,
+		Debug:       report.TdAttributes[0]&tdAttributeDebug != 0,
+		Timestamp:   status.issueDate,
+	}, nil
+}
+
+type tdxIssuer struct {
+	provider tsm.ReportProvider
+}
+
+type TDXIssuerOption func(*tdxIssuer)
+
+// WithReportProvider option allows to override the configfs-tsm report provider. Mainly used for testing
+func WithReportProvider(provider tsm.ReportProvider) TDXIssuerOption {
+	return func(i *tdxIssuer) {
+		i.provider = provider
+	}
+}
+
+// NewTDXIssuer creates a new attestation issuer for enclaves running in an Intel TDX trust domain.
+// The issuer obtains TD quotes through the configfs-tsm interface of the guest kernel.
+func NewTDXIssuer(opts ...TDXIssuerOption) *types.Issuer {
+	i := &tdxIssuer{}
+
+	// apply options
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	if i.provider == nil {
+		i.provider = tsm.NewConfigFSProvider()
+	}
+
+	return &types.Issuer{
+		Type:  TDXType,
+		Issue: i.issue,
+	}
+}
+
+func (i *tdxIssuer) issue(customData []byte) ([]byte, error) {
+	report, err := i.provider.GetReport(tsm.ReportData(customData))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get TD quote")
+	}
+	if report.Provider != tsm.ProviderTDX {
+		return nil, fmt.Errorf("unexpected report provider '%s'", report.Provider)
+	}
+
+	// as for DCAP, the attestation is the base64-encoded quote
+	return json.Marshal(&types.Attestation{
+		Type: TDXType,
+		Data: base64.StdEncoding.EncodeToString(report.OutBlob),
+	})
+}
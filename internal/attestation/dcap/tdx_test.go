@@ -0,0 +1,200 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/tsm"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMrtd = "4b8e5d3f1c2a7e9b0d6f8a1c3e5b7d9f2a4c6e8b0d1f3a5c7e9b2d4f6a8c0e1b3d5f7a9c2e4b6d8f0a1c3e5b7d9f2a4c"
+
+func newTDXTestPlatform(t *testing.T) *testPlatform {
+	platform := newTestPlatform(t)
+	platform.tdx = true
+	return platform
+}
+
+func TestVerifyTDXQuote(t *testing.T) {
+	platform := newTDXTestPlatform(t)
+	verifier := NewTDXVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow }))
+	assert.Equal(t, TDXType, verifier.Type)
+
+	expected := &types.ValidationValues{Statement: testStatement, Mrenclave: testMrtd}
+	evidence := &types.Evidence{Type: TDXType, Data: platform.evidence(testMrtd, testStatement)}
+
+	// success
+	assert.NoError(t, verifier.Verify(evidence, expected))
+
+	// wrong mrtd
+	err := verifier.Verify(evidence, &types.ValidationValues{Statement: testStatement, Mrenclave: "00" + testMrtd[2:]})
+	assert.ErrorContains(t, err, "mrtd does not match")
+
+	// wrong statement
+	err = verifier.Verify(evidence, &types.ValidationValues{Statement: []byte("other statement"), Mrenclave: testMrtd})
+	assert.ErrorContains(t, err, "report data does not match statement")
+
+	// TDX module TCB below all TCB levels
+	platform.tdxTcbSvn = 1
+	evidence = &types.Evidence{Type: TDXType, Data: platform.evidence(testMrtd, testStatement)}
+	assert.EqualError(t, verifier.Verify(evidence, expected), "quote verification failed: TCB level not supported")
+
+	// TDX module TCB out of date
+	platform.tdxTcbSvn = 3
+	evidence = &types.Evidence{Type: TDXType, Data: platform.evidence(testMrtd, testStatement)}
+	err = NewTDXVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow }), WithAcceptedTcbStatuses(TcbStatusUpToDate)).Verify(evidence, expected)
+	assert.EqualError(t, err, "quote verification failed: platform TCB status 'OutOfDate' not accepted")
+
+	// SGX quotes are rejected by the TDX verifier and vice versa
+	sgxPlatform := newTestPlatform(t)
+	evidence = &types.Evidence{Type: TDXType, Data: sgxPlatform.evidence(testMrenclave, testStatement)}
+	err = NewTDXVerifier(WithTrustedRoots(sgxPlatform.roots()), WithCurrentTime(func() time.Time { return testNow })).Verify(evidence, expected)
+	assert.EqualError(t, err, "not a TDX quote (tee type 0x0)")
+
+	platform.tdxTcbSvn = 4
+	evidence = &types.Evidence{Type: DCAPType, Data: platform.evidence(testMrtd, testStatement)}
+	err = NewDCAPVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow })).Verify(evidence, expected)
+	assert.EqualError(t, err, "not an SGX quote (tee type 0x81)")
+
+	// SGX TCB info is rejected for TDX quotes
+	qc := &QuoteWithCollateral{}
+	require.NoError(t, json.Unmarshal([]byte(platform.evidence(testMrtd, testStatement)), qc))
+	qc.Collateral = sgxPlatform.collateral()
+	data, err := json.Marshal(qc)
+	require.NoError(t, err)
+	err = verifier.Verify(&types.Evidence{Type: TDXType, Data: string(data)}, expected)
+	assert.ErrorContains(t, err, "quote verification failed")
+}
+
+func TestParseTDXQuote(t *testing.T) {
+	platform := newTDXTestPlatform(t)
+	platform.tdDebug = true
+	quote, err := ParseQuote(platform.quote(testMrtd, tsm.ReportData(testStatement)))
+	require.NoError(t, err)
+	assert.Equal(t, uint16(4), quote.Header.Version)
+
+	report, err := quote.TDReport()
+	require.NoError(t, err)
+	assert.Equal(t, testMrtd, encodeHex(report.MrTd))
+	assert.Equal(t, tsm.ReportData(testStatement), report.ReportData)
+	assert.Equal(t, byte(tdAttributeDebug), report.TdAttributes[0])
+	assert.Equal(t, byte(4), report.TeeTcbSvn[0])
+
+	raw := platform.quote(testMrtd, tsm.ReportData(testStatement))
+	_, err = ParseQuote(append([]byte{}, raw[:quoteHeaderLength+tdxReportBodyLength-1]...))
+	assert.ErrorContains(t, err, "quote too short")
+
+	quote, err = ParseQuote(newTestPlatform(t).quote(testMrenclave, make([]byte, 64)))
+	require.NoError(t, err)
+	_, err = quote.TDReport()
+	assert.EqualError(t, err, "not a TDX quote (tee type 0x0)")
+}
+
+func TestTDXQuoteClaims(t *testing.T) {
+	platform := newTDXTestPlatform(t)
+	platform.tdDebug = true
+	verifier := NewTDXVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow }))
+	evidence := &types.Evidence{Type: TDXType, Data: platform.evidence(testMrtd, testStatement)}
+
+	claims, err := verifier.Claims(evidence)
+	require.NoError(t, err)
+	assert.Equal(t, &types.Claims{
+		Status:    TcbStatusUpToDate,
+		Debug:     true,
+		Timestamp: testNow.Add(-24 * time.Hour),
+	}, claims)
+}
+
+func TestTDXConverter(t *testing.T) {
+	platform := newTDXTestPlatform(t)
+	fakeHttpClient := fakePCCS(platform)
+
+	attestation := encodeQuote(platform.quote(testMrtd, tsm.ReportData(testStatement)))
+	convert := newDCAPConverter(NewPCCSClient(WithHttpClient(fakeHttpClient)))
+	evidence, err := convert([]byte(attestation))
+	require.NoError(t, err)
+
+	// the PCK CRL is fetched from the SGX API, the TCB info and QE identity from the TDX API
+	assert.Equal(t, 3, fakeHttpClient.DoCallCount())
+	assert.Equal(t, DefaultPCCSUrl+"/pckcrl?ca=platform&encoding=der", fakeHttpClient.DoArgsForCall(0).URL.String())
+	assert.Equal(t, "https://api.trustedservices.intel.com/tdx/certification/v4/tcb?fmspc=00906ea10000", fakeHttpClient.DoArgsForCall(1).URL.String())
+	assert.Equal(t, "https://api.trustedservices.intel.com/tdx/certification/v4/qe/identity", fakeHttpClient.DoArgsForCall(2).URL.String())
+
+	verifier := NewTDXVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow }))
+	err = verifier.Verify(&types.Evidence{Type: TDXType, Data: string(evidence)}, &types.ValidationValues{
+		Statement: testStatement,
+		Mrenclave: testMrtd,
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, TDXType, NewTDXConverter().Type)
+}
+
+func TestTDXIssuer(t *testing.T) {
+	platform := newTDXTestPlatform(t)
+	provider := &fakes.ReportProvider{}
+	provider.GetReportCalls(func(reportData []byte) (*tsm.Report, error) {
+		return &tsm.Report{Provider: tsm.ProviderTDX, OutBlob: platform.quote(testMrtd, reportData)}, nil
+	})
+
+	issuer := NewTDXIssuer(WithReportProvider(provider))
+	assert.Equal(t, TDXType, issuer.Type)
+
+	attestationBytes, err := issuer.Issue(testStatement)
+	require.NoError(t, err)
+	assert.Equal(t, tsm.ReportData(testStatement), provider.GetReportArgsForCall(0))
+
+	// the attestation converts to evidence accepted by the verifier
+	attestation := &types.Attestation{}
+	require.NoError(t, json.Unmarshal(attestationBytes, attestation))
+	assert.Equal(t, TDXType, attestation.Type)
+	evidence, err := newDCAPConverter(NewPCCSClient(WithHttpClient(fakePCCS(platform))))([]byte(attestation.Data))
+	require.NoError(t, err)
+	verifier := NewTDXVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow }))
+	assert.NoError(t, verifier.Verify(&types.Evidence{Type: TDXType, Data: string(evidence)}, &types.ValidationValues{
+		Statement: testStatement,
+		Mrenclave: testMrtd,
+	}))
+
+	// not a TD
+	provider.GetReportReturns(&tsm.Report{Provider: tsm.ProviderSEVSNP}, nil)
+	_, err = issuer.Issue(testStatement)
+	assert.EqualError(t, err, "unexpected report provider 'sev_guest'")
+
+	provider.GetReportReturns(nil, fmt.Errorf("no configfs-tsm"))
+	_, err = issuer.Issue(testStatement)
+	assert.EqualError(t, err, "cannot get TD quote: no configfs-tsm")
+}
+
+func TestLoadTrustedRoots(t *testing.T) {
+	platform := newTDXTestPlatform(t)
+	path := filepath.Join(t.TempDir(), "root.pem")
+	require.NoError(t, os.WriteFile(path, pemChain(platform.root), 0600))
+
+	roots, err := LoadTrustedRoots(path)
+	require.NoError(t, err)
+	verifier := NewTDXVerifier(WithTrustedRoots(roots), WithCurrentTime(func() time.Time { return testNow }))
+	evidence := &types.Evidence{Type: TDXType, Data: platform.evidence(testMrtd, testStatement)}
+	assert.NoError(t, verifier.Verify(evidence, &types.ValidationValues{Statement: testStatement, Mrenclave: testMrtd}))
+
+	require.NoError(t, os.WriteFile(path, []byte("no pem"), 0600))
+	_, err = LoadTrustedRoots(path)
+	assert.ErrorContains(t, err, "no certificates found")
+
+	_, err = LoadTrustedRoots(filepath.Join(t.TempDir(), "missing.pem"))
+	assert.ErrorContains(t, err, "cannot read trusted roots")
+}
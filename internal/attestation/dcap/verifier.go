@@ -0,0 +1,269 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/pkg/errors"
+)
+
+// DefaultAcceptedTcbStatuses are the TCB statuses accepted by default. These correspond to the quote statuses
+// accepted for EPID attestation (see common/crypto/attestation-api/evidence/verify-evidence.cpp).
+var DefaultAcceptedTcbStatuses = []string{
+	TcbStatusUpToDate,
+	TcbStatusSWHardeningNeeded,
+	TcbStatusConfigurationNeeded,
+	TcbStatusConfigurationAndSWHardeningNeeded,
+	TcbStatusOutOfDate,
+}
+
+// QuoteWithCollateral is the evidence produced by the DCAP converter
+type QuoteWithCollateral struct {
+	// Quote is the base64-encoded ECDSA quote
+	Quote      string      `json:"quote"`
+	Collateral *Collateral `json:"collateral"`
+}
+
+// quoteStatus is the outcome of a successful quote verification
+type quoteStatus struct {
+	// tcbStatus is the platform TCB status; if the platform TCB is up to date, the QE TCB status takes precedence
+	tcbStatus   string
+	advisoryIDs []string
+	// issueDate is the issue date of the TCB info used
+	issueDate time.Time
+}
+
+type verifier struct {
+	roots            *x509.CertPool
+	now              func() time.Time
+	acceptedStatuses []string
+}
+
+type VerifierOption func(*verifier)
+
+// WithTrustedRoots option allows to override the default trust anchor, the Intel SGX Root CA. Mainly used for testing
+func WithTrustedRoots(roots *x509.CertPool) VerifierOption {
+	return func(v *verifier) {
+		v.roots = roots
+	}
+}
+
+// WithCurrentTime option allows to override the time used to check the validity of certificates and collateral
+func WithCurrentTime(now func() time.Time) VerifierOption {
+	return func(v *verifier) {
+		v.now = now
+	}
+}
+
+// WithAcceptedTcbStatuses option allows to override the accepted TCB statuses (DefaultAcceptedTcbStatuses)
+func WithAcceptedTcbStatuses(statuses ...string) VerifierOption {
+	return func(v *verifier) {
+		v.acceptedStatuses = statuses
+	}
+}
+
+func newVerifier(opts ...VerifierOption) *verifier {
+	v := &verifier{
+		now:              time.Now,
+		acceptedStatuses: DefaultAcceptedTcbStatuses,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	if v.roots == nil {
+		v.roots = DefaultTrustedRoots()
+	}
+
+	return v
+}
+
+// NewDCAPVerifier creates a new attestation verifier for Intel SGX DCAP (ECDSA) attestation
+func NewDCAPVerifier(opts ...VerifierOption) *types.Verifier {
+	v := newVerifier(opts...)
+	return &types.Verifier{
+		Type:   DCAPType,
+		Verify: v.verifySGX,
+		Claims: v.claimsSGX,
+	}
+}
+
+func (v *verifier) verifySGX(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) error {
+	quote, _, err := v.verifyEvidence(evidence)
+	if err != nil {
+		return err
+	}
+
+	report, err := quote.EnclaveReport()
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(hex.EncodeToString(report.MrEnclave), expectedValidationValues.Mrenclave) {
+		return fmt.Errorf("mrenclave does not match: expected %s but got %x", expectedValidationValues.Mrenclave, report.MrEnclave)
+	}
+
+	return checkReportData(report.ReportData, expectedValidationValues.Statement)
+}
+
+// claimsSGX returns the claims of the (verified) evidence for the appraisal policy
+func (v *verifier) claimsSGX(evidence *types.Evidence) (*types.Claims, error) {
+	quote, status, err := v.verifyEvidence(evidence)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := quote.EnclaveReport()
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Claims{
+		Status:      status.tcbStatus,
+		AdvisoryIDs: status.advisoryIDs,
+		Debug:       report.Attributes[0]&attributeDebug != 0,
+		IsvSvn:      report.IsvSvn,
+		Mrsigner:    hex.EncodeToString(report.MrSigner),
+		Timestamp:   status.issueDate,
+	}, nil
+}
+
+// verifyEvidence parses the quote and collateral of the evidence and verifies that the quote was produced by a
+// genuine quoting enclave on a platform with an accepted TCB status
+func (v *verifier) verifyEvidence(evidence *types.Evidence) (*Quote, *quoteStatus, error) {
+	qc := &QuoteWithCollateral{}
+	if err := json.Unmarshal([]byte(evidence.Data), qc); err != nil {
+		return nil, nil, errors.Wrap(err, "cannot unmarshal evidence")
+	}
+	if qc.Collateral == nil {
+		return nil, nil, fmt.Errorf("evidence has no collateral")
+	}
+
+	rawQuote, err := base64.StdEncoding.DecodeString(qc.Quote)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot decode quote")
+	}
+
+	quote, err := ParseQuote(rawQuote)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot parse quote")
+	}
+
+	status, err := v.verifyQuote(quote, qc.Collateral)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "quote verification failed")
+	}
+
+	return quote, status, nil
+}
+
+func (v *verifier) verifyQuote(quote *Quote, collateral *Collateral) (*quoteStatus, error) {
+	now := v.now()
+
+	// the quote is signed by the attestation key ...
+	attestationKey, err := rawPublicKey(quote.AttestationKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid attestation key")
+	}
+	if err := verifyECDSASignature(attestationKey, quote.signedData, quote.Signature); err != nil {
+		return nil, errors.Wrap(err, "invalid quote signature")
+	}
+
+	// ... which is bound to the report of the quoting enclave ...
+	expectedQeReportData := sha256.Sum256(append(append([]byte{}, quote.AttestationKey...), quote.QeAuthData...))
+	if !bytes.Equal(quote.QeReport.ReportData[:sha256.Size], expectedQeReportData[:]) ||
+		!bytes.Equal(quote.QeReport.ReportData[sha256.Size:], make([]byte, sha256.Size)) {
+		return nil, fmt.Errorf("QE report data does not match attestation key")
+	}
+
+	// ... which is signed by the PCK of the platform certified by Intel
+	pckCert, err := verifyCertChain(string(quote.PCKCertChain), v.roots, now)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid PCK certificate chain")
+	}
+	pckKey, err := certificatePublicKey(pckCert)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyECDSASignature(pckKey, quote.rawQeReport, quote.QeReportSignature); err != nil {
+		return nil, errors.Wrap(err, "invalid QE report signature")
+	}
+
+	if err := verifyPckCrl(collateral, pckCert, v.roots, now); err != nil {
+		return nil, err
+	}
+
+	pck, err := parsePCKExtensions(pckCert)
+	if err != nil {
+		return nil, err
+	}
+
+	var teeTcbSvn []byte
+	if quote.Header.TeeType == TeeTypeTDX {
+		report, err := quote.TDReport()
+		if err != nil {
+			return nil, err
+		}
+		teeTcbSvn = report.TeeTcbSvn
+	}
+
+	info, level, err := verifyTcbInfo(collateral, pck, teeTcbSvn, v.roots, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.checkTcbStatus("platform", level.TcbStatus); err != nil {
+		return nil, err
+	}
+
+	qeStatus, err := verifyQeIdentity(collateral, quote.QeReport, v.roots, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.checkTcbStatus("QE", qeStatus); err != nil {
+		return nil, err
+	}
+
+	status := &quoteStatus{
+		tcbStatus:   level.TcbStatus,
+		advisoryIDs: level.AdvisoryIDs,
+		issueDate:   info.IssueDate,
+	}
+	if status.tcbStatus == TcbStatusUpToDate {
+		status.tcbStatus = qeStatus
+	}
+	return status, nil
+}
+
+func (v *verifier) checkTcbStatus(component, status string) error {
+	for _, accepted := range v.acceptedStatuses {
+		if status == accepted {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s TCB status '%s' not accepted", component, status)
+}
+
+// checkReportData checks that the report data is SHA256(statement) || 32 zero bytes, the same binding as for EPID
+func checkReportData(reportData []byte, statement []byte) error {
+	expected := sha256.Sum256(statement)
+	if !bytes.Equal(reportData[:sha256.Size], expected[:]) || !bytes.Equal(reportData[sha256.Size:], make([]byte, sha256.Size)) {
+		return fmt.Errorf("report data does not match statement: expected %x but got %x", expected, reportData)
+	}
+	return nil
+}
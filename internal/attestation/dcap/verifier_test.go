@@ -0,0 +1,224 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMrenclave = "98aed61c91f258a37a1e0bb7fdd6b7a3e2b9a16a7e5ee16bf9c9f1b0a1c3e7d2"
+
+var testStatement = []byte("some statement")
+
+func TestVerifySGXQuote(t *testing.T) {
+	platform := newTestPlatform(t)
+	verifier := NewDCAPVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow }))
+	assert.Equal(t, DCAPType, verifier.Type)
+
+	expected := &types.ValidationValues{Statement: testStatement, Mrenclave: testMrenclave}
+	evidence := &types.Evidence{Type: DCAPType, Data: platform.evidence(testMrenclave, testStatement)}
+
+	// success
+	assert.NoError(t, verifier.Verify(evidence, expected))
+
+	// mrenclave is compared case-insensitive
+	assert.NoError(t, verifier.Verify(evidence, &types.ValidationValues{Statement: testStatement, Mrenclave: "98AED61C91F258A37A1E0BB7FDD6B7A3E2B9A16A7E5EE16BF9C9F1B0A1C3E7D2"}))
+
+	// wrong mrenclave
+	err := verifier.Verify(evidence, &types.ValidationValues{Statement: testStatement, Mrenclave: "00" + testMrenclave[2:]})
+	assert.ErrorContains(t, err, "mrenclave does not match")
+
+	// wrong statement
+	err = verifier.Verify(evidence, &types.ValidationValues{Statement: []byte("other statement"), Mrenclave: testMrenclave})
+	assert.ErrorContains(t, err, "report data does not match statement")
+
+	// Intel SGX Root CA is the default trust anchor
+	err = NewDCAPVerifier(WithCurrentTime(func() time.Time { return testNow })).Verify(evidence, expected)
+	assert.ErrorContains(t, err, "invalid PCK certificate chain")
+
+	// expired collateral
+	err = NewDCAPVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow.Add(60 * 24 * time.Hour) })).Verify(evidence, expected)
+	assert.ErrorContains(t, err, "expired")
+
+	// malformed evidence
+	assert.ErrorContains(t, verifier.Verify(&types.Evidence{Type: DCAPType, Data: "not json"}, expected), "cannot unmarshal evidence")
+	assert.ErrorContains(t, verifier.Verify(&types.Evidence{Type: DCAPType, Data: `{"quote": "AAAA"}`}, expected), "evidence has no collateral")
+}
+
+func TestVerifySGXQuoteTampered(t *testing.T) {
+	platform := newTestPlatform(t)
+	verifier := NewDCAPVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow }))
+	expected := &types.ValidationValues{Statement: testStatement, Mrenclave: testMrenclave}
+
+	tamper := func(modify func(qc *QuoteWithCollateral)) *types.Evidence {
+		qc := &QuoteWithCollateral{}
+		require.NoError(t, json.Unmarshal([]byte(platform.evidence(testMrenclave, testStatement)), qc))
+		modify(qc)
+		data, err := json.Marshal(qc)
+		require.NoError(t, err)
+		return &types.Evidence{Type: DCAPType, Data: string(data)}
+	}
+
+	// quote report body modified
+	evidence := tamper(func(qc *QuoteWithCollateral) {
+		quote := platform.quote(testMrenclave, make([]byte, 64))
+		quote[quoteHeaderLength+64] ^= 0xff
+		qc.Quote = encodeQuote(quote)
+	})
+	assert.ErrorContains(t, verifier.Verify(evidence, expected), "invalid quote signature")
+
+	// TCB info signature invalid
+	evidence = tamper(func(qc *QuoteWithCollateral) {
+		qc.Collateral.TcbInfo = replaceOnce(t, qc.Collateral.TcbInfo, `"pceId":"0000"`, `"pceId":"0001"`)
+	})
+	assert.ErrorContains(t, verifier.Verify(evidence, expected), "invalid TCB info: invalid signature")
+
+	// QE identity signed by untrusted key
+	other := newTestPlatform(t)
+	evidence = tamper(func(qc *QuoteWithCollateral) {
+		qc.Collateral.QeIdentity = other.collateral().QeIdentity
+	})
+	assert.ErrorContains(t, verifier.Verify(evidence, expected), "invalid QE identity: invalid signature")
+}
+
+func TestVerifySGXQuoteTcbStatus(t *testing.T) {
+	expected := &types.ValidationValues{Statement: testStatement, Mrenclave: testMrenclave}
+
+	// revoked PCK certificate
+	platform := newTestPlatform(t)
+	platform.revoked = true
+	verifier := NewDCAPVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow }))
+	err := verifier.Verify(&types.Evidence{Type: DCAPType, Data: platform.evidence(testMrenclave, testStatement)}, expected)
+	assert.ErrorContains(t, err, "PCK certificate revoked")
+
+	// platform TCB out of date is accepted by default but can be rejected
+	platform = newTestPlatform(t)
+	platform.tcbStatus = TcbStatusOutOfDate
+	evidence := &types.Evidence{Type: DCAPType, Data: platform.evidence(testMrenclave, testStatement)}
+	verifier = NewDCAPVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow }))
+	assert.NoError(t, verifier.Verify(evidence, expected))
+	verifier = NewDCAPVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow }),
+		WithAcceptedTcbStatuses(TcbStatusUpToDate))
+	assert.EqualError(t, verifier.Verify(evidence, expected), "quote verification failed: platform TCB status 'OutOfDate' not accepted")
+
+	// revoked TCB level is never accepted by default
+	platform = newTestPlatform(t)
+	platform.tcbStatus = TcbStatusRevoked
+	verifier = NewDCAPVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow }))
+	err = verifier.Verify(&types.Evidence{Type: DCAPType, Data: platform.evidence(testMrenclave, testStatement)}, expected)
+	assert.ErrorContains(t, err, "platform TCB status 'Revoked' not accepted")
+
+	// QE isvsvn below all QE TCB levels
+	platform = newTestPlatform(t)
+	platform.qeIsvSvn = 2
+	verifier = NewDCAPVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow }))
+	err = verifier.Verify(&types.Evidence{Type: DCAPType, Data: platform.evidence(testMrenclave, testStatement)}, expected)
+	assert.ErrorContains(t, err, "QE isvsvn 2 not supported")
+
+	// QE signed by a different vendor key
+	platform = newTestPlatform(t)
+	platform.qeMrSigner = testMrenclave
+	verifier = NewDCAPVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow }))
+	err = verifier.Verify(&types.Evidence{Type: DCAPType, Data: platform.evidence(testMrenclave, testStatement)}, expected)
+	assert.ErrorContains(t, err, "QE mrsigner")
+}
+
+// TestVerifyRecordedTDXQuote verifies the recorded production TDX quote and collateral against the Intel SGX Root CA
+func TestVerifyRecordedTDXQuote(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "tdx", "quote.dat"))
+	require.NoError(t, err)
+
+	quote, err := ParseQuote(raw)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(4), quote.Header.Version)
+	assert.Equal(t, uint32(TeeTypeTDX), quote.Header.TeeType)
+
+	collateral := recordedTDXCollateral(t)
+	v := newVerifier(WithCurrentTime(func() time.Time { return time.Date(2023, 6, 20, 0, 0, 0, 0, time.UTC) }))
+
+	// the platform TCB of the recorded quote is below all TCB levels of the recorded TCB info, hence, all checks
+	// preceding the TCB level selection (quote signature, QE report, PCK certificate chain, PCK CRL and TCB info
+	// signature) pass
+	_, err = v.verifyQuote(quote, collateral)
+	assert.EqualError(t, err, "TCB level not supported")
+
+	status, err := verifyQeIdentity(collateral, quote.QeReport, v.roots, v.now())
+	assert.NoError(t, err)
+	assert.Equal(t, TcbStatusUpToDate, status)
+
+	// collateral expired
+	v = newVerifier(WithCurrentTime(func() time.Time { return time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC) }))
+	_, err = v.verifyQuote(quote, collateral)
+	assert.ErrorContains(t, err, "PCK CRL expired")
+
+	// an SGX verifier does not accept TDX quotes
+	_, err = quote.EnclaveReport()
+	assert.EqualError(t, err, "not an SGX quote (tee type 0x81)")
+}
+
+func TestParseQuote(t *testing.T) {
+	platform := newTestPlatform(t)
+	raw := platform.quote(testMrenclave, make([]byte, 64))
+
+	quote, err := ParseQuote(raw)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(3), quote.Header.Version)
+	report, err := quote.EnclaveReport()
+	require.NoError(t, err)
+	assert.Equal(t, testMrenclave, encodeHex(report.MrEnclave))
+	assert.Equal(t, uint16(8), quote.QeReport.IsvSvn)
+
+	_, err = ParseQuote(raw[:100])
+	assert.ErrorContains(t, err, "quote too short")
+
+	_, err = ParseQuote(raw[:len(raw)-1])
+	assert.ErrorContains(t, err, "quote too short")
+
+	// truncated quotes without spare capacity, e.g., received from an untrusted client
+	for _, length := range []int{0, quoteHeaderLength, 58, quoteHeaderLength + sgxReportBodyLength + 2} {
+		_, err = ParseQuote(append([]byte{}, raw[:length]...))
+		assert.ErrorContains(t, err, "quote too short", "length %d", length)
+	}
+
+	modified := append([]byte{}, raw...)
+	modified[0] = 2
+	_, err = ParseQuote(modified)
+	assert.EqualError(t, err, "unsupported quote version 2")
+
+	modified = append([]byte{}, raw...)
+	modified[12] = 0
+	_, err = ParseQuote(modified)
+	assert.ErrorContains(t, err, "unknown QE vendor id")
+}
+
+func TestSGXQuoteClaims(t *testing.T) {
+	platform := newTestPlatform(t)
+	platform.tcbStatus = TcbStatusSWHardeningNeeded
+	verifier := NewDCAPVerifier(WithTrustedRoots(platform.roots()), WithCurrentTime(func() time.Time { return testNow }))
+	evidence := &types.Evidence{Type: DCAPType, Data: platform.evidence(testMrenclave, testStatement)}
+
+	claims, err := verifier.Claims(evidence)
+	require.NoError(t, err)
+	assert.Equal(t, &types.Claims{
+		Status:    TcbStatusSWHardeningNeeded,
+		Debug:     false,
+		IsvSvn:    0,
+		Mrsigner:  "0000000000000000000000000000000000000000000000000000000000000000",
+		Timestamp: testNow.Add(-24 * time.Hour),
+	}, claims)
+
+	_, err = verifier.Claims(&types.Evidence{Type: DCAPType, Data: "not json"})
+	assert.ErrorContains(t, err, "cannot unmarshal evidence")
+}
@@ -0,0 +1,104 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package epid
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/pkg/errors"
+)
+
+// iasTimestampFormat is the format of the timestamp in the IAS report (UTC, without time zone)
+const iasTimestampFormat = "2006-01-02T15:04:05.999999"
+
+// offsets within ISVEnclaveQuoteBody (sgx_quote_t without signature); the report body (sgx_report_body_t) follows
+// the 48 byte quote header
+const (
+	quoteReportOffset      = 48
+	reportAttributesOffset = quoteReportOffset + 48
+	reportMrEnclaveOffset  = quoteReportOffset + 64
+	reportMrSignerOffset   = quoteReportOffset + 128
+	reportIsvSvnOffset     = quoteReportOffset + 258
+	reportDataOffset       = quoteReportOffset + 320
+	quoteBodyLength        = quoteReportOffset + 384
+	attributeDebug         = 0x02
+)
+
+// Claims returns the claims of an EPID attestation report for the appraisal policy.
+// Note that Claims does not verify the report signature; it must only be used with evidence that passed verification.
+func Claims(evidence *types.Evidence) (*types.Claims, error) {
+	body, err := decodeReport(evidence)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := time.Parse(iasTimestampFormat, body.Timestamp)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid IAS report timestamp")
+	}
+
+	quoteBody, err := decodeQuoteBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Claims{
+		Status:      body.IsvEnclaveQuoteStatus,
+		AdvisoryIDs: body.AdvisoryIDs,
+		Debug:       quoteBody[reportAttributesOffset]&attributeDebug != 0,
+		IsvSvn:      binary.LittleEndian.Uint16(quoteBody[reportIsvSvnOffset:]),
+		Mrsigner:    hex.EncodeToString(quoteBody[reportMrSignerOffset : reportMrSignerOffset+32]),
+		Timestamp:   timestamp,
+	}, nil
+}
+
+// EnclaveReport returns the mrenclave and report data of the quote in an EPID attestation report.
+// As Claims, EnclaveReport does not verify the report signature.
+func EnclaveReport(evidence *types.Evidence) (mrenclave, reportData []byte, err error) {
+	body, err := decodeReport(evidence)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	quoteBody, err := decodeQuoteBody(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return quoteBody[reportMrEnclaveOffset : reportMrEnclaveOffset+32], quoteBody[reportDataOffset : reportDataOffset+64], nil
+}
+
+// decodeReport returns the body of the IAS report
+func decodeReport(evidence *types.Evidence) (*IASResponseBody, error) {
+	report := &IASReport{}
+	if err := json.Unmarshal([]byte(evidence.Data), report); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal IAS report")
+	}
+
+	body := &IASResponseBody{}
+	if err := json.Unmarshal([]byte(report.Body), body); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal IAS report body")
+	}
+	return body, nil
+}
+
+// decodeQuoteBody returns the enclave quote body of the IAS report body
+func decodeQuoteBody(body *IASResponseBody) ([]byte, error) {
+	quoteBody, err := base64.StdEncoding.DecodeString(body.IsvEnclaveQuoteBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode quote body")
+	}
+	if len(quoteBody) < quoteBodyLength {
+		return nil, fmt.Errorf("quote body too short (%d bytes)", len(quoteBody))
+	}
+	return quoteBody, nil
+}
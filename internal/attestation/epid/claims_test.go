@@ -0,0 +1,72 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package epid
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func iasEvidence(t *testing.T, body *IASResponseBody) *types.Evidence {
+	bodyJson, err := json.Marshal(body)
+	require.NoError(t, err)
+	reportJson, err := json.Marshal(&IASReport{Signature: "signature", Certificates: "certs", Body: string(bodyJson)})
+	require.NoError(t, err)
+	return &types.Evidence{Type: LinkableType, Data: string(reportJson)}
+}
+
+func TestClaims(t *testing.T) {
+	quoteBody := make([]byte, 432)
+	quoteBody[reportAttributesOffset] = 0x07
+	quoteBody[reportMrSignerOffset] = 0xab
+	binary.LittleEndian.PutUint16(quoteBody[reportIsvSvnOffset:], 3)
+
+	evidence := iasEvidence(t, &IASResponseBody{
+		Timestamp:             "2021-03-04T05:06:07.123456",
+		IsvEnclaveQuoteStatus: "GROUP_OUT_OF_DATE",
+		IsvEnclaveQuoteBody:   base64.StdEncoding.EncodeToString(quoteBody),
+		AdvisoryIDs:           []string{"INTEL-SA-00334"},
+	})
+
+	claims, err := Claims(evidence)
+	require.NoError(t, err)
+	assert.Equal(t, "GROUP_OUT_OF_DATE", claims.Status)
+	assert.Equal(t, []string{"INTEL-SA-00334"}, claims.AdvisoryIDs)
+	assert.True(t, claims.Debug)
+	assert.Equal(t, uint16(3), claims.IsvSvn)
+	assert.Equal(t, "ab00000000000000000000000000000000000000000000000000000000000000", claims.Mrsigner)
+	assert.Equal(t, time.Date(2021, 3, 4, 5, 6, 7, 123456000, time.UTC), claims.Timestamp)
+
+	// malformed reports
+	_, err = Claims(&types.Evidence{Data: "not json"})
+	assert.ErrorContains(t, err, "cannot unmarshal IAS report")
+	_, err = Claims(iasEvidence(t, &IASResponseBody{Timestamp: "yesterday"}))
+	assert.ErrorContains(t, err, "invalid IAS report timestamp")
+	_, err = Claims(iasEvidence(t, &IASResponseBody{Timestamp: "2021-03-04T05:06:07.123456", IsvEnclaveQuoteBody: "AAAA"}))
+	assert.EqualError(t, err, "quote body too short (3 bytes)")
+}
+
+func TestEnclaveReport(t *testing.T) {
+	quoteBody := make([]byte, 432)
+	quoteBody[reportMrEnclaveOffset] = 0xcd
+	quoteBody[reportDataOffset] = 0xef
+
+	mrenclave, reportData, err := EnclaveReport(iasEvidence(t, &IASResponseBody{IsvEnclaveQuoteBody: base64.StdEncoding.EncodeToString(quoteBody)}))
+	require.NoError(t, err)
+	assert.Equal(t, append([]byte{0xcd}, make([]byte, 31)...), mrenclave)
+	assert.Equal(t, append([]byte{0xef}, make([]byte, 63)...), reportData)
+
+	_, _, err = EnclaveReport(iasEvidence(t, &IASResponseBody{IsvEnclaveQuoteBody: "AAAA"}))
+	assert.EqualError(t, err, "quote body too short (3 bytes)")
+}
@@ -8,6 +8,8 @@ SPDX-License-Identifier: Apache-2.0
 package epid
 
 import (
+	"os"
+
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
 	"github.com/pkg/errors"
 )
@@ -17,7 +19,8 @@ const (
 	LinkableType   = "epid-linkable"
 )
 
-// NewEpidUnlinkableConverter creates a new attestation converter for Intel SGX EPID (unlinkable) attestation
+// NewEpidUnlinkableConverter creates a new attestation converter for Intel SGX EPID (unlinkable) attestation.
+// The converter requests the attestation report from the IAS at $IAS_URL or, if not set, from DefaultIASUrl.
 func NewEpidUnlinkableConverter() *types.Converter {
 	return &types.Converter{
 		Type:      UnlinkableType,
@@ -25,7 +28,8 @@ func NewEpidUnlinkableConverter() *types.Converter {
 	}
 }
 
-// NewEpidLinkableConverter creates a new attestation converter for Intel SGX EPID (linkable) attestation
+// NewEpidLinkableConverter creates a new attestation converter for Intel SGX EPID (linkable) attestation.
+// The converter requests the attestation report from the IAS at $IAS_URL or, if not set, from DefaultIASUrl.
 func NewEpidLinkableConverter() *types.Converter {
 	return &types.Converter{
 		Type:      LinkableType,
@@ -41,7 +45,12 @@ func newEpidConverter() types.ConvertFunction {
 			return nil, errors.Wrap(err, "cannot load IAS API key")
 		}
 
-		ias := NewIASClient(apiKey)
+		var opts []IASClientOption
+		if iasUrl := os.Getenv("IAS_URL"); len(iasUrl) != 0 {
+			opts = append(opts, WithUrl(iasUrl))
+		}
+
+		ias := NewIASClient(apiKey, opts...)
 		evidence, err := ias.RequestAttestationReport(string(attestationBytes))
 		if err != nil {
 			return nil, errors.Wrap(err, "cannot convert epid attestation")
@@ -74,6 +74,7 @@ func NewEpidLinkableVerifier() *types.Verifier {
 	return &types.Verifier{
 		Type:   epid.LinkableType,
 		Verify: Verify,
+		Claims: epid.Claims,
 	}
 }
 
@@ -81,5 +82,6 @@ func NewEpidUnlinkableVerifier() *types.Verifier {
 	return &types.Verifier{
 		Type:   epid.UnlinkableType,
 		Verify: Verify,
+		Claims: epid.Claims,
 	}
 }
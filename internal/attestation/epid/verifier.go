@@ -0,0 +1,225 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package epid
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/pkg/errors"
+)
+
+// DefaultAcceptedQuoteStatuses are the quote statuses accepted by default, the same as accepted by the PDO-based
+// verifier (see common/crypto/attestation-api/evidence/verify-evidence.cpp)
+var DefaultAcceptedQuoteStatuses = []string{
+	"OK",
+	"GROUP_OUT_OF_DATE",
+	"CONFIGURATION_NEEDED",
+	"SW_HARDENING_NEEDED",
+	"CONFIGURATION_AND_SW_HARDENING_NEEDED",
+}
+
+type verifier struct {
+	roots            *x509.CertPool
+	now              func() time.Time
+	acceptedStatuses []string
+}
+
+type VerifierOption func(*verifier)
+
+// WithRootCertificates option sets the trust anchor for IAS reports, that is, the Intel SGX Attestation Report
+// Signing CA certificate or the root certificate of a mock attestation service
+func WithRootCertificates(roots *x509.CertPool) VerifierOption {
+	return func(v *verifier) {
+		v.roots = roots
+	}
+}
+
+// WithCurrentTime option allows to override the time used to check the validity of the report signing certificate
+func WithCurrentTime(now func() time.Time) VerifierOption {
+	return func(v *verifier) {
+		v.now = now
+	}
+}
+
+// WithAcceptedQuoteStatuses option allows to override the accepted quote statuses (DefaultAcceptedQuoteStatuses)
+func WithAcceptedQuoteStatuses(statuses ...string) VerifierOption {
+	return func(v *verifier) {
+		v.acceptedStatuses = statuses
+	}
+}
+
+// NewEpidLinkableVerifier creates a new attestation verifier for Intel SGX EPID (linkable) attestation.
+// In contrast to pdo.NewEpidLinkableVerifier, the IAS report is verified in Go against the root certificates
+// given by WithRootCertificates.
+func NewEpidLinkableVerifier(opts ...VerifierOption) *types.Verifier {
+	return newEpidVerifier(LinkableType, opts...)
+}
+
+// NewEpidUnlinkableVerifier creates a new attestation verifier for Intel SGX EPID (unlinkable) attestation.
+// In contrast to pdo.NewEpidUnlinkableVerifier, the IAS report is verified in Go against the root certificates
+// given by WithRootCertificates.
+func NewEpidUnlinkableVerifier(opts ...VerifierOption) *types.Verifier {
+	return newEpidVerifier(UnlinkableType, opts...)
+}
+
+func newEpidVerifier(attestationType string, opts ...VerifierOption) *types.Verifier {
+	v := &verifier{
+		now:              time.Now,
+		acceptedStatuses: DefaultAcceptedQuoteStatuses,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return &types.Verifier{
+		Type:   attestationType,
+		Verify: v.verify,
+		Claims: Claims,
+	}
+}
+
+func (v *verifier) verify(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) error {
+	report := &IASReport{}
+	if err := json.Unmarshal([]byte(evidence.Data), report); err != nil {
+		return errors.Wrap(err, "cannot unmarshal IAS report")
+	}
+
+	if err := v.verifyReportSignature(report); err != nil {
+		return err
+	}
+
+	body := &IASResponseBody{}
+	if err := json.Unmarshal([]byte(report.Body), body); err != nil {
+		return errors.Wrap(err, "cannot unmarshal IAS report body")
+	}
+
+	if !contains(v.acceptedStatuses, body.IsvEnclaveQuoteStatus) {
+		return fmt.Errorf("quote status '%s' not accepted", body.IsvEnclaveQuoteStatus)
+	}
+
+	quoteBody, err := base64.StdEncoding.DecodeString(body.IsvEnclaveQuoteBody)
+	if err != nil {
+		return errors.Wrap(err, "cannot decode quote body")
+	}
+	if len(quoteBody) != quoteBodyLength {
+		return fmt.Errorf("unexpected quote body size %d", len(quoteBody))
+	}
+
+	mrenclave := quoteBody[reportMrEnclaveOffset : reportMrEnclaveOffset+32]
+	if hex.EncodeToString(mrenclave) != expectedValidationValues.Mrenclave {
+		return fmt.Errorf("mrenclave does not match: expected %s but got %x", expectedValidationValues.Mrenclave, mrenclave)
+	}
+
+	// the report data is SHA256(statement) || 32 zero bytes
+	reportData := quoteBody[reportDataOffset:quoteBodyLength]
+	expectedStatementHash := sha256.Sum256(expectedValidationValues.Statement)
+	if !bytes.Equal(reportData[:sha256.Size], expectedStatementHash[:]) || !bytes.Equal(reportData[sha256.Size:], make([]byte, sha256.Size)) {
+		return fmt.Errorf("report data does not match statement")
+	}
+
+	return nil
+}
+
+// verifyReportSignature checks that the IAS report body is signed by a report signing certificate issued by one
+// of the trusted roots
+func (v *verifier) verifyReportSignature(report *IASReport) error {
+	if v.roots == nil {
+		return fmt.Errorf("no IAS root certificates configured")
+	}
+
+	certs, err := parseReportCertificates(report.Certificates)
+	if err != nil {
+		return errors.Wrap(err, "invalid IAS certificates")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+	if _, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		CurrentTime:   v.now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return errors.Wrap(err, "invalid IAS report signing certificate")
+	}
+
+	publicKey, ok := certs[0].PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("IAS report signing key is not an RSA key")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(report.Signature)
+	if err != nil {
+		return errors.Wrap(err, "cannot decode IAS report signature")
+	}
+
+	digest := sha256.Sum256([]byte(report.Body))
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature); err != nil {
+		return fmt.Errorf("invalid IAS report signature")
+	}
+
+	return nil
+}
+
+// parseReportCertificates parses the (url-encoded) PEM certificate chain of the X-IASReport-Signing-Certificate
+// header; the first certificate is the report signing certificate
+func parseReportCertificates(chain string) ([]*x509.Certificate, error) {
+	if strings.Contains(chain, "%") {
+		unescaped, err := url.PathUnescape(chain)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot decode certificates")
+		}
+		chain = unescaped
+	}
+
+	var certs []*x509.Certificate
+	rest := []byte(chain)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse certificate")
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+
+	return certs, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/tsm"
+)
+
+type ReportProvider struct {
+	GetReportStub        func([]byte) (*tsm.Report, error)
+	getReportMutex       sync.RWMutex
+	getReportArgsForCall []struct {
+		arg1 []byte
+	}
+	getReportReturns struct {
+		result1 *tsm.Report
+		result2 error
+	}
+	getReportReturnsOnCall map[int]struct {
+		result1 *tsm.Report
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *ReportProvider) GetReport(arg1 []byte) (*tsm.Report, error) {
+	var arg1Copy []byte
+	if arg1 != nil {
+		arg1Copy = make([]byte, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.getReportMutex.Lock()
+	ret, specificReturn := fake.getReportReturnsOnCall[len(fake.getReportArgsForCall)]
+	fake.getReportArgsForCall = append(fake.getReportArgsForCall, struct {
+		arg1 []byte
+	}{arg1Copy})
+	stub := fake.GetReportStub
+	fakeReturns := fake.getReportReturns
+	fake.recordInvocation("GetReport", []interface{}{arg1Copy})
+	fake.getReportMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *ReportProvider) GetReportCallCount() int {
+	fake.getReportMutex.RLock()
+	defer fake.getReportMutex.RUnlock()
+	return len(fake.getReportArgsForCall)
+}
+
+func (fake *ReportProvider) GetReportCalls(stub func([]byte) (*tsm.Report, error)) {
+	fake.getReportMutex.Lock()
+	defer fake.getReportMutex.Unlock()
+	fake.GetReportStub = stub
+}
+
+func (fake *ReportProvider) GetReportArgsForCall(i int) []byte {
+	fake.getReportMutex.RLock()
+	defer fake.getReportMutex.RUnlock()
+	argsForCall := fake.getReportArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *ReportProvider) GetReportReturns(result1 *tsm.Report, result2 error) {
+	fake.getReportMutex.Lock()
+	defer fake.getReportMutex.Unlock()
+	fake.GetReportStub = nil
+	fake.getReportReturns = struct {
+		result1 *tsm.Report
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReportProvider) GetReportReturnsOnCall(i int, result1 *tsm.Report, result2 error) {
+	fake.getReportMutex.Lock()
+	defer fake.getReportMutex.Unlock()
+	fake.GetReportStub = nil
+	if fake.getReportReturnsOnCall == nil {
+		fake.getReportReturnsOnCall = make(map[int]struct {
+			result1 *tsm.Report
+			result2 error
+		})
+	}
+	fake.getReportReturnsOnCall[i] = struct {
+		result1 *tsm.Report
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReportProvider) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *ReportProvider) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ tsm.ReportProvider = new(ReportProvider)
@@ -0,0 +1,277 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/dcap"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/epid"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/sevsnp"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/tsm"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/pkg/errors"
+)
+
+// Field is a named field of a decoded attestation report
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Report holds the fields of an attestation report as decoded, but not verified, by InspectCredentials
+type Report struct {
+	// Mrenclave is the hex-encoded mrenclave (or measurement) of the attested enclave
+	Mrenclave string
+	// ReportData is the data bound by the report, i.e., SHA256(attested data) || 32 zero bytes
+	ReportData []byte
+	// Fields are further type-specific fields in display order
+	Fields []Field
+}
+
+// Step is a single step of the credentials inspection; Err is nil if the step passed
+type Step struct {
+	Name string
+	Err  error
+}
+
+// Inspection is the result of InspectCredentials
+type Inspection struct {
+	AttestedData    *protos.AttestedData
+	AttestationType string
+	EvidenceType    string
+	Report          *Report
+	Steps           []Step
+}
+
+// Passed returns true if all steps of the inspection passed
+func (i *Inspection) Passed() bool {
+	for _, s := range i.Steps {
+		if s.Err != nil {
+			return false
+		}
+	}
+	return len(i.Steps) > 0
+}
+
+func (i *Inspection) step(name string, err error) bool {
+	i.Steps = append(i.Steps, Step{Name: name, Err: err})
+	return err == nil
+}
+
+// InspectCredentials decodes the credentials and verifies them step by step against the expected mrenclave to
+// diagnose failed enclave registrations. The last step is the verification of the evidence by VerifyCredentials,
+// i.e., the check performed by ERCC (without appraisal policy); the preceding steps narrow down the cause of a
+// failed verification.
+func (c *CredentialVerifier) InspectCredentials(credentials *protos.Credentials, expectedMrenclave string) *Inspection {
+	inspection := &Inspection{}
+
+	attestedData, err := utils.UnmarshalAttestedData(credentials.GetSerializedAttestedData())
+	if inspection.step("decode attested data", err) {
+		inspection.AttestedData = attestedData
+	}
+
+	if att, err := unmarshalAttestation(credentials.GetAttestation()); err == nil {
+		inspection.AttestationType = att.Type
+	}
+
+	if len(credentials.GetEvidence()) == 0 {
+		inspection.step("decode evidence", fmt.Errorf("credentials contain no evidence; the attestation must be converted first"))
+		return inspection
+	}
+	evidence, err := unmarshalEvidence(credentials.GetEvidence())
+	if !inspection.step("decode evidence", err) {
+		return inspection
+	}
+	inspection.EvidenceType = evidence.Type
+
+	if _, ok := c.dispatcher.verifiers[evidence.Type]; !inspection.step("verifier available", verifierError(ok, evidence.Type)) {
+		return inspection
+	}
+
+	report, err := decodeReport(evidence)
+	if inspection.step("decode report", err) {
+		inspection.Report = report
+
+		var mrenclaveErr error
+		if !strings.EqualFold(report.Mrenclave, expectedMrenclave) {
+			mrenclaveErr = fmt.Errorf("expected %s but got %s", expectedMrenclave, report.Mrenclave)
+		}
+		inspection.step("mrenclave matches", mrenclaveErr)
+
+		var reportDataErr error
+		if expected := tsm.ReportData(credentials.GetSerializedAttestedData().GetValue()); !bytes.Equal(report.ReportData, expected) {
+			reportDataErr = fmt.Errorf("expected %x but got %x", expected, report.ReportData)
+		}
+		inspection.step("report data binds attested data", reportDataErr)
+	}
+
+	inspection.step("verify evidence", c.VerifyCredentials(credentials, expectedMrenclave))
+	return inspection
+}
+
+func verifierError(ok bool, evidenceType string) error {
+	if ok {
+		return nil
+	}
+	return fmt.Errorf("'%s' type is not registered", evidenceType)
+}
+
+// decodeReport decodes the attestation report of the evidence without verifying it
+func decodeReport(evidence *types.Evidence) (*Report, error) {
+	switch evidence.Type {
+	case simulation.SimulationType:
+		return decodeSimulationReport(evidence)
+	case epid.LinkableType, epid.UnlinkableType:
+		return decodeEpidReport(evidence)
+	case dcap.DCAPType, dcap.TDXType:
+		return decodeDCAPReport(evidence)
+	case sevsnp.SEVSNPType:
+		return decodeSEVSNPReport(evidence)
+	default:
+		return nil, fmt.Errorf("cannot decode reports of type '%s'", evidence.Type)
+	}
+}
+
+func decodeSimulationReport(evidence *types.Evidence) (*Report, error) {
+	signedQuoteBytes, err := base64.StdEncoding.DecodeString(evidence.Data)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode simulated quote")
+	}
+	signedQuote := &simulation.SignedQuote{}
+	if err := json.Unmarshal(signedQuoteBytes, signedQuote); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal signed simulated quote")
+	}
+	quote := &simulation.Quote{}
+	if err := json.Unmarshal(signedQuote.Quote, quote); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal simulated quote")
+	}
+
+	return &Report{
+		Mrenclave:  quote.Mrenclave,
+		ReportData: quote.ReportData,
+		Fields: []Field{
+			{"version", fmt.Sprint(quote.Version)},
+			{"issued at", quote.IssuedAt.String()},
+		},
+	}, nil
+}
+
+func decodeEpidReport(evidence *types.Evidence) (*Report, error) {
+	mrenclave, reportData, err := epid.EnclaveReport(evidence)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := epid.Claims(evidence)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Report{
+		Mrenclave:  hex.EncodeToString(mrenclave),
+		ReportData: reportData,
+		Fields:     claimsFields(claims),
+	}, nil
+}
+
+func decodeDCAPReport(evidence *types.Evidence) (*Report, error) {
+	qc := &dcap.QuoteWithCollateral{}
+	if err := json.Unmarshal([]byte(evidence.Data), qc); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal evidence")
+	}
+	rawQuote, err := base64.StdEncoding.DecodeString(qc.Quote)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode quote")
+	}
+	quote, err := dcap.ParseQuote(rawQuote)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse quote")
+	}
+
+	fields := []Field{
+		{"quote version", fmt.Sprint(quote.Header.Version)},
+		{"tee type", fmt.Sprintf("0x%x", quote.Header.TeeType)},
+		{"collateral", fmt.Sprint(qc.Collateral != nil)},
+	}
+
+	if quote.Header.TeeType == dcap.TeeTypeTDX {
+		report, err := quote.TDReport()
+		if err != nil {
+			return nil, err
+		}
+		return &Report{
+			Mrenclave:  hex.EncodeToString(report.MrTd),
+			ReportData: report.ReportData,
+			Fields: append(fields,
+				Field{"tee tcb svn", hex.EncodeToString(report.TeeTcbSvn)},
+				Field{"td attributes", hex.EncodeToString(report.TdAttributes)},
+				Field{"mrconfigid", hex.EncodeToString(report.MrConfigID)},
+				Field{"mrowner", hex.EncodeToString(report.MrOwner)},
+			),
+		}, nil
+	}
+
+	report, err := quote.EnclaveReport()
+	if err != nil {
+		return nil, err
+	}
+	return &Report{
+		Mrenclave:  hex.EncodeToString(report.MrEnclave),
+		ReportData: report.ReportData,
+		Fields: append(fields,
+			Field{"mrsigner", hex.EncodeToString(report.MrSigner)},
+			Field{"isv prod id", fmt.Sprint(report.IsvProdID)},
+			Field{"isv svn", fmt.Sprint(report.IsvSvn)},
+			Field{"attributes", hex.EncodeToString(report.Attributes)},
+		),
+	}, nil
+}
+
+func decodeSEVSNPReport(evidence *types.Evidence) (*Report, error) {
+	rv := &sevsnp.ReportWithVCEK{}
+	if err := json.Unmarshal([]byte(evidence.Data), rv); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal evidence")
+	}
+	report, err := sevsnp.ParseReport(rv.Report)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse report")
+	}
+
+	return &Report{
+		Mrenclave:  hex.EncodeToString(report.Measurement),
+		ReportData: report.ReportData,
+		Fields: []Field{
+			{"report version", fmt.Sprint(report.Version)},
+			{"guest svn", fmt.Sprint(report.GuestSvn)},
+			{"policy", fmt.Sprintf("0x%x", report.Policy)},
+			{"debug", fmt.Sprint(report.Debug())},
+			{"vmpl", fmt.Sprint(report.Vmpl)},
+			{"reported tcb", fmt.Sprintf("%+v", report.ReportedTcb)},
+			{"chip id", hex.EncodeToString(report.ChipID)},
+			{"vcek", fmt.Sprint(len(rv.VCEK) > 0)},
+		},
+	}, nil
+}
+
+func claimsFields(claims *types.Claims) []Field {
+	return []Field{
+		{"status", claims.Status},
+		{"advisory ids", strings.Join(claims.AdvisoryIDs, ", ")},
+		{"debug", fmt.Sprint(claims.Debug)},
+		{"isv svn", fmt.Sprint(claims.IsvSvn)},
+		{"mrsigner", claims.Mrsigner},
+		{"timestamp", claims.Timestamp.String()},
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// simulatedCredentials returns converted credentials with a simulated attestation for the given mrenclave
+func simulatedCredentials(t *testing.T, mrenclave string) *protos.Credentials {
+	attestedData, err := anypb.New(&protos.AttestedData{
+		CcParams:  &protos.CCParameters{ChaincodeId: "some-chaincode", Version: mrenclave},
+		EnclaveVk: []byte("enclave vk"),
+	})
+	require.NoError(t, err)
+
+	att, err := simulation.NewSimulationIssuer(simulation.WithMrenclave(mrenclave)).Issue(attestedData.Value)
+	require.NoError(t, err)
+
+	credentials := &protos.Credentials{SerializedAttestedData: attestedData, Attestation: att}
+	credentials, err = NewCredentialConverter(simulation.NewSimulationConverter()).convertCredentials(credentials)
+	require.NoError(t, err)
+	return credentials
+}
+
+func stepNames(inspection *Inspection) []string {
+	var names []string
+	for _, s := range inspection.Steps {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestInspectCredentials(t *testing.T) {
+	verifier := NewCredentialVerifier(simulation.NewSimulationVerifier())
+	credentials := simulatedCredentials(t, "some-mrenclave")
+
+	inspection := verifier.InspectCredentials(credentials, "some-mrenclave")
+	assert.True(t, inspection.Passed())
+	assert.Equal(t, []string{"decode attested data", "decode evidence", "verifier available", "decode report",
+		"mrenclave matches", "report data binds attested data", "verify evidence"}, stepNames(inspection))
+	assert.Equal(t, "some-chaincode", inspection.AttestedData.GetCcParams().GetChaincodeId())
+	assert.Equal(t, simulation.SimulationType, inspection.AttestationType)
+	assert.Equal(t, simulation.SimulationType, inspection.EvidenceType)
+	assert.Equal(t, "some-mrenclave", inspection.Report.Mrenclave)
+
+	// wrong mrenclave
+	inspection = verifier.InspectCredentials(credentials, "other-mrenclave")
+	assert.False(t, inspection.Passed())
+	assert.EqualError(t, inspection.Steps[4].Err, "expected other-mrenclave but got some-mrenclave")
+	assert.NoError(t, inspection.Steps[5].Err)
+	assert.ErrorContains(t, inspection.Steps[6].Err, "mrenclave does not match")
+
+	// attested data replaced after attestation
+	tampered := simulatedCredentials(t, "some-mrenclave")
+	tampered.SerializedAttestedData = simulatedCredentials(t, "other-mrenclave").SerializedAttestedData
+	inspection = verifier.InspectCredentials(tampered, "some-mrenclave")
+	assert.NoError(t, inspection.Steps[4].Err)
+	assert.ErrorContains(t, inspection.Steps[5].Err, "expected")
+	assert.ErrorContains(t, inspection.Steps[6].Err, "report data does not match statement")
+
+	// attestation not converted
+	inspection = verifier.InspectCredentials(&protos.Credentials{SerializedAttestedData: credentials.SerializedAttestedData, Attestation: credentials.Attestation}, "some-mrenclave")
+	assert.False(t, inspection.Passed())
+	assert.Equal(t, []string{"decode attested data", "decode evidence"}, stepNames(inspection))
+	assert.ErrorContains(t, inspection.Steps[1].Err, "credentials contain no evidence")
+
+	// no verifier for the evidence type
+	evidence, err := json.Marshal(&types.Evidence{Type: "dcap", Data: "{}"})
+	require.NoError(t, err)
+	inspection = verifier.InspectCredentials(&protos.Credentials{SerializedAttestedData: credentials.SerializedAttestedData, Evidence: evidence}, "some-mrenclave")
+	assert.Equal(t, "dcap", inspection.EvidenceType)
+	assert.EqualError(t, inspection.Steps[2].Err, "'dcap' type is not registered")
+	assert.Len(t, inspection.Steps, 3)
+
+	// undecodable report
+	evidence, err = json.Marshal(&types.Evidence{Type: simulation.SimulationType, Data: "not base64"})
+	require.NoError(t, err)
+	inspection = verifier.InspectCredentials(&protos.Credentials{SerializedAttestedData: credentials.SerializedAttestedData, Evidence: evidence}, "some-mrenclave")
+	assert.Equal(t, []string{"decode attested data", "decode evidence", "verifier available", "decode report", "verify evidence"}, stepNames(inspection))
+	assert.ErrorContains(t, inspection.Steps[3].Err, "cannot decode simulated quote")
+	assert.Nil(t, inspection.Report)
+}
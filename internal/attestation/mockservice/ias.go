@@ -0,0 +1,137 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package mockservice
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/epid"
+	"github.com/pkg/errors"
+)
+
+const (
+	// epidQuoteBodyLength is the size of sgx_quote_t without signature_len and signature
+	epidQuoteBodyLength = 432
+	epidQuoteVersion    = 2
+	// offset of the report body (sgx_report_body_t) in sgx_quote_t
+	epidQuoteReportOffset = 48
+	iasTimestampFormat    = "2006-01-02T15:04:05.000000"
+	iasReportVersion      = 4
+)
+
+// iasReportBody is the attestation verification report as returned by IAS
+type iasReportBody struct {
+	ID                    string   `json:"id"`
+	Timestamp             string   `json:"timestamp"`
+	Version               int      `json:"version"`
+	IsvEnclaveQuoteStatus string   `json:"isvEnclaveQuoteStatus"`
+	IsvEnclaveQuoteBody   string   `json:"isvEnclaveQuoteBody"`
+	Nonce                 string   `json:"nonce,omitempty"`
+	AdvisoryURL           string   `json:"advisoryURL,omitempty"`
+	AdvisoryIDs           []string `json:"advisoryIDs,omitempty"`
+}
+
+// EPIDQuote returns a base64-encoded EPID quote for an enclave with the given mrenclave (hex) and report data,
+// as produced by the quoting enclave. The quote carries no EPID signature and is only accepted by the mock service.
+func (s *Service) EPIDQuote(mrenclave string, reportData []byte, linkable bool) (string, error) {
+	mr, err := hex.DecodeString(mrenclave)
+	if err != nil || len(mr) != 32 {
+		return "", fmt.Errorf("invalid mrenclave '%s'", mrenclave)
+	}
+	if len(reportData) > 64 {
+		return "", fmt.Errorf("report data too long")
+	}
+
+	quote := make([]byte, epidQuoteBodyLength+4)
+	binary.LittleEndian.PutUint16(quote[0:], epidQuoteVersion)
+	if linkable {
+		binary.LittleEndian.PutUint16(quote[2:], 1)
+	}
+	copy(quote[epidQuoteReportOffset+64:], mr)
+	copy(quote[epidQuoteReportOffset+320:], reportData)
+	// signature_len is zero
+
+	return base64.StdEncoding.EncodeToString(quote), nil
+}
+
+func (s *Service) handleReport(w http.ResponseWriter, r *http.Request) {
+	if len(r.Header.Get("Ocp-Apim-Subscription-Key")) == 0 {
+		http.Error(w, "missing subscription key", http.StatusUnauthorized)
+		return
+	}
+
+	request := &epid.IASRequest{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	quote, err := base64.StdEncoding.DecodeString(request.Quote)
+	if err != nil || len(quote) < epidQuoteBodyLength+4 ||
+		int(binary.LittleEndian.Uint32(quote[epidQuoteBodyLength:])) != len(quote)-epidQuoteBodyLength-4 {
+		http.Error(w, "invalid isvEnclaveQuote", http.StatusBadRequest)
+		return
+	}
+
+	body, signature, err := s.signedReport(quote[:epidQuoteBodyLength], request.Nonce)
+	if err != nil {
+		logger.Errorf("cannot create IAS report: %s", err)
+		http.Error(w, "cannot create report", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Request-ID", randomHex(16))
+	w.Header().Set("X-IASReport-Signature", signature)
+	w.Header().Set("X-IASReport-Signing-Certificate", urlEncodedChain(s.iasSigning, s.root))
+	_, _ = w.Write(body)
+}
+
+// signedReport returns the attestation verification report for the given quote body and its base64-encoded
+// signature
+func (s *Service) signedReport(quoteBody []byte, nonce string) ([]byte, string, error) {
+	report := &iasReportBody{
+		ID:                    randomHex(16),
+		Timestamp:             s.now().UTC().Format(iasTimestampFormat),
+		Version:               iasReportVersion,
+		IsvEnclaveQuoteStatus: s.quoteStatus,
+		IsvEnclaveQuoteBody:   base64.StdEncoding.EncodeToString(quoteBody),
+		Nonce:                 nonce,
+		AdvisoryIDs:           s.advisoryIDs,
+	}
+	if len(report.AdvisoryIDs) > 0 {
+		report.AdvisoryURL = "https://security-center.intel.com"
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "cannot marshal report")
+	}
+
+	digest := sha256.Sum256(body)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.iasSigningKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return nil, "", errors.Wrap(err, "cannot sign report")
+	}
+
+	return body, base64.StdEncoding.EncodeToString(signature), nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
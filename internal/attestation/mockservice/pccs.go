@@ -0,0 +1,263 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package mockservice
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	dcapQuoteVersion                  = 3
+	attestationKeyTypeECDSAP256       = 2
+	quoteHeaderLength                 = 48
+	sgxReportBodyLength               = 384
+	certificationDataTypePCKCertChain = 5
+	tcbComponentCount                 = 16
+	tcbComponentSvn                   = 3
+	pceSvn                            = 13
+	qeIsvProdID                       = 1
+	qeIsvSvn                          = 8
+	// collateralValidity is the validity period of the CRL, TCB info and QE identity served
+	collateralValidity = 30 * 24 * time.Hour
+)
+
+var intelQEVendorID = []byte{0x93, 0x9A, 0x72, 0x33, 0xF7, 0x9C, 0x4C, 0xA9, 0x94, 0x0A, 0x0D, 0xB3, 0x95, 0x7F, 0x06, 0x07}
+
+// qeMrSigner is the mrsigner of the mock quoting enclave
+var qeMrSigner = make([]byte, 32)
+
+// Intel SGX PCK certificate extensions
+var (
+	oidSGXExtensions = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1}
+	oidPPID          = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 1}
+	oidTCB           = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 2}
+	oidPCEID         = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 3}
+	oidFMSPC         = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 4}
+)
+
+const (
+	oidTCBPceSvnSuffix = 17
+	oidTCBCpuSvnSuffix = 18
+)
+
+type sgxExtensionEntry struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+// DCAPQuote returns a base64-encoded version 3 ECDSA quote for an enclave with the given mrenclave (hex) and report
+// data, signed by the quoting enclave of the mock platform
+func (s *Service) DCAPQuote(mrenclave string, reportData []byte) (string, error) {
+	mr, err := hex.DecodeString(mrenclave)
+	if err != nil || len(mr) != 32 {
+		return "", fmt.Errorf("invalid mrenclave '%s'", mrenclave)
+	}
+	if len(reportData) > 64 {
+		return "", fmt.Errorf("report data too long")
+	}
+
+	header := make([]byte, quoteHeaderLength)
+	binary.LittleEndian.PutUint16(header[0:], dcapQuoteVersion)
+	binary.LittleEndian.PutUint16(header[2:], attestationKeyTypeECDSAP256)
+	copy(header[12:], intelQEVendorID)
+
+	body := reportBody(mr, nil, 0, 0, reportData)
+
+	attestationKey := make([]byte, 64)
+	s.attestationKey.X.FillBytes(attestationKey[:32])
+	s.attestationKey.Y.FillBytes(attestationKey[32:])
+	authData := make([]byte, 32)
+	qeReportData := sha256.Sum256(append(append([]byte{}, attestationKey...), authData...))
+	qeReport := reportBody(nil, qeMrSigner, qeIsvProdID, qeIsvSvn, qeReportData[:])
+	qeReport[48] = 0x11 // attributes INIT and MODE64BIT
+
+	signedData := append(header, body...)
+	quoteSignature, err := sign(s.attestationKey, signedData)
+	if err != nil {
+		return "", err
+	}
+	qeReportSignature, err := sign(s.pckKey, qeReport)
+	if err != nil {
+		return "", err
+	}
+	chain := pemChain(s.pck, s.platformCA, s.root)
+
+	var sigData []byte
+	sigData = append(sigData, quoteSignature...)
+	sigData = append(sigData, attestationKey...)
+	sigData = append(sigData, qeReport...)
+	sigData = append(sigData, qeReportSignature...)
+	sigData = binary.LittleEndian.AppendUint16(sigData, uint16(len(authData)))
+	sigData = append(sigData, authData...)
+	sigData = binary.LittleEndian.AppendUint16(sigData, certificationDataTypePCKCertChain)
+	sigData = binary.LittleEndian.AppendUint32(sigData, uint32(len(chain)))
+	sigData = append(sigData, chain...)
+
+	quote := binary.LittleEndian.AppendUint32(signedData, uint32(len(sigData)))
+	return base64.StdEncoding.EncodeToString(append(quote, sigData...)), nil
+}
+
+func (s *Service) handlePckCrl(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("ca") != "platform" {
+		http.Error(w, "unknown ca", http.StatusNotFound)
+		return
+	}
+
+	now := s.now()
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: now,
+		NextUpdate: now.Add(collateralValidity),
+	}, s.platformCA, s.platformCAKey)
+	if err != nil {
+		logger.Errorf("cannot create PCK CRL: %s", err)
+		http.Error(w, "cannot create CRL", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("SGX-PCK-CRL-Issuer-Chain", urlEncodedChain(s.platformCA, s.root))
+	_, _ = w.Write(crl)
+}
+
+func (s *Service) handleTcbInfo(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("fmspc") != hex.EncodeToString(s.fmspc) {
+		http.Error(w, "unknown fmspc", http.StatusNotFound)
+		return
+	}
+
+	components := make([]map[string]int, tcbComponentCount)
+	for i := range components {
+		components[i] = map[string]int{"svn": tcbComponentSvn}
+	}
+
+	now := s.now().UTC()
+	tcbInfo := map[string]interface{}{
+		"id":                      "SGX",
+		"version":                 3,
+		"issueDate":               now,
+		"nextUpdate":              now.Add(collateralValidity),
+		"fmspc":                   hex.EncodeToString(s.fmspc),
+		"pceId":                   "0000",
+		"tcbType":                 0,
+		"tcbEvaluationDataNumber": 1,
+		"tcbLevels": []map[string]interface{}{
+			{"tcb": map[string]interface{}{"sgxtcbcomponents": components, "pcesvn": pceSvn}, "tcbDate": now, "tcbStatus": s.tcbStatus},
+		},
+	}
+
+	s.writeSignedBody(w, "TCB-Info-Issuer-Chain", "tcbInfo", tcbInfo)
+}
+
+func (s *Service) handleQeIdentity(w http.ResponseWriter, r *http.Request) {
+	now := s.now().UTC()
+	qeIdentity := map[string]interface{}{
+		"id":                      "QE",
+		"version":                 2,
+		"issueDate":               now,
+		"nextUpdate":              now.Add(collateralValidity),
+		"tcbEvaluationDataNumber": 1,
+		"miscselect":              "00000000",
+		"miscselectMask":          "FFFFFFFF",
+		"attributes":              "11000000000000000000000000000000",
+		"attributesMask":          "FBFFFFFFFFFFFFFF0000000000000000",
+		"mrsigner":                hex.EncodeToString(qeMrSigner),
+		"isvprodid":               qeIsvProdID,
+		"tcbLevels": []map[string]interface{}{
+			{"tcb": map[string]int{"isvsvn": qeIsvSvn}, "tcbDate": now, "tcbStatus": "UpToDate"},
+		},
+	}
+
+	s.writeSignedBody(w, "SGX-Enclave-Identity-Issuer-Chain", "enclaveIdentity", qeIdentity)
+}
+
+// writeSignedBody writes {"<field>": v, "signature": "<hex signature over v>"} signed by the TCB signing key
+func (s *Service) writeSignedBody(w http.ResponseWriter, issuerChainHeader, field string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, "cannot marshal response", http.StatusInternalServerError)
+		return
+	}
+	signature, err := sign(s.tcbSigningKey, data)
+	if err != nil {
+		logger.Errorf("cannot sign %s: %s", field, err)
+		http.Error(w, "cannot sign response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(issuerChainHeader, urlEncodedChain(s.tcbSigning, s.root))
+	_, _ = fmt.Fprintf(w, `{"%s":%s,"signature":"%s"}`, field, data, hex.EncodeToString(signature))
+}
+
+// sign returns the raw (r || s) ECDSA signature over SHA256(data), as used in quotes and collateral
+func sign(key *ecdsa.PrivateKey, data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot sign")
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signature, nil
+}
+
+func reportBody(mrenclave, mrsigner []byte, isvProdID, isvSvn uint16, reportData []byte) []byte {
+	body := make([]byte, sgxReportBodyLength)
+	copy(body[64:], mrenclave)
+	copy(body[128:], mrsigner)
+	binary.LittleEndian.PutUint16(body[256:], isvProdID)
+	binary.LittleEndian.PutUint16(body[258:], isvSvn)
+	copy(body[320:], reportData)
+	return body
+}
+
+// sgxExtension returns the Intel SGX extension of the PCK certificate of the mock platform
+func sgxExtension(fmspc []byte) (*pkix.Extension, error) {
+	marshal := func(v interface{}) asn1.RawValue {
+		b, _ := asn1.Marshal(v)
+		return asn1.RawValue{FullBytes: b}
+	}
+	tcbOID := func(suffix int) asn1.ObjectIdentifier {
+		return append(append(asn1.ObjectIdentifier{}, oidTCB...), suffix)
+	}
+
+	var tcb []sgxExtensionEntry
+	for i := 1; i <= tcbComponentCount; i++ {
+		tcb = append(tcb, sgxExtensionEntry{ID: tcbOID(i), Value: marshal(tcbComponentSvn)})
+	}
+	tcb = append(tcb,
+		sgxExtensionEntry{ID: tcbOID(oidTCBPceSvnSuffix), Value: marshal(pceSvn)},
+		sgxExtensionEntry{ID: tcbOID(oidTCBCpuSvnSuffix), Value: marshal(make([]byte, 16))},
+	)
+
+	value, err := asn1.Marshal([]sgxExtensionEntry{
+		{ID: oidPPID, Value: marshal(make([]byte, 16))},
+		{ID: oidTCB, Value: marshal(tcb)},
+		{ID: oidPCEID, Value: marshal([]byte{0x00, 0x00})},
+		{ID: oidFMSPC, Value: marshal(fmspc)},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal SGX extension")
+	}
+	return &pkix.Extension{Id: oidSGXExtensions, Value: value}, nil
+}
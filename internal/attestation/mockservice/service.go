@@ -0,0 +1,219 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package mockservice implements a mock attestation service with IAS- and PCCS-compatible endpoints for hermetic
+// attestation testing. The service acts as its own PKI: IAS reports and DCAP collateral are signed with keys
+// certified by a test root CA, which must be passed to the verifiers (epid.WithRootCertificates and
+// dcap.WithTrustedRoots) instead of the Intel root certificates.
+//
+// As the service does not verify EPID signatures, it accepts any well-formed EPID quote, e.g., those created with
+// Service.EPIDQuote. DCAP collateral is only served for the mock platform, that is, for quotes created with
+// Service.DCAPQuote.
+package mockservice
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/pkg/errors"
+)
+
+var logger = flogging.MustGetLogger("fpc.attestation.mockservice")
+
+const (
+	// IASPath is the path of the IAS report endpoint; use with epid.WithUrl or $IAS_URL
+	IASPath = "/sgx/dev/attestation/v4/report"
+	// PCCSPath is the base path of the PCCS endpoints; use with dcap.WithUrl or $PCCS_URL
+	PCCSPath = "/sgx/certification/v4"
+)
+
+// Service is a mock attestation service; it implements http.Handler and can be served with, e.g., httptest.NewServer
+type Service struct {
+	rootKey, platformCAKey, pckKey, tcbSigningKey, attestationKey *ecdsa.PrivateKey
+	iasSigningKey                                                 *rsa.PrivateKey
+	root, platformCA, pck, tcbSigning, iasSigning                 *x509.Certificate
+
+	fmspc       []byte
+	quoteStatus string
+	advisoryIDs []string
+	tcbStatus   string
+	now         func() time.Time
+
+	mux *http.ServeMux
+}
+
+type Option func(*Service)
+
+// WithQuoteStatus option sets the quote status and advisory IDs reported by the IAS endpoint (default `OK`)
+func WithQuoteStatus(status string, advisoryIDs ...string) Option {
+	return func(s *Service) {
+		s.quoteStatus = status
+		s.advisoryIDs = advisoryIDs
+	}
+}
+
+// WithTcbStatus option sets the status of the TCB level of the mock platform served by the PCCS endpoints
+// (default `UpToDate`)
+func WithTcbStatus(status string) Option {
+	return func(s *Service) {
+		s.tcbStatus = status
+	}
+}
+
+// WithCurrentTime option allows to override the time used for report timestamps and collateral issue dates
+func WithCurrentTime(now func() time.Time) Option {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+// New creates a new mock attestation service with a freshly generated test PKI
+func New(opts ...Option) (*Service, error) {
+	s := &Service{
+		fmspc:       []byte{0x00, 0x90, 0x6e, 0xa1, 0x00, 0x00},
+		quoteStatus: "OK",
+		tcbStatus:   "UpToDate",
+		now:         time.Now,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if err := s.createPKI(); err != nil {
+		return nil, errors.Wrap(err, "cannot create test PKI")
+	}
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("POST "+IASPath, s.handleReport)
+	s.mux.HandleFunc("GET "+PCCSPath+"/pckcrl", s.handlePckCrl)
+	s.mux.HandleFunc("GET "+PCCSPath+"/tcb", s.handleTcbInfo)
+	s.mux.HandleFunc("GET "+PCCSPath+"/qe/identity", s.handleQeIdentity)
+
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler
+func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger.Debugf("%s %s", r.Method, r.URL)
+	s.mux.ServeHTTP(w, r)
+}
+
+// RootCertificate returns the test root CA certificate
+func (s *Service) RootCertificate() *x509.Certificate {
+	return s.root
+}
+
+// RootCertificatePEM returns the PEM-encoded test root CA certificate
+func (s *Service) RootCertificatePEM() []byte {
+	return pemChain(s.root)
+}
+
+// Roots returns a certificate pool containing the test root CA certificate
+func (s *Service) Roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(s.root)
+	return pool
+}
+
+func (s *Service) createPKI() error {
+	var err error
+
+	if s.rootKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		return err
+	}
+	if s.root, err = s.newCert(1, "Mock Attestation Service Root CA", true, &s.rootKey.PublicKey, nil, s.rootKey, nil); err != nil {
+		return err
+	}
+
+	// DCAP: PCK platform CA, PCK certificate of the mock platform and TCB signing certificate
+	if s.platformCAKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		return err
+	}
+	if s.platformCA, err = s.newCert(2, "Intel SGX PCK Platform CA", true, &s.platformCAKey.PublicKey, s.root, s.rootKey, nil); err != nil {
+		return err
+	}
+	if s.pckKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		return err
+	}
+	ext, err := sgxExtension(s.fmspc)
+	if err != nil {
+		return err
+	}
+	if s.pck, err = s.newCert(3, "Intel SGX PCK Certificate", false, &s.pckKey.PublicKey, s.platformCA, s.platformCAKey, ext); err != nil {
+		return err
+	}
+	if s.tcbSigningKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		return err
+	}
+	if s.tcbSigning, err = s.newCert(4, "Mock SGX TCB Signing", false, &s.tcbSigningKey.PublicKey, s.root, s.rootKey, nil); err != nil {
+		return err
+	}
+	if s.attestationKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		return err
+	}
+
+	// EPID: IAS report signing certificate
+	if s.iasSigningKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		return err
+	}
+	s.iasSigning, err = s.newCert(5, "Mock Attestation Report Signing", false, &s.iasSigningKey.PublicKey, s.root, s.rootKey, nil)
+	return err
+}
+
+func (s *Service) newCert(serial int64, cn string, isCA bool, pub interface{}, parent *x509.Certificate, parentKey interface{}, ext *pkix.Extension) (*x509.Certificate, error) {
+	now := s.now()
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: cn, Organization: []string{"Hyperledger FPC Mock Attestation Service"}},
+		NotBefore:             now.Add(-24 * time.Hour),
+		NotAfter:              now.Add(10 * 365 * 24 * time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	}
+	if ext != nil {
+		template.ExtraExtensions = []pkix.Extension{*ext}
+	}
+
+	if parent == nil {
+		// self-signed
+		parent = template
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, parentKey)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+func pemChain(certs ...*x509.Certificate) []byte {
+	var chain []byte
+	for _, c := range certs {
+		chain = append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+	}
+	return chain
+}
+
+// urlEncodedChain returns the certificate chain url-encoded as in the IAS and PCS response headers
+func urlEncodedChain(certs ...*x509.Certificate) string {
+	return url.PathEscape(string(pemChain(certs...)))
+}
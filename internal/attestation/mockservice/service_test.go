@@ -0,0 +1,147 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package mockservice
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/dcap"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/epid"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMrenclave = "98aed61c91f258a37c68ed4943297695647ec7bbe6008cc111b0a12650ebeb91"
+
+var testStatement = []byte("some statement")
+
+func reportData(statement []byte) []byte {
+	hash := sha256.Sum256(statement)
+	return append(hash[:], make([]byte, sha256.Size)...)
+}
+
+func startService(t *testing.T, opts ...Option) (*Service, *httptest.Server) {
+	service, err := New(opts...)
+	require.NoError(t, err)
+	server := httptest.NewServer(service)
+	t.Cleanup(server.Close)
+	return service, server
+}
+
+func TestEPIDConversionAndVerification(t *testing.T) {
+	service, server := startService(t)
+	t.Setenv("IAS_URL", server.URL+IASPath)
+	t.Setenv("IAS_API_KEY", "some_key")
+
+	quote, err := service.EPIDQuote(testMrenclave, reportData(testStatement), true)
+	require.NoError(t, err)
+
+	evidence, err := epid.NewEpidLinkableConverter().Converter([]byte(quote))
+	require.NoError(t, err)
+
+	verifier := epid.NewEpidLinkableVerifier(epid.WithRootCertificates(service.Roots()))
+	expected := &types.ValidationValues{Statement: testStatement, Mrenclave: testMrenclave}
+	assert.NoError(t, verifier.Verify(&types.Evidence{Type: epid.LinkableType, Data: string(evidence)}, expected))
+
+	// claims of the report
+	claims, err := verifier.Claims(&types.Evidence{Type: epid.LinkableType, Data: string(evidence)})
+	require.NoError(t, err)
+	assert.Equal(t, "OK", claims.Status)
+
+	// wrong statement and mrenclave
+	err = verifier.Verify(&types.Evidence{Type: epid.LinkableType, Data: string(evidence)}, &types.ValidationValues{Statement: []byte("other"), Mrenclave: testMrenclave})
+	assert.EqualError(t, err, "report data does not match statement")
+	err = verifier.Verify(&types.Evidence{Type: epid.LinkableType, Data: string(evidence)}, &types.ValidationValues{Statement: testStatement, Mrenclave: "00" + testMrenclave[2:]})
+	assert.ErrorContains(t, err, "mrenclave does not match")
+
+	// reports of another mock service are not trusted
+	other, err := New()
+	require.NoError(t, err)
+	err = epid.NewEpidLinkableVerifier(epid.WithRootCertificates(other.Roots())).Verify(&types.Evidence{Type: epid.LinkableType, Data: string(evidence)}, expected)
+	assert.ErrorContains(t, err, "invalid IAS report signing certificate")
+
+	// no roots configured
+	err = epid.NewEpidLinkableVerifier().Verify(&types.Evidence{Type: epid.LinkableType, Data: string(evidence)}, expected)
+	assert.EqualError(t, err, "no IAS root certificates configured")
+}
+
+func TestEPIDQuoteStatus(t *testing.T) {
+	service, server := startService(t, WithQuoteStatus("GROUP_REVOKED", "INTEL-SA-00334"))
+	ias := epid.NewIASClient("some_key", epid.WithUrl(server.URL+IASPath))
+
+	quote, err := service.EPIDQuote(testMrenclave, reportData(testStatement), false)
+	require.NoError(t, err)
+	evidence, err := ias.RequestAttestationReport(quote)
+	require.NoError(t, err)
+
+	verifier := epid.NewEpidUnlinkableVerifier(epid.WithRootCertificates(service.Roots()))
+	err = verifier.Verify(&types.Evidence{Type: epid.UnlinkableType, Data: evidence}, &types.ValidationValues{Statement: testStatement, Mrenclave: testMrenclave})
+	assert.EqualError(t, err, "quote status 'GROUP_REVOKED' not accepted")
+
+	claims, err := verifier.Claims(&types.Evidence{Type: epid.UnlinkableType, Data: evidence})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"INTEL-SA-00334"}, claims.AdvisoryIDs)
+
+	// malformed quotes and missing API keys are rejected as by IAS
+	_, err = ias.RequestAttestationReport("AAAA")
+	assert.ErrorContains(t, err, "400")
+	_, err = epid.NewIASClient("", epid.WithUrl(server.URL+IASPath)).RequestAttestationReport(quote)
+	assert.ErrorContains(t, err, "401")
+}
+
+func TestDCAPConversionAndVerification(t *testing.T) {
+	service, server := startService(t)
+	t.Setenv("PCCS_URL", server.URL+PCCSPath)
+
+	quote, err := service.DCAPQuote(testMrenclave, reportData(testStatement))
+	require.NoError(t, err)
+
+	evidence, err := dcap.NewDCAPConverter().Converter([]byte(quote))
+	require.NoError(t, err)
+
+	verifier := dcap.NewDCAPVerifier(dcap.WithTrustedRoots(service.Roots()))
+	expected := &types.ValidationValues{Statement: testStatement, Mrenclave: testMrenclave}
+	assert.NoError(t, verifier.Verify(&types.Evidence{Type: dcap.DCAPType, Data: string(evidence)}, expected))
+
+	// the Intel SGX Root CA is the default trust anchor
+	err = dcap.NewDCAPVerifier().Verify(&types.Evidence{Type: dcap.DCAPType, Data: string(evidence)}, expected)
+	assert.ErrorContains(t, err, "invalid PCK certificate chain")
+
+	// unknown platform
+	resp, err := http.Get(server.URL + PCCSPath + "/tcb?fmspc=00606a000000")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestDCAPTcbStatus(t *testing.T) {
+	service, server := startService(t, WithTcbStatus(dcap.TcbStatusRevoked))
+	t.Setenv("PCCS_URL", server.URL+PCCSPath)
+
+	quote, err := service.DCAPQuote(testMrenclave, reportData(testStatement))
+	require.NoError(t, err)
+	evidence, err := dcap.NewDCAPConverter().Converter([]byte(quote))
+	require.NoError(t, err)
+
+	err = dcap.NewDCAPVerifier(dcap.WithTrustedRoots(service.Roots())).Verify(&types.Evidence{Type: dcap.DCAPType, Data: string(evidence)},
+		&types.ValidationValues{Statement: testStatement, Mrenclave: testMrenclave})
+	assert.ErrorContains(t, err, "platform TCB status 'Revoked' not accepted")
+}
+
+func TestRootCertificate(t *testing.T) {
+	service, err := New()
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(service.RootCertificatePEM()))
+	assert.True(t, service.RootCertificate().IsCA)
+}
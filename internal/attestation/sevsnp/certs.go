@@ -0,0 +1,175 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sevsnp
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// OIDs of the VCEK certificate extensions, see AMD Versioned Chip Endorsement Key (VCEK) Certificate and
+// KDS Interface Specification
+var (
+	oidBootLoaderSPL = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 3704, 1, 3, 1}
+	oidTEESPL        = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 3704, 1, 3, 2}
+	oidSNPSPL        = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 3704, 1, 3, 3}
+	oidMicrocodeSPL  = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 3704, 1, 3, 8}
+	oidHardwareID    = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 3704, 1, 4}
+)
+
+// vcekGUID identifies the VCEK in the certificate table returned by the SEV-SNP guest driver (GHCB specification)
+var vcekGUID = []byte{0x63, 0xda, 0x75, 0x8d, 0xe6, 0x64, 0x45, 0x64, 0xad, 0xc5, 0xf4, 0xb9, 0x3b, 0xe8, 0xac, 0xcd}
+
+// vcekExtensions holds the platform information encoded in the extensions of a VCEK certificate
+type vcekExtensions struct {
+	tcb        TCBVersion
+	hardwareID []byte
+}
+
+// LoadCertificates reads the PEM-encoded AMD certificates in the given file, e.g., the ASK and ARK certificate chain
+// returned by the AMD KDS (/vcek/v1/{product}/cert_chain)
+func LoadCertificates(path string) ([]*x509.Certificate, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read certificates")
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse certificate")
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return certs, nil
+}
+
+// verifyVCEK verifies the VCEK certificate against the AMD certificates and returns its public key and extensions
+func verifyVCEK(raw []byte, roots, intermediates *x509.CertPool, now time.Time) (*ecdsa.PublicKey, *vcekExtensions, error) {
+	vcek, err := x509.ParseCertificate(raw)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot parse VCEK certificate")
+	}
+
+	_, err = vcek.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot verify VCEK certificate chain")
+	}
+
+	pub, ok := vcek.PublicKey.(*ecdsa.PublicKey)
+	if !ok || pub.Curve != elliptic.P384() {
+		return nil, nil, fmt.Errorf("VCEK is not an ECDSA P-384 key")
+	}
+
+	ext, err := parseVCEKExtensions(vcek)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pub, ext, nil
+}
+
+func parseVCEKExtensions(cert *x509.Certificate) (*vcekExtensions, error) {
+	ext := &vcekExtensions{}
+	spls := map[string]*uint8{
+		oidBootLoaderSPL.String(): &ext.tcb.BootLoader,
+		oidTEESPL.String():        &ext.tcb.TEE,
+		oidSNPSPL.String():        &ext.tcb.SNP,
+		oidMicrocodeSPL.String():  &ext.tcb.Microcode,
+	}
+
+	found := 0
+	for _, e := range cert.Extensions {
+		if e.Id.Equal(oidHardwareID) {
+			// the hardware id is either a DER-encoded octet string or the raw chip id
+			if _, err := asn1.Unmarshal(e.Value, &ext.hardwareID); err != nil {
+				ext.hardwareID = e.Value
+			}
+			found++
+			continue
+		}
+
+		spl, ok := spls[e.Id.String()]
+		if !ok {
+			continue
+		}
+		var v int
+		if _, err := asn1.Unmarshal(e.Value, &v); err != nil || v < 0 || v > 255 {
+			return nil, fmt.Errorf("invalid VCEK extension %s", e.Id)
+		}
+		*spl = uint8(v)
+		found++
+	}
+
+	if found != len(spls)+1 {
+		return nil, fmt.Errorf("VCEK certificate is missing extensions")
+	}
+	return ext, nil
+}
+
+// checkPlatform checks that the report was signed by the VCEK of the reporting chip at the reported TCB
+func (ext *vcekExtensions) checkPlatform(report *Report) error {
+	if report.Flags&flagMaskChipID != 0 {
+		return fmt.Errorf("reports with masked chip id are not supported")
+	}
+	if !bytes.Equal(ext.hardwareID, report.ChipID) {
+		return fmt.Errorf("VCEK hardware id %x does not match chip id %x", ext.hardwareID, report.ChipID)
+	}
+	if ext.tcb != report.ReportedTcb {
+		return fmt.Errorf("VCEK TCB %+v does not match reported TCB %+v", ext.tcb, report.ReportedTcb)
+	}
+	return nil
+}
+
+// vcekFromCertTable returns the VCEK certificate of the certificate table provided by the SEV-SNP guest driver along
+// with the report. The table consists of entries (guid, offset, length) terminated by an all-zero entry; the offsets
+// refer to the start of the table.
+func vcekFromCertTable(table []byte) ([]byte, error) {
+	const entryLength = 24
+	for off := 0; off+entryLength <= len(table); off += entryLength {
+		guid := table[off : off+16]
+		if bytes.Equal(guid, make([]byte, 16)) {
+			break
+		}
+		if !bytes.Equal(guid, vcekGUID) {
+			continue
+		}
+
+		certOffset := int(binary.LittleEndian.Uint32(table[off+16:]))
+		certLength := int(binary.LittleEndian.Uint32(table[off+20:]))
+		if certOffset+certLength > len(table) {
+			return nil, fmt.Errorf("invalid certificate table entry")
+		}
+		return table[certOffset : certOffset+certLength], nil
+	}
+	return nil, fmt.Errorf("certificate table has no VCEK (guid %s)", hex.EncodeToString(vcekGUID))
+}
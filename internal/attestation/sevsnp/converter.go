@@ -0,0 +1,66 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sevsnp
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/pkg/errors"
+)
+
+const SEVSNPType = "sev-snp"
+
+// ReportWithVCEK is the attestation and evidence data of SEV-SNP attestation
+type ReportWithVCEK struct {
+	// Report is the raw attestation report
+	Report []byte `json:"report"`
+	// VCEK is the DER-encoded VCEK certificate of the platform; it is optional in attestations
+	VCEK []byte `json:"vcek,omitempty"`
+}
+
+// NewSEVSNPConverter creates a new attestation converter for AMD SEV-SNP attestation.
+// If the attestation does not include the VCEK certificate, the converter fetches it from the KDS at $AMD_KDS_URL or,
+// if not set, from DefaultKDSUrl, for the processor product $SEV_SNP_PRODUCT (DefaultProduct if not set).
+func NewSEVSNPConverter() *types.Converter {
+	var opts []KDSClientOption
+	if kdsUrl := os.Getenv("AMD_KDS_URL"); len(kdsUrl) != 0 {
+		opts = append(opts, WithUrl(kdsUrl))
+	}
+	if product := os.Getenv("SEV_SNP_PRODUCT"); len(product) != 0 {
+		opts = append(opts, WithProduct(product))
+	}
+
+	return &types.Converter{
+		Type:      SEVSNPType,
+		Converter: newSEVSNPConverter(NewKDSClient(opts...)),
+	}
+}
+
+func newSEVSNPConverter(kds *KDSClient) types.ConvertFunction {
+	return func(attestationBytes []byte) (evidenceBytes []byte, err error) {
+		rv := &ReportWithVCEK{}
+		if err := json.Unmarshal(attestationBytes, rv); err != nil {
+			return nil, errors.Wrap(err, "cannot unmarshal attestation")
+		}
+
+		if len(rv.VCEK) == 0 {
+			report, err := ParseReport(rv.Report)
+			if err != nil {
+				return nil, errors.Wrap(err, "cannot parse report")
+			}
+
+			rv.VCEK, err = kds.GetVCEK(report)
+			if err != nil {
+				return nil, errors.Wrap(err, "cannot get VCEK certificate")
+			}
+		}
+
+		return json.Marshal(rv)
+	}
+}
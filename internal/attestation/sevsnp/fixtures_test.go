@@ -0,0 +1,183 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sevsnp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/tsm"
+	"github.com/stretchr/testify/require"
+)
+
+// testNow is the time used to verify the test platform reports
+var testNow = time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+const testMeasurement = "6d3f1a9b2c4e8d7f0a1b3c5d7e9f2a4b6c8d0e1f3a5b7c9d2e4f6a8b0c1d3e5f7a9b2c4d6e8f0a1b3c5d7e9f2a4b6c8d"
+
+var testStatement = []byte("some statement")
+
+// testPlatform mimics an AMD SEV-SNP platform with the AMD PKI; the ARK replaces the AMD Root Key and must be passed
+// to the verifier using WithCertificates
+type testPlatform struct {
+	t *testing.T
+
+	arkKey, askKey *rsa.PrivateKey
+	vcekKey        *ecdsa.PrivateKey
+	ark, ask, vcek *x509.Certificate
+
+	chipID []byte
+	tcb    TCBVersion
+	// vcekTcb is the TCB encoded in the VCEK certificate; it equals tcb unless modified for testing
+	vcekTcb TCBVersion
+	policy  uint64
+	flags   uint32
+}
+
+func newTestPlatform(t *testing.T) *testPlatform {
+	p := &testPlatform{
+		t:      t,
+		chipID: make([]byte, 64),
+		tcb:    TCBVersion{BootLoader: 3, TEE: 0, SNP: 14, Microcode: 209},
+		policy: 0x30000,
+	}
+	_, err := rand.Read(p.chipID)
+	require.NoError(t, err)
+	p.vcekTcb = p.tcb
+
+	p.arkKey = p.newRSAKey()
+	p.ark = p.newCert(1, "ARK-Milan", true, &p.arkKey.PublicKey, nil, p.arkKey, nil)
+	p.askKey = p.newRSAKey()
+	p.ask = p.newCert(2, "SEV-Milan", true, &p.askKey.PublicKey, p.ark, p.arkKey, nil)
+	p.vcekKey, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	p.vcek = p.newCert(3, "SEV-VCEK", false, &p.vcekKey.PublicKey, p.ask, p.askKey, p.vcekExtensions())
+
+	return p
+}
+
+func (p *testPlatform) newRSAKey() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(p.t, err)
+	return key
+}
+
+func (p *testPlatform) newCert(serial int64, cn string, isCA bool, pub crypto.PublicKey, parent *x509.Certificate, parentKey *rsa.PrivateKey, ext []pkix.Extension) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: cn, Organization: []string{"Advanced Micro Devices"}},
+		NotBefore:             time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2040, 1, 1, 0, 0, 0, 0, time.UTC),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		SignatureAlgorithm:    x509.SHA384WithRSAPSS,
+		ExtraExtensions:       ext,
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	}
+
+	if parent == nil {
+		// self-signed
+		parent = template
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, parentKey)
+	require.NoError(p.t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(p.t, err)
+	return cert
+}
+
+func (p *testPlatform) vcekExtensions() []pkix.Extension {
+	marshal := func(v interface{}) []byte {
+		b, err := asn1.Marshal(v)
+		require.NoError(p.t, err)
+		return b
+	}
+	return []pkix.Extension{
+		{Id: oidBootLoaderSPL, Value: marshal(int(p.vcekTcb.BootLoader))},
+		{Id: oidTEESPL, Value: marshal(int(p.vcekTcb.TEE))},
+		{Id: oidSNPSPL, Value: marshal(int(p.vcekTcb.SNP))},
+		{Id: oidMicrocodeSPL, Value: marshal(int(p.vcekTcb.Microcode))},
+		{Id: oidHardwareID, Value: marshal(p.chipID)},
+	}
+}
+
+// reissueVCEK issues a new VCEK certificate, e.g., after modifying vcekTcb
+func (p *testPlatform) reissueVCEK() {
+	p.vcek = p.newCert(3, "SEV-VCEK", false, &p.vcekKey.PublicKey, p.ask, p.askKey, p.vcekExtensions())
+}
+
+// report returns a VCEK signed attestation report for a guest with the given measurement and report data
+func (p *testPlatform) report(measurement string, reportData []byte) []byte {
+	raw := make([]byte, ReportLength)
+	binary.LittleEndian.PutUint32(raw[0x00:], 2)
+	binary.LittleEndian.PutUint32(raw[0x04:], 1)
+	binary.LittleEndian.PutUint64(raw[0x08:], p.policy)
+	binary.LittleEndian.PutUint32(raw[0x34:], signatureAlgoECDSAP384)
+	binary.LittleEndian.PutUint32(raw[0x48:], p.flags)
+	copy(raw[0x50:], reportData)
+	m, err := hex.DecodeString(measurement)
+	require.NoError(p.t, err)
+	copy(raw[0x90:], m)
+	raw[0xE0] = 0x42 // id key digest
+	raw[0x180] = p.tcb.BootLoader
+	raw[0x181] = p.tcb.TEE
+	raw[0x186] = p.tcb.SNP
+	raw[0x187] = p.tcb.Microcode
+	copy(raw[0x1A0:], p.chipID)
+
+	digest := sha512.Sum384(raw[:signedDataLength])
+	r, s, err := ecdsa.Sign(rand.Reader, p.vcekKey, digest[:])
+	require.NoError(p.t, err)
+	copy(raw[0x2A0:], littleEndian(r, signatureLength))
+	copy(raw[0x2A0+signatureLength:], littleEndian(s, signatureLength))
+	return raw
+}
+
+// evidence returns the SEV-SNP evidence for a guest with the given measurement and statement
+func (p *testPlatform) evidence(measurement string, statement []byte) string {
+	evidence, err := json.Marshal(&ReportWithVCEK{
+		Report: p.report(measurement, tsm.ReportData(statement)),
+		VCEK:   p.vcek.Raw,
+	})
+	require.NoError(p.t, err)
+	return string(evidence)
+}
+
+// certTable returns a certificate table as provided by the SEV-SNP guest driver including the VCEK certificate
+func (p *testPlatform) certTable() []byte {
+	// one entry and the terminating all-zero entry
+	table := make([]byte, 48)
+	copy(table, vcekGUID)
+	binary.LittleEndian.PutUint32(table[16:], uint32(len(table)))
+	binary.LittleEndian.PutUint32(table[20:], uint32(len(p.vcek.Raw)))
+	return append(table, p.vcek.Raw...)
+}
+
+func littleEndian(i *big.Int, length int) []byte {
+	be := i.FillBytes(make([]byte, length))
+	le := make([]byte, length)
+	for j, b := range be {
+		le[length-1-j] = b
+	}
+	return le
+}
@@ -0,0 +1,78 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sevsnp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/tsm"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/pkg/errors"
+)
+
+type issuer struct {
+	provider tsm.ReportProvider
+}
+
+type IssuerOption func(*issuer)
+
+// WithReportProvider option allows to override the configfs-tsm report provider. Mainly used for testing
+func WithReportProvider(provider tsm.ReportProvider) IssuerOption {
+	return func(i *issuer) {
+		i.provider = provider
+	}
+}
+
+// NewSEVSNPIssuer creates a new attestation issuer for enclaves running in an AMD SEV-SNP guest.
+// The issuer obtains attestation reports through the configfs-tsm interface of the guest kernel and includes the VCEK
+// certificate if provided by the host.
+func NewSEVSNPIssuer(opts ...IssuerOption) *types.Issuer {
+	i := &issuer{}
+
+	// apply options
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	if i.provider == nil {
+		i.provider = tsm.NewConfigFSProvider()
+	}
+
+	return &types.Issuer{
+		Type:  SEVSNPType,
+		Issue: i.issue,
+	}
+}
+
+func (i *issuer) issue(customData []byte) ([]byte, error) {
+	report, err := i.provider.GetReport(tsm.ReportData(customData))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get attestation report")
+	}
+	if report.Provider != tsm.ProviderSEVSNP {
+		return nil, fmt.Errorf("unexpected report provider '%s'", report.Provider)
+	}
+
+	rv := &ReportWithVCEK{Report: report.OutBlob}
+	if len(report.AuxBlob) > 0 {
+		// the host may not provide certificates; the converter fetches the VCEK certificate then
+		if rv.VCEK, err = vcekFromCertTable(report.AuxBlob); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.Marshal(rv)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal attestation report")
+	}
+
+	return json.Marshal(&types.Attestation{
+		Type: SEVSNPType,
+		Data: string(data),
+	})
+}
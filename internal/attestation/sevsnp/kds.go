@@ -0,0 +1,112 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sevsnp
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultKDSUrl is the AMD Key Distribution Service (KDS)
+const DefaultKDSUrl = "https://kdsintf.amd.com"
+
+// DefaultProduct is the product name of the AMD EPYC processor generation used to fetch VCEK certificates
+const DefaultProduct = "Milan"
+
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type KDSClient struct {
+	url        string
+	product    string
+	httpClient HTTPClient
+}
+
+type KDSClientOption func(*KDSClient)
+
+// WithUrl option allows to override the default KDS endpoint (DefaultKDSUrl)
+func WithUrl(url string) KDSClientOption {
+	return func(c *KDSClient) {
+		c.url = url
+	}
+}
+
+// WithProduct option allows to override the processor product name (DefaultProduct), e.g., `Genoa`
+func WithProduct(product string) KDSClientOption {
+	return func(c *KDSClient) {
+		c.product = product
+	}
+}
+
+// WithHttpClient option allows to use a custom http client. Mainly used for testing
+func WithHttpClient(client HTTPClient) KDSClientOption {
+	return func(c *KDSClient) {
+		c.httpClient = client
+	}
+}
+
+// NewKDSClient returns a new KDSClient instance using DefaultKDSUrl as endpoint.
+// Optionally, KDSClientOption can be provided to change the behavior of the KDSClient.
+func NewKDSClient(opts ...KDSClientOption) *KDSClient {
+	client := &KDSClient{
+		url:     DefaultKDSUrl,
+		product: DefaultProduct,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	// create default http client if not provided via options
+	if client.httpClient == nil {
+		client.httpClient = &http.Client{}
+	}
+
+	return client
+}
+
+// GetVCEK fetches the DER-encoded VCEK certificate for the chip and TCB of the given report
+func (c *KDSClient) GetVCEK(report *Report) ([]byte, error) {
+	query := url.Values{
+		"blSPL":    {strconv.Itoa(int(report.ReportedTcb.BootLoader))},
+		"teeSPL":   {strconv.Itoa(int(report.ReportedTcb.TEE))},
+		"snpSPL":   {strconv.Itoa(int(report.ReportedTcb.SNP))},
+		"ucodeSPL": {strconv.Itoa(int(report.ReportedTcb.Microcode))},
+	}
+	u := fmt.Sprintf("%s/vcek/v1/%s/%s?%s", c.url, c.product, hex.EncodeToString(report.ChipID), query.Encode())
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create http request")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot perform http request")
+	}
+	defer resp.Body.Close()
+
+	// check response status code
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("request failed! Reason: %d %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read response")
+	}
+
+	return body, nil
+}
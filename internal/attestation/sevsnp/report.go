@@ -0,0 +1,135 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sevsnp
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// Attestation report format as defined in the AMD SEV Secure Nested Paging Firmware ABI Specification
+// (ATTESTATION_REPORT structure, Table 22).
+
+const (
+	// ReportLength is the length of an attestation report
+	ReportLength = 0x4A0
+
+	signedDataLength       = 0x2A0
+	signatureLength        = 72
+	signatureAlgoECDSAP384 = 1
+
+	// policyDebug is the DEBUG flag of the guest policy
+	policyDebug = 1 << 19
+	// flagMaskChipID is set if the chip id is masked in the report
+	flagMaskChipID = 1 << 1
+	// flagSigningKeyMask selects the key that signed the report (0 for the VCEK)
+	flagSigningKeyMask  = 0x7 << 2
+	flagSigningKeyShift = 2
+)
+
+// TCBVersion is the TCB version of the platform, i.e., the security patch levels (SPL) of the firmware components
+type TCBVersion struct {
+	BootLoader uint8
+	TEE        uint8
+	SNP        uint8
+	Microcode  uint8
+}
+
+// Report is a parsed SEV-SNP attestation report
+type Report struct {
+	Version     uint32
+	GuestSvn    uint32
+	Policy      uint64
+	Vmpl        uint32
+	Flags       uint32
+	ReportData  []byte
+	Measurement []byte
+	HostData    []byte
+	IDKeyDigest []byte
+	// ReportedTcb is the TCB version the VCEK signing the report was derived from
+	ReportedTcb TCBVersion
+	ChipID      []byte
+
+	// SignatureR and SignatureS are the ECDSA P-384 signature over signedData
+	SignatureR, SignatureS *big.Int
+
+	signedData []byte
+}
+
+// ParseReport parses an SEV-SNP attestation report
+func ParseReport(raw []byte) (*Report, error) {
+	if len(raw) < ReportLength {
+		return nil, fmt.Errorf("report too short: expected %d bytes but got %d", ReportLength, len(raw))
+	}
+
+	r := &Report{
+		Version:     binary.LittleEndian.Uint32(raw[0x00:]),
+		GuestSvn:    binary.LittleEndian.Uint32(raw[0x04:]),
+		Policy:      binary.LittleEndian.Uint64(raw[0x08:]),
+		Vmpl:        binary.LittleEndian.Uint32(raw[0x30:]),
+		Flags:       binary.LittleEndian.Uint32(raw[0x48:]),
+		ReportData:  raw[0x50:0x90],
+		Measurement: raw[0x90:0xC0],
+		HostData:    raw[0xC0:0xE0],
+		IDKeyDigest: raw[0xE0:0x110],
+		ReportedTcb: parseTCBVersion(raw[0x180:0x188]),
+		ChipID:      raw[0x1A0:0x1E0],
+		SignatureR:  littleEndianInt(raw[0x2A0 : 0x2A0+signatureLength]),
+		SignatureS:  littleEndianInt(raw[0x2A0+signatureLength : 0x2A0+2*signatureLength]),
+		signedData:  raw[:signedDataLength],
+	}
+
+	if r.Version < 2 {
+		return nil, fmt.Errorf("unsupported report version %d", r.Version)
+	}
+	if algo := binary.LittleEndian.Uint32(raw[0x34:]); algo != signatureAlgoECDSAP384 {
+		return nil, fmt.Errorf("unsupported signature algorithm %d", algo)
+	}
+	if signingKey := (r.Flags & flagSigningKeyMask) >> flagSigningKeyShift; signingKey != 0 {
+		return nil, fmt.Errorf("unsupported signing key %d, only VCEK signed reports are supported", signingKey)
+	}
+
+	return r, nil
+}
+
+// Debug returns true if the guest policy allows debugging
+func (r *Report) Debug() bool {
+	return r.Policy&policyDebug != 0
+}
+
+// verifySignature verifies the report signature with the public key of the VCEK
+func (r *Report) verifySignature(vcek *ecdsa.PublicKey) error {
+	digest := sha512.Sum384(r.signedData)
+	if !ecdsa.Verify(vcek, digest[:], r.SignatureR, r.SignatureS) {
+		return errors.New("invalid report signature")
+	}
+	return nil
+}
+
+func parseTCBVersion(raw []byte) TCBVersion {
+	// bytes 2 to 5 are reserved
+	return TCBVersion{
+		BootLoader: raw[0],
+		TEE:        raw[1],
+		SNP:        raw[6],
+		Microcode:  raw[7],
+	}
+}
+
+// littleEndianInt returns the little endian encoded unsigned integer
+func littleEndianInt(raw []byte) *big.Int {
+	be := make([]byte, len(raw))
+	for i, b := range raw {
+		be[len(raw)-1-i] = b
+	}
+	return new(big.Int).SetBytes(be)
+}
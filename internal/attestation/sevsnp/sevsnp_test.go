@@ -0,0 +1,271 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sevsnp
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/tsm"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestVerifier(platform *testPlatform) *types.Verifier {
+	return NewSEVSNPVerifier(WithCertificates(platform.ask, platform.ark), WithCurrentTime(func() time.Time { return testNow }))
+}
+
+func TestVerifySEVSNPReport(t *testing.T) {
+	platform := newTestPlatform(t)
+	verifier := newTestVerifier(platform)
+	assert.Equal(t, SEVSNPType, verifier.Type)
+
+	expected := &types.ValidationValues{Statement: testStatement, Mrenclave: testMeasurement}
+	evidence := &types.Evidence{Type: SEVSNPType, Data: platform.evidence(testMeasurement, testStatement)}
+
+	// success
+	assert.NoError(t, verifier.Verify(evidence, expected))
+
+	// wrong measurement
+	err := verifier.Verify(evidence, &types.ValidationValues{Statement: testStatement, Mrenclave: "00" + testMeasurement[2:]})
+	assert.ErrorContains(t, err, "measurement does not match")
+
+	// wrong statement
+	err = verifier.Verify(evidence, &types.ValidationValues{Statement: []byte("other statement"), Mrenclave: testMeasurement})
+	assert.ErrorContains(t, err, "report data does not match statement")
+
+	// no AMD certificates configured
+	err = NewSEVSNPVerifier().Verify(evidence, expected)
+	assert.EqualError(t, err, "no AMD root certificates configured")
+
+	// certificates of another platform
+	err = newTestVerifier(newTestPlatform(t)).Verify(evidence, expected)
+	assert.ErrorContains(t, err, "cannot verify VCEK certificate chain")
+
+	// expired VCEK certificate
+	err = NewSEVSNPVerifier(WithCertificates(platform.ask, platform.ark), WithCurrentTime(func() time.Time { return testNow.AddDate(20, 0, 0) })).Verify(evidence, expected)
+	assert.ErrorContains(t, err, "cannot verify VCEK certificate chain")
+
+	// tampered report
+	rv := &ReportWithVCEK{}
+	require.NoError(t, json.Unmarshal([]byte(evidence.Data), rv))
+	rv.Report[0x04] = 2
+	data, err := json.Marshal(rv)
+	require.NoError(t, err)
+	err = verifier.Verify(&types.Evidence{Type: SEVSNPType, Data: string(data)}, expected)
+	assert.EqualError(t, err, "report verification failed: invalid report signature")
+
+	// VCEK certificate of another chip
+	other := newTestPlatform(t)
+	rv.Report = platform.report(testMeasurement, tsm.ReportData(testStatement))
+	rv.VCEK = other.vcek.Raw
+	data, err = json.Marshal(rv)
+	require.NoError(t, err)
+	err = NewSEVSNPVerifier(WithCertificates(other.ask, other.ark), WithCurrentTime(func() time.Time { return testNow })).Verify(&types.Evidence{Type: SEVSNPType, Data: string(data)}, expected)
+	assert.EqualError(t, err, "report verification failed: invalid report signature")
+
+	// VCEK certificate for another TCB
+	platform.vcekTcb.SNP = 8
+	platform.reissueVCEK()
+	evidence = &types.Evidence{Type: SEVSNPType, Data: platform.evidence(testMeasurement, testStatement)}
+	err = verifier.Verify(evidence, expected)
+	assert.ErrorContains(t, err, "report verification failed: VCEK TCB")
+
+	// masked chip id
+	platform = newTestPlatform(t)
+	platform.flags = flagMaskChipID
+	err = newTestVerifier(platform).Verify(&types.Evidence{Type: SEVSNPType, Data: platform.evidence(testMeasurement, testStatement)}, expected)
+	assert.EqualError(t, err, "report verification failed: reports with masked chip id are not supported")
+
+	// malformed evidence
+	assert.ErrorContains(t, verifier.Verify(&types.Evidence{Type: SEVSNPType, Data: "not json"}, expected), "cannot unmarshal evidence")
+	assert.EqualError(t, verifier.Verify(&types.Evidence{Type: SEVSNPType, Data: `{"report": "AAAA"}`}, expected), "evidence has no VCEK certificate")
+	assert.ErrorContains(t, verifier.Verify(&types.Evidence{Type: SEVSNPType, Data: `{"report": "AAAA", "vcek": "AAAA"}`}, expected), "cannot parse report")
+}
+
+func TestParseReport(t *testing.T) {
+	platform := newTestPlatform(t)
+	platform.policy |= policyDebug
+	raw := platform.report(testMeasurement, tsm.ReportData(testStatement))
+
+	report, err := ParseReport(raw)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), report.Version)
+	assert.Equal(t, testMeasurement, fmt.Sprintf("%x", report.Measurement))
+	assert.Equal(t, tsm.ReportData(testStatement), report.ReportData)
+	assert.Equal(t, platform.tcb, report.ReportedTcb)
+	assert.Equal(t, platform.chipID, report.ChipID)
+	assert.True(t, report.Debug())
+
+	_, err = ParseReport(raw[:100])
+	assert.EqualError(t, err, "report too short: expected 1184 bytes but got 100")
+
+	modified := append([]byte{}, raw...)
+	modified[0] = 1
+	_, err = ParseReport(modified)
+	assert.EqualError(t, err, "unsupported report version 1")
+
+	modified = append([]byte{}, raw...)
+	modified[0x34] = 2
+	_, err = ParseReport(modified)
+	assert.EqualError(t, err, "unsupported signature algorithm 2")
+
+	modified = append([]byte{}, raw...)
+	modified[0x48] = 1 << flagSigningKeyShift
+	_, err = ParseReport(modified)
+	assert.EqualError(t, err, "unsupported signing key 1, only VCEK signed reports are supported")
+}
+
+func TestSEVSNPClaims(t *testing.T) {
+	platform := newTestPlatform(t)
+	platform.policy |= policyDebug
+	verifier := newTestVerifier(platform)
+
+	claims, err := verifier.Claims(&types.Evidence{Type: SEVSNPType, Data: platform.evidence(testMeasurement, testStatement)})
+	require.NoError(t, err)
+	assert.Equal(t, &types.Claims{
+		Status:    StatusOK,
+		Debug:     true,
+		IsvSvn:    1,
+		Mrsigner:  "420000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		Timestamp: testNow,
+	}, claims)
+
+	_, err = verifier.Claims(&types.Evidence{Type: SEVSNPType, Data: "not json"})
+	assert.ErrorContains(t, err, "cannot unmarshal evidence")
+}
+
+func TestSEVSNPConverter(t *testing.T) {
+	platform := newTestPlatform(t)
+	fakeHttpClient := &fakes.HTTPClient{}
+	fakeHttpClient.DoReturns(&http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewReader(platform.vcek.Raw))}, nil)
+
+	// the VCEK certificate is fetched from the KDS if not included in the attestation
+	attestation, err := json.Marshal(&ReportWithVCEK{Report: platform.report(testMeasurement, tsm.ReportData(testStatement))})
+	require.NoError(t, err)
+	convert := newSEVSNPConverter(NewKDSClient(WithProduct("Genoa"), WithHttpClient(fakeHttpClient)))
+	evidence, err := convert(attestation)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, fakeHttpClient.DoCallCount())
+	assert.Equal(t, fmt.Sprintf("%s/vcek/v1/Genoa/%x?blSPL=3&snpSPL=14&teeSPL=0&ucodeSPL=209", DefaultKDSUrl, platform.chipID), fakeHttpClient.DoArgsForCall(0).URL.String())
+
+	err = newTestVerifier(platform).Verify(&types.Evidence{Type: SEVSNPType, Data: string(evidence)}, &types.ValidationValues{
+		Statement: testStatement,
+		Mrenclave: testMeasurement,
+	})
+	assert.NoError(t, err)
+
+	// an included VCEK certificate is retained
+	attestation, err = json.Marshal(&ReportWithVCEK{Report: platform.report(testMeasurement, tsm.ReportData(testStatement)), VCEK: platform.vcek.Raw})
+	require.NoError(t, err)
+	_, err = convert(attestation)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fakeHttpClient.DoCallCount())
+
+	// KDS not available
+	fakeHttpClient.DoReturns(&http.Response{StatusCode: 404, Status: "404 Not Found", Body: io.NopCloser(&bytes.Buffer{})}, nil)
+	attestation, err = json.Marshal(&ReportWithVCEK{Report: platform.report(testMeasurement, tsm.ReportData(testStatement))})
+	require.NoError(t, err)
+	_, err = convert(attestation)
+	assert.ErrorContains(t, err, "cannot get VCEK certificate: request failed! Reason: 404")
+
+	// invalid attestation
+	_, err = convert([]byte("not json"))
+	assert.ErrorContains(t, err, "cannot unmarshal attestation")
+	_, err = convert([]byte(`{"report": "AAAA"}`))
+	assert.ErrorContains(t, err, "cannot parse report")
+
+	assert.Equal(t, SEVSNPType, NewSEVSNPConverter().Type)
+}
+
+func TestSEVSNPIssuer(t *testing.T) {
+	platform := newTestPlatform(t)
+	provider := &fakes.ReportProvider{}
+	provider.GetReportCalls(func(reportData []byte) (*tsm.Report, error) {
+		return &tsm.Report{
+			Provider: tsm.ProviderSEVSNP,
+			OutBlob:  platform.report(testMeasurement, reportData),
+			AuxBlob:  platform.certTable(),
+		}, nil
+	})
+
+	issuer := NewSEVSNPIssuer(WithReportProvider(provider))
+	assert.Equal(t, SEVSNPType, issuer.Type)
+
+	attestationBytes, err := issuer.Issue(testStatement)
+	require.NoError(t, err)
+	assert.Equal(t, tsm.ReportData(testStatement), provider.GetReportArgsForCall(0))
+
+	// the attestation includes the VCEK certificate and, hence, is the evidence
+	attestation := &types.Attestation{}
+	require.NoError(t, json.Unmarshal(attestationBytes, attestation))
+	assert.Equal(t, SEVSNPType, attestation.Type)
+	err = newTestVerifier(platform).Verify(&types.Evidence{Type: SEVSNPType, Data: attestation.Data}, &types.ValidationValues{
+		Statement: testStatement,
+		Mrenclave: testMeasurement,
+	})
+	assert.NoError(t, err)
+
+	// no certificates provided by the host
+	provider.GetReportReturns(&tsm.Report{Provider: tsm.ProviderSEVSNP, OutBlob: []byte("report")}, nil)
+	attestationBytes, err = issuer.Issue(testStatement)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(attestationBytes, attestation))
+	assert.JSONEq(t, `{"report": "cmVwb3J0"}`, attestation.Data)
+
+	// certificate table without VCEK
+	provider.GetReportReturns(&tsm.Report{Provider: tsm.ProviderSEVSNP, OutBlob: []byte("report"), AuxBlob: make([]byte, 24)}, nil)
+	_, err = issuer.Issue(testStatement)
+	assert.ErrorContains(t, err, "certificate table has no VCEK")
+
+	// not an SEV-SNP guest
+	provider.GetReportReturns(&tsm.Report{Provider: tsm.ProviderTDX}, nil)
+	_, err = issuer.Issue(testStatement)
+	assert.EqualError(t, err, "unexpected report provider 'tdx_guest'")
+
+	provider.GetReportReturns(nil, fmt.Errorf("no configfs-tsm"))
+	_, err = issuer.Issue(testStatement)
+	assert.EqualError(t, err, "cannot get attestation report: no configfs-tsm")
+}
+
+func TestLoadCertificates(t *testing.T) {
+	platform := newTestPlatform(t)
+	path := filepath.Join(t.TempDir(), "cert_chain.pem")
+	chain := append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: platform.ask.Raw}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: platform.ark.Raw})...)
+	require.NoError(t, os.WriteFile(path, chain, 0600))
+
+	certs, err := LoadCertificates(path)
+	require.NoError(t, err)
+	assert.Len(t, certs, 2)
+
+	verifier := NewSEVSNPVerifier(WithCertificates(certs...), WithCurrentTime(func() time.Time { return testNow }))
+	evidence := &types.Evidence{Type: SEVSNPType, Data: platform.evidence(testMeasurement, testStatement)}
+	assert.NoError(t, verifier.Verify(evidence, &types.ValidationValues{Statement: testStatement, Mrenclave: testMeasurement}))
+
+	// the ASK alone is not a trust anchor
+	verifier = NewSEVSNPVerifier(WithCertificates(platform.ask), WithCurrentTime(func() time.Time { return testNow }))
+	assert.EqualError(t, verifier.Verify(evidence, &types.ValidationValues{Statement: testStatement, Mrenclave: testMeasurement}), "no AMD root certificates configured")
+
+	require.NoError(t, os.WriteFile(path, []byte("no pem"), 0600))
+	_, err = LoadCertificates(path)
+	assert.ErrorContains(t, err, "no certificates found")
+
+	_, err = LoadCertificates(filepath.Join(t.TempDir(), "missing.pem"))
+	assert.ErrorContains(t, err, "cannot read certificates")
+}
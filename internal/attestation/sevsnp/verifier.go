@@ -0,0 +1,147 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sevsnp
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/tsm"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/pkg/errors"
+)
+
+// StatusOK is the status of verified SEV-SNP reports; the KDS issues VCEK certificates for all TCB versions, hence,
+// the minimum acceptable TCB is a matter of the appraisal of the reported TCB
+const StatusOK = "OK"
+
+type verifier struct {
+	roots         *x509.CertPool
+	intermediates *x509.CertPool
+	hasRoots      bool
+	now           func() time.Time
+}
+
+type VerifierOption func(*verifier)
+
+// WithCertificates option sets the AMD certificates used to verify VCEK certificates; self-signed certificates
+// (i.e., the AMD Root Key) are used as trust anchors, all others (i.e., the AMD SEV Key) as intermediates
+func WithCertificates(certs ...*x509.Certificate) VerifierOption {
+	return func(v *verifier) {
+		for _, c := range certs {
+			if bytes.Equal(c.RawIssuer, c.RawSubject) {
+				v.roots.AddCert(c)
+				v.hasRoots = true
+			} else {
+				v.intermediates.AddCert(c)
+			}
+		}
+	}
+}
+
+// WithCurrentTime option allows to override the time used to check the validity of certificates
+func WithCurrentTime(now func() time.Time) VerifierOption {
+	return func(v *verifier) {
+		v.now = now
+	}
+}
+
+// NewSEVSNPVerifier creates a new attestation verifier for AMD SEV-SNP attestation. The launch measurement of the
+// guest takes the role of the mrenclave. The AMD certificates must be provided using WithCertificates, e.g., as
+// loaded with LoadCertificates; without certificates, all evidence is rejected.
+func NewSEVSNPVerifier(opts ...VerifierOption) *types.Verifier {
+	v := &verifier{
+		roots:         x509.NewCertPool(),
+		intermediates: x509.NewCertPool(),
+		now:           time.Now,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return &types.Verifier{
+		Type:   SEVSNPType,
+		Verify: v.verify,
+		Claims: v.claims,
+	}
+}
+
+func (v *verifier) verify(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) error {
+	report, err := v.verifyEvidence(evidence)
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(hex.EncodeToString(report.Measurement), expectedValidationValues.Mrenclave) {
+		return fmt.Errorf("measurement does not match: expected %s but got %x", expectedValidationValues.Mrenclave, report.Measurement)
+	}
+
+	expected := tsm.ReportData(expectedValidationValues.Statement)
+	if !bytes.Equal(report.ReportData, expected) {
+		return fmt.Errorf("report data does not match statement: expected %x but got %x", expected, report.ReportData)
+	}
+
+	return nil
+}
+
+// claims returns the claims of the (verified) evidence for the appraisal policy. SEV-SNP reports carry no timestamp;
+// their freshness relies on the registration nonce, hence, the time of verification is used.
+func (v *verifier) claims(evidence *types.Evidence) (*types.Claims, error) {
+	report, err := v.verifyEvidence(evidence)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Claims{
+		Status:    StatusOK,
+		Debug:     report.Debug(),
+		IsvSvn:    uint16(report.GuestSvn),
+		Mrsigner:  hex.EncodeToString(report.IDKeyDigest),
+		Timestamp: v.now(),
+	}, nil
+}
+
+// verifyEvidence parses the report and VCEK certificate of the evidence and verifies that the report was signed by
+// the VCEK of a genuine AMD processor
+func (v *verifier) verifyEvidence(evidence *types.Evidence) (*Report, error) {
+	rv := &ReportWithVCEK{}
+	if err := json.Unmarshal([]byte(evidence.Data), rv); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal evidence")
+	}
+	if len(rv.VCEK) == 0 {
+		return nil, fmt.Errorf("evidence has no VCEK certificate")
+	}
+
+	report, err := ParseReport(rv.Report)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse report")
+	}
+
+	if !v.hasRoots {
+		return nil, fmt.Errorf("no AMD root certificates configured")
+	}
+
+	vcek, ext, err := verifyVCEK(rv.VCEK, v.roots, v.intermediates, v.now())
+	if err != nil {
+		return nil, errors.Wrap(err, "report verification failed")
+	}
+	if err := report.verifySignature(vcek); err != nil {
+		return nil, errors.Wrap(err, "report verification failed")
+	}
+	if err := ext.checkPlatform(report); err != nil {
+		return nil, errors.Wrap(err, "report verification failed")
+	}
+
+	return report, nil
+}
@@ -6,16 +6,86 @@ SPDX-License-Identifier: Apache-2.0
 
 package simulation
 
-import "github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/pkg/errors"
+)
+
+type issuer struct {
+	mrenclave  string
+	signingKey *ecdsa.PrivateKey
+}
+
+type IssuerOption func(*issuer)
+
+// WithMrenclave option sets the fake mrenclave reported in simulated quotes
+func WithMrenclave(mrenclave string) IssuerOption {
+	return func(i *issuer) {
+		i.mrenclave = mrenclave
+	}
+}
+
+// WithSigningKey option allows to override the dev signing key. Mainly used for testing
+func WithSigningKey(key *ecdsa.PrivateKey) IssuerOption {
+	return func(i *issuer) {
+		i.signingKey = key
+	}
+}
+
+// NewSimulationIssuer creates a new attestation issuer for Intel SGX simulation mode.
+// The issuer produces simulated quotes for the fake mrenclave given by WithMrenclave, signed with the dev signing key.
+func NewSimulationIssuer(opts ...IssuerOption) *types.Issuer {
+	i := &issuer{}
+
+	// apply options
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	if i.signingKey == nil {
+		i.signingKey = DevSigningKey()
+	}
 
-// NewSimulationIssuer creates a new attestation issuer for Intel SGX simulation mode
-func NewSimulationIssuer() *types.Issuer {
 	return &types.Issuer{
 		Type:  SimulationType,
-		Issue: issue,
+		Issue: i.issue,
 	}
 }
 
-func issue(customData []byte) ([]byte, error) {
-	return []byte("{\"attestation_type\":\"" + SimulationType + "\",\"attestation\":\"MA==\"}"), nil
+func (i *issuer) issue(customData []byte) ([]byte, error) {
+	quote, err := json.Marshal(&Quote{
+		Version:    QuoteVersion,
+		Mrenclave:  i.mrenclave,
+		ReportData: reportData(customData),
+		IssuedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal simulated quote")
+	}
+
+	hash := sha256.Sum256(quote)
+	signature, err := ecdsa.SignASN1(rand.Reader, i.signingKey, hash[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot sign simulated quote")
+	}
+
+	signedQuote, err := json.Marshal(&SignedQuote{
+		Quote:     quote,
+		Signature: signature,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal signed simulated quote")
+	}
+
+	return json.Marshal(&types.Attestation{
+		Type: SimulationType,
+		Data: base64.StdEncoding.EncodeToString(signedQuote),
+	})
 }
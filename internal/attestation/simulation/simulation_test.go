@@ -0,0 +1,157 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package simulation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeMrenclave = "fake_mrenclave"
+
+var statement = []byte("some attested data")
+
+func issueEvidence(t *testing.T, opts ...IssuerOption) *types.Evidence {
+	attestationBytes, err := NewSimulationIssuer(opts...).Issue(statement)
+	require.NoError(t, err)
+
+	attestation := &types.Attestation{}
+	require.NoError(t, json.Unmarshal(attestationBytes, attestation))
+	assert.Equal(t, SimulationType, attestation.Type)
+
+	evidenceBytes, err := NewSimulationConverter().Converter([]byte(attestation.Data))
+	require.NoError(t, err)
+
+	return &types.Evidence{Type: SimulationType, Data: string(evidenceBytes)}
+}
+
+func TestSimulationAttestation(t *testing.T) {
+	verifier := NewSimulationVerifier()
+	evidence := issueEvidence(t, WithMrenclave(fakeMrenclave))
+
+	// success
+	err := verifier.Verify(evidence, &types.ValidationValues{Statement: statement, Mrenclave: fakeMrenclave})
+	assert.NoError(t, err)
+
+	// wrong mrenclave
+	err = verifier.Verify(evidence, &types.ValidationValues{Statement: statement, Mrenclave: "other_mrenclave"})
+	assert.EqualError(t, err, "mrenclave does not match: expected other_mrenclave but got fake_mrenclave")
+
+	// tampered statement
+	err = verifier.Verify(evidence, &types.ValidationValues{Statement: []byte("tampered attested data"), Mrenclave: fakeMrenclave})
+	assert.ErrorContains(t, err, "report data does not match statement")
+
+	// quote signed with a key other than the dev signing key
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	evidence = issueEvidence(t, WithMrenclave(fakeMrenclave), WithSigningKey(otherKey))
+	err = verifier.Verify(evidence, &types.ValidationValues{Statement: statement, Mrenclave: fakeMrenclave})
+	assert.EqualError(t, err, "invalid simulated quote signature")
+
+	// but verifies with the corresponding verification key
+	err = NewSimulationVerifier(WithVerificationKey(&otherKey.PublicKey)).Verify(evidence, &types.ValidationValues{Statement: statement, Mrenclave: fakeMrenclave})
+	assert.NoError(t, err)
+}
+
+func TestSimulationAttestationTampered(t *testing.T) {
+	verifier := NewSimulationVerifier()
+	expected := &types.ValidationValues{Statement: statement, Mrenclave: fakeMrenclave}
+
+	evidence := issueEvidence(t, WithMrenclave("other_mrenclave"))
+	signedQuoteBytes, err := base64.StdEncoding.DecodeString(evidence.Data)
+	require.NoError(t, err)
+	signedQuote := &SignedQuote{}
+	require.NoError(t, json.Unmarshal(signedQuoteBytes, signedQuote))
+
+	// replace the mrenclave in the quote without re-signing
+	quote := &Quote{}
+	require.NoError(t, json.Unmarshal(signedQuote.Quote, quote))
+	quote.Mrenclave = fakeMrenclave
+	signedQuote.Quote, err = json.Marshal(quote)
+	require.NoError(t, err)
+	signedQuoteBytes, err = json.Marshal(signedQuote)
+	require.NoError(t, err)
+
+	err = verifier.Verify(&types.Evidence{Type: SimulationType, Data: base64.StdEncoding.EncodeToString(signedQuoteBytes)}, expected)
+	assert.EqualError(t, err, "invalid simulated quote signature")
+
+	// malformed evidence
+	err = verifier.Verify(&types.Evidence{Type: SimulationType, Data: "not base64"}, expected)
+	assert.ErrorContains(t, err, "cannot decode simulated quote")
+	err = verifier.Verify(&types.Evidence{Type: SimulationType, Data: base64.StdEncoding.EncodeToString([]byte("no json"))}, expected)
+	assert.ErrorContains(t, err, "cannot unmarshal signed simulated quote")
+}
+
+func TestSimulationAttestationLegacy(t *testing.T) {
+	legacy := &types.Evidence{Type: SimulationType, Data: "MA=="}
+	expected := &types.ValidationValues{Statement: statement, Mrenclave: fakeMrenclave}
+
+	err := NewSimulationVerifier().Verify(legacy, expected)
+	assert.EqualError(t, err, "simulated attestation does not contain a quote")
+
+	err = NewSimulationVerifier(WithLegacyAttestations()).Verify(legacy, expected)
+	assert.NoError(t, err)
+
+	// quotes are still checked if legacy attestations are accepted
+	evidence := issueEvidence(t, WithMrenclave(fakeMrenclave))
+	err = NewSimulationVerifier(WithLegacyAttestations()).Verify(evidence, &types.ValidationValues{Statement: statement, Mrenclave: "other_mrenclave"})
+	assert.ErrorContains(t, err, "mrenclave does not match")
+}
+
+func TestLegacyAttestationsFromEnv(t *testing.T) {
+	legacy := &types.Evidence{Type: SimulationType, Data: "MA=="}
+	expected := &types.ValidationValues{Statement: statement, Mrenclave: fakeMrenclave}
+
+	// disabled by default
+	t.Setenv(LegacyAttestationsEnv, "")
+	opts, err := LegacyAttestationsFromEnv()
+	require.NoError(t, err)
+	assert.Error(t, NewSimulationVerifier(opts...).Verify(legacy, expected))
+
+	t.Setenv(LegacyAttestationsEnv, "false")
+	opts, err = LegacyAttestationsFromEnv()
+	require.NoError(t, err)
+	assert.Error(t, NewSimulationVerifier(opts...).Verify(legacy, expected))
+
+	t.Setenv(LegacyAttestationsEnv, "true")
+	opts, err = LegacyAttestationsFromEnv()
+	require.NoError(t, err)
+	assert.NoError(t, NewSimulationVerifier(opts...).Verify(legacy, expected))
+
+	t.Setenv(LegacyAttestationsEnv, "maybe")
+	_, err = LegacyAttestationsFromEnv()
+	assert.ErrorContains(t, err, "invalid value of "+LegacyAttestationsEnv)
+}
+
+func TestSimulationClaims(t *testing.T) {
+	verifier := NewSimulationVerifier()
+	evidence := issueEvidence(t, WithMrenclave(fakeMrenclave))
+
+	claims, err := verifier.Claims(evidence)
+	require.NoError(t, err)
+	assert.Equal(t, "OK", claims.Status)
+	assert.True(t, claims.Debug)
+	assert.WithinDuration(t, time.Now(), claims.Timestamp, time.Minute)
+
+	// debug enclaves must be explicitly allowed
+	policy := &types.AppraisalPolicy{}
+	assert.EqualError(t, policy.Appraise(claims, time.Now()), "debug enclaves are not allowed")
+	policy.AllowDebug = true
+	assert.NoError(t, policy.Appraise(claims, time.Now()))
+
+	_, err = verifier.Claims(&types.Evidence{Type: SimulationType, Data: "MA=="})
+	assert.EqualError(t, err, "simulated attestation does not contain a quote")
+}
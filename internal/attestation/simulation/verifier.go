@@ -7,15 +7,163 @@ SPDX-License-Identifier: Apache-2.0
 package simulation
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/pkg/errors"
 )
 
-func NewSimulationVerifier() *types.Verifier {
+var logger = flogging.MustGetLogger("fpc.attestation.simulation")
+
+// legacyEvidence is the evidence produced by enclaves that do not issue simulated quotes (i.e., base64 of "0")
+const legacyEvidence = "MA=="
+
+// LegacyAttestationsEnv is the environment variable that enables WithLegacyAttestations (see LegacyAttestationsFromEnv),
+// e.g., for networks running C++ chaincode in SGX simulation mode. Legacy attestations are rejected unless it is true.
+const LegacyAttestationsEnv = "FPC_ACCEPT_LEGACY_SIMULATED_ATTESTATIONS"
+
+type verifier struct {
+	verificationKey *ecdsa.PublicKey
+	acceptLegacy    bool
+}
+
+type VerifierOption func(*verifier)
+
+// WithVerificationKey option allows to override the dev signing key used to verify simulated quotes. Mainly used for testing
+func WithVerificationKey(key *ecdsa.PublicKey) VerifierOption {
+	return func(v *verifier) {
+		v.verificationKey = key
+	}
+}
+
+// WithLegacyAttestations option accepts the unbound simulated attestation of enclaves that do not issue simulated
+// quotes yet (i.e., the C++ enclave in SGX simulation mode). Such attestations are not checked at all.
+func WithLegacyAttestations() VerifierOption {
+	return func(v *verifier) {
+		v.acceptLegacy = true
+	}
+}
+
+// LegacyAttestationsFromEnv returns the WithLegacyAttestations option if LegacyAttestationsEnv is set to true, and no
+// options otherwise
+func LegacyAttestationsFromEnv() ([]VerifierOption, error) {
+	value := os.Getenv(LegacyAttestationsEnv)
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	accept, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid value of %s", LegacyAttestationsEnv)
+	}
+	if !accept {
+		return nil, nil
+	}
+
+	logger.Warningf("%s is set; simulated attestations without quote are accepted", LegacyAttestationsEnv)
+	return []VerifierOption{WithLegacyAttestations()}, nil
+}
+
+// NewSimulationVerifier creates a new attestation verifier for Intel SGX simulation mode.
+// The verifier checks simulated quotes as it is done for real hardware, that is, the quote must be signed with
+// the dev signing key, the mrenclave must match the expected mrenclave, and the report data must bind the statement.
+func NewSimulationVerifier(opts ...VerifierOption) *types.Verifier {
+	v := &verifier{}
+
+	// apply options
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	if v.verificationKey == nil {
+		v.verificationKey = &DevSigningKey().PublicKey
+	}
+
 	return &types.Verifier{
-		Type: SimulationType,
-		Verify: func(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) (err error) {
-			// NO-OP
-			return nil
-		},
+		Type:   SimulationType,
+		Verify: v.verify,
+		Claims: v.claims,
 	}
 }
+
+func (v *verifier) verify(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) error {
+	if evidence.Data == legacyEvidence {
+		if !v.acceptLegacy {
+			return fmt.Errorf("simulated attestation does not contain a quote")
+		}
+		logger.Warningf("accepting simulated attestation without quote; mrenclave and statement are not checked")
+		return nil
+	}
+
+	quote, err := v.verifyQuote(evidence)
+	if err != nil {
+		return err
+	}
+
+	if quote.Mrenclave != expectedValidationValues.Mrenclave {
+		return fmt.Errorf("mrenclave does not match: expected %s but got %s", expectedValidationValues.Mrenclave, quote.Mrenclave)
+	}
+
+	expectedReportData := reportData(expectedValidationValues.Statement)
+	if !bytes.Equal(quote.ReportData, expectedReportData) {
+		return fmt.Errorf("report data does not match statement: expected %x but got %x", expectedReportData, quote.ReportData)
+	}
+
+	return nil
+}
+
+// claims returns the claims of a simulated quote for the appraisal policy. Simulated enclaves are always reported
+// as debug enclaves with status `OK`.
+func (v *verifier) claims(evidence *types.Evidence) (*types.Claims, error) {
+	if evidence.Data == legacyEvidence {
+		return nil, fmt.Errorf("simulated attestation does not contain a quote")
+	}
+
+	quote, err := v.verifyQuote(evidence)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Claims{
+		Status:    "OK",
+		Debug:     true,
+		Timestamp: quote.IssuedAt,
+	}, nil
+}
+
+// verifyQuote decodes the signed simulated quote of the evidence and checks its signature and version
+func (v *verifier) verifyQuote(evidence *types.Evidence) (*Quote, error) {
+	signedQuoteBytes, err := base64.StdEncoding.DecodeString(evidence.Data)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode simulated quote")
+	}
+
+	signedQuote := &SignedQuote{}
+	if err := json.Unmarshal(signedQuoteBytes, signedQuote); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal signed simulated quote")
+	}
+
+	hash := sha256.Sum256(signedQuote.Quote)
+	if !ecdsa.VerifyASN1(v.verificationKey, hash[:], signedQuote.Signature) {
+		return nil, fmt.Errorf("invalid simulated quote signature")
+	}
+
+	quote := &Quote{}
+	if err := json.Unmarshal(signedQuote.Quote, quote); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal simulated quote")
+	}
+
+	if quote.Version != QuoteVersion {
+		return nil, fmt.Errorf("unsupported simulated quote version %d", quote.Version)
+	}
+
+	return quote, nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package tsm provides access to the attestation reports of confidential VMs (AMD SEV-SNP, Intel TDX) through the
+// Linux configfs-tsm interface (see Documentation/ABI/testing/configfs-tsm in the Linux kernel).
+package tsm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultPath is the mount point of the configfs-tsm report interface
+const DefaultPath = "/sys/kernel/config/tsm/report"
+
+// ReportDataLength is the length of the report data bound in an attestation report
+const ReportDataLength = 64
+
+// Providers as reported by configfs-tsm
+const (
+	ProviderSEVSNP = "sev_guest"
+	ProviderTDX    = "tdx_guest"
+)
+
+// Report is an attestation report returned by a ReportProvider
+type Report struct {
+	// Provider is the TEE that produced the report, e.g., ProviderSEVSNP or ProviderTDX
+	Provider string
+	// OutBlob is the attestation report (SEV-SNP) or quote (TDX)
+	OutBlob []byte
+	// AuxBlob is optional auxiliary data, e.g., the SEV-SNP certificate table containing the VCEK
+	AuxBlob []byte
+}
+
+// ReportProvider returns attestation reports binding report data of ReportDataLength bytes
+type ReportProvider interface {
+	GetReport(reportData []byte) (*Report, error)
+}
+
+// ReportData returns the report data binding the statement, i.e., SHA256(statement) || 32 zero bytes, the same
+// binding as for SGX attestations
+func ReportData(statement []byte) []byte {
+	hash := sha256.Sum256(statement)
+	return append(hash[:], make([]byte, ReportDataLength-sha256.Size)...)
+}
+
+type configFSProvider struct {
+	path string
+	// newEntry creates a new report entry below path; configfs populates the entry with the report attributes
+	newEntry func(path string) (string, error)
+}
+
+type ConfigFSOption func(*configFSProvider)
+
+// WithPath option allows to override the configfs-tsm mount point (DefaultPath)
+func WithPath(path string) ConfigFSOption {
+	return func(p *configFSProvider) {
+		p.path = path
+	}
+}
+
+// NewConfigFSProvider returns a ReportProvider using the configfs-tsm interface
+func NewConfigFSProvider(opts ...ConfigFSOption) ReportProvider {
+	p := &configFSProvider{
+		path: DefaultPath,
+		newEntry: func(path string) (string, error) {
+			return os.MkdirTemp(path, "fpc-")
+		},
+	}
+
+	// apply options
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+func (p *configFSProvider) GetReport(reportData []byte) (*Report, error) {
+	if len(reportData) != ReportDataLength {
+		return nil, fmt.Errorf("report data must be %d bytes but got %d", ReportDataLength, len(reportData))
+	}
+
+	entry, err := p.newEntry(p.path)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create configfs-tsm report entry")
+	}
+	defer os.Remove(entry)
+
+	if err := os.WriteFile(filepath.Join(entry, "inblob"), reportData, 0600); err != nil {
+		return nil, errors.Wrap(err, "cannot write report data")
+	}
+
+	generation, err := readAttribute(entry, "generation")
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	provider, err := readAttribute(entry, "provider")
+	if err != nil {
+		return nil, err
+	}
+	report.Provider = string(bytes.TrimSpace(provider))
+
+	if report.OutBlob, err = readAttribute(entry, "outblob"); err != nil {
+		return nil, err
+	}
+
+	// not all providers have auxiliary data
+	report.AuxBlob, err = readAttribute(entry, "auxblob")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	// the generation is incremented by every write to inblob; detect concurrent writers to our entry
+	current, err := readAttribute(entry, "generation")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(generation, current) {
+		return nil, fmt.Errorf("report entry was modified concurrently")
+	}
+
+	return report, nil
+}
+
+func readAttribute(entry, name string) ([]byte, error) {
+	b, err := os.ReadFile(filepath.Join(entry, name))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", name)
+	}
+	return b, nil
+}
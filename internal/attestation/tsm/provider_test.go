@@ -0,0 +1,69 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package tsm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o ../fakes/report_provider.go -fake-name ReportProvider . ReportProvider
+
+// fakeEntry mimics configfs-tsm by populating a new report entry with the given attributes
+func fakeEntry(t *testing.T, attributes map[string]string) func(string) (string, error) {
+	return func(path string) (string, error) {
+		entry, err := os.MkdirTemp(path, "fpc-")
+		if err != nil {
+			return "", err
+		}
+		for name, value := range attributes {
+			if err := os.WriteFile(filepath.Join(entry, name), []byte(value), 0600); err != nil {
+				return "", err
+			}
+		}
+		t.Cleanup(func() { os.RemoveAll(entry) })
+		return entry, nil
+	}
+}
+
+func TestGetReport(t *testing.T) {
+	p := NewConfigFSProvider(WithPath(t.TempDir())).(*configFSProvider)
+	p.newEntry = fakeEntry(t, map[string]string{
+		"provider":   ProviderSEVSNP + "\n",
+		"outblob":    "report",
+		"auxblob":    "certs",
+		"generation": "1\n",
+	})
+
+	// wrong report data length
+	report, err := p.GetReport([]byte("too short"))
+	assert.EqualError(t, err, "report data must be 64 bytes but got 9")
+	assert.Nil(t, report)
+
+	report, err = p.GetReport(make([]byte, ReportDataLength))
+	assert.NoError(t, err)
+	assert.Equal(t, &Report{Provider: ProviderSEVSNP, OutBlob: []byte("report"), AuxBlob: []byte("certs")}, report)
+
+	// auxblob is optional
+	p.newEntry = fakeEntry(t, map[string]string{
+		"provider":   ProviderTDX,
+		"outblob":    "quote",
+		"generation": "1",
+	})
+	report, err = p.GetReport(make([]byte, ReportDataLength))
+	assert.NoError(t, err)
+	assert.Equal(t, &Report{Provider: ProviderTDX, OutBlob: []byte("quote")}, report)
+
+	// configfs-tsm not available
+	p = NewConfigFSProvider(WithPath(filepath.Join(t.TempDir(), "missing"))).(*configFSProvider)
+	report, err = p.GetReport(make([]byte, ReportDataLength))
+	assert.ErrorContains(t, err, "cannot create configfs-tsm report entry")
+	assert.Nil(t, report)
+}
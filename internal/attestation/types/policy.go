@@ -0,0 +1,142 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AppraisalPolicy defines which verified attestation reports are acceptable.
+// All fields are optional; the zero value only rejects debug enclaves.
+type AppraisalPolicy struct {
+	// AllowedStatuses are the quote statuses accepted unconditionally, e.g., `OK` for EPID or `UpToDate` for DCAP.
+	// If neither AllowedStatuses nor ConditionalStatuses are set, any status accepted by the verifier is accepted.
+	AllowedStatuses []string `json:"allowed_statuses,omitempty"`
+	// ConditionalStatuses are the quote statuses accepted only if all advisory IDs of the report are listed in
+	// AllowedAdvisoryIDs, e.g., `GROUP_OUT_OF_DATE` or `SW_HARDENING_NEEDED`
+	ConditionalStatuses []string `json:"conditional_statuses,omitempty"`
+	AllowedAdvisoryIDs  []string `json:"allowed_advisory_ids,omitempty"`
+	// AllowDebug accepts debug enclaves
+	AllowDebug bool `json:"allow_debug,omitempty"`
+	// MinIsvSvn is the minimum security version of the enclave
+	MinIsvSvn uint16 `json:"min_isv_svn,omitempty"`
+	// AllowedMrsigners are the (hex-encoded) enclave signers accepted; if empty, any signer is accepted
+	AllowedMrsigners []string `json:"allowed_mrsigners,omitempty"`
+	// MaxReportAgeSeconds is the maximum age of the attestation report; if zero, reports never expire
+	MaxReportAgeSeconds int64 `json:"max_report_age_seconds,omitempty"`
+	// CredentialValiditySeconds is the validity of enclave credentials registered (or renewed) under this policy,
+	// after which the enclave must be re-attested; if zero, the ERCC default applies
+	CredentialValiditySeconds int64 `json:"credential_validity_seconds,omitempty"`
+}
+
+// Claims are the properties of a verified attestation report appraised by an AppraisalPolicy
+type Claims struct {
+	Status      string
+	AdvisoryIDs []string
+	Debug       bool
+	IsvSvn      uint16
+	// Mrsigner is the hex-encoded enclave signer
+	Mrsigner string
+	// Timestamp is the time the report was issued
+	Timestamp time.Time
+}
+
+// ParseAppraisalPolicy parses a json-encoded AppraisalPolicy; unknown fields are rejected
+func ParseAppraisalPolicy(policyJson []byte) (*AppraisalPolicy, error) {
+	decoder := json.NewDecoder(bytes.NewReader(policyJson))
+	decoder.DisallowUnknownFields()
+
+	policy := &AppraisalPolicy{}
+	if err := decoder.Decode(policy); err != nil {
+		return nil, errors.Wrap(err, "cannot parse appraisal policy")
+	}
+
+	if policy.MaxReportAgeSeconds < 0 {
+		return nil, fmt.Errorf("max_report_age_seconds must not be negative")
+	}
+
+	if policy.CredentialValiditySeconds < 0 {
+		return nil, fmt.Errorf("credential_validity_seconds must not be negative")
+	}
+
+	return policy, nil
+}
+
+// Appraise checks the claims of a verified attestation report against the policy; now is the reference time used
+// to determine the age of the report
+func (p *AppraisalPolicy) Appraise(claims *Claims, now time.Time) error {
+	if err := p.appraiseStatus(claims); err != nil {
+		return err
+	}
+
+	if claims.Debug && !p.AllowDebug {
+		return fmt.Errorf("debug enclaves are not allowed")
+	}
+
+	if claims.IsvSvn < p.MinIsvSvn {
+		return fmt.Errorf("isv svn %d is lower than required minimum %d", claims.IsvSvn, p.MinIsvSvn)
+	}
+
+	if len(p.AllowedMrsigners) > 0 && !containsFold(p.AllowedMrsigners, claims.Mrsigner) {
+		return fmt.Errorf("mrsigner %s is not allowed", claims.Mrsigner)
+	}
+
+	if p.MaxReportAgeSeconds > 0 {
+		age := now.Sub(claims.Timestamp)
+		if age > time.Duration(p.MaxReportAgeSeconds)*time.Second {
+			return fmt.Errorf("report issued at %s is older than %d seconds", claims.Timestamp.UTC().Format(time.RFC3339), p.MaxReportAgeSeconds)
+		}
+	}
+
+	return nil
+}
+
+func (p *AppraisalPolicy) appraiseStatus(claims *Claims) error {
+	if len(p.AllowedStatuses) == 0 && len(p.ConditionalStatuses) == 0 {
+		return nil
+	}
+
+	if contains(p.AllowedStatuses, claims.Status) {
+		return nil
+	}
+
+	if !contains(p.ConditionalStatuses, claims.Status) {
+		return fmt.Errorf("quote status '%s' is not allowed", claims.Status)
+	}
+
+	for _, id := range claims.AdvisoryIDs {
+		if !contains(p.AllowedAdvisoryIDs, id) {
+			return fmt.Errorf("quote status '%s' with advisory '%s' is not allowed", claims.Status, id)
+		}
+	}
+
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, e := range list {
+		if strings.EqualFold(e, s) {
+			return true
+		}
+	}
+	return false
+}
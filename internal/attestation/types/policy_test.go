@@ -0,0 +1,77 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAppraisalPolicy(t *testing.T) {
+	policy, err := ParseAppraisalPolicy([]byte(`{"allowed_statuses": ["OK"], "min_isv_svn": 2, "max_report_age_seconds": 60}`))
+	require.NoError(t, err)
+	assert.Equal(t, &AppraisalPolicy{AllowedStatuses: []string{"OK"}, MinIsvSvn: 2, MaxReportAgeSeconds: 60}, policy)
+
+	_, err = ParseAppraisalPolicy([]byte(`{"allow_everything": true}`))
+	assert.ErrorContains(t, err, "cannot parse appraisal policy")
+
+	_, err = ParseAppraisalPolicy([]byte(`{"max_report_age_seconds": -1}`))
+	assert.EqualError(t, err, "max_report_age_seconds must not be negative")
+
+	_, err = ParseAppraisalPolicy([]byte(`{"credential_validity_seconds": -1}`))
+	assert.EqualError(t, err, "credential_validity_seconds must not be negative")
+}
+
+func TestAppraise(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	claims := func() *Claims {
+		return &Claims{
+			Status:      "GROUP_OUT_OF_DATE",
+			AdvisoryIDs: []string{"INTEL-SA-00161", "INTEL-SA-00334"},
+			IsvSvn:      2,
+			Mrsigner:    "ABCD",
+			Timestamp:   now.Add(-time.Minute),
+		}
+	}
+
+	// the zero policy only rejects debug enclaves
+	policy := &AppraisalPolicy{}
+	assert.NoError(t, policy.Appraise(claims(), now))
+	debug := claims()
+	debug.Debug = true
+	assert.EqualError(t, policy.Appraise(debug, now), "debug enclaves are not allowed")
+	policy.AllowDebug = true
+	assert.NoError(t, policy.Appraise(debug, now))
+
+	// statuses
+	policy = &AppraisalPolicy{AllowedStatuses: []string{"OK"}}
+	assert.EqualError(t, policy.Appraise(claims(), now), "quote status 'GROUP_OUT_OF_DATE' is not allowed")
+	policy.ConditionalStatuses = []string{"GROUP_OUT_OF_DATE"}
+	policy.AllowedAdvisoryIDs = []string{"INTEL-SA-00161"}
+	assert.EqualError(t, policy.Appraise(claims(), now), "quote status 'GROUP_OUT_OF_DATE' with advisory 'INTEL-SA-00334' is not allowed")
+	policy.AllowedAdvisoryIDs = append(policy.AllowedAdvisoryIDs, "INTEL-SA-00334")
+	assert.NoError(t, policy.Appraise(claims(), now))
+
+	// isv svn
+	policy = &AppraisalPolicy{MinIsvSvn: 3}
+	assert.EqualError(t, policy.Appraise(claims(), now), "isv svn 2 is lower than required minimum 3")
+
+	// mrsigner is compared case-insensitive
+	policy = &AppraisalPolicy{AllowedMrsigners: []string{"abcd"}}
+	assert.NoError(t, policy.Appraise(claims(), now))
+	policy = &AppraisalPolicy{AllowedMrsigners: []string{"0123"}}
+	assert.EqualError(t, policy.Appraise(claims(), now), "mrsigner ABCD is not allowed")
+
+	// report age
+	policy = &AppraisalPolicy{MaxReportAgeSeconds: 60}
+	assert.NoError(t, policy.Appraise(claims(), now))
+	policy.MaxReportAgeSeconds = 59
+	assert.EqualError(t, policy.Appraise(claims(), now), "report issued at 2026-01-01T11:59:00Z is older than 59 seconds")
+}
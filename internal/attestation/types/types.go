@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 
 package types
 
+import "time"
+
 type ConvertFunction func(attestationBytes []byte) (evidenceBytes []byte, err error)
 
 type Converter struct {
@@ -16,9 +18,14 @@ type Converter struct {
 
 type VerifyFunction func(evidence *Evidence, expectedValidationValues *ValidationValues) error
 
+// ClaimsFunction extracts the claims appraised by an AppraisalPolicy from evidence verified by the VerifyFunction
+type ClaimsFunction func(evidence *Evidence) (*Claims, error)
+
 type Verifier struct {
 	Type   string
 	Verify VerifyFunction
+	// Claims is optional; verifiers without Claims do not support appraisal policies
+	Claims ClaimsFunction
 }
 
 type IssueFunction func(customData []byte) ([]byte, error)
@@ -41,4 +48,8 @@ type Evidence struct {
 type ValidationValues struct {
 	Statement []byte
 	Mrenclave string
+	// Policy is the (optional) appraisal policy applied to the verified evidence
+	Policy *AppraisalPolicy
+	// Timestamp is the reference time for the appraisal policy
+	Timestamp time.Time
 }
@@ -9,6 +9,7 @@ package attestation
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
@@ -16,12 +17,12 @@ import (
 )
 
 type verifierDispatcher struct {
-	verifiers map[string]types.VerifyFunction
+	verifiers map[string]*types.Verifier
 }
 
 func newVerifierDispatcher() *verifierDispatcher {
 	return &verifierDispatcher{
-		verifiers: make(map[string]types.VerifyFunction),
+		verifiers: make(map[string]*types.Verifier),
 	}
 }
 
@@ -32,19 +33,43 @@ func (d *verifierDispatcher) Register(verifiers ...*types.Verifier) error {
 			return fmt.Errorf("'%s' type is already registered", v.Type)
 		}
 		logger.Debugf("Register verifier of type '%s'", v.Type)
-		d.verifiers[v.Type] = v.Verify
+		d.verifiers[v.Type] = v
 	}
 	return nil
 }
 
+// Verify performs the evidence verification with help of the registered Verifier.
+// If the expected validation values contain an appraisal policy, the policy is applied to the claims of the
+// verified evidence.
 func (d *verifierDispatcher) Verify(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) error {
-	verify, ok := d.verifiers[evidence.Type]
+	verifier, ok := d.verifiers[evidence.Type]
 	if !ok {
 		return fmt.Errorf("'%s' type is not registered", evidence.Type)
 	}
 
 	logger.Debugf("Invoke verifier of type '%s'", evidence.Type)
-	return verify(evidence, expectedValidationValues)
+	if err := verifier.Verify(evidence, expectedValidationValues); err != nil {
+		return err
+	}
+
+	policy := expectedValidationValues.Policy
+	if policy == nil {
+		return nil
+	}
+
+	if verifier.Claims == nil {
+		return fmt.Errorf("'%s' type does not support appraisal policies", evidence.Type)
+	}
+
+	claims, err := verifier.Claims(evidence)
+	if err != nil {
+		return errors.Wrap(err, "cannot get claims for appraisal")
+	}
+
+	if err := policy.Appraise(claims, expectedValidationValues.Timestamp); err != nil {
+		return errors.Wrap(err, "appraisal policy not satisfied")
+	}
+	return nil
 }
 
 type CredentialVerifier struct {
@@ -53,6 +78,7 @@ type CredentialVerifier struct {
 
 type Verifier interface {
 	VerifyCredentials(credentials *protos.Credentials, expectedMrenclave string) (err error)
+	VerifyCredentialsWithPolicy(credentials *protos.Credentials, expectedMrenclave string, policy *types.AppraisalPolicy, timestamp time.Time) (err error)
 }
 
 func NewCredentialVerifier(verifier ...*types.Verifier) *CredentialVerifier {
@@ -67,6 +93,12 @@ func NewCredentialVerifier(verifier ...*types.Verifier) *CredentialVerifier {
 }
 
 func (c *CredentialVerifier) VerifyCredentials(credentials *protos.Credentials, expectedMrenclave string) error {
+	return c.VerifyCredentialsWithPolicy(credentials, expectedMrenclave, nil, time.Time{})
+}
+
+// VerifyCredentialsWithPolicy verifies the credentials and, if a policy is given, applies the appraisal policy to
+// the verified evidence; timestamp is the reference time to determine the age of the attestation report
+func (c *CredentialVerifier) VerifyCredentialsWithPolicy(credentials *protos.Credentials, expectedMrenclave string, policy *types.AppraisalPolicy, timestamp time.Time) error {
 
 	evidence, err := unmarshalEvidence(credentials.Evidence)
 	if err != nil {
@@ -76,6 +108,8 @@ func (c *CredentialVerifier) VerifyCredentials(credentials *protos.Credentials,
 	expectedValues := &types.ValidationValues{
 		Statement: credentials.SerializedAttestedData.Value,
 		Mrenclave: expectedMrenclave,
+		Policy:    policy,
+		Timestamp: timestamp,
 	}
 
 	return c.dispatcher.Verify(evidence, expectedValues)
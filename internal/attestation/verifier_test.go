@@ -8,6 +8,7 @@ package attestation
 
 import (
 	"testing"
+	"time"
 
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
@@ -56,3 +57,35 @@ func TestVerifier(t *testing.T) {
 	err = d.Register(simulation.NewSimulationVerifier())
 	assert.NoError(t, err)
 }
+
+func TestVerifierWithPolicy(t *testing.T) {
+	d := newVerifierDispatcher()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	claims := &types.Claims{Status: "OK", Timestamp: now.Add(-time.Hour)}
+	err := d.Register(NewDummyVerifier(), &types.Verifier{
+		Type: "dummy-with-claims",
+		Verify: func(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) error {
+			return nil
+		},
+		Claims: func(evidence *types.Evidence) (*types.Claims, error) {
+			return claims, nil
+		},
+	})
+	assert.NoError(t, err)
+
+	policy := &types.AppraisalPolicy{AllowedStatuses: []string{"OK"}, MaxReportAgeSeconds: 7200}
+	ref := &types.ValidationValues{Policy: policy, Timestamp: now}
+
+	// verifiers without claims cannot be used with a policy
+	err = d.Verify(&types.Evidence{Type: "dummy"}, ref)
+	assert.EqualError(t, err, "'dummy' type does not support appraisal policies")
+
+	err = d.Verify(&types.Evidence{Type: "dummy-with-claims"}, ref)
+	assert.NoError(t, err)
+
+	// report too old
+	policy.MaxReportAgeSeconds = 60
+	err = d.Verify(&types.Evidence{Type: "dummy-with-claims"}, ref)
+	assert.ErrorContains(t, err, "appraisal policy not satisfied: report issued at")
+}
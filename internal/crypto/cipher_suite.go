@@ -0,0 +1,287 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/pkg/errors"
+)
+
+const (
+	// AES256KeyLength is the key length of the AES-256-GCM encryption of the ECIES and hybrid cipher suites
+	AES256KeyLength = 32
+)
+
+// DefaultCipherSuites are the cipher suites used by clients in order of preference
+var DefaultCipherSuites = []protos.CipherSuite{
+	protos.CipherSuite_CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM,
+	protos.CipherSuite_CIPHER_SUITE_ECIES_P256_HKDF_SHA256_AES256_GCM,
+	protos.CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM,
+}
+
+// PostQuantumCipherSuites are the cipher suites for clients that opt in to the hybrid post-quantum key transport,
+// i.e., DefaultCipherSuites preceded by CIPHER_SUITE_HYBRID_MLKEM768_X25519_HKDF_SHA256_AES256_GCM
+var PostQuantumCipherSuites = append([]protos.CipherSuite{
+	protos.CipherSuite_CIPHER_SUITE_HYBRID_MLKEM768_X25519_HKDF_SHA256_AES256_GCM,
+}, DefaultCipherSuites...)
+
+// SupportedCipherSuites are the cipher suites supported by the go implementations (and advertised by the go enclave)
+// in order of preference
+var SupportedCipherSuites = PostQuantumCipherSuites
+
+// CipherSuite implements the algorithms of a protos.CipherSuite, i.e., the (asymmetric) key transport with the
+// chaincode encryption key and the (symmetric) encryption of requests and responses.
+type CipherSuite interface {
+	ID() protos.CipherSuite
+	NewKeyTransportKeys() (publicKey []byte, privateKey []byte, e error)
+	PkEncryptMessage(publicKey []byte, message []byte) ([]byte, error)
+	PkDecryptMessage(privateKey []byte, encryptedMessage []byte) (message []byte, e error)
+	NewSymmetricKey() ([]byte, error)
+	EncryptMessage(key []byte, message []byte) (encryptedMessage []byte, e error)
+	DecryptMessage(key []byte, encryptedMessage []byte) ([]byte, error)
+}
+
+// NewCipherSuite returns the implementation of a cipher suite. CIPHER_SUITE_RSA_OAEP_AES128_GCM is implemented by
+// the given CSP, which keeps it compatible with the PDO crypto implementation of the C++ enclave; the ECIES and the
+// hybrid suites are implemented in pure go.
+func NewCipherSuite(csp CSP, id protos.CipherSuite) (CipherSuite, error) {
+	switch id {
+	case protos.CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM:
+		return &cspCipherSuite{CSP: csp}, nil
+	case protos.CipherSuite_CIPHER_SUITE_ECIES_P256_HKDF_SHA256_AES256_GCM:
+		return &eciesCipherSuite{id: id, curve: ecdh.P256()}, nil
+	case protos.CipherSuite_CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM:
+		return &eciesCipherSuite{id: id, curve: ecdh.X25519()}, nil
+	case protos.CipherSuite_CIPHER_SUITE_HYBRID_MLKEM768_X25519_HKDF_SHA256_AES256_GCM:
+		return &hybridCipherSuite{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported cipher suite %v", id)
+	}
+}
+
+// NegotiateCipherSuite returns the first of the preferred cipher suites that is supported by the chaincode, together
+// with the chaincode encryption key for this suite
+func NegotiateCipherSuite(preferred []protos.CipherSuite, chaincodeEks []*protos.ChaincodeEncryptionKey) (protos.CipherSuite, []byte, error) {
+	for _, id := range preferred {
+		for _, ek := range chaincodeEks {
+			if ek.GetCipherSuite() == id {
+				return id, ek.GetChaincodeEk(), nil
+			}
+		}
+	}
+
+	supported := make([]protos.CipherSuite, 0, len(chaincodeEks))
+	for _, ek := range chaincodeEks {
+		supported = append(supported, ek.GetCipherSuite())
+	}
+	return 0, nil, fmt.Errorf("no common cipher suite (preferred: %v, supported by chaincode: %v)", preferred, supported)
+}
+
+// cspCipherSuite implements CIPHER_SUITE_RSA_OAEP_AES128_GCM with the CSP
+type cspCipherSuite struct {
+	CSP
+}
+
+func (s *cspCipherSuite) ID() protos.CipherSuite {
+	return protos.CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM
+}
+
+func (s *cspCipherSuite) NewKeyTransportKeys() (publicKey []byte, privateKey []byte, e error) {
+	return s.NewRSAKeys()
+}
+
+// eciesCipherSuite implements the ECIES suites: the key transport encrypts with AES-256-GCM under a key derived with
+// HKDF-SHA256 from an ECDH with an ephemeral key; the encrypted message is the ephemeral public key followed by the
+// AES-GCM ciphertext (nonce + tag + ciphertext, as for EncryptMessage).
+type eciesCipherSuite struct {
+	aes256GCM
+	id    protos.CipherSuite
+	curve ecdh.Curve
+}
+
+func (s *eciesCipherSuite) ID() protos.CipherSuite {
+	return s.id
+}
+
+func (s *eciesCipherSuite) NewKeyTransportKeys() (publicKey []byte, privateKey []byte, e error) {
+	pri, err := s.curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot generate ecdh key")
+	}
+
+	pkcs8Pri, err := x509.MarshalPKCS8PrivateKey(pri)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot serialize private key")
+	}
+	privateKey = pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: pkcs8Pri,
+	})
+
+	pkixPub, err := x509.MarshalPKIXPublicKey(pri.PublicKey())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot serialize public key")
+	}
+	publicKey = pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: pkixPub,
+	})
+
+	return publicKey, privateKey, nil
+}
+
+func (s *eciesCipherSuite) PkEncryptMessage(publicKey []byte, message []byte) ([]byte, error) {
+	pub, err := s.parsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeral, err := s.curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot generate ephemeral key")
+	}
+
+	key, err := s.deriveKey(ephemeral, pub, ephemeral.PublicKey().Bytes(), pub.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := s.EncryptMessage(key, message)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(ephemeral.PublicKey().Bytes(), ciphertext...), nil
+}
+
+func (s *eciesCipherSuite) PkDecryptMessage(privateKey []byte, encryptedMessage []byte) (message []byte, e error) {
+	pri, err := s.parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeralLength := len(pri.PublicKey().Bytes())
+	if len(encryptedMessage) <= ephemeralLength {
+		return nil, fmt.Errorf("encrypted message to small. expect len to be larger than %d, actual %d", ephemeralLength, len(encryptedMessage))
+	}
+
+	ephemeral, err := s.curve.NewPublicKey(encryptedMessage[:ephemeralLength])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid ephemeral public key")
+	}
+
+	key, err := s.deriveKey(pri, ephemeral, ephemeral.Bytes(), pri.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.DecryptMessage(key, encryptedMessage[ephemeralLength:])
+}
+
+// deriveKey derives the AES-256 key from the ECDH shared secret; the info binds the suite and both public keys
+func (s *eciesCipherSuite) deriveKey(pri *ecdh.PrivateKey, pub *ecdh.PublicKey, ephemeralPublicKey, recipientPublicKey []byte) ([]byte, error) {
+	secret, err := pri.ECDH(pub)
+	if err != nil {
+		return nil, errors.Wrap(err, "ecdh failed")
+	}
+
+	info := append([]byte(s.id.String()), ephemeralPublicKey...)
+	info = append(info, recipientPublicKey...)
+	return hkdf.Key(sha256.New, secret, nil, string(info), AES256KeyLength)
+}
+
+func (s *eciesCipherSuite) parsePublicKey(publicKey []byte) (*ecdh.PublicKey, error) {
+	block, _ := pem.Decode(publicKey)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("failed to decode PEM block containing public key")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse public key")
+	}
+
+	var ecdhPub *ecdh.PublicKey
+	switch k := pub.(type) {
+	case *ecdh.PublicKey:
+		ecdhPub = k
+	case *ecdsa.PublicKey:
+		if ecdhPub, err = k.ECDH(); err != nil {
+			return nil, errors.Wrap(err, "cannot convert public key")
+		}
+	default:
+		return nil, fmt.Errorf("unexpected public key type %T", pub)
+	}
+
+	if ecdhPub.Curve() != s.curve {
+		return nil, fmt.Errorf("public key does not match cipher suite %v", s.id)
+	}
+	return ecdhPub, nil
+}
+
+func (s *eciesCipherSuite) parsePrivateKey(privateKey []byte) (*ecdh.PrivateKey, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("failed to decode PEM block containing private key")
+	}
+
+	pri, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse private key")
+	}
+
+	var ecdhPri *ecdh.PrivateKey
+	switch k := pri.(type) {
+	case *ecdh.PrivateKey:
+		ecdhPri = k
+	case *ecdsa.PrivateKey:
+		if ecdhPri, err = k.ECDH(); err != nil {
+			return nil, errors.Wrap(err, "cannot convert private key")
+		}
+	default:
+		return nil, fmt.Errorf("unexpected private key type %T", pri)
+	}
+
+	if ecdhPri.Curve() != s.curve {
+		return nil, fmt.Errorf("private key does not match cipher suite %v", s.id)
+	}
+	return ecdhPri, nil
+}
+
+// aes256GCM implements the (symmetric) encryption of requests and responses with AES-256-GCM
+type aes256GCM struct{}
+
+func (aes256GCM) NewSymmetricKey() ([]byte, error) {
+	key := make([]byte, AES256KeyLength)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (aes256GCM) EncryptMessage(key []byte, message []byte) (encryptedMessage []byte, e error) {
+	if len(key) != AES256KeyLength {
+		return nil, fmt.Errorf("invalid key length %d, expected %d", len(key), AES256KeyLength)
+	}
+	return GoCrypto{}.EncryptMessage(key, message)
+}
+
+func (aes256GCM) DecryptMessage(key []byte, encryptedMessage []byte) ([]byte, error) {
+	if len(key) != AES256KeyLength {
+		return nil, fmt.Errorf("invalid key length %d, expected %d", len(key), AES256KeyLength)
+	}
+	return GoCrypto{}.DecryptMessage(key, encryptedMessage)
+}
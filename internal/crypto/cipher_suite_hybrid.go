@@ -0,0 +1,188 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/mlkem"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/pkg/errors"
+)
+
+const (
+	hybridPublicKeyPEMType  = "FPC HYBRID MLKEM768 X25519 PUBLIC KEY"
+	hybridPrivateKeyPEMType = "FPC HYBRID MLKEM768 X25519 PRIVATE KEY"
+
+	x25519KeySize = 32
+
+	hybridPublicKeySize  = mlkem.EncapsulationKeySize768 + x25519KeySize
+	hybridPrivateKeySize = mlkem.SeedSize + x25519KeySize
+	hybridHeaderSize     = mlkem.CiphertextSize768 + x25519KeySize
+)
+
+// hybridCipherSuite implements CIPHER_SUITE_HYBRID_MLKEM768_X25519_HKDF_SHA256_AES256_GCM: the key transport
+// encrypts with AES-256-GCM under a key derived with HKDF-SHA256 from both, an ML-KEM-768 encapsulation and an X25519
+// ECDH with an ephemeral key; hence, it remains secure as long as one of both is not broken.
+//
+// The public key is a PEM block holding the ML-KEM-768 encapsulation key followed by the X25519 public key; the
+// private key is a PEM block holding the ML-KEM-768 seed followed by the X25519 private key. The encrypted message
+// is the ML-KEM-768 ciphertext, followed by the ephemeral X25519 public key, followed by the AES-GCM ciphertext
+// (nonce + tag + ciphertext, as for EncryptMessage).
+type hybridCipherSuite struct {
+	aes256GCM
+}
+
+func (s *hybridCipherSuite) ID() protos.CipherSuite {
+	return protos.CipherSuite_CIPHER_SUITE_HYBRID_MLKEM768_X25519_HKDF_SHA256_AES256_GCM
+}
+
+func (s *hybridCipherSuite) NewKeyTransportKeys() (publicKey []byte, privateKey []byte, e error) {
+	dk, err := mlkem.GenerateKey768()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot generate ml-kem key")
+	}
+
+	pri, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot generate ecdh key")
+	}
+
+	privateKey = pem.EncodeToMemory(&pem.Block{
+		Type:  hybridPrivateKeyPEMType,
+		Bytes: append(dk.Bytes(), pri.Bytes()...),
+	})
+	publicKey = pem.EncodeToMemory(&pem.Block{
+		Type:  hybridPublicKeyPEMType,
+		Bytes: append(dk.EncapsulationKey().Bytes(), pri.PublicKey().Bytes()...),
+	})
+
+	return publicKey, privateKey, nil
+}
+
+func (s *hybridCipherSuite) PkEncryptMessage(publicKey []byte, message []byte) ([]byte, error) {
+	ek, pub, err := s.parsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	kemSecret, kemCiphertext := ek.Encapsulate()
+
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot generate ephemeral key")
+	}
+	ecdhSecret, err := ephemeral.ECDH(pub)
+	if err != nil {
+		return nil, errors.Wrap(err, "ecdh failed")
+	}
+
+	header := append(kemCiphertext, ephemeral.PublicKey().Bytes()...)
+	key, err := s.deriveKey(kemSecret, ecdhSecret, header, pub.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := s.EncryptMessage(key, message)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(header, ciphertext...), nil
+}
+
+func (s *hybridCipherSuite) PkDecryptMessage(privateKey []byte, encryptedMessage []byte) (message []byte, e error) {
+	dk, pri, err := s.parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(encryptedMessage) <= hybridHeaderSize {
+		return nil, fmt.Errorf("encrypted message to small. expect len to be larger than %d, actual %d", hybridHeaderSize, len(encryptedMessage))
+	}
+	header := encryptedMessage[:hybridHeaderSize]
+
+	kemSecret, err := dk.Decapsulate(header[:mlkem.CiphertextSize768])
+	if err != nil {
+		return nil, errors.Wrap(err, "ml-kem decapsulation failed")
+	}
+
+	ephemeral, err := ecdh.X25519().NewPublicKey(header[mlkem.CiphertextSize768:])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid ephemeral public key")
+	}
+	ecdhSecret, err := pri.ECDH(ephemeral)
+	if err != nil {
+		return nil, errors.Wrap(err, "ecdh failed")
+	}
+
+	key, err := s.deriveKey(kemSecret, ecdhSecret, header, pri.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.DecryptMessage(key, encryptedMessage[hybridHeaderSize:])
+}
+
+// deriveKey derives the AES-256 key from the concatenation of both shared secrets; the info binds the suite, the
+// ML-KEM ciphertext, the ephemeral public key (i.e., the header) and the recipient X25519 public key
+func (s *hybridCipherSuite) deriveKey(kemSecret, ecdhSecret, header, recipientPublicKey []byte) ([]byte, error) {
+	secret := append(append([]byte{}, kemSecret...), ecdhSecret...)
+
+	info := append([]byte(s.ID().String()), header...)
+	info = append(info, recipientPublicKey...)
+	return hkdf.Key(sha256.New, secret, nil, string(info), AES256KeyLength)
+}
+
+func (s *hybridCipherSuite) parsePublicKey(publicKey []byte) (*mlkem.EncapsulationKey768, *ecdh.PublicKey, error) {
+	block, _ := pem.Decode(publicKey)
+	if block == nil || block.Type != hybridPublicKeyPEMType {
+		return nil, nil, fmt.Errorf("failed to decode PEM block containing public key")
+	}
+	if len(block.Bytes) != hybridPublicKeySize {
+		return nil, nil, fmt.Errorf("invalid public key length %d, expected %d", len(block.Bytes), hybridPublicKeySize)
+	}
+
+	ek, err := mlkem.NewEncapsulationKey768(block.Bytes[:mlkem.EncapsulationKeySize768])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot parse ml-kem encapsulation key")
+	}
+
+	pub, err := ecdh.X25519().NewPublicKey(block.Bytes[mlkem.EncapsulationKeySize768:])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot parse ecdh public key")
+	}
+
+	return ek, pub, nil
+}
+
+func (s *hybridCipherSuite) parsePrivateKey(privateKey []byte) (*mlkem.DecapsulationKey768, *ecdh.PrivateKey, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil || block.Type != hybridPrivateKeyPEMType {
+		return nil, nil, fmt.Errorf("failed to decode PEM block containing private key")
+	}
+	if len(block.Bytes) != hybridPrivateKeySize {
+		return nil, nil, fmt.Errorf("invalid private key length %d, expected %d", len(block.Bytes), hybridPrivateKeySize)
+	}
+
+	dk, err := mlkem.NewDecapsulationKey768(block.Bytes[:mlkem.SeedSize])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot parse ml-kem decapsulation key")
+	}
+
+	pri, err := ecdh.X25519().NewPrivateKey(block.Bytes[mlkem.SeedSize:])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot parse ecdh private key")
+	}
+
+	return dk, pri, nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCipherSuites(t *testing.T) {
+	msg := []byte("some message")
+
+	for _, tc := range allTestCases {
+		for _, id := range SupportedCipherSuites {
+			suite, err := NewCipherSuite(tc.CSP, id)
+			require.NoError(t, err)
+			assert.Equal(t, id, suite.ID())
+
+			// key transport
+			pubKey, privKey, err := suite.NewKeyTransportKeys()
+			require.NoError(t, err, id)
+
+			cipher, err := suite.PkEncryptMessage(pubKey, msg)
+			require.NoError(t, err, id)
+			plain, err := suite.PkDecryptMessage(privKey, cipher)
+			assert.NoError(t, err, id)
+			assert.Equal(t, msg, plain, id)
+
+			// tampered ciphertext
+			cipher[len(cipher)-1] ^= 0x01
+			_, err = suite.PkDecryptMessage(privKey, cipher)
+			assert.Error(t, err, id)
+
+			// another key
+			_, otherPrivKey, err := suite.NewKeyTransportKeys()
+			require.NoError(t, err, id)
+			cipher, err = suite.PkEncryptMessage(pubKey, msg)
+			require.NoError(t, err, id)
+			_, err = suite.PkDecryptMessage(otherPrivKey, cipher)
+			assert.Error(t, err, id)
+
+			// message encryption
+			key, err := suite.NewSymmetricKey()
+			require.NoError(t, err, id)
+			cipher, err = suite.EncryptMessage(key, msg)
+			require.NoError(t, err, id)
+			plain, err = suite.DecryptMessage(key, cipher)
+			assert.NoError(t, err, id)
+			assert.Equal(t, msg, plain, id)
+		}
+	}
+
+	_, err := NewCipherSuite(NewGoCrypto(), protos.CipherSuite(42))
+	assert.EqualError(t, err, "unsupported cipher suite 42")
+}
+
+func TestECIESCipherSuites(t *testing.T) {
+	p256, err := NewCipherSuite(NewGoCrypto(), protos.CipherSuite_CIPHER_SUITE_ECIES_P256_HKDF_SHA256_AES256_GCM)
+	require.NoError(t, err)
+	x25519, err := NewCipherSuite(NewGoCrypto(), protos.CipherSuite_CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM)
+	require.NoError(t, err)
+
+	// keys of another suite are rejected
+	p256Pub, _, err := p256.NewKeyTransportKeys()
+	require.NoError(t, err)
+	_, err = x25519.PkEncryptMessage(p256Pub, []byte("some message"))
+	assert.ErrorContains(t, err, "public key does not match cipher suite")
+
+	rsaPub, _, err := NewGoCrypto().NewRSAKeys()
+	require.NoError(t, err)
+	_, err = p256.PkEncryptMessage(rsaPub, []byte("some message"))
+	assert.ErrorContains(t, err, "failed to decode PEM block containing public key")
+
+	// AES-256-GCM only
+	aes128Key, err := NewGoCrypto().NewSymmetricKey()
+	require.NoError(t, err)
+	_, err = p256.EncryptMessage(aes128Key, []byte("some message"))
+	assert.EqualError(t, err, "invalid key length 16, expected 32")
+}
+
+func TestHybridCipherSuite(t *testing.T) {
+	hybrid, err := NewCipherSuite(NewGoCrypto(), protos.CipherSuite_CIPHER_SUITE_HYBRID_MLKEM768_X25519_HKDF_SHA256_AES256_GCM)
+	require.NoError(t, err)
+	x25519, err := NewCipherSuite(NewGoCrypto(), protos.CipherSuite_CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM)
+	require.NoError(t, err)
+
+	pubKey, privKey, err := hybrid.NewKeyTransportKeys()
+	require.NoError(t, err)
+	cipher, err := hybrid.PkEncryptMessage(pubKey, []byte("some message"))
+	require.NoError(t, err)
+
+	// tampering with the ml-kem ciphertext or the ephemeral key breaks the key derivation
+	for _, i := range []int{0, hybridHeaderSize - 1} {
+		tampered := append([]byte{}, cipher...)
+		tampered[i] ^= 0x01
+		_, err = hybrid.PkDecryptMessage(privKey, tampered)
+		assert.Error(t, err, i)
+	}
+
+	_, err = hybrid.PkDecryptMessage(privKey, cipher[:hybridHeaderSize])
+	assert.ErrorContains(t, err, "encrypted message to small")
+
+	// keys of another suite are rejected
+	x25519Pub, x25519Priv, err := x25519.NewKeyTransportKeys()
+	require.NoError(t, err)
+	_, err = hybrid.PkEncryptMessage(x25519Pub, []byte("some message"))
+	assert.EqualError(t, err, "failed to decode PEM block containing public key")
+	_, err = hybrid.PkDecryptMessage(x25519Priv, cipher)
+	assert.EqualError(t, err, "failed to decode PEM block containing private key")
+	_, err = x25519.PkEncryptMessage(pubKey, []byte("some message"))
+	assert.Error(t, err)
+
+	// AES-256-GCM only
+	aes128Key, err := NewGoCrypto().NewSymmetricKey()
+	require.NoError(t, err)
+	_, err = hybrid.EncryptMessage(aes128Key, []byte("some message"))
+	assert.EqualError(t, err, "invalid key length 16, expected 32")
+}
+
+func TestNegotiateCipherSuite(t *testing.T) {
+	eks := []*protos.ChaincodeEncryptionKey{
+		{CipherSuite: protos.CipherSuite_CIPHER_SUITE_ECIES_P256_HKDF_SHA256_AES256_GCM, ChaincodeEk: []byte("p256 key")},
+		{CipherSuite: protos.CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM, ChaincodeEk: []byte("rsa key")},
+	}
+
+	suite, ek, err := NegotiateCipherSuite(DefaultCipherSuites, eks)
+	assert.NoError(t, err)
+	assert.Equal(t, protos.CipherSuite_CIPHER_SUITE_ECIES_P256_HKDF_SHA256_AES256_GCM, suite)
+	assert.Equal(t, []byte("p256 key"), ek)
+
+	// the client preference wins
+	suite, ek, err = NegotiateCipherSuite([]protos.CipherSuite{protos.CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM, protos.CipherSuite_CIPHER_SUITE_ECIES_P256_HKDF_SHA256_AES256_GCM}, eks)
+	assert.NoError(t, err)
+	assert.Equal(t, protos.CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM, suite)
+	assert.Equal(t, []byte("rsa key"), ek)
+
+	// the hybrid suite is only used if the chaincode supports it
+	suite, _, err = NegotiateCipherSuite(PostQuantumCipherSuites, eks)
+	assert.NoError(t, err)
+	assert.Equal(t, protos.CipherSuite_CIPHER_SUITE_ECIES_P256_HKDF_SHA256_AES256_GCM, suite)
+
+	_, _, err = NegotiateCipherSuite([]protos.CipherSuite{protos.CipherSuite_CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM}, eks)
+	assert.ErrorContains(t, err, "no common cipher suite")
+}
@@ -24,42 +24,145 @@ type EncryptionProvider interface {
 	NewEncryptionContext() (EncryptionContext, error)
 }
 
+// BatchEncryptionProvider is implemented by encryption providers that create the encryption contexts for all requests
+// of a batch at once, such that the chaincode encryption key is retrieved only once per batch
+type BatchEncryptionProvider interface {
+	EncryptionProvider
+	NewEncryptionContexts(n int) ([]EncryptionContext, error)
+}
+
 type EncryptionProviderImpl struct {
+	// CSP performs the cryptographic operations, i.e., the encryption with the chaincode public key and ephemeral
+	// symmetric keys; if not set, GetDefaultCSP() is used
 	CSP                CSP
 	GetCcEncryptionKey func() ([]byte, error)
+
+	// GetCcEncryptionKeys returns the (base64-encoded) ChaincodeEncryptionKeys advertised by the chaincode. If set,
+	// the cipher suite is negotiated with CipherSuites; otherwise, or if it fails (e.g., with an ERCC that does not
+	// provide the keys yet) and CipherSuites include it, CIPHER_SUITE_RSA_OAEP_AES128_GCM is used with the key
+	// returned by GetCcEncryptionKey.
+	GetCcEncryptionKeys func() ([]byte, error)
+
+	// CipherSuites are the cipher suites acceptable to the client in order of preference (default DefaultCipherSuites)
+	CipherSuites []protos.CipherSuite
 }
 
 func (p EncryptionProviderImpl) NewEncryptionContext() (EncryptionContext, error) {
-	// pick request encryption key
-	requestEncryptionKey, err := p.CSP.NewSymmetricKey()
+	suite, ccEncryptionKey, err := p.negotiateCipherSuite()
 	if err != nil {
 		return nil, err
 	}
+	return p.newEncryptionContext(suite, ccEncryptionKey)
+}
 
-	// pick response encryption key
-	resultEncryptionKey, err := p.CSP.NewSymmetricKey()
+// NewEncryptionContexts returns n encryption contexts, each with its own request and response keys, for the requests
+// of a batch; the cipher suite is negotiated only once for all of them.
+func (p EncryptionProviderImpl) NewEncryptionContexts(n int) ([]EncryptionContext, error) {
+	suite, ccEncryptionKey, err := p.negotiateCipherSuite()
 	if err != nil {
 		return nil, err
 	}
 
-	ccEncryptionKey, err := p.GetCcEncryptionKey()
+	ctxs := make([]EncryptionContext, n)
+	for i := range ctxs {
+		if ctxs[i], err = p.newEncryptionContext(suite, ccEncryptionKey); err != nil {
+			return nil, err
+		}
+	}
+	return ctxs, nil
+}
+
+func (p EncryptionProviderImpl) newEncryptionContext(suite CipherSuite, ccEncryptionKey []byte) (EncryptionContext, error) {
+	// pick request encryption key
+	requestEncryptionKey, err := suite.NewSymmetricKey()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get chaincode encryption key from ercc: %s", err.Error())
+		return nil, err
 	}
-	//decode key
-	ccEncryptionKey, err = base64.StdEncoding.DecodeString(string(ccEncryptionKey))
+
+	// pick response encryption key
+	resultEncryptionKey, err := suite.NewSymmetricKey()
 	if err != nil {
 		return nil, err
 	}
 
 	return &EncryptionContextImpl{
-		csp:                    p.CSP,
+		csp:                    p.csp(),
+		suite:                  suite,
 		requestEncryptionKey:   requestEncryptionKey,
 		responseEncryptionKey:  resultEncryptionKey,
 		chaincodeEncryptionKey: ccEncryptionKey,
 	}, nil
 }
 
+// csp returns the CSP of the provider or the default CSP if not set
+func (p EncryptionProviderImpl) csp() CSP {
+	if p.CSP == nil {
+		return GetDefaultCSP()
+	}
+	return p.CSP
+}
+
+// negotiateCipherSuite returns the cipher suite and the corresponding chaincode encryption key
+func (p EncryptionProviderImpl) negotiateCipherSuite() (CipherSuite, []byte, error) {
+	if p.GetCcEncryptionKeys == nil {
+		return p.legacyCipherSuite()
+	}
+
+	preferred := p.CipherSuites
+	if len(preferred) == 0 {
+		preferred = DefaultCipherSuites
+	}
+
+	ccEncryptionKeysBytes, err := p.GetCcEncryptionKeys()
+	if err != nil {
+		// ERCC before cipher suite negotiation only provides the chaincode encryption key for RSA-OAEP
+		if p.GetCcEncryptionKey != nil && containsCipherSuite(preferred, protos.CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM) {
+			logger.Debugf("cannot get chaincode encryption keys, falling back to CIPHER_SUITE_RSA_OAEP_AES128_GCM: %s", err)
+			return p.legacyCipherSuite()
+		}
+		return nil, nil, fmt.Errorf("failed to get chaincode encryption keys from ercc: %s", err.Error())
+	}
+	ccEncryptionKeys, err := utils.UnmarshalChaincodeEncryptionKeys(string(ccEncryptionKeysBytes))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot decode chaincode encryption keys")
+	}
+
+	id, ccEncryptionKey, err := NegotiateCipherSuite(preferred, ccEncryptionKeys.GetChaincodeEks())
+	if err != nil {
+		return nil, nil, err
+	}
+	logger.Debugf("using cipher suite %v", id)
+
+	suite, err := NewCipherSuite(p.csp(), id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return suite, ccEncryptionKey, nil
+}
+
+// legacyCipherSuite returns CIPHER_SUITE_RSA_OAEP_AES128_GCM and the chaincode encryption key of GetCcEncryptionKey
+func (p EncryptionProviderImpl) legacyCipherSuite() (CipherSuite, []byte, error) {
+	ccEncryptionKey, err := p.GetCcEncryptionKey()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get chaincode encryption key from ercc: %s", err.Error())
+	}
+	//decode key
+	ccEncryptionKey, err = base64.StdEncoding.DecodeString(string(ccEncryptionKey))
+	if err != nil {
+		return nil, nil, err
+	}
+	return &cspCipherSuite{CSP: p.csp()}, ccEncryptionKey, nil
+}
+
+func containsCipherSuite(suites []protos.CipherSuite, id protos.CipherSuite) bool {
+	for _, suite := range suites {
+		if suite == id {
+			return true
+		}
+	}
+	return false
+}
+
 // EncryptionContext defines the interface of an object responsible to encrypt the contents of a transaction invocation
 // and to decrypt the corresponding response.
 // Conceal and Reveal must be called only once during the lifetime of an object that implements this interface. That is,
@@ -67,16 +170,74 @@ func (p EncryptionProviderImpl) NewEncryptionContext() (EncryptionContext, error
 type EncryptionContext interface {
 	Conceal(function string, args []string) (string, error)
 	Reveal(r []byte) ([]byte, error)
+	// RevealBatchResponse is like Reveal for the response of a batch invocation, where index is the position of
+	// the request concealed with this EncryptionContext within the batch.
+	RevealBatchResponse(r []byte, index int) ([]byte, error)
 }
 
 type EncryptionContextImpl struct {
 	csp                    CSP
+	suite                  CipherSuite
 	requestEncryptionKey   []byte
 	responseEncryptionKey  []byte
 	chaincodeEncryptionKey []byte
 }
 
+// cipherSuite returns the negotiated cipher suite; without, CIPHER_SUITE_RSA_OAEP_AES128_GCM is used with the CSP
+func (e *EncryptionContextImpl) cipherSuite() CipherSuite {
+	if e.suite == nil {
+		return &cspCipherSuite{CSP: e.csp}
+	}
+	return e.suite
+}
+
 func (e *EncryptionContextImpl) Reveal(signedResponseBytesB64 []byte) ([]byte, error) {
+	response, err := extractChaincodeResponseMessage(signedResponseBytesB64)
+	if err != nil {
+		return nil, err
+	}
+
+	clearResponseBytes, err := e.cipherSuite().DecryptMessage(e.responseEncryptionKey, response.EncryptedResponse)
+	if err != nil {
+		return nil, errors.Wrap(err, "decryption of response failed")
+	}
+
+	return unpadResponse(response, clearResponseBytes)
+}
+
+func (e *EncryptionContextImpl) RevealBatchResponse(signedResponseBytesB64 []byte, index int) ([]byte, error) {
+	response, err := extractChaincodeResponseMessage(signedResponseBytesB64)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedResponses := response.GetEncryptedBatchResponses()
+	if index < 0 || index >= len(encryptedResponses) {
+		return nil, fmt.Errorf("no response for batch request %d, batch response contains %d responses", index, len(encryptedResponses))
+	}
+
+	clearResponseBytes, err := e.cipherSuite().DecryptMessage(e.responseEncryptionKey, encryptedResponses[index])
+	if err != nil {
+		return nil, errors.Wrap(err, "decryption of response failed")
+	}
+
+	return unpadResponse(response, clearResponseBytes)
+}
+
+// unpadResponse removes the padding of a decrypted response if the enclave padded the responses
+func unpadResponse(response *protos.ChaincodeResponseMessage, clearResponseBytes []byte) ([]byte, error) {
+	if !response.GetPaddedResponses() {
+		return clearResponseBytes, nil
+	}
+
+	clearResponseBytes, err := Unpad(clearResponseBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot remove padding of response")
+	}
+	return clearResponseBytes, nil
+}
+
+func extractChaincodeResponseMessage(signedResponseBytesB64 []byte) (*protos.ChaincodeResponseMessage, error) {
 	signedResponseBytes, err := base64.StdEncoding.DecodeString(string(signedResponseBytesB64))
 	if err != nil {
 		return nil, err
@@ -97,12 +258,7 @@ func (e *EncryptionContextImpl) Reveal(signedResponseBytesB64 []byte) ([]byte, e
 		return nil, errors.Wrap(err, "failed to extract response message")
 	}
 
-	clearResponseBytes, err := e.csp.DecryptMessage(e.responseEncryptionKey, response.EncryptedResponse)
-	if err != nil {
-		return nil, errors.Wrap(err, "decryption of response failed")
-	}
-
-	return clearResponseBytes, nil
+	return response, nil
 }
 
 func (e *EncryptionContextImpl) Conceal(function string, args []string) (string, error) {
@@ -123,7 +279,7 @@ func (e *EncryptionContextImpl) Conceal(function string, args []string) (string,
 		return "", err
 	}
 
-	encryptedKeyTransport, err := e.csp.PkEncryptMessage(e.chaincodeEncryptionKey, serializedKeyTransport)
+	encryptedKeyTransport, err := e.cipherSuite().PkEncryptMessage(e.chaincodeEncryptionKey, serializedKeyTransport)
 	if err != nil {
 		return "", errors.Wrap(err, "encryption of request encryption key failed")
 	}
@@ -139,7 +295,7 @@ func (e *EncryptionContextImpl) Conceal(function string, args []string) (string,
 		return "", err
 	}
 
-	encryptedRequest, err := e.csp.EncryptMessage(e.requestEncryptionKey, serializedCcRequest)
+	encryptedRequest, err := e.cipherSuite().EncryptMessage(e.requestEncryptionKey, serializedCcRequest)
 	if err != nil {
 		return "", errors.Wrap(err, "encryption of request failed")
 	}
@@ -148,6 +304,7 @@ func (e *EncryptionContextImpl) Conceal(function string, args []string) (string,
 	encryptedCcRequest := &protos.ChaincodeRequestMessage{
 		EncryptedRequest:             encryptedRequest,
 		EncryptedKeyTransportMessage: encryptedKeyTransport,
+		CipherSuite:                  e.cipherSuite().ID(),
 	}
 
 	serializedEncryptedCcRequest, err := utils.MarshallProto(encryptedCcRequest)
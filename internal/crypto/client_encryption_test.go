@@ -16,6 +16,8 @@ import (
 	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 	"github.com/hyperledger/fabric/protoutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestNewEncryptionContext(t *testing.T) {
@@ -49,6 +51,12 @@ func TestNewEncryptionContext(t *testing.T) {
 	ctx, err = provider.NewEncryptionContext()
 	assert.NotNil(t, ctx)
 	assert.NoError(t, err)
+
+	// without CSP, the default CSP is used
+	provider.CSP = nil
+	ctx, err = provider.NewEncryptionContext()
+	assert.NoError(t, err)
+	assert.Equal(t, GetDefaultCSP(), ctx.(*EncryptionContextImpl).csp)
 }
 
 func TestConceal(t *testing.T) {
@@ -100,6 +108,99 @@ func TestConceal(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestNegotiateCipherSuiteAndConceal(t *testing.T) {
+	suite, err := NewCipherSuite(GetDefaultCSP(), protos.CipherSuite_CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM)
+	assert.NoError(t, err)
+	pubKey, privKey, err := suite.NewKeyTransportKeys()
+	assert.NoError(t, err)
+	rsaPubKey, _, err := GetDefaultCSP().NewRSAKeys()
+	assert.NoError(t, err)
+
+	ccEncryptionKeys := utils.MarshallProtoBase64(&protos.ChaincodeEncryptionKeys{ChaincodeEks: []*protos.ChaincodeEncryptionKey{
+		{CipherSuite: protos.CipherSuite_CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM, ChaincodeEk: pubKey},
+		{CipherSuite: protos.CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM, ChaincodeEk: rsaPubKey},
+	}})
+	provider := &EncryptionProviderImpl{
+		CSP: GetDefaultCSP(),
+		GetCcEncryptionKeys: func() ([]byte, error) {
+			return []byte(ccEncryptionKeys), nil
+		},
+	}
+	ctx, err := provider.NewEncryptionContext()
+	assert.NoError(t, err)
+
+	request, err := ctx.Conceal("some function", []string{"some", "args"})
+	assert.NoError(t, err)
+
+	requestBytes, err := base64.StdEncoding.DecodeString(request)
+	assert.NoError(t, err)
+	requestMessage := &protos.ChaincodeRequestMessage{}
+	assert.NoError(t, proto.Unmarshal(requestBytes, requestMessage))
+	assert.Equal(t, protos.CipherSuite_CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM, requestMessage.GetCipherSuite())
+
+	// the chaincode can decrypt the key transport message with the key of the negotiated suite
+	keyTransportBytes, err := suite.PkDecryptMessage(privKey, requestMessage.GetEncryptedKeyTransportMessage())
+	assert.NoError(t, err)
+	keyTransport := &protos.KeyTransportMessage{}
+	assert.NoError(t, proto.Unmarshal(keyTransportBytes, keyTransport))
+	assert.Len(t, keyTransport.GetRequestEncryptionKey(), AES256KeyLength)
+	_, err = suite.DecryptMessage(keyTransport.GetRequestEncryptionKey(), requestMessage.GetEncryptedRequest())
+	assert.NoError(t, err)
+
+	// the client may restrict the acceptable suites
+	provider.CipherSuites = []protos.CipherSuite{protos.CipherSuite_CIPHER_SUITE_ECIES_P256_HKDF_SHA256_AES256_GCM}
+	_, err = provider.NewEncryptionContext()
+	assert.ErrorContains(t, err, "no common cipher suite")
+
+	provider.GetCcEncryptionKeys = func() ([]byte, error) {
+		return []byte("not base64"), nil
+	}
+	_, err = provider.NewEncryptionContext()
+	assert.ErrorContains(t, err, "cannot decode chaincode encryption keys")
+}
+
+func TestNewEncryptionContexts(t *testing.T) {
+	suite, err := NewCipherSuite(GetDefaultCSP(), protos.CipherSuite_CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM)
+	assert.NoError(t, err)
+	pubKey, _, err := suite.NewKeyTransportKeys()
+	assert.NoError(t, err)
+
+	calls := 0
+	var provider BatchEncryptionProvider = &EncryptionProviderImpl{
+		CSP: GetDefaultCSP(),
+		GetCcEncryptionKeys: func() ([]byte, error) {
+			calls++
+			return []byte(utils.MarshallProtoBase64(&protos.ChaincodeEncryptionKeys{ChaincodeEks: []*protos.ChaincodeEncryptionKey{
+				{CipherSuite: protos.CipherSuite_CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM, ChaincodeEk: pubKey},
+			}})), nil
+		},
+	}
+
+	// the cipher suite is negotiated once for all contexts
+	ctxs, err := provider.NewEncryptionContexts(3)
+	require.NoError(t, err)
+	assert.Len(t, ctxs, 3)
+	assert.Equal(t, 1, calls)
+
+	// but each context has its own keys
+	keys := make(map[string]bool)
+	for _, ctx := range ctxs {
+		ctxImpl := ctx.(*EncryptionContextImpl)
+		assert.Equal(t, protos.CipherSuite_CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM, ctxImpl.cipherSuite().ID())
+		keys[string(ctxImpl.requestEncryptionKey)] = true
+		keys[string(ctxImpl.responseEncryptionKey)] = true
+	}
+	assert.Len(t, keys, 6)
+
+	provider = &EncryptionProviderImpl{
+		GetCcEncryptionKey: func() ([]byte, error) {
+			return nil, fmt.Errorf("some error while fetching key")
+		},
+	}
+	_, err = provider.NewEncryptionContexts(2)
+	assert.ErrorContains(t, err, "some error while fetching key")
+}
+
 func TestReveal(t *testing.T) {
 	msg := []byte("some response")
 
@@ -157,4 +258,72 @@ func TestReveal(t *testing.T) {
 	resp, err = ctx.Reveal([]byte(utils.MarshallProtoBase64(&protos.SignedChaincodeResponseMessage{ChaincodeResponseMessage: responseBytes})))
 	assert.Equal(t, resp, msg)
 	assert.NoError(t, err)
+
+	// padded responses
+	paddedMsg, err := Pad(msg, 64)
+	require.NoError(t, err)
+	encryptedMsg, err = GetDefaultCSP().EncryptMessage(responseEncryptionKey, paddedMsg)
+	assert.NoError(t, err)
+	response = &protos.ChaincodeResponseMessage{EncryptedResponse: encryptedMsg, PaddedResponses: true}
+	responseBytes = protoutil.MarshalOrPanic(response)
+	resp, err = ctx.Reveal([]byte(utils.MarshallProtoBase64(&protos.SignedChaincodeResponseMessage{ChaincodeResponseMessage: responseBytes})))
+	assert.Equal(t, msg, resp)
+	assert.NoError(t, err)
+
+	// unpadded response marked as padded
+	encryptedMsg, err = GetDefaultCSP().EncryptMessage(responseEncryptionKey, msg)
+	assert.NoError(t, err)
+	response = &protos.ChaincodeResponseMessage{EncryptedResponse: encryptedMsg, PaddedResponses: true}
+	responseBytes = protoutil.MarshalOrPanic(response)
+	resp, err = ctx.Reveal([]byte(utils.MarshallProtoBase64(&protos.SignedChaincodeResponseMessage{ChaincodeResponseMessage: responseBytes})))
+	assert.Nil(t, resp)
+	assert.ErrorContains(t, err, "cannot remove padding of response")
+}
+
+func TestRevealBatchResponse(t *testing.T) {
+	msg := []byte("some response")
+
+	responseEncryptionKey, err := GetDefaultCSP().NewSymmetricKey()
+	assert.NoError(t, err)
+	otherResponseEncryptionKey, err := GetDefaultCSP().NewSymmetricKey()
+	assert.NoError(t, err)
+
+	ctx := &EncryptionContextImpl{
+		csp:                   GetDefaultCSP(),
+		responseEncryptionKey: responseEncryptionKey,
+	}
+
+	encryptedMsg, err := GetDefaultCSP().EncryptMessage(responseEncryptionKey, msg)
+	assert.NoError(t, err)
+	otherEncryptedMsg, err := GetDefaultCSP().EncryptMessage(otherResponseEncryptionKey, []byte("other response"))
+	assert.NoError(t, err)
+
+	response := &protos.ChaincodeResponseMessage{EncryptedBatchResponses: [][]byte{otherEncryptedMsg, encryptedMsg}}
+	signedResponse := []byte(utils.MarshallProtoBase64(&protos.SignedChaincodeResponseMessage{ChaincodeResponseMessage: protoutil.MarshalOrPanic(response)}))
+
+	// index out of range
+	resp, err := ctx.RevealBatchResponse(signedResponse, 2)
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "no response for batch request 2, batch response contains 2 responses")
+
+	// response encrypted for other request
+	resp, err = ctx.RevealBatchResponse(signedResponse, 0)
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+
+	// should succeed
+	resp, err = ctx.RevealBatchResponse(signedResponse, 1)
+	assert.Equal(t, msg, resp)
+	assert.NoError(t, err)
+
+	// padded responses
+	paddedMsg, err := Pad(msg, 64)
+	require.NoError(t, err)
+	encryptedMsg, err = GetDefaultCSP().EncryptMessage(responseEncryptionKey, paddedMsg)
+	assert.NoError(t, err)
+	response = &protos.ChaincodeResponseMessage{EncryptedBatchResponses: [][]byte{encryptedMsg}, PaddedResponses: true}
+	signedResponse = []byte(utils.MarshallProtoBase64(&protos.SignedChaincodeResponseMessage{ChaincodeResponseMessage: protoutil.MarshalOrPanic(response)}))
+	resp, err = ctx.RevealBatchResponse(signedResponse, 0)
+	assert.Equal(t, msg, resp)
+	assert.NoError(t, err)
 }
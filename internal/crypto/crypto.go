@@ -18,6 +18,12 @@ type CSP interface {
 	PkEncryptMessage(publicKey []byte, message []byte) ([]byte, error)
 	DecryptMessage(key []byte, encryptedMessage []byte) ([]byte, error)
 	EncryptMessage(key []byte, message []byte) (encryptedMessage []byte, e error)
+	// DecryptWithAAD decrypts a message encrypted with EncryptWithAAD; decryption fails if the additional
+	// authenticated data (AAD) does not match the one used for encryption
+	DecryptWithAAD(key []byte, encryptedMessage []byte, aad []byte) ([]byte, error)
+	// EncryptWithAAD is a symmetric-key encryption as EncryptMessage that also authenticates (but does not encrypt)
+	// the additional authenticated data (AAD)
+	EncryptWithAAD(key []byte, message []byte, aad []byte) (encryptedMessage []byte, e error)
 }
 
 func GetDefaultCSP() CSP {
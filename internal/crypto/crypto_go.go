@@ -198,6 +198,10 @@ func (g GoCrypto) PkEncryptMessage(publicKey []byte, message []byte) ([]byte, er
 }
 
 func (g GoCrypto) DecryptMessage(key []byte, encryptedMessage []byte) ([]byte, error) {
+	return g.DecryptWithAAD(key, encryptedMessage, nil)
+}
+
+func (g GoCrypto) DecryptWithAAD(key []byte, encryptedMessage []byte, aad []byte) ([]byte, error) {
 
 	if len(encryptedMessage) <= NonceLength+TagLength {
 		return nil, fmt.Errorf("encrypted message to small. expect len to be larger than %d, actual %d", NonceLength+TagLength, len(encryptedMessage))
@@ -223,7 +227,7 @@ func (g GoCrypto) DecryptMessage(key []byte, encryptedMessage []byte) ([]byte, e
 		return nil, err
 	}
 
-	plaintext, err := aesgcm.Open(nil, nonce, aesgcmCiphertext, nil)
+	plaintext, err := aesgcm.Open(nil, nonce, aesgcmCiphertext, aad)
 	if err != nil {
 		return nil, err
 	}
@@ -232,6 +236,10 @@ func (g GoCrypto) DecryptMessage(key []byte, encryptedMessage []byte) ([]byte, e
 }
 
 func (g GoCrypto) EncryptMessage(key []byte, message []byte) (encryptedMessage []byte, err error) {
+	return g.EncryptWithAAD(key, message, nil)
+}
+
+func (g GoCrypto) EncryptWithAAD(key []byte, message []byte, aad []byte) (encryptedMessage []byte, err error) {
 
 	// generate nonce (IV)
 	nonce := make([]byte, NonceLength)
@@ -250,7 +258,7 @@ func (g GoCrypto) EncryptMessage(key []byte, message []byte) (encryptedMessage [
 		return nil, err
 	}
 
-	aesgcmCiphertext := aesgcm.Seal(nil, nonce, message, nil)
+	aesgcmCiphertext := aesgcm.Seal(nil, nonce, message, aad)
 
 	// Note that Seal appends the authentication tag to the cipertext, whereas PDO crypto prepends the tag
 	ciphertext, tag := aesgcmCiphertext[:len(aesgcmCiphertext)-TagLength], aesgcmCiphertext[len(aesgcmCiphertext)-TagLength:]
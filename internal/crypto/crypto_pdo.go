@@ -290,3 +290,15 @@ func (c PDOCrypto) EncryptMessage(key []byte, message []byte) (encryptedMessage
 
 	return C.GoBytes(encryptedMessagePtr, C.int(encryptedMessageActualLen)), nil
 }
+
+// DecryptWithAAD is a symmetric-key decryption with additional authenticated data. As the PDO crypto lib does not
+// support associated data, it is performed with the (format-compatible) go implementation.
+func (c PDOCrypto) DecryptWithAAD(key []byte, encryptedMessage []byte, aad []byte) ([]byte, error) {
+	return GoCrypto{}.DecryptWithAAD(key, encryptedMessage, aad)
+}
+
+// EncryptWithAAD is a symmetric-key encryption with additional authenticated data. As the PDO crypto lib does not
+// support associated data, it is performed with the (format-compatible) go implementation.
+func (c PDOCrypto) EncryptWithAAD(key []byte, message []byte, aad []byte) (encryptedMessage []byte, e error) {
+	return GoCrypto{}.EncryptWithAAD(key, message, aad)
+}
@@ -169,3 +169,38 @@ func TestSymEncryption(t *testing.T) {
 		assert.NoError(t, err)
 	}
 }
+
+func TestSymEncryptionWithAAD(t *testing.T) {
+	msg := []byte("some message")
+	aad := []byte("some associated data")
+
+	for _, tc := range allTestCases {
+		key, err := tc.CSP.NewSymmetricKey()
+		assert.NoError(t, err)
+
+		cipher, err := tc.CSP.EncryptWithAAD(key, msg, aad)
+		assert.NotNil(t, cipher)
+		assert.NoError(t, err)
+
+		// should succeed
+		plain, err := tc.CSP.DecryptWithAAD(key, cipher, aad)
+		assert.Equal(t, msg, plain)
+		assert.NoError(t, err)
+
+		// should fail with different or missing associated data
+		plain, err = tc.CSP.DecryptWithAAD(key, cipher, []byte("other associated data"))
+		assert.Nil(t, plain)
+		assert.Error(t, err)
+
+		plain, err = tc.CSP.DecryptMessage(key, cipher)
+		assert.Nil(t, plain)
+		assert.Error(t, err)
+
+		// messages without associated data are compatible with EncryptMessage/DecryptMessage
+		cipher, err = tc.CSP.EncryptMessage(key, msg)
+		assert.NoError(t, err)
+		plain, err = tc.CSP.DecryptWithAAD(key, cipher, nil)
+		assert.Equal(t, msg, plain)
+		assert.NoError(t, err)
+	}
+}
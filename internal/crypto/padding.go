@@ -0,0 +1,41 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"fmt"
+)
+
+// paddingMarker separates the plaintext from the padding (ISO/IEC 7816-4), i.e., a padded plaintext is
+// plaintext || 0x80 || 0x00 ... 0x00
+const paddingMarker byte = 0x80
+
+// Pad pads the plaintext to the given length, which must exceed the length of the plaintext by at least one byte
+func Pad(plaintext []byte, length int) ([]byte, error) {
+	if length <= len(plaintext) {
+		return nil, fmt.Errorf("cannot pad %d bytes to %d bytes", len(plaintext), length)
+	}
+
+	padded := make([]byte, length)
+	copy(padded, plaintext)
+	padded[len(plaintext)] = paddingMarker
+	return padded, nil
+}
+
+// Unpad removes the padding added by Pad
+func Unpad(padded []byte) ([]byte, error) {
+	for i := len(padded) - 1; i >= 0; i-- {
+		switch padded[i] {
+		case 0x00:
+			continue
+		case paddingMarker:
+			return padded[:i], nil
+		}
+		break
+	}
+	return nil, fmt.Errorf("invalid padding")
+}
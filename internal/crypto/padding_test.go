@@ -0,0 +1,36 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPadding(t *testing.T) {
+	for _, plaintext := range [][]byte{nil, []byte("some value"), {0x80, 0x00}, {0x00}} {
+		padded, err := Pad(plaintext, len(plaintext)+16)
+		require.NoError(t, err)
+		assert.Len(t, padded, len(plaintext)+16)
+
+		unpadded, err := Unpad(padded)
+		assert.NoError(t, err)
+		assert.Equal(t, len(plaintext), len(unpadded))
+		assert.Equal(t, string(plaintext), string(unpadded))
+	}
+
+	// the padding requires at least one byte
+	_, err := Pad([]byte("some value"), len("some value"))
+	assert.EqualError(t, err, "cannot pad 10 bytes to 10 bytes")
+
+	for _, padded := range [][]byte{nil, {0x00, 0x00}, []byte("some value")} {
+		_, err = Unpad(padded)
+		assert.EqualError(t, err, "invalid padding")
+	}
+}
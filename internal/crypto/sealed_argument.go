@@ -0,0 +1,169 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultSealedArgumentCipherSuite is the cipher suite of recipient keys if not specified otherwise
+const DefaultSealedArgumentCipherSuite = protos.CipherSuite_CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM
+
+// sealedArgumentMetadataDomain separates the signatures of SealedArgumentMetadata from the other signatures of the
+// enclave signing key, e.g., of ChaincodeResponseMessages, as the chaincode and the client control some of its fields
+const sealedArgumentMetadataDomain = "FPC-SEALED-ARGUMENT-METADATA-v1"
+
+// SealedArgumentMetadataToSign returns the message that the enclave signs for a serialized SealedArgumentMetadata
+func SealedArgumentMetadataToSign(metadataBytes []byte) []byte {
+	return append([]byte(sealedArgumentMetadataDomain), metadataBytes...)
+}
+
+// SealArgument seals the content to the public key of a recipient, which must be a key transport key of the given
+// cipher suite (see CipherSuite.NewKeyTransportKeys). The returned base64-encoded SealedArgument can be passed as an
+// argument of a chaincode invocation; only the recipient can open it with OpenSealedArgument.
+func SealArgument(csp CSP, id protos.CipherSuite, recipientPublicKey []byte, content []byte) (string, error) {
+	suite, err := NewCipherSuite(csp, id)
+	if err != nil {
+		return "", err
+	}
+
+	contentKey, err := suite.NewSymmetricKey()
+	if err != nil {
+		return "", err
+	}
+
+	encryptedKey, err := suite.PkEncryptMessage(recipientPublicKey, contentKey)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot encrypt content key for recipient")
+	}
+
+	recipientPkHash := sha256.Sum256(recipientPublicKey)
+	encryptedContent, err := csp.EncryptWithAAD(contentKey, content, sealedArgumentAAD(id, recipientPkHash[:]))
+	if err != nil {
+		return "", errors.Wrap(err, "cannot encrypt content")
+	}
+
+	sealedArgumentBytes, err := proto.Marshal(&protos.SealedArgument{
+		CipherSuite:      id,
+		RecipientPkHash:  recipientPkHash[:],
+		EncryptedKey:     encryptedKey,
+		EncryptedContent: encryptedContent,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(sealedArgumentBytes), nil
+}
+
+// OpenSealedArgument returns the content of a (base64-encoded) SealedArgument with the private key of the recipient
+func OpenSealedArgument(csp CSP, privateKey []byte, sealedArgument string) ([]byte, error) {
+	sealed, _, err := UnmarshalSealedArgument(sealedArgument)
+	if err != nil {
+		return nil, err
+	}
+
+	suite, err := NewCipherSuite(csp, sealed.GetCipherSuite())
+	if err != nil {
+		return nil, err
+	}
+
+	contentKey, err := suite.PkDecryptMessage(privateKey, sealed.GetEncryptedKey())
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decrypt content key, sealed argument is not for this recipient")
+	}
+
+	content, err := csp.DecryptWithAAD(contentKey, sealed.GetEncryptedContent(), sealedArgumentAAD(sealed.GetCipherSuite(), sealed.GetRecipientPkHash()))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decrypt content")
+	}
+
+	return content, nil
+}
+
+// UnmarshalSealedArgument decodes a base64-encoded SealedArgument; it also returns the SHA-256 hash of the serialized
+// SealedArgument, as bound by the SealedArgumentMetadata
+func UnmarshalSealedArgument(sealedArgument string) (*protos.SealedArgument, []byte, error) {
+	sealedArgumentBytes, err := base64.StdEncoding.DecodeString(sealedArgument)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot decode sealed argument")
+	}
+
+	sealed := &protos.SealedArgument{}
+	if err := proto.Unmarshal(sealedArgumentBytes, sealed); err != nil {
+		return nil, nil, errors.Wrap(err, "invalid SealedArgument")
+	}
+	if len(sealed.GetRecipientPkHash()) != sha256.Size || len(sealed.GetEncryptedKey()) == 0 || len(sealed.GetEncryptedContent()) == 0 {
+		return nil, nil, fmt.Errorf("incomplete SealedArgument")
+	}
+
+	hash := sha256.Sum256(sealedArgumentBytes)
+	return sealed, hash[:], nil
+}
+
+// VerifySealedArgumentMetadata verifies that the (base64-encoded) SignedSealedArgumentMetadata is signed by the
+// enclave with the given verification key and attests the given (base64-encoded) SealedArgument
+func VerifySealedArgumentMetadata(csp CSP, enclaveVk []byte, signedMetadata string, sealedArgument string) (*protos.SealedArgumentMetadata, error) {
+	signed, metadata, err := UnmarshalSignedSealedArgumentMetadata(signedMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	enclaveVkHash := sha256.Sum256(enclaveVk)
+	if metadata.GetEnclaveId() != strings.ToUpper(hex.EncodeToString(enclaveVkHash[:])) {
+		return nil, fmt.Errorf("enclave_id does not match the enclave verification key")
+	}
+
+	if err := csp.VerifyMessage(enclaveVk, SealedArgumentMetadataToSign(signed.GetSealedArgumentMetadata()), signed.GetSignature()); err != nil {
+		return nil, errors.Wrap(err, "invalid enclave signature")
+	}
+
+	sealed, sealedArgumentHash, err := UnmarshalSealedArgument(sealedArgument)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(metadata.GetSealedArgumentHash(), sealedArgumentHash) || !bytes.Equal(metadata.GetRecipientPkHash(), sealed.GetRecipientPkHash()) {
+		return nil, fmt.Errorf("metadata does not attest the sealed argument")
+	}
+
+	return metadata, nil
+}
+
+// UnmarshalSignedSealedArgumentMetadata decodes a base64-encoded SignedSealedArgumentMetadata and the contained
+// SealedArgumentMetadata, without verifying the signature
+func UnmarshalSignedSealedArgumentMetadata(signedMetadata string) (*protos.SignedSealedArgumentMetadata, *protos.SealedArgumentMetadata, error) {
+	signedMetadataBytes, err := base64.StdEncoding.DecodeString(signedMetadata)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot decode signed metadata")
+	}
+
+	signed := &protos.SignedSealedArgumentMetadata{}
+	if err := proto.Unmarshal(signedMetadataBytes, signed); err != nil {
+		return nil, nil, errors.Wrap(err, "invalid SignedSealedArgumentMetadata")
+	}
+
+	metadata := &protos.SealedArgumentMetadata{}
+	if err := proto.Unmarshal(signed.GetSealedArgumentMetadata(), metadata); err != nil {
+		return nil, nil, errors.Wrap(err, "invalid SealedArgumentMetadata")
+	}
+
+	return signed, metadata, nil
+}
+
+// sealedArgumentAAD binds the cipher suite and the recipient to the encrypted content
+func sealedArgumentAAD(id protos.CipherSuite, recipientPkHash []byte) []byte {
+	return append([]byte(id.String()), recipientPkHash...)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSealArgument(t *testing.T) {
+	csp := NewGoCrypto()
+	content := []byte("some document")
+
+	for _, id := range SupportedCipherSuites {
+		suite, err := NewCipherSuite(csp, id)
+		require.NoError(t, err)
+		pk, sk, err := suite.NewKeyTransportKeys()
+		require.NoError(t, err, id)
+
+		sealedArgument, err := SealArgument(csp, id, pk, content)
+		require.NoError(t, err, id)
+
+		opened, err := OpenSealedArgument(csp, sk, sealedArgument)
+		assert.NoError(t, err, id)
+		assert.Equal(t, content, opened, id)
+
+		// another recipient cannot open it
+		_, otherSk, err := suite.NewKeyTransportKeys()
+		require.NoError(t, err, id)
+		_, err = OpenSealedArgument(csp, otherSk, sealedArgument)
+		assert.Error(t, err, id)
+	}
+
+	suite, err := NewCipherSuite(csp, DefaultSealedArgumentCipherSuite)
+	require.NoError(t, err)
+	pk, sk, err := suite.NewKeyTransportKeys()
+	require.NoError(t, err)
+	sealedArgument, err := SealArgument(csp, DefaultSealedArgumentCipherSuite, pk, content)
+	require.NoError(t, err)
+
+	// the recipient is bound to the content
+	sealed, _, err := UnmarshalSealedArgument(sealedArgument)
+	require.NoError(t, err)
+	sealed.RecipientPkHash[0] ^= 0x01
+	_, err = OpenSealedArgument(csp, sk, base64.StdEncoding.EncodeToString(mustMarshal(t, sealed)))
+	assert.ErrorContains(t, err, "cannot decrypt content")
+
+	_, err = OpenSealedArgument(csp, sk, base64.StdEncoding.EncodeToString(mustMarshal(t, &protos.SealedArgument{})))
+	assert.EqualError(t, err, "incomplete SealedArgument")
+}
+
+func TestVerifySealedArgumentMetadata(t *testing.T) {
+	csp := NewGoCrypto()
+	enclaveVk, enclaveSk, err := csp.NewECDSAKeys()
+	require.NoError(t, err)
+	enclaveVkHash := sha256.Sum256(enclaveVk)
+	enclaveId := strings.ToUpper(hex.EncodeToString(enclaveVkHash[:]))
+
+	suite, err := NewCipherSuite(csp, DefaultSealedArgumentCipherSuite)
+	require.NoError(t, err)
+	pk, _, err := suite.NewKeyTransportKeys()
+	require.NoError(t, err)
+	sealedArgument, err := SealArgument(csp, DefaultSealedArgumentCipherSuite, pk, []byte("some document"))
+	require.NoError(t, err)
+	otherSealedArgument, err := SealArgument(csp, DefaultSealedArgumentCipherSuite, pk, []byte("another document"))
+	require.NoError(t, err)
+
+	sealed, sealedArgumentHash, err := UnmarshalSealedArgument(sealedArgument)
+	require.NoError(t, err)
+
+	sign := func(metadata *protos.SealedArgumentMetadata, sk []byte) string {
+		metadataBytes := mustMarshal(t, metadata)
+		sig, err := csp.SignMessage(sk, SealedArgumentMetadataToSign(metadataBytes))
+		require.NoError(t, err)
+		return base64.StdEncoding.EncodeToString(mustMarshal(t, &protos.SignedSealedArgumentMetadata{
+			SealedArgumentMetadata: metadataBytes,
+			Signature:              sig,
+		}))
+	}
+
+	metadata := &protos.SealedArgumentMetadata{
+		SealedArgumentHash: sealedArgumentHash,
+		RecipientPkHash:    sealed.GetRecipientPkHash(),
+		EnclaveId:          enclaveId,
+		Metadata:           []byte("sent by alice"),
+	}
+	signedMetadata := sign(metadata, enclaveSk)
+
+	verified, err := VerifySealedArgumentMetadata(csp, enclaveVk, signedMetadata, sealedArgument)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("sent by alice"), verified.GetMetadata())
+
+	// another sealed argument
+	_, err = VerifySealedArgumentMetadata(csp, enclaveVk, signedMetadata, otherSealedArgument)
+	assert.EqualError(t, err, "metadata does not attest the sealed argument")
+
+	// another enclave
+	otherVk, otherSk, err := csp.NewECDSAKeys()
+	require.NoError(t, err)
+	_, err = VerifySealedArgumentMetadata(csp, otherVk, signedMetadata, sealedArgument)
+	assert.EqualError(t, err, "enclave_id does not match the enclave verification key")
+	_, err = VerifySealedArgumentMetadata(csp, enclaveVk, sign(metadata, otherSk), sealedArgument)
+	assert.ErrorContains(t, err, "invalid enclave signature")
+
+	// a signature of the enclave over the metadata without the domain, e.g., for another message, is rejected
+	metadataBytes := mustMarshal(t, metadata)
+	sig, err := csp.SignMessage(enclaveSk, metadataBytes)
+	require.NoError(t, err)
+	undomained := base64.StdEncoding.EncodeToString(mustMarshal(t, &protos.SignedSealedArgumentMetadata{
+		SealedArgumentMetadata: metadataBytes,
+		Signature:              sig,
+	}))
+	_, err = VerifySealedArgumentMetadata(csp, enclaveVk, undomained, sealedArgument)
+	assert.ErrorContains(t, err, "invalid enclave signature")
+}
+
+func mustMarshal(t *testing.T, m proto.Message) []byte {
+	b, err := proto.Marshal(m)
+	require.NoError(t, err)
+	return b
+}
@@ -0,0 +1,206 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/pkg/errors"
+)
+
+// An EncryptionContextImpl can be exported in sealed form, so that a request can be built (i.e., Conceal) in one process,
+// for example, on an air-gapped workstation where the proposal is signed, and the corresponding response
+// can be decrypted (i.e., Reveal) later in a different process.
+// The request and response encryption keys are sealed either with a key derived from a passphrase or with a local
+// symmetric key (e.g., a key unwrapped from a HSM). The header of the sealed context (i.e., the version, sealing type
+// and key derivation parameters) is authenticated with the sealed keys.
+
+const (
+	SealedContextVersion = 1
+
+	PassphraseSealing = "passphrase"
+	KeySealing        = "key"
+
+	// DefaultPBKDF2Iterations is the number of PBKDF2-HMAC-SHA256 iterations used to derive the sealing key from a passphrase
+	DefaultPBKDF2Iterations = 600000
+	// MaxPBKDF2Iterations bounds the iterations accepted from a sealed encryption context, as they are not
+	// authenticated before the sealing key is derived
+	MaxPBKDF2Iterations = 10 * DefaultPBKDF2Iterations
+	saltLength          = 16
+)
+
+type sealedEncryptionContext struct {
+	Version    int    `json:"version"`
+	Sealing    string `json:"sealing"`
+	Salt       []byte `json:"salt,omitempty"`
+	Iterations int    `json:"iterations,omitempty"`
+	SealedKeys []byte `json:"sealed_keys"`
+}
+
+type encryptionContextKeys struct {
+	RequestEncryptionKey   []byte `json:"request_encryption_key"`
+	ResponseEncryptionKey  []byte `json:"response_encryption_key"`
+	ChaincodeEncryptionKey []byte `json:"chaincode_encryption_key"`
+	// contexts sealed before cipher suites were introduced use CIPHER_SUITE_RSA_OAEP_AES128_GCM (i.e., 0)
+	CipherSuite protos.CipherSuite `json:"cipher_suite,omitempty"`
+}
+
+// SealWithPassphrase returns the serialized encryption context with its keys sealed using a key derived from passphrase.
+func (e *EncryptionContextImpl) SealWithPassphrase(passphrase []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("passphrase is empty")
+	}
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	sealingKey, err := deriveSealingKey(passphrase, salt, DefaultPBKDF2Iterations)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.seal(&sealedEncryptionContext{
+		Version:    SealedContextVersion,
+		Sealing:    PassphraseSealing,
+		Salt:       salt,
+		Iterations: DefaultPBKDF2Iterations,
+	}, sealingKey)
+}
+
+// SealWithKey returns the serialized encryption context with its keys sealed using the given symmetric key.
+func (e *EncryptionContextImpl) SealWithKey(key []byte) ([]byte, error) {
+	return e.seal(&sealedEncryptionContext{
+		Version: SealedContextVersion,
+		Sealing: KeySealing,
+	}, key)
+}
+
+func (e *EncryptionContextImpl) seal(sealed *sealedEncryptionContext, sealingKey []byte) ([]byte, error) {
+	keys, err := json.Marshal(&encryptionContextKeys{
+		RequestEncryptionKey:   e.requestEncryptionKey,
+		ResponseEncryptionKey:  e.responseEncryptionKey,
+		ChaincodeEncryptionKey: e.chaincodeEncryptionKey,
+		CipherSuite:            e.cipherSuite().ID(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	aad, err := sealed.header()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed.SealedKeys, err = e.csp.EncryptWithAAD(sealingKey, keys, aad)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot seal encryption context keys")
+	}
+
+	return json.Marshal(sealed)
+}
+
+// UnsealWithPassphrase restores an encryption context sealed with SealWithPassphrase.
+func UnsealWithPassphrase(csp CSP, sealedContext []byte, passphrase []byte) (*EncryptionContextImpl, error) {
+	sealed, err := unmarshalSealedContext(sealedContext, PassphraseSealing)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed.Salt) == 0 || sealed.Iterations <= 0 {
+		return nil, fmt.Errorf("invalid key derivation parameters")
+	}
+	if sealed.Iterations > MaxPBKDF2Iterations {
+		return nil, fmt.Errorf("PBKDF2 iterations %d exceed the maximum of %d", sealed.Iterations, MaxPBKDF2Iterations)
+	}
+
+	sealingKey, err := deriveSealingKey(passphrase, sealed.Salt, sealed.Iterations)
+	if err != nil {
+		return nil, err
+	}
+
+	return unseal(csp, sealed, sealingKey)
+}
+
+// UnsealWithKey restores an encryption context sealed with SealWithKey.
+func UnsealWithKey(csp CSP, sealedContext []byte, key []byte) (*EncryptionContextImpl, error) {
+	sealed, err := unmarshalSealedContext(sealedContext, KeySealing)
+	if err != nil {
+		return nil, err
+	}
+
+	return unseal(csp, sealed, key)
+}
+
+func unmarshalSealedContext(sealedContext []byte, expectedSealing string) (*sealedEncryptionContext, error) {
+	sealed := &sealedEncryptionContext{}
+	if err := json.Unmarshal(sealedContext, sealed); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal sealed encryption context")
+	}
+
+	if sealed.Version != SealedContextVersion {
+		return nil, fmt.Errorf("unsupported sealed encryption context version %d", sealed.Version)
+	}
+
+	if sealed.Sealing != expectedSealing {
+		return nil, fmt.Errorf("encryption context is sealed with '%s' but '%s' was expected", sealed.Sealing, expectedSealing)
+	}
+
+	return sealed, nil
+}
+
+// header returns the serialized sealed encryption context without the sealed keys, which is authenticated as AAD of
+// the sealed keys
+func (s *sealedEncryptionContext) header() ([]byte, error) {
+	header := *s
+	header.SealedKeys = nil
+	return json.Marshal(&header)
+}
+
+func unseal(csp CSP, sealed *sealedEncryptionContext, sealingKey []byte) (*EncryptionContextImpl, error) {
+	aad, err := sealed.header()
+	if err != nil {
+		return nil, err
+	}
+
+	keysBytes, err := csp.DecryptWithAAD(sealingKey, sealed.SealedKeys, aad)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot unseal encryption context keys")
+	}
+
+	keys := &encryptionContextKeys{}
+	if err := json.Unmarshal(keysBytes, keys); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal encryption context keys")
+	}
+
+	if len(keys.ResponseEncryptionKey) == 0 {
+		return nil, fmt.Errorf("no response encryption key")
+	}
+
+	suite, err := NewCipherSuite(csp, keys.CipherSuite)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncryptionContextImpl{
+		csp:                    csp,
+		suite:                  suite,
+		requestEncryptionKey:   keys.RequestEncryptionKey,
+		responseEncryptionKey:  keys.ResponseEncryptionKey,
+		chaincodeEncryptionKey: keys.ChaincodeEncryptionKey,
+	}, nil
+}
+
+func deriveSealingKey(passphrase []byte, salt []byte, iterations int) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, string(passphrase), salt, iterations, SymKeyLength)
+}
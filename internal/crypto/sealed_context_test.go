@@ -0,0 +1,158 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEncryptionContext(t *testing.T) *EncryptionContextImpl {
+	pubKey, _, err := GetDefaultCSP().NewRSAKeys()
+	assert.NoError(t, err)
+
+	provider := &EncryptionProviderImpl{
+		CSP: GetDefaultCSP(),
+		GetCcEncryptionKey: func() ([]byte, error) {
+			return []byte(base64.StdEncoding.EncodeToString(pubKey)), nil
+		},
+	}
+	ctx, err := provider.NewEncryptionContext()
+	assert.NoError(t, err)
+	return ctx.(*EncryptionContextImpl)
+}
+
+func encryptedResponse(t *testing.T, key []byte, msg []byte) []byte {
+	encryptedMsg, err := GetDefaultCSP().EncryptMessage(key, msg)
+	assert.NoError(t, err)
+	responseBytes := protoutil.MarshalOrPanic(&protos.ChaincodeResponseMessage{EncryptedResponse: encryptedMsg})
+	return []byte(utils.MarshallProtoBase64(&protos.SignedChaincodeResponseMessage{ChaincodeResponseMessage: responseBytes}))
+}
+
+func TestSealWithPassphrase(t *testing.T) {
+	ctx := newTestEncryptionContext(t)
+	passphrase := []byte("correct horse battery staple")
+
+	_, err := ctx.SealWithPassphrase(nil)
+	assert.Error(t, err)
+
+	sealed, err := ctx.SealWithPassphrase(passphrase)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(sealed), base64.StdEncoding.EncodeToString(ctx.responseEncryptionKey))
+
+	// wrong passphrase
+	restored, err := UnsealWithPassphrase(GetDefaultCSP(), sealed, []byte("wrong passphrase"))
+	assert.Nil(t, restored)
+	assert.Error(t, err)
+
+	// key derivation parameters are bounded
+	s := &sealedEncryptionContext{}
+	assert.NoError(t, json.Unmarshal(sealed, s))
+	s.Iterations = MaxPBKDF2Iterations + 1
+	tampered, _ := json.Marshal(s)
+	_, err = UnsealWithPassphrase(GetDefaultCSP(), tampered, passphrase)
+	assert.EqualError(t, err, "PBKDF2 iterations 6000001 exceed the maximum of 6000000")
+
+	// wrong sealing type
+	restored, err = UnsealWithKey(GetDefaultCSP(), sealed, passphrase)
+	assert.Nil(t, restored)
+	assert.EqualError(t, err, "encryption context is sealed with 'passphrase' but 'key' was expected")
+
+	restored, err = UnsealWithPassphrase(GetDefaultCSP(), sealed, passphrase)
+	assert.NoError(t, err)
+	assert.Equal(t, ctx.requestEncryptionKey, restored.requestEncryptionKey)
+	assert.Equal(t, ctx.responseEncryptionKey, restored.responseEncryptionKey)
+	assert.Equal(t, ctx.chaincodeEncryptionKey, restored.chaincodeEncryptionKey)
+
+	// restored context can reveal responses
+	msg := []byte("some response")
+	resp, err := restored.Reveal(encryptedResponse(t, ctx.responseEncryptionKey, msg))
+	assert.NoError(t, err)
+	assert.Equal(t, msg, resp)
+}
+
+func TestSealWithKey(t *testing.T) {
+	ctx := newTestEncryptionContext(t)
+
+	_, err := ctx.SealWithKey([]byte("invalid key length"))
+	assert.Error(t, err)
+
+	localKey, err := GetDefaultCSP().NewSymmetricKey()
+	assert.NoError(t, err)
+
+	sealed, err := ctx.SealWithKey(localKey)
+	assert.NoError(t, err)
+
+	otherKey, err := GetDefaultCSP().NewSymmetricKey()
+	assert.NoError(t, err)
+	_, err = UnsealWithKey(GetDefaultCSP(), sealed, otherKey)
+	assert.Error(t, err)
+
+	restored, err := UnsealWithKey(GetDefaultCSP(), sealed, localKey)
+	assert.NoError(t, err)
+	assert.Equal(t, ctx.responseEncryptionKey, restored.responseEncryptionKey)
+
+	// invalid inputs
+	_, err = UnsealWithKey(GetDefaultCSP(), []byte("not json"), localKey)
+	assert.Error(t, err)
+
+	s := &sealedEncryptionContext{}
+	assert.NoError(t, json.Unmarshal(sealed, s))
+	s.Version = 42
+	tampered, _ := json.Marshal(s)
+	_, err = UnsealWithKey(GetDefaultCSP(), tampered, localKey)
+	assert.EqualError(t, err, "unsupported sealed encryption context version 42")
+
+	// the header is authenticated
+	s.Version = SealedContextVersion
+	s.Salt = []byte("some salt")
+	tampered, _ = json.Marshal(s)
+	_, err = UnsealWithKey(GetDefaultCSP(), tampered, localKey)
+	assert.ErrorContains(t, err, "cannot unseal encryption context keys")
+}
+
+func TestSealWithCipherSuite(t *testing.T) {
+	suite, err := NewCipherSuite(GetDefaultCSP(), protos.CipherSuite_CIPHER_SUITE_ECIES_P256_HKDF_SHA256_AES256_GCM)
+	assert.NoError(t, err)
+	pubKey, _, err := suite.NewKeyTransportKeys()
+	assert.NoError(t, err)
+
+	provider := &EncryptionProviderImpl{
+		CSP: GetDefaultCSP(),
+		GetCcEncryptionKeys: func() ([]byte, error) {
+			return []byte(utils.MarshallProtoBase64(&protos.ChaincodeEncryptionKeys{ChaincodeEks: []*protos.ChaincodeEncryptionKey{
+				{CipherSuite: suite.ID(), ChaincodeEk: pubKey},
+			}})), nil
+		},
+	}
+	ctx, err := provider.NewEncryptionContext()
+	assert.NoError(t, err)
+
+	localKey, err := GetDefaultCSP().NewSymmetricKey()
+	assert.NoError(t, err)
+	sealed, err := ctx.(*EncryptionContextImpl).SealWithKey(localKey)
+	assert.NoError(t, err)
+
+	// the restored context keeps the negotiated cipher suite
+	restored, err := UnsealWithKey(GetDefaultCSP(), sealed, localKey)
+	assert.NoError(t, err)
+	assert.Equal(t, suite.ID(), restored.cipherSuite().ID())
+
+	msg := []byte("some response")
+	encryptedMsg, err := suite.EncryptMessage(restored.responseEncryptionKey, msg)
+	assert.NoError(t, err)
+	responseBytes := protoutil.MarshalOrPanic(&protos.ChaincodeResponseMessage{EncryptedResponse: encryptedMsg})
+	resp, err := restored.Reveal([]byte(utils.MarshallProtoBase64(&protos.SignedChaincodeResponseMessage{ChaincodeResponseMessage: responseBytes})))
+	assert.NoError(t, err)
+	assert.Equal(t, msg, resp)
+}
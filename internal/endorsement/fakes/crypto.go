@@ -20,6 +20,21 @@ type CryptoProvider struct {
 		result1 []byte
 		result2 error
 	}
+	DecryptWithAADStub        func([]byte, []byte, []byte) ([]byte, error)
+	decryptWithAADMutex       sync.RWMutex
+	decryptWithAADArgsForCall []struct {
+		arg1 []byte
+		arg2 []byte
+		arg3 []byte
+	}
+	decryptWithAADReturns struct {
+		result1 []byte
+		result2 error
+	}
+	decryptWithAADReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
 	EncryptMessageStub        func([]byte, []byte) ([]byte, error)
 	encryptMessageMutex       sync.RWMutex
 	encryptMessageArgsForCall []struct {
@@ -34,6 +49,21 @@ type CryptoProvider struct {
 		result1 []byte
 		result2 error
 	}
+	EncryptWithAADStub        func([]byte, []byte, []byte) ([]byte, error)
+	encryptWithAADMutex       sync.RWMutex
+	encryptWithAADArgsForCall []struct {
+		arg1 []byte
+		arg2 []byte
+		arg3 []byte
+	}
+	encryptWithAADReturns struct {
+		result1 []byte
+		result2 error
+	}
+	encryptWithAADReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
 	NewECDSAKeysStub        func() ([]byte, []byte, error)
 	newECDSAKeysMutex       sync.RWMutex
 	newECDSAKeysArgsForCall []struct {
@@ -208,6 +238,87 @@ func (fake *CryptoProvider) DecryptMessageReturnsOnCall(i int, result1 []byte, r
 	}{result1, result2}
 }
 
+func (fake *CryptoProvider) DecryptWithAAD(arg1 []byte, arg2 []byte, arg3 []byte) ([]byte, error) {
+	var arg1Copy []byte
+	if arg1 != nil {
+		arg1Copy = make([]byte, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	var arg2Copy []byte
+	if arg2 != nil {
+		arg2Copy = make([]byte, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	var arg3Copy []byte
+	if arg3 != nil {
+		arg3Copy = make([]byte, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.decryptWithAADMutex.Lock()
+	ret, specificReturn := fake.decryptWithAADReturnsOnCall[len(fake.decryptWithAADArgsForCall)]
+	fake.decryptWithAADArgsForCall = append(fake.decryptWithAADArgsForCall, struct {
+		arg1 []byte
+		arg2 []byte
+		arg3 []byte
+	}{arg1Copy, arg2Copy, arg3Copy})
+	stub := fake.DecryptWithAADStub
+	fakeReturns := fake.decryptWithAADReturns
+	fake.recordInvocation("DecryptWithAAD", []interface{}{arg1Copy, arg2Copy, arg3Copy})
+	fake.decryptWithAADMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *CryptoProvider) DecryptWithAADCallCount() int {
+	fake.decryptWithAADMutex.RLock()
+	defer fake.decryptWithAADMutex.RUnlock()
+	return len(fake.decryptWithAADArgsForCall)
+}
+
+func (fake *CryptoProvider) DecryptWithAADCalls(stub func([]byte, []byte, []byte) ([]byte, error)) {
+	fake.decryptWithAADMutex.Lock()
+	defer fake.decryptWithAADMutex.Unlock()
+	fake.DecryptWithAADStub = stub
+}
+
+func (fake *CryptoProvider) DecryptWithAADArgsForCall(i int) ([]byte, []byte, []byte) {
+	fake.decryptWithAADMutex.RLock()
+	defer fake.decryptWithAADMutex.RUnlock()
+	argsForCall := fake.decryptWithAADArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *CryptoProvider) DecryptWithAADReturns(result1 []byte, result2 error) {
+	fake.decryptWithAADMutex.Lock()
+	defer fake.decryptWithAADMutex.Unlock()
+	fake.DecryptWithAADStub = nil
+	fake.decryptWithAADReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *CryptoProvider) DecryptWithAADReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.decryptWithAADMutex.Lock()
+	defer fake.decryptWithAADMutex.Unlock()
+	fake.DecryptWithAADStub = nil
+	if fake.decryptWithAADReturnsOnCall == nil {
+		fake.decryptWithAADReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.decryptWithAADReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *CryptoProvider) EncryptMessage(arg1 []byte, arg2 []byte) ([]byte, error) {
 	var arg1Copy []byte
 	if arg1 != nil {
@@ -283,6 +394,87 @@ func (fake *CryptoProvider) EncryptMessageReturnsOnCall(i int, result1 []byte, r
 	}{result1, result2}
 }
 
+func (fake *CryptoProvider) EncryptWithAAD(arg1 []byte, arg2 []byte, arg3 []byte) ([]byte, error) {
+	var arg1Copy []byte
+	if arg1 != nil {
+		arg1Copy = make([]byte, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	var arg2Copy []byte
+	if arg2 != nil {
+		arg2Copy = make([]byte, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	var arg3Copy []byte
+	if arg3 != nil {
+		arg3Copy = make([]byte, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.encryptWithAADMutex.Lock()
+	ret, specificReturn := fake.encryptWithAADReturnsOnCall[len(fake.encryptWithAADArgsForCall)]
+	fake.encryptWithAADArgsForCall = append(fake.encryptWithAADArgsForCall, struct {
+		arg1 []byte
+		arg2 []byte
+		arg3 []byte
+	}{arg1Copy, arg2Copy, arg3Copy})
+	stub := fake.EncryptWithAADStub
+	fakeReturns := fake.encryptWithAADReturns
+	fake.recordInvocation("EncryptWithAAD", []interface{}{arg1Copy, arg2Copy, arg3Copy})
+	fake.encryptWithAADMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *CryptoProvider) EncryptWithAADCallCount() int {
+	fake.encryptWithAADMutex.RLock()
+	defer fake.encryptWithAADMutex.RUnlock()
+	return len(fake.encryptWithAADArgsForCall)
+}
+
+func (fake *CryptoProvider) EncryptWithAADCalls(stub func([]byte, []byte, []byte) ([]byte, error)) {
+	fake.encryptWithAADMutex.Lock()
+	defer fake.encryptWithAADMutex.Unlock()
+	fake.EncryptWithAADStub = stub
+}
+
+func (fake *CryptoProvider) EncryptWithAADArgsForCall(i int) ([]byte, []byte, []byte) {
+	fake.encryptWithAADMutex.RLock()
+	defer fake.encryptWithAADMutex.RUnlock()
+	argsForCall := fake.encryptWithAADArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *CryptoProvider) EncryptWithAADReturns(result1 []byte, result2 error) {
+	fake.encryptWithAADMutex.Lock()
+	defer fake.encryptWithAADMutex.Unlock()
+	fake.EncryptWithAADStub = nil
+	fake.encryptWithAADReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *CryptoProvider) EncryptWithAADReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.encryptWithAADMutex.Lock()
+	defer fake.encryptWithAADMutex.Unlock()
+	fake.EncryptWithAADStub = nil
+	if fake.encryptWithAADReturnsOnCall == nil {
+		fake.encryptWithAADReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.encryptWithAADReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *CryptoProvider) NewECDSAKeys() ([]byte, []byte, error) {
 	fake.newECDSAKeysMutex.Lock()
 	ret, specificReturn := fake.newECDSAKeysReturnsOnCall[len(fake.newECDSAKeysArgsForCall)]
@@ -763,24 +955,6 @@ func (fake *CryptoProvider) VerifyMessageReturnsOnCall(i int, result1 error) {
 func (fake *CryptoProvider) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.decryptMessageMutex.RLock()
-	defer fake.decryptMessageMutex.RUnlock()
-	fake.encryptMessageMutex.RLock()
-	defer fake.encryptMessageMutex.RUnlock()
-	fake.newECDSAKeysMutex.RLock()
-	defer fake.newECDSAKeysMutex.RUnlock()
-	fake.newRSAKeysMutex.RLock()
-	defer fake.newRSAKeysMutex.RUnlock()
-	fake.newSymmetricKeyMutex.RLock()
-	defer fake.newSymmetricKeyMutex.RUnlock()
-	fake.pkDecryptMessageMutex.RLock()
-	defer fake.pkDecryptMessageMutex.RUnlock()
-	fake.pkEncryptMessageMutex.RLock()
-	defer fake.pkEncryptMessageMutex.RUnlock()
-	fake.signMessageMutex.RLock()
-	defer fake.signMessageMutex.RUnlock()
-	fake.verifyMessageMutex.RLock()
-	defer fake.verifyMessageMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -29,8 +29,24 @@ type Validation interface {
 	Validate(signedResponseMessage *protos.SignedChaincodeResponseMessage, attestedData *protos.AttestedData) error
 }
 
-func NewValidator() *ValidatorImpl {
-	return &ValidatorImpl{csp: crypto.GetDefaultCSP()}
+// ValidatorOption configures a ValidatorImpl created with NewValidator
+type ValidatorOption func(*ValidatorImpl)
+
+// WithCSP option sets the CSP used to verify the enclave signatures; by default, crypto.GetDefaultCSP() is used
+func WithCSP(csp crypto.CSP) ValidatorOption {
+	return func(v *ValidatorImpl) {
+		v.csp = csp
+	}
+}
+
+func NewValidator(opts ...ValidatorOption) *ValidatorImpl {
+	v := &ValidatorImpl{csp: crypto.GetDefaultCSP()}
+
+	// apply options
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
 }
 
 type ValidatorImpl struct {
@@ -59,12 +75,9 @@ func (v *ValidatorImpl) ReplayReadWrites(stub shim.ChaincodeStubInterface, fpcrw
 		}
 
 		for i := 0; i < len(rwset.Reads); i++ {
-			k := utils.TransformToFPCKey(rwset.Reads[i].Key)
-
-			// check if composite key, if so, derive Fabric key
-			if utils.IsFPCCompositeKey(k) {
-				comp := utils.SplitFPCCompositeKey(k)
-				k, _ = stub.CreateCompositeKey(comp[0], comp[1:])
+			k, err := fabricKey(stub, rwset.Reads[i].Key)
+			if err != nil {
+				return err
 			}
 
 			v, err := stub.GetState(k)
@@ -117,12 +130,9 @@ func (v *ValidatorImpl) ReplayReadWrites(stub shim.ChaincodeStubInterface, fpcrw
 	if rwset.GetWrites() != nil {
 		logger.Debugf("Replaying writes")
 		for _, w := range rwset.Writes {
-			k := utils.TransformToFPCKey(w.Key)
-
-			// check if composite key, if so, derive Fabric key
-			if utils.IsFPCCompositeKey(k) {
-				comp := utils.SplitFPCCompositeKey(k)
-				k, _ = stub.CreateCompositeKey(comp[0], comp[1:])
+			k, err := fabricKey(stub, w.Key)
+			if err != nil {
+				return err
 			}
 
 			if w.IsDelete {
@@ -142,6 +152,25 @@ func (v *ValidatorImpl) ReplayReadWrites(stub shim.ChaincodeStubInterface, fpcrw
 	return nil
 }
 
+// fabricKey derives the Fabric key of a key in the FPC rwset. Note that the enclave may store keys mapped by a keyed
+// PRF (see hashed state keys in ecc_go); as the mapping is applied to the object type and each attribute of a
+// composite key separately, the mapped keys are replayed like cleartext keys without knowing the mapping.
+func fabricKey(stub shim.ChaincodeStubInterface, key string) (string, error) {
+	k := utils.TransformToFPCKey(key)
+
+	// check if composite key, if so, derive Fabric key
+	if !utils.IsFPCCompositeKey(k) {
+		return k, nil
+	}
+
+	comp := utils.SplitFPCCompositeKey(k)
+	compositeKey, err := stub.CreateCompositeKey(comp[0], comp[1:])
+	if err != nil {
+		return "", fmt.Errorf("error (%s) creating composite key for key %s", err, k)
+	}
+	return compositeKey, nil
+}
+
 func (v *ValidatorImpl) Validate(signedResponseMessage *protos.SignedChaincodeResponseMessage, attestedData *protos.AttestedData) error {
 	if signedResponseMessage.GetSignature() == nil {
 		return fmt.Errorf("no enclave signature")
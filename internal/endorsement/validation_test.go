@@ -162,6 +162,36 @@ func TestReplayReadWrites(t *testing.T) {
 	assert.EqualValues(t, expectedFabricCompKey, k)
 	assert.EqualValues(t, writeCompKey.Value, val)
 
+	// error when composite key cannot be created
+	stub = &fakes.ChaincodeStub{}
+	stub.CreateCompositeKeyReturns("", fmt.Errorf("some error"))
+	err = v.ReplayReadWrites(stub, fpcrwset)
+	assert.Error(t, err)
+	assert.Equal(t, 0, stub.PutStateCallCount())
+
+	// no error (writes) with hashed keys, i.e., hex-encoded components
+	hashedCompKey := ".6f626a656374.617474726962757465."
+	someRWSet = &kvrwset.KVRWSet{
+		Writes: []*kvrwset.KVWrite{
+			{Key: "6b6579", Value: []byte("some value")},
+			{Key: hashedCompKey, Value: []byte("other value")},
+		},
+	}
+	fpcrwset = &protos.FPCKVSet{
+		RwSet: someRWSet,
+	}
+	stub = &fakes.ChaincodeStub{}
+	stub.CreateCompositeKeyReturns("\x006f626a656374\x00617474726962757465\x00", nil)
+	err = v.ReplayReadWrites(stub, fpcrwset)
+	assert.NoError(t, err)
+	objectType, attributes := stub.CreateCompositeKeyArgsForCall(0)
+	assert.Equal(t, "6f626a656374", objectType)
+	assert.Equal(t, []string{"617474726962757465"}, attributes)
+	k, _ = stub.PutStateArgsForCall(0)
+	assert.Equal(t, "6b6579", k)
+	k, _ = stub.PutStateArgsForCall(1)
+	assert.Equal(t, "\x006f626a656374\x00617474726962757465\x00", k)
+
 	// error when rangequery
 	someRWSet = &kvrwset.KVRWSet{
 		RangeQueriesInfo: []*kvrwset.RangeQueryInfo{{
@@ -176,6 +206,15 @@ func TestReplayReadWrites(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestNewValidator(t *testing.T) {
+	v := NewValidator()
+	assert.Equal(t, crypto.GetDefaultCSP(), v.csp)
+
+	c := &fakes.CryptoProvider{}
+	v = NewValidator(WithCSP(c))
+	assert.Equal(t, c, v.csp)
+}
+
 func TestValidate(t *testing.T) {
 	// TODO
 	c := &fakes.CryptoProvider{}
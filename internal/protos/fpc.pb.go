@@ -17,6 +17,7 @@ import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	anypb "google.golang.org/protobuf/types/known/anypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -29,6 +30,67 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// algorithms protecting chaincode requests and responses, i.e., the (asymmetric) key transport with the chaincode
+// encryption key and the (symmetric) encryption of requests and responses
+type CipherSuite int32
+
+const (
+	// RSA-3072 OAEP (SHA-1) key transport and AES-128-GCM encryption, as implemented by the C++ enclave (crypto_pdo);
+	// this is the suite of requests without cipher suite
+	CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM CipherSuite = 0
+	// ECIES key transport with P-256 ECDH, HKDF-SHA256 and AES-256-GCM; AES-256-GCM encryption
+	CipherSuite_CIPHER_SUITE_ECIES_P256_HKDF_SHA256_AES256_GCM CipherSuite = 1
+	// ECIES key transport with X25519 ECDH, HKDF-SHA256 and AES-256-GCM; AES-256-GCM encryption
+	CipherSuite_CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM CipherSuite = 2
+	// hybrid post-quantum key transport combining the ML-KEM-768 and X25519 shared secrets with HKDF-SHA256 and
+	// AES-256-GCM; AES-256-GCM encryption. The chaincode encryption key holds an ML-KEM-768 encapsulation key and
+	// an X25519 public key, such that the request keys stay confidential as long as either of both is unbroken.
+	CipherSuite_CIPHER_SUITE_HYBRID_MLKEM768_X25519_HKDF_SHA256_AES256_GCM CipherSuite = 3
+)
+
+// Enum value maps for CipherSuite.
+var (
+	CipherSuite_name = map[int32]string{
+		0: "CIPHER_SUITE_RSA_OAEP_AES128_GCM",
+		1: "CIPHER_SUITE_ECIES_P256_HKDF_SHA256_AES256_GCM",
+		2: "CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM",
+		3: "CIPHER_SUITE_HYBRID_MLKEM768_X25519_HKDF_SHA256_AES256_GCM",
+	}
+	CipherSuite_value = map[string]int32{
+		"CIPHER_SUITE_RSA_OAEP_AES128_GCM":                           0,
+		"CIPHER_SUITE_ECIES_P256_HKDF_SHA256_AES256_GCM":             1,
+		"CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM":           2,
+		"CIPHER_SUITE_HYBRID_MLKEM768_X25519_HKDF_SHA256_AES256_GCM": 3,
+	}
+)
+
+func (x CipherSuite) Enum() *CipherSuite {
+	p := new(CipherSuite)
+	*p = x
+	return p
+}
+
+func (x CipherSuite) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CipherSuite) Descriptor() protoreflect.EnumDescriptor {
+	return file_fpc_fpc_proto_enumTypes[0].Descriptor()
+}
+
+func (CipherSuite) Type() protoreflect.EnumType {
+	return &file_fpc_fpc_proto_enumTypes[0]
+}
+
+func (x CipherSuite) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CipherSuite.Descriptor instead.
+func (CipherSuite) EnumDescriptor() ([]byte, []int) {
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{0}
+}
+
 type CCParameters struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// name of the chaincode
@@ -116,7 +178,10 @@ type HostParameters struct {
 	// particular FPC Chaincode enclave.   See additional information in
 	// fpc-registration.puml in the 'Org-Enclave binding/certification' group.
 	// Note that this field may be moved elsewhere.
-	Certificate   []byte `protobuf:"bytes,3,opt,name=certificate,proto3" json:"certificate,omitempty"`
+	Certificate []byte `protobuf:"bytes,3,opt,name=certificate,proto3" json:"certificate,omitempty"`
+	// registration nonce issued by ERCC (see `issueRegistrationNonce`) and passed with the InitEnclaveMessage.
+	// As the host parameters are part of the AttestedData, the nonce binds the credentials to a fresh registration.
+	Nonce         string `protobuf:"bytes,4,opt,name=nonce,proto3" json:"nonce,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -172,6 +237,13 @@ func (x *HostParameters) GetCertificate() []byte {
 	return nil
 }
 
+func (x *HostParameters) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
 type AttestedData struct {
 	state      protoimpl.MessageState `protogen:"open.v1"`
 	CcParams   *CCParameters          `protobuf:"bytes,1,opt,name=cc_params,json=ccParams,proto3" json:"cc_params,omitempty"`
@@ -185,7 +257,10 @@ type AttestedData struct {
 	TlccMrenclave string `protobuf:"bytes,5,opt,name=tlcc_mrenclave,json=tlccMrenclave,proto3" json:"tlcc_mrenclave,omitempty"`
 	// chaincode encryption key
 	// NOTE: This is a (momentary) short-cut over the FPC and FPC Lite specification in `docs/design/fabric-v2+/fpc-registration.puml` and `docs/design/fabric-v2+/fpc-key-dist.puml`
-	ChaincodeEk   []byte `protobuf:"bytes,6,opt,name=chaincode_ek,json=chaincodeEk,proto3" json:"chaincode_ek,omitempty"`
+	ChaincodeEk []byte `protobuf:"bytes,6,opt,name=chaincode_ek,json=chaincodeEk,proto3" json:"chaincode_ek,omitempty"`
+	// the cipher suites supported by the chaincode in order of preference, each with its chaincode encryption key.
+	// If empty, the chaincode only supports CIPHER_SUITE_RSA_OAEP_AES128_GCM with chaincode_ek.
+	ChaincodeEks  []*ChaincodeEncryptionKey `protobuf:"bytes,7,rep,name=chaincode_eks,json=chaincodeEks,proto3" json:"chaincode_eks,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -262,6 +337,111 @@ func (x *AttestedData) GetChaincodeEk() []byte {
 	return nil
 }
 
+func (x *AttestedData) GetChaincodeEks() []*ChaincodeEncryptionKey {
+	if x != nil {
+		return x.ChaincodeEks
+	}
+	return nil
+}
+
+type ChaincodeEncryptionKey struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	CipherSuite CipherSuite            `protobuf:"varint,1,opt,name=cipher_suite,json=cipherSuite,proto3,enum=fpc.CipherSuite" json:"cipher_suite,omitempty"`
+	// the (serialized) public key for the key transport of the cipher suite
+	ChaincodeEk   []byte `protobuf:"bytes,2,opt,name=chaincode_ek,json=chaincodeEk,proto3" json:"chaincode_ek,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChaincodeEncryptionKey) Reset() {
+	*x = ChaincodeEncryptionKey{}
+	mi := &file_fpc_fpc_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChaincodeEncryptionKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChaincodeEncryptionKey) ProtoMessage() {}
+
+func (x *ChaincodeEncryptionKey) ProtoReflect() protoreflect.Message {
+	mi := &file_fpc_fpc_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChaincodeEncryptionKey.ProtoReflect.Descriptor instead.
+func (*ChaincodeEncryptionKey) Descriptor() ([]byte, []int) {
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ChaincodeEncryptionKey) GetCipherSuite() CipherSuite {
+	if x != nil {
+		return x.CipherSuite
+	}
+	return CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM
+}
+
+func (x *ChaincodeEncryptionKey) GetChaincodeEk() []byte {
+	if x != nil {
+		return x.ChaincodeEk
+	}
+	return nil
+}
+
+type ChaincodeEncryptionKeys struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// the chaincode encryption keys of the supported cipher suites in order of preference (see AttestedData.chaincode_eks)
+	ChaincodeEks  []*ChaincodeEncryptionKey `protobuf:"bytes,1,rep,name=chaincode_eks,json=chaincodeEks,proto3" json:"chaincode_eks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChaincodeEncryptionKeys) Reset() {
+	*x = ChaincodeEncryptionKeys{}
+	mi := &file_fpc_fpc_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChaincodeEncryptionKeys) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChaincodeEncryptionKeys) ProtoMessage() {}
+
+func (x *ChaincodeEncryptionKeys) ProtoReflect() protoreflect.Message {
+	mi := &file_fpc_fpc_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChaincodeEncryptionKeys.ProtoReflect.Descriptor instead.
+func (*ChaincodeEncryptionKeys) Descriptor() ([]byte, []int) {
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ChaincodeEncryptionKeys) GetChaincodeEks() []*ChaincodeEncryptionKey {
+	if x != nil {
+		return x.ChaincodeEks
+	}
+	return nil
+}
+
 type Credentials struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// serialization of type **AttestedData**
@@ -269,14 +449,18 @@ type Credentials struct {
 	// serialized attestation/quote as output by `get_attestatation`, see `interfaces.attestation.md`
 	Attestation []byte `protobuf:"bytes,2,opt,name=attestation,proto3" json:"attestation,omitempty"`
 	// serialized attestation evidence as output by `AttestationToEvidence`, see `interfaces.attestation.md`
-	Evidence      []byte `protobuf:"bytes,3,opt,name=evidence,proto3" json:"evidence,omitempty"`
+	Evidence []byte `protobuf:"bytes,3,opt,name=evidence,proto3" json:"evidence,omitempty"`
+	// the time after which the credentials are no longer valid and the enclave must be re-attested;
+	// set by ERCC on registration and renewal, not covered by the attestation.
+	// Credentials without expiry never expire.
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Credentials) Reset() {
 	*x = Credentials{}
-	mi := &file_fpc_fpc_proto_msgTypes[3]
+	mi := &file_fpc_fpc_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -288,7 +472,7 @@ func (x *Credentials) String() string {
 func (*Credentials) ProtoMessage() {}
 
 func (x *Credentials) ProtoReflect() protoreflect.Message {
-	mi := &file_fpc_fpc_proto_msgTypes[3]
+	mi := &file_fpc_fpc_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -301,7 +485,7 @@ func (x *Credentials) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Credentials.ProtoReflect.Descriptor instead.
 func (*Credentials) Descriptor() ([]byte, []int) {
-	return file_fpc_fpc_proto_rawDescGZIP(), []int{3}
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *Credentials) GetSerializedAttestedData() *anypb.Any {
@@ -325,6 +509,13 @@ func (x *Credentials) GetEvidence() []byte {
 	return nil
 }
 
+func (x *Credentials) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
 type InitEnclaveMessage struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// the (externally accessible) address of the peer endpoint in format <ip-addr|hostname>:<port-number>
@@ -332,13 +523,15 @@ type InitEnclaveMessage struct {
 	// parameters passed for initialization of the attestation API as required by that API
 	// (i.e., a base64-encoded json string, see 'interfaces.attestation.md' and 'common/crypto/attestation-api')
 	AttestationParams []byte `protobuf:"bytes,2,opt,name=attestation_params,json=attestationParams,proto3" json:"attestation_params,omitempty"`
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+	// registration nonce issued by ERCC (i.e., the id of the `issueRegistrationNonce` transaction)
+	Nonce         string `protobuf:"bytes,3,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *InitEnclaveMessage) Reset() {
 	*x = InitEnclaveMessage{}
-	mi := &file_fpc_fpc_proto_msgTypes[4]
+	mi := &file_fpc_fpc_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -350,7 +543,7 @@ func (x *InitEnclaveMessage) String() string {
 func (*InitEnclaveMessage) ProtoMessage() {}
 
 func (x *InitEnclaveMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_fpc_fpc_proto_msgTypes[4]
+	mi := &file_fpc_fpc_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -363,7 +556,7 @@ func (x *InitEnclaveMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InitEnclaveMessage.ProtoReflect.Descriptor instead.
 func (*InitEnclaveMessage) Descriptor() ([]byte, []int) {
-	return file_fpc_fpc_proto_rawDescGZIP(), []int{4}
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *InitEnclaveMessage) GetPeerEndpoint() string {
@@ -380,6 +573,13 @@ func (x *InitEnclaveMessage) GetAttestationParams() []byte {
 	return nil
 }
 
+func (x *InitEnclaveMessage) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
 type CleartextChaincodeRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// the function and args to invoke
@@ -390,7 +590,7 @@ type CleartextChaincodeRequest struct {
 
 func (x *CleartextChaincodeRequest) Reset() {
 	*x = CleartextChaincodeRequest{}
-	mi := &file_fpc_fpc_proto_msgTypes[5]
+	mi := &file_fpc_fpc_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -402,7 +602,7 @@ func (x *CleartextChaincodeRequest) String() string {
 func (*CleartextChaincodeRequest) ProtoMessage() {}
 
 func (x *CleartextChaincodeRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_fpc_fpc_proto_msgTypes[5]
+	mi := &file_fpc_fpc_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -415,7 +615,7 @@ func (x *CleartextChaincodeRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CleartextChaincodeRequest.ProtoReflect.Descriptor instead.
 func (*CleartextChaincodeRequest) Descriptor() ([]byte, []int) {
-	return file_fpc_fpc_proto_rawDescGZIP(), []int{5}
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *CleartextChaincodeRequest) GetInput() *peer.ChaincodeInput {
@@ -429,15 +629,18 @@ type ChaincodeRequestMessage struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// an encryption (symmetric) of the serialization of CleartextChaincodeRequest with KeyTransportMessage.request_encryption_key
 	EncryptedRequest []byte `protobuf:"bytes,1,opt,name=encrypted_request,json=encryptedRequest,proto3" json:"encrypted_request,omitempty"`
-	// an encryption (asymmetric) of the serialization of request KeyTransportMessage with AttestedData.chaincode_ek
+	// an encryption (asymmetric) of the serialization of request KeyTransportMessage with the chaincode encryption
+	// key of the cipher suite (i.e., AttestedData.chaincode_ek for CIPHER_SUITE_RSA_OAEP_AES128_GCM)
 	EncryptedKeyTransportMessage []byte `protobuf:"bytes,2,opt,name=encrypted_key_transport_message,json=encryptedKeyTransportMessage,proto3" json:"encrypted_key_transport_message,omitempty"`
-	unknownFields                protoimpl.UnknownFields
-	sizeCache                    protoimpl.SizeCache
+	// the cipher suite of the key transport and the request and response encryption
+	CipherSuite   CipherSuite `protobuf:"varint,3,opt,name=cipher_suite,json=cipherSuite,proto3,enum=fpc.CipherSuite" json:"cipher_suite,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ChaincodeRequestMessage) Reset() {
 	*x = ChaincodeRequestMessage{}
-	mi := &file_fpc_fpc_proto_msgTypes[6]
+	mi := &file_fpc_fpc_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -449,7 +652,7 @@ func (x *ChaincodeRequestMessage) String() string {
 func (*ChaincodeRequestMessage) ProtoMessage() {}
 
 func (x *ChaincodeRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_fpc_fpc_proto_msgTypes[6]
+	mi := &file_fpc_fpc_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -462,7 +665,7 @@ func (x *ChaincodeRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ChaincodeRequestMessage.ProtoReflect.Descriptor instead.
 func (*ChaincodeRequestMessage) Descriptor() ([]byte, []int) {
-	return file_fpc_fpc_proto_rawDescGZIP(), []int{6}
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *ChaincodeRequestMessage) GetEncryptedRequest() []byte {
@@ -479,6 +682,58 @@ func (x *ChaincodeRequestMessage) GetEncryptedKeyTransportMessage() []byte {
 	return nil
 }
 
+func (x *ChaincodeRequestMessage) GetCipherSuite() CipherSuite {
+	if x != nil {
+		return x.CipherSuite
+	}
+	return CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM
+}
+
+type ChaincodeBatchRequestMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// serializations of ChaincodeRequestMessage, executed in the given order within a single transaction
+	ChaincodeRequestMessages [][]byte `protobuf:"bytes,1,rep,name=chaincode_request_messages,json=chaincodeRequestMessages,proto3" json:"chaincode_request_messages,omitempty"`
+	unknownFields            protoimpl.UnknownFields
+	sizeCache                protoimpl.SizeCache
+}
+
+func (x *ChaincodeBatchRequestMessage) Reset() {
+	*x = ChaincodeBatchRequestMessage{}
+	mi := &file_fpc_fpc_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChaincodeBatchRequestMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChaincodeBatchRequestMessage) ProtoMessage() {}
+
+func (x *ChaincodeBatchRequestMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_fpc_fpc_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChaincodeBatchRequestMessage.ProtoReflect.Descriptor instead.
+func (*ChaincodeBatchRequestMessage) Descriptor() ([]byte, []int) {
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ChaincodeBatchRequestMessage) GetChaincodeRequestMessages() [][]byte {
+	if x != nil {
+		return x.ChaincodeRequestMessages
+	}
+	return nil
+}
+
 type KeyTransportMessage struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// key to decrypt CleartextChaincodeRequest
@@ -491,7 +746,7 @@ type KeyTransportMessage struct {
 
 func (x *KeyTransportMessage) Reset() {
 	*x = KeyTransportMessage{}
-	mi := &file_fpc_fpc_proto_msgTypes[7]
+	mi := &file_fpc_fpc_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -503,7 +758,7 @@ func (x *KeyTransportMessage) String() string {
 func (*KeyTransportMessage) ProtoMessage() {}
 
 func (x *KeyTransportMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_fpc_fpc_proto_msgTypes[7]
+	mi := &file_fpc_fpc_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -516,7 +771,7 @@ func (x *KeyTransportMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KeyTransportMessage.ProtoReflect.Descriptor instead.
 func (*KeyTransportMessage) Descriptor() ([]byte, []int) {
-	return file_fpc_fpc_proto_rawDescGZIP(), []int{7}
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *KeyTransportMessage) GetRequestEncryptionKey() []byte {
@@ -543,7 +798,7 @@ type CleartextChaincodeResponse struct {
 
 func (x *CleartextChaincodeResponse) Reset() {
 	*x = CleartextChaincodeResponse{}
-	mi := &file_fpc_fpc_proto_msgTypes[8]
+	mi := &file_fpc_fpc_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -555,7 +810,7 @@ func (x *CleartextChaincodeResponse) String() string {
 func (*CleartextChaincodeResponse) ProtoMessage() {}
 
 func (x *CleartextChaincodeResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_fpc_fpc_proto_msgTypes[8]
+	mi := &file_fpc_fpc_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -568,7 +823,7 @@ func (x *CleartextChaincodeResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CleartextChaincodeResponse.ProtoReflect.Descriptor instead.
 func (*CleartextChaincodeResponse) Descriptor() ([]byte, []int) {
-	return file_fpc_fpc_proto_rawDescGZIP(), []int{8}
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *CleartextChaincodeResponse) GetResponse() *peer.Response {
@@ -590,7 +845,7 @@ type FPCKVSet struct {
 
 func (x *FPCKVSet) Reset() {
 	*x = FPCKVSet{}
-	mi := &file_fpc_fpc_proto_msgTypes[9]
+	mi := &file_fpc_fpc_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -602,7 +857,7 @@ func (x *FPCKVSet) String() string {
 func (*FPCKVSet) ProtoMessage() {}
 
 func (x *FPCKVSet) ProtoReflect() protoreflect.Message {
-	mi := &file_fpc_fpc_proto_msgTypes[9]
+	mi := &file_fpc_fpc_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -615,7 +870,7 @@ func (x *FPCKVSet) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FPCKVSet.ProtoReflect.Descriptor instead.
 func (*FPCKVSet) Descriptor() ([]byte, []int) {
-	return file_fpc_fpc_proto_rawDescGZIP(), []int{9}
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *FPCKVSet) GetRwSet() *kvrwset.KVRWSet {
@@ -646,14 +901,22 @@ type ChaincodeResponseMessage struct {
 	// and not extracted from it; validation chaincode will check for consistency
 	ChaincodeRequestMessageHash []byte `protobuf:"bytes,4,opt,name=chaincode_request_message_hash,json=chaincodeRequestMessageHash,proto3" json:"chaincode_request_message_hash,omitempty"`
 	// identity for public key used to sign
-	EnclaveId     string `protobuf:"bytes,5,opt,name=enclave_id,json=enclaveId,proto3" json:"enclave_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	EnclaveId string `protobuf:"bytes,5,opt,name=enclave_id,json=enclaveId,proto3" json:"enclave_id,omitempty"`
+	// for batch invocations (i.e., `__invokeBatch`), the encrypted responses (as for encrypted_response), one for each
+	// request of the ChaincodeBatchRequestMessage, with the response_encryption_key of the corresponding request.
+	// In this case, encrypted_response is empty, fpc_rw_set covers all requests, and chaincode_request_message_hash is the
+	// hash of the serialized ChaincodeBatchRequestMessage.
+	EncryptedBatchResponses [][]byte `protobuf:"bytes,6,rep,name=encrypted_batch_responses,json=encryptedBatchResponses,proto3" json:"encrypted_batch_responses,omitempty"`
+	// if set, the decrypted encrypted_response (or encrypted_batch_responses) are padded to hide the length of the
+	// response, i.e., the serialized response is followed by 0x80 and zero or more 0x00 bytes (ISO/IEC 7816-4)
+	PaddedResponses bool `protobuf:"varint,7,opt,name=padded_responses,json=paddedResponses,proto3" json:"padded_responses,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *ChaincodeResponseMessage) Reset() {
 	*x = ChaincodeResponseMessage{}
-	mi := &file_fpc_fpc_proto_msgTypes[10]
+	mi := &file_fpc_fpc_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -665,7 +928,7 @@ func (x *ChaincodeResponseMessage) String() string {
 func (*ChaincodeResponseMessage) ProtoMessage() {}
 
 func (x *ChaincodeResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_fpc_fpc_proto_msgTypes[10]
+	mi := &file_fpc_fpc_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -678,7 +941,7 @@ func (x *ChaincodeResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ChaincodeResponseMessage.ProtoReflect.Descriptor instead.
 func (*ChaincodeResponseMessage) Descriptor() ([]byte, []int) {
-	return file_fpc_fpc_proto_rawDescGZIP(), []int{10}
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *ChaincodeResponseMessage) GetEncryptedResponse() []byte {
@@ -716,6 +979,20 @@ func (x *ChaincodeResponseMessage) GetEnclaveId() string {
 	return ""
 }
 
+func (x *ChaincodeResponseMessage) GetEncryptedBatchResponses() [][]byte {
+	if x != nil {
+		return x.EncryptedBatchResponses
+	}
+	return nil
+}
+
+func (x *ChaincodeResponseMessage) GetPaddedResponses() bool {
+	if x != nil {
+		return x.PaddedResponses
+	}
+	return false
+}
+
 type SignedChaincodeResponseMessage struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// binary encoding of a ChaincodeResponseMessage protobuf
@@ -728,7 +1005,7 @@ type SignedChaincodeResponseMessage struct {
 
 func (x *SignedChaincodeResponseMessage) Reset() {
 	*x = SignedChaincodeResponseMessage{}
-	mi := &file_fpc_fpc_proto_msgTypes[11]
+	mi := &file_fpc_fpc_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -740,7 +1017,7 @@ func (x *SignedChaincodeResponseMessage) String() string {
 func (*SignedChaincodeResponseMessage) ProtoMessage() {}
 
 func (x *SignedChaincodeResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_fpc_fpc_proto_msgTypes[11]
+	mi := &file_fpc_fpc_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -753,7 +1030,7 @@ func (x *SignedChaincodeResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SignedChaincodeResponseMessage.ProtoReflect.Descriptor instead.
 func (*SignedChaincodeResponseMessage) Descriptor() ([]byte, []int) {
-	return file_fpc_fpc_proto_rawDescGZIP(), []int{11}
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *SignedChaincodeResponseMessage) GetChaincodeResponseMessage() []byte {
@@ -770,21 +1047,249 @@ func (x *SignedChaincodeResponseMessage) GetSignature() []byte {
 	return nil
 }
 
+// SealedArgument is an invocation argument that a client sealed to the public key of a specific recipient. The enclave
+// can neither read the content nor modify it unnoticed, but can attest metadata about it (see SealedArgumentMetadata).
+type SealedArgument struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// the cipher suite of the recipient key
+	CipherSuite CipherSuite `protobuf:"varint,1,opt,name=cipher_suite,json=cipherSuite,proto3,enum=fpc.CipherSuite" json:"cipher_suite,omitempty"`
+	// hash (SHA-256) of the recipient public key
+	RecipientPkHash []byte `protobuf:"bytes,2,opt,name=recipient_pk_hash,json=recipientPkHash,proto3" json:"recipient_pk_hash,omitempty"`
+	// content encryption key, encrypted (asymmetric) with the recipient public key
+	EncryptedKey []byte `protobuf:"bytes,3,opt,name=encrypted_key,json=encryptedKey,proto3" json:"encrypted_key,omitempty"`
+	// an encryption (symmetric) of the content with the content encryption key; the encryption authenticates the
+	// cipher suite and the recipient_pk_hash as associated data
+	EncryptedContent []byte `protobuf:"bytes,4,opt,name=encrypted_content,json=encryptedContent,proto3" json:"encrypted_content,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *SealedArgument) Reset() {
+	*x = SealedArgument{}
+	mi := &file_fpc_fpc_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SealedArgument) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SealedArgument) ProtoMessage() {}
+
+func (x *SealedArgument) ProtoReflect() protoreflect.Message {
+	mi := &file_fpc_fpc_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SealedArgument.ProtoReflect.Descriptor instead.
+func (*SealedArgument) Descriptor() ([]byte, []int) {
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *SealedArgument) GetCipherSuite() CipherSuite {
+	if x != nil {
+		return x.CipherSuite
+	}
+	return CipherSuite_CIPHER_SUITE_RSA_OAEP_AES128_GCM
+}
+
+func (x *SealedArgument) GetRecipientPkHash() []byte {
+	if x != nil {
+		return x.RecipientPkHash
+	}
+	return nil
+}
+
+func (x *SealedArgument) GetEncryptedKey() []byte {
+	if x != nil {
+		return x.EncryptedKey
+	}
+	return nil
+}
+
+func (x *SealedArgument) GetEncryptedContent() []byte {
+	if x != nil {
+		return x.EncryptedContent
+	}
+	return nil
+}
+
+// SealedArgumentMetadata is the metadata attested by an enclave for a SealedArgument
+type SealedArgumentMetadata struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// hash (SHA-256) of the serialized SealedArgument
+	SealedArgumentHash []byte `protobuf:"bytes,1,opt,name=sealed_argument_hash,json=sealedArgumentHash,proto3" json:"sealed_argument_hash,omitempty"`
+	// hash (SHA-256) of the recipient public key, as in the SealedArgument
+	RecipientPkHash []byte `protobuf:"bytes,2,opt,name=recipient_pk_hash,json=recipientPkHash,proto3" json:"recipient_pk_hash,omitempty"`
+	// the transaction that attested the metadata
+	ChannelId string                 `protobuf:"bytes,3,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"`
+	TxId      string                 `protobuf:"bytes,4,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// identity for public key used to sign
+	EnclaveId string `protobuf:"bytes,6,opt,name=enclave_id,json=enclaveId,proto3" json:"enclave_id,omitempty"`
+	// metadata defined by the chaincode, e.g., the sender or the type of the content
+	Metadata      []byte `protobuf:"bytes,7,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SealedArgumentMetadata) Reset() {
+	*x = SealedArgumentMetadata{}
+	mi := &file_fpc_fpc_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SealedArgumentMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SealedArgumentMetadata) ProtoMessage() {}
+
+func (x *SealedArgumentMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_fpc_fpc_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SealedArgumentMetadata.ProtoReflect.Descriptor instead.
+func (*SealedArgumentMetadata) Descriptor() ([]byte, []int) {
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SealedArgumentMetadata) GetSealedArgumentHash() []byte {
+	if x != nil {
+		return x.SealedArgumentHash
+	}
+	return nil
+}
+
+func (x *SealedArgumentMetadata) GetRecipientPkHash() []byte {
+	if x != nil {
+		return x.RecipientPkHash
+	}
+	return nil
+}
+
+func (x *SealedArgumentMetadata) GetChannelId() string {
+	if x != nil {
+		return x.ChannelId
+	}
+	return ""
+}
+
+func (x *SealedArgumentMetadata) GetTxId() string {
+	if x != nil {
+		return x.TxId
+	}
+	return ""
+}
+
+func (x *SealedArgumentMetadata) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *SealedArgumentMetadata) GetEnclaveId() string {
+	if x != nil {
+		return x.EnclaveId
+	}
+	return ""
+}
+
+func (x *SealedArgumentMetadata) GetMetadata() []byte {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type SignedSealedArgumentMetadata struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// binary encoding of a SealedArgumentMetadata protobuf
+	SealedArgumentMetadata []byte `protobuf:"bytes,1,opt,name=sealed_argument_metadata,json=sealedArgumentMetadata,proto3" json:"sealed_argument_metadata,omitempty"`
+	// signature of the enclave over "FPC-SEALED-ARGUMENT-METADATA-v1" || sealed_argument_metadata
+	Signature     []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignedSealedArgumentMetadata) Reset() {
+	*x = SignedSealedArgumentMetadata{}
+	mi := &file_fpc_fpc_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignedSealedArgumentMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignedSealedArgumentMetadata) ProtoMessage() {}
+
+func (x *SignedSealedArgumentMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_fpc_fpc_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignedSealedArgumentMetadata.ProtoReflect.Descriptor instead.
+func (*SignedSealedArgumentMetadata) Descriptor() ([]byte, []int) {
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SignedSealedArgumentMetadata) GetSealedArgumentMetadata() []byte {
+	if x != nil {
+		return x.SealedArgumentMetadata
+	}
+	return nil
+}
+
+func (x *SignedSealedArgumentMetadata) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
 var File_fpc_fpc_proto protoreflect.FileDescriptor
 
 const file_fpc_fpc_proto_rawDesc = "" +
 	"\n" +
-	"\rfpc/fpc.proto\x12\x03fpc\x1a\x19google/protobuf/any.proto\x1a\x14peer/chaincode.proto\x1a\x13peer/proposal.proto\x1a\x1cpeer/proposal_response.proto\x1a#ledger/rwset/kvrwset/kv_rwset.proto\"\x86\x01\n" +
+	"\rfpc/fpc.proto\x12\x03fpc\x1a\x19google/protobuf/any.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x14peer/chaincode.proto\x1a\x13peer/proposal.proto\x1a\x1cpeer/proposal_response.proto\x1a#ledger/rwset/kvrwset/kv_rwset.proto\"\x86\x01\n" +
 	"\fCCParameters\x12!\n" +
 	"\fchaincode_id\x18\x01 \x01(\tR\vchaincodeId\x12\x18\n" +
 	"\aversion\x18\x02 \x01(\tR\aversion\x12\x1a\n" +
 	"\bsequence\x18\x03 \x01(\x03R\bsequence\x12\x1d\n" +
 	"\n" +
-	"channel_id\x18\x04 \x01(\tR\tchannelId\"w\n" +
+	"channel_id\x18\x04 \x01(\tR\tchannelId\"\x8d\x01\n" +
 	"\x0eHostParameters\x12\x1e\n" +
 	"\vpeer_msp_id\x18\x01 \x01(\tR\tpeerMspId\x12#\n" +
 	"\rpeer_endpoint\x18\x02 \x01(\tR\fpeerEndpoint\x12 \n" +
-	"\vcertificate\x18\x03 \x01(\fR\vcertificate\"\x80\x02\n" +
+	"\vcertificate\x18\x03 \x01(\fR\vcertificate\x12\x14\n" +
+	"\x05nonce\x18\x04 \x01(\tR\x05nonce\"\xc2\x02\n" +
 	"\fAttestedData\x12.\n" +
 	"\tcc_params\x18\x01 \x01(\v2\x11.fpc.CCParametersR\bccParams\x124\n" +
 	"\vhost_params\x18\x02 \x01(\v2\x13.fpc.HostParametersR\n" +
@@ -793,19 +1298,31 @@ const file_fpc_fpc_proto_rawDesc = "" +
 	"enclave_vk\x18\x03 \x01(\fR\tenclaveVk\x12!\n" +
 	"\fchannel_hash\x18\x04 \x01(\fR\vchannelHash\x12%\n" +
 	"\x0etlcc_mrenclave\x18\x05 \x01(\tR\rtlccMrenclave\x12!\n" +
-	"\fchaincode_ek\x18\x06 \x01(\fR\vchaincodeEk\"\x9b\x01\n" +
+	"\fchaincode_ek\x18\x06 \x01(\fR\vchaincodeEk\x12@\n" +
+	"\rchaincode_eks\x18\a \x03(\v2\x1b.fpc.ChaincodeEncryptionKeyR\fchaincodeEks\"p\n" +
+	"\x16ChaincodeEncryptionKey\x123\n" +
+	"\fcipher_suite\x18\x01 \x01(\x0e2\x10.fpc.CipherSuiteR\vcipherSuite\x12!\n" +
+	"\fchaincode_ek\x18\x02 \x01(\fR\vchaincodeEk\"[\n" +
+	"\x17ChaincodeEncryptionKeys\x12@\n" +
+	"\rchaincode_eks\x18\x01 \x03(\v2\x1b.fpc.ChaincodeEncryptionKeyR\fchaincodeEks\"\xd6\x01\n" +
 	"\vCredentials\x12N\n" +
 	"\x18serialized_attested_data\x18\x01 \x01(\v2\x14.google.protobuf.AnyR\x16serializedAttestedData\x12 \n" +
 	"\vattestation\x18\x02 \x01(\fR\vattestation\x12\x1a\n" +
-	"\bevidence\x18\x03 \x01(\fR\bevidence\"h\n" +
+	"\bevidence\x18\x03 \x01(\fR\bevidence\x129\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"~\n" +
 	"\x12InitEnclaveMessage\x12#\n" +
 	"\rpeer_endpoint\x18\x01 \x01(\tR\fpeerEndpoint\x12-\n" +
-	"\x12attestation_params\x18\x02 \x01(\fR\x11attestationParams\"I\n" +
+	"\x12attestation_params\x18\x02 \x01(\fR\x11attestationParams\x12\x14\n" +
+	"\x05nonce\x18\x03 \x01(\tR\x05nonce\"I\n" +
 	"\x19CleartextChaincodeRequest\x12,\n" +
-	"\x05input\x18\x01 \x01(\v2\x16.protos.ChaincodeInputR\x05input\"\x8d\x01\n" +
+	"\x05input\x18\x01 \x01(\v2\x16.protos.ChaincodeInputR\x05input\"\xc2\x01\n" +
 	"\x17ChaincodeRequestMessage\x12+\n" +
 	"\x11encrypted_request\x18\x01 \x01(\fR\x10encryptedRequest\x12E\n" +
-	"\x1fencrypted_key_transport_message\x18\x02 \x01(\fR\x1cencryptedKeyTransportMessage\"\x83\x01\n" +
+	"\x1fencrypted_key_transport_message\x18\x02 \x01(\fR\x1cencryptedKeyTransportMessage\x123\n" +
+	"\fcipher_suite\x18\x03 \x01(\x0e2\x10.fpc.CipherSuiteR\vcipherSuite\"\\\n" +
+	"\x1cChaincodeBatchRequestMessage\x12<\n" +
+	"\x1achaincode_request_messages\x18\x01 \x03(\fR\x18chaincodeRequestMessages\"\x83\x01\n" +
 	"\x13KeyTransportMessage\x124\n" +
 	"\x16request_encryption_key\x18\x01 \x01(\fR\x14requestEncryptionKey\x126\n" +
 	"\x17response_encryption_key\x18\x02 \x01(\fR\x15responseEncryptionKey\"J\n" +
@@ -813,7 +1330,7 @@ const file_fpc_fpc_proto_rawDesc = "" +
 	"\bresponse\x18\x01 \x01(\v2\x10.protos.ResponseR\bresponse\"_\n" +
 	"\bFPCKVSet\x12'\n" +
 	"\x06rw_set\x18\x01 \x01(\v2\x10.kvrwset.KVRWSetR\x05rwSet\x12*\n" +
-	"\x11read_value_hashes\x18\x02 \x03(\fR\x0freadValueHashes\"\x8e\x02\n" +
+	"\x11read_value_hashes\x18\x02 \x03(\fR\x0freadValueHashes\"\xf5\x02\n" +
 	"\x18ChaincodeResponseMessage\x12-\n" +
 	"\x12encrypted_response\x18\x01 \x01(\fR\x11encryptedResponse\x12+\n" +
 	"\n" +
@@ -821,10 +1338,35 @@ const file_fpc_fpc_proto_rawDesc = "" +
 	"\bproposal\x18\x03 \x01(\v2\x16.protos.SignedProposalR\bproposal\x12C\n" +
 	"\x1echaincode_request_message_hash\x18\x04 \x01(\fR\x1bchaincodeRequestMessageHash\x12\x1d\n" +
 	"\n" +
-	"enclave_id\x18\x05 \x01(\tR\tenclaveId\"|\n" +
+	"enclave_id\x18\x05 \x01(\tR\tenclaveId\x12:\n" +
+	"\x19encrypted_batch_responses\x18\x06 \x03(\fR\x17encryptedBatchResponses\x12)\n" +
+	"\x10padded_responses\x18\a \x01(\bR\x0fpaddedResponses\"|\n" +
 	"\x1eSignedChaincodeResponseMessage\x12<\n" +
 	"\x1achaincode_response_message\x18\x01 \x01(\fR\x18chaincodeResponseMessage\x12\x1c\n" +
-	"\tsignature\x18\x02 \x01(\fR\tsignatureBAZ?github.com/hyperledger/fabric-private-chaincode/internal/protosb\x06proto3"
+	"\tsignature\x18\x02 \x01(\fR\tsignature\"\xc3\x01\n" +
+	"\x0eSealedArgument\x123\n" +
+	"\fcipher_suite\x18\x01 \x01(\x0e2\x10.fpc.CipherSuiteR\vcipherSuite\x12*\n" +
+	"\x11recipient_pk_hash\x18\x02 \x01(\fR\x0frecipientPkHash\x12#\n" +
+	"\rencrypted_key\x18\x03 \x01(\fR\fencryptedKey\x12+\n" +
+	"\x11encrypted_content\x18\x04 \x01(\fR\x10encryptedContent\"\x9f\x02\n" +
+	"\x16SealedArgumentMetadata\x120\n" +
+	"\x14sealed_argument_hash\x18\x01 \x01(\fR\x12sealedArgumentHash\x12*\n" +
+	"\x11recipient_pk_hash\x18\x02 \x01(\fR\x0frecipientPkHash\x12\x1d\n" +
+	"\n" +
+	"channel_id\x18\x03 \x01(\tR\tchannelId\x12\x13\n" +
+	"\x05tx_id\x18\x04 \x01(\tR\x04txId\x128\n" +
+	"\ttimestamp\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1d\n" +
+	"\n" +
+	"enclave_id\x18\x06 \x01(\tR\tenclaveId\x12\x1a\n" +
+	"\bmetadata\x18\a \x01(\fR\bmetadata\"v\n" +
+	"\x1cSignedSealedArgumentMetadata\x128\n" +
+	"\x18sealed_argument_metadata\x18\x01 \x01(\fR\x16sealedArgumentMetadata\x12\x1c\n" +
+	"\tsignature\x18\x02 \x01(\fR\tsignature*\xdd\x01\n" +
+	"\vCipherSuite\x12$\n" +
+	" CIPHER_SUITE_RSA_OAEP_AES128_GCM\x10\x00\x122\n" +
+	".CIPHER_SUITE_ECIES_P256_HKDF_SHA256_AES256_GCM\x10\x01\x124\n" +
+	"0CIPHER_SUITE_ECIES_X25519_HKDF_SHA256_AES256_GCM\x10\x02\x12>\n" +
+	":CIPHER_SUITE_HYBRID_MLKEM768_X25519_HKDF_SHA256_AES256_GCM\x10\x03BAZ?github.com/hyperledger/fabric-private-chaincode/internal/protosb\x06proto3"
 
 var (
 	file_fpc_fpc_proto_rawDescOnce sync.Once
@@ -838,40 +1380,56 @@ func file_fpc_fpc_proto_rawDescGZIP() []byte {
 	return file_fpc_fpc_proto_rawDescData
 }
 
-var file_fpc_fpc_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_fpc_fpc_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_fpc_fpc_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
 var file_fpc_fpc_proto_goTypes = []any{
-	(*CCParameters)(nil),                   // 0: fpc.CCParameters
-	(*HostParameters)(nil),                 // 1: fpc.HostParameters
-	(*AttestedData)(nil),                   // 2: fpc.AttestedData
-	(*Credentials)(nil),                    // 3: fpc.Credentials
-	(*InitEnclaveMessage)(nil),             // 4: fpc.InitEnclaveMessage
-	(*CleartextChaincodeRequest)(nil),      // 5: fpc.CleartextChaincodeRequest
-	(*ChaincodeRequestMessage)(nil),        // 6: fpc.ChaincodeRequestMessage
-	(*KeyTransportMessage)(nil),            // 7: fpc.KeyTransportMessage
-	(*CleartextChaincodeResponse)(nil),     // 8: fpc.CleartextChaincodeResponse
-	(*FPCKVSet)(nil),                       // 9: fpc.FPCKVSet
-	(*ChaincodeResponseMessage)(nil),       // 10: fpc.ChaincodeResponseMessage
-	(*SignedChaincodeResponseMessage)(nil), // 11: fpc.SignedChaincodeResponseMessage
-	(*anypb.Any)(nil),                      // 12: google.protobuf.Any
-	(*peer.ChaincodeInput)(nil),            // 13: protos.ChaincodeInput
-	(*peer.Response)(nil),                  // 14: protos.Response
-	(*kvrwset.KVRWSet)(nil),                // 15: kvrwset.KVRWSet
-	(*peer.SignedProposal)(nil),            // 16: protos.SignedProposal
+	(CipherSuite)(0),                       // 0: fpc.CipherSuite
+	(*CCParameters)(nil),                   // 1: fpc.CCParameters
+	(*HostParameters)(nil),                 // 2: fpc.HostParameters
+	(*AttestedData)(nil),                   // 3: fpc.AttestedData
+	(*ChaincodeEncryptionKey)(nil),         // 4: fpc.ChaincodeEncryptionKey
+	(*ChaincodeEncryptionKeys)(nil),        // 5: fpc.ChaincodeEncryptionKeys
+	(*Credentials)(nil),                    // 6: fpc.Credentials
+	(*InitEnclaveMessage)(nil),             // 7: fpc.InitEnclaveMessage
+	(*CleartextChaincodeRequest)(nil),      // 8: fpc.CleartextChaincodeRequest
+	(*ChaincodeRequestMessage)(nil),        // 9: fpc.ChaincodeRequestMessage
+	(*ChaincodeBatchRequestMessage)(nil),   // 10: fpc.ChaincodeBatchRequestMessage
+	(*KeyTransportMessage)(nil),            // 11: fpc.KeyTransportMessage
+	(*CleartextChaincodeResponse)(nil),     // 12: fpc.CleartextChaincodeResponse
+	(*FPCKVSet)(nil),                       // 13: fpc.FPCKVSet
+	(*ChaincodeResponseMessage)(nil),       // 14: fpc.ChaincodeResponseMessage
+	(*SignedChaincodeResponseMessage)(nil), // 15: fpc.SignedChaincodeResponseMessage
+	(*SealedArgument)(nil),                 // 16: fpc.SealedArgument
+	(*SealedArgumentMetadata)(nil),         // 17: fpc.SealedArgumentMetadata
+	(*SignedSealedArgumentMetadata)(nil),   // 18: fpc.SignedSealedArgumentMetadata
+	(*anypb.Any)(nil),                      // 19: google.protobuf.Any
+	(*timestamppb.Timestamp)(nil),          // 20: google.protobuf.Timestamp
+	(*peer.ChaincodeInput)(nil),            // 21: protos.ChaincodeInput
+	(*peer.Response)(nil),                  // 22: protos.Response
+	(*kvrwset.KVRWSet)(nil),                // 23: kvrwset.KVRWSet
+	(*peer.SignedProposal)(nil),            // 24: protos.SignedProposal
 }
 var file_fpc_fpc_proto_depIdxs = []int32{
-	0,  // 0: fpc.AttestedData.cc_params:type_name -> fpc.CCParameters
-	1,  // 1: fpc.AttestedData.host_params:type_name -> fpc.HostParameters
-	12, // 2: fpc.Credentials.serialized_attested_data:type_name -> google.protobuf.Any
-	13, // 3: fpc.CleartextChaincodeRequest.input:type_name -> protos.ChaincodeInput
-	14, // 4: fpc.CleartextChaincodeResponse.response:type_name -> protos.Response
-	15, // 5: fpc.FPCKVSet.rw_set:type_name -> kvrwset.KVRWSet
-	9,  // 6: fpc.ChaincodeResponseMessage.fpc_rw_set:type_name -> fpc.FPCKVSet
-	16, // 7: fpc.ChaincodeResponseMessage.proposal:type_name -> protos.SignedProposal
-	8,  // [8:8] is the sub-list for method output_type
-	8,  // [8:8] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	1,  // 0: fpc.AttestedData.cc_params:type_name -> fpc.CCParameters
+	2,  // 1: fpc.AttestedData.host_params:type_name -> fpc.HostParameters
+	4,  // 2: fpc.AttestedData.chaincode_eks:type_name -> fpc.ChaincodeEncryptionKey
+	0,  // 3: fpc.ChaincodeEncryptionKey.cipher_suite:type_name -> fpc.CipherSuite
+	4,  // 4: fpc.ChaincodeEncryptionKeys.chaincode_eks:type_name -> fpc.ChaincodeEncryptionKey
+	19, // 5: fpc.Credentials.serialized_attested_data:type_name -> google.protobuf.Any
+	20, // 6: fpc.Credentials.expires_at:type_name -> google.protobuf.Timestamp
+	21, // 7: fpc.CleartextChaincodeRequest.input:type_name -> protos.ChaincodeInput
+	0,  // 8: fpc.ChaincodeRequestMessage.cipher_suite:type_name -> fpc.CipherSuite
+	22, // 9: fpc.CleartextChaincodeResponse.response:type_name -> protos.Response
+	23, // 10: fpc.FPCKVSet.rw_set:type_name -> kvrwset.KVRWSet
+	13, // 11: fpc.ChaincodeResponseMessage.fpc_rw_set:type_name -> fpc.FPCKVSet
+	24, // 12: fpc.ChaincodeResponseMessage.proposal:type_name -> protos.SignedProposal
+	0,  // 13: fpc.SealedArgument.cipher_suite:type_name -> fpc.CipherSuite
+	20, // 14: fpc.SealedArgumentMetadata.timestamp:type_name -> google.protobuf.Timestamp
+	15, // [15:15] is the sub-list for method output_type
+	15, // [15:15] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
 }
 
 func init() { file_fpc_fpc_proto_init() }
@@ -884,13 +1442,14 @@ func file_fpc_fpc_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_fpc_fpc_proto_rawDesc), len(file_fpc_fpc_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   12,
+			NumEnums:      1,
+			NumMessages:   18,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_fpc_fpc_proto_goTypes,
 		DependencyIndexes: file_fpc_fpc_proto_depIdxs,
+		EnumInfos:         file_fpc_fpc_proto_enumTypes,
 		MessageInfos:      file_fpc_fpc_proto_msgTypes,
 	}.Build()
 	File_fpc_fpc_proto = out.File
@@ -7,13 +7,19 @@ SPDX-License-Identifier: Apache-2.0
 package utils
 
 import (
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 
 	"github.com/hyperledger/fabric/protoutil"
 )
 
+// adminOU is the organizational unit of admin identities with Fabric NodeOUs enabled
+const adminOU = "admin"
+
 type IdentityEvaluatorInterface interface {
 	EvaluateCreatorIdentity(creatorIdentityBytes []byte, ownerMSP string) error
+	EvaluateAdminIdentity(creatorIdentityBytes []byte, adminMSPs []string) error
 }
 
 type IdentityEvaluator struct {
@@ -34,6 +40,42 @@ func (id *IdentityEvaluator) EvaluateCreatorIdentity(creatorIdentityBytes []byte
 	return nil
 }
 
+// EvaluateAdminIdentity checks that the identity is an admin (i.e., its certificate has the admin NodeOU) of one of
+// the given msps. This function requires marshalled msp.SerializedIdentity as input.
+func (id *IdentityEvaluator) EvaluateAdminIdentity(creatorIdentityBytes []byte, adminMSPs []string) error {
+	sID, err := protoutil.UnmarshalSerializedIdentity(creatorIdentityBytes)
+	if err != nil {
+		return fmt.Errorf("error while deserialzing creator identity, err: %s", err)
+	}
+
+	found := false
+	for _, mspId := range adminMSPs {
+		if sID.Mspid == mspId {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("creator msp %s is not authorized", sID.Mspid)
+	}
+
+	block, _ := pem.Decode(sID.IdBytes)
+	if block == nil {
+		return fmt.Errorf("creator identity does not contain a certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("cannot parse creator certificate, err: %s", err)
+	}
+
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if ou == adminOU {
+			return nil
+		}
+	}
+	return fmt.Errorf("creator is not an admin of msp %s", sID.Mspid)
+}
+
 func ExtractMSPID(serializedIdentityRaw []byte) (string, error) {
 	sID, err := protoutil.UnmarshalSerializedIdentity(serializedIdentityRaw)
 	if err != nil {
@@ -1,12 +1,36 @@
 package utils
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+
 	"github.com/hyperledger/fabric-protos-go/msp"
 	"github.com/hyperledger/fabric/protoutil"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
 
+// newSerializedIdentity returns a serialized identity with a self-signed certificate with the given OUs
+func newSerializedIdentity(mspId string, ous ...string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ShouldNot(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "someone", OrganizationalUnit: ous},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).ShouldNot(HaveOccurred())
+	return protoutil.MarshalOrPanic(&msp.SerializedIdentity{
+		Mspid:   mspId,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	})
+}
+
 var _ = Describe("Chaincode utils", func() {
 
 	Context("EvaluateCreatorIdentity", func() {
@@ -43,4 +67,46 @@ var _ = Describe("Chaincode utils", func() {
 			})
 		})
 	})
+
+	Context("EvaluateAdminIdentity", func() {
+
+		var (
+			eval *IdentityEvaluator
+		)
+
+		BeforeEach(func() {
+			eval = &IdentityEvaluator{}
+		})
+
+		When("creatorIdentity is invalid", func() {
+			It("should return an error", func() {
+				err := eval.EvaluateAdminIdentity([]byte("someGarbageBytes"), []string{"dummyMsp"})
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		When("mspid is not authorized", func() {
+			It("should return an error", func() {
+				sid := newSerializedIdentity("someMSP", "admin")
+				err := eval.EvaluateAdminIdentity(sid, []string{"dummyMsp"})
+				Expect(err).Should(MatchError("creator msp someMSP is not authorized"))
+			})
+		})
+
+		When("creator is not an admin", func() {
+			It("should return an error", func() {
+				sid := newSerializedIdentity("dummyMsp", "client")
+				err := eval.EvaluateAdminIdentity(sid, []string{"otherMsp", "dummyMsp"})
+				Expect(err).Should(MatchError("creator is not an admin of msp dummyMsp"))
+			})
+		})
+
+		When("creator is an admin of an authorized msp", func() {
+			It("should return no error", func() {
+				sid := newSerializedIdentity("dummyMsp", "admin")
+				err := eval.EvaluateAdminIdentity(sid, []string{"otherMsp", "dummyMsp"})
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+	})
 })
@@ -13,6 +13,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
 
 	//lint:ignore SA1019 old protos are needed for fabric
 	protoV1 "github.com/golang/protobuf/proto"
@@ -63,6 +64,24 @@ func UnmarshalCredentials(credentialsBase64 string) (*protos.Credentials, error)
 	return credentials, nil
 }
 
+func UnmarshalChaincodeEncryptionKeys(chaincodeEncryptionKeysBase64 string) (*protos.ChaincodeEncryptionKeys, error) {
+	data, err := base64.StdEncoding.DecodeString(chaincodeEncryptionKeysBase64)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &protos.ChaincodeEncryptionKeys{}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, errors.Wrap(err, "invalid ChaincodeEncryptionKeys")
+	}
+
+	if len(msg.GetChaincodeEks()) == 0 {
+		return nil, fmt.Errorf("no chaincode encryption keys")
+	}
+
+	return msg, nil
+}
+
 func UnmarshalAttestedData(serializedAttestedData *anypb.Any) (*protos.AttestedData, error) {
 	if serializedAttestedData == nil {
 		return nil, errors.New("attested data is empty")
@@ -101,6 +120,19 @@ func UnmarshalQueryChaincodeDefinitionResult(data []byte) (*lifecycle.QueryChain
 	return df, nil
 }
 
+func UnmarshalChaincodeBatchRequestMessage(data []byte) (*protos.ChaincodeBatchRequestMessage, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("ChaincodeBatchRequestMessage is empty")
+	}
+
+	msg := &protos.ChaincodeBatchRequestMessage{}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, errors.Wrap(err, "invalid ChaincodeBatchRequestMessage")
+	}
+
+	return msg, nil
+}
+
 func UnmarshalSignedChaincodeResponseMessage(data []byte) (*protos.SignedChaincodeResponseMessage, error) {
 	if len(data) == 0 {
 		return nil, fmt.Errorf("SignedChaincodeResponseMessage is empty")
@@ -135,6 +167,16 @@ func GetEnclaveId(attestedData *protos.AttestedData) string {
 	return strings.ToUpper(hex.EncodeToString(h[:]))
 }
 
+// CredentialsExpired returns true if the credentials have an expiry set by ERCC and now is after it.
+// Credentials without expiry never expire.
+func CredentialsExpired(credentials *protos.Credentials, now time.Time) bool {
+	expiresAt := credentials.GetExpiresAt()
+	if expiresAt == nil {
+		return false
+	}
+	return now.After(expiresAt.AsTime())
+}
+
 func ExtractEndpoint(credentials *protos.Credentials) (string, error) {
 	attestedData := &protos.AttestedData{}
 	err := credentials.SerializedAttestedData.UnmarshalTo(attestedData)
@@ -233,8 +275,21 @@ func GetChaincodeRequestMessageFromSignedProposal(signedProposal *pb.SignedPropo
 	return chaincodeRequestMessageBytes, nil
 }
 
+// ChaincodeError is returned by UnwrapResponse if the chaincode completed the invocation with an error status,
+// i.e., the peer.Response has a status other than 200.
+type ChaincodeError struct {
+	// Status is the status code of the chaincode response (e.g., 400 or 500)
+	Status int32
+	// Message is the error message set by the chaincode
+	Message string
+}
+
+func (e *ChaincodeError) Error() string {
+	return e.Message
+}
+
 // UnwrapResponse unmarshalls the given serialized peer.Response message and returns the Payload field if Status is 200;
-// otherwise, the Message field is returned as an error
+// otherwise, a *ChaincodeError with the Status and Message fields is returned
 func UnwrapResponse(responseBytes []byte) (payload []byte, err error) {
 	clearResponse, err := protoutil.UnmarshalResponse(responseBytes)
 	if err != nil {
@@ -242,7 +297,7 @@ func UnwrapResponse(responseBytes []byte) (payload []byte, err error) {
 	}
 
 	if clearResponse.Status != 200 {
-		return nil, errors.New(clearResponse.Message)
+		return nil, &ChaincodeError{Status: clearResponse.Status, Message: clearResponse.Message}
 	}
 
 	return clearResponse.Payload, nil
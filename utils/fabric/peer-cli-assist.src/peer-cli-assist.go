@@ -9,12 +9,16 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"time"
 
+	ercc "github.com/hyperledger/fabric-private-chaincode/ercc/attestation"
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
 	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
 	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
@@ -25,9 +29,17 @@ var logger = flogging.MustGetLogger("peer-cli-assist")
 
 func printHelp() {
 	fmt.Printf(
-		`Usage: %s [attestation2Evidence | handleRequestAndResponse <cid> <pipe>]
+		`Usage: %s [attestation2Evidence | inspectCredentials <mrenclave> | handleRequestAndResponse <cid> <pipe> |
+	concealRequest <c_ek> <context-file> <passphrase-file> | revealResponse <context-file> <passphrase-file>]
 - attestation2Evidence: convert attestation to evidence in (base64-encoded) Credentials protobuf
   (Input and outpus are via stdin and stdout, respectively.)
+- inspectCredentials: decode a (base64-encoded) Credentials protobuf, as passed to ercc.RegisterEnclave, and
+  verify it step by step, e.g., to find out why an enclave registration failed.
+  Expects one parameter
+  - <mrenclave> the expected mrenclave, i.e., the version of the chaincode definition
+  As input, expects the (base64-encoded) Credentials protobuf (with evidence, see attestation2Evidence) and prints
+  the attested data, the decoded attestation report and the result of each verification step.
+  Exits with a non-zero status if any step fails.
 - handleRequestAndResponse: handles the encryption of invocation requests as well as the decryption
   of the corresponding responses.
   Expects three parameters
@@ -39,6 +51,18 @@ func printHelp() {
     after which it returns (as single line) the (base64-encoded) ChaincodeRequestMessage protobuf, and then
   - a (base64-encoded) ChaincodeResponseMessage protobuf, after which it will decrypt it and 
     return a json-encoded fabric response protobuf object
+- concealRequest: offline variant of the request handling of handleRequestAndResponse.
+  Expects three parameters
+  - <c_ek> the chaincode encryption key, as returned from ercc.QueryChaincodeEncryptionKey
+  - <context-file> a path to a file where the sealed encryption context is written to
+  - <passphrase-file> a path to a file containing the passphrase used to seal the encryption context
+  As input, expects a (single line!) json string in peer cli format (see above) and returns
+  the (base64-encoded) ChaincodeRequestMessage protobuf
+- revealResponse: offline variant of the response handling of handleRequestAndResponse.
+  Expects two parameters
+  - <context-file> a path to a sealed encryption context as written by concealRequest
+  - <passphrase-file> a path to a file containing the passphrase used to seal the encryption context
+  As input, expects a (base64-encoded) ChaincodeResponseMessage protobuf and returns the decrypted response payload
 `,
 		os.Args[0])
 	// TODO: above we have to fix the response payload format (json?)
@@ -67,6 +91,13 @@ func main() {
 			os.Exit(1)
 		}
 		fmt.Printf("%s\n", credentialsStringOut)
+	case "inspectCredentials":
+		if len(os.Args) != 3 {
+			fmt.Fprintf(os.Stderr, "ERROR: command 'inspectCredentials' needs exactly one argument\n")
+			printHelp()
+			os.Exit(1)
+		}
+		inspectCredentials(os.Args[2])
 	case "handleRequestAndResponse":
 		if len(os.Args) != 4 {
 			fmt.Fprintf(os.Stderr, "ERROR: command 'handleRequestAndResponse' needs exactly two arguments\n")
@@ -74,6 +105,20 @@ func main() {
 			os.Exit(1)
 		}
 		handleEncryptedRequestAndResponse(os.Args[2], os.Args[3])
+	case "concealRequest":
+		if len(os.Args) != 5 {
+			fmt.Fprintf(os.Stderr, "ERROR: command 'concealRequest' needs exactly three arguments\n")
+			printHelp()
+			os.Exit(1)
+		}
+		concealRequest(os.Args[2], os.Args[3], os.Args[4])
+	case "revealResponse":
+		if len(os.Args) != 4 {
+			fmt.Fprintf(os.Stderr, "ERROR: command 'revealResponse' needs exactly two arguments\n")
+			printHelp()
+			os.Exit(1)
+		}
+		revealResponse(os.Args[2], os.Args[3])
 	default:
 		fmt.Fprintf(os.Stderr, "ERROR: Illegal command '%s'\n", os.Args[1])
 		printHelp()
@@ -91,18 +136,77 @@ func handleEncryptedRequestAndResponse(chaincodeEncryptionKey string, resultPipe
 		os.Exit(1)
 	}
 
-	// read request
+	clearRequest := readClearRequest(reader)
+
+	// setup crypto context
+	ep := &crypto.EncryptionProviderImpl{
+		CSP: crypto.GetDefaultCSP(),
+		GetCcEncryptionKey: func() ([]byte, error) {
+			// TODO: might have to do some re-formatting, e.g., de-hex, here?
+			return []byte(chaincodeEncryptionKey), nil
+		}}
+
+	ctx, err := ep.NewEncryptionContext()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: could not setup crypto context: %v\n", err)
+		os.Exit(1)
+	}
+	logger.Debugf("Setup crypto context based on CC-ek '%v'", chaincodeEncryptionKey)
+
+	// encrypt request ...
+	encryptedRequest, err := ctx.Conceal(*clearRequest.Function, *clearRequest.Args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: could not encrypt request: %v\n", err)
+		os.Exit(1)
+	}
+	// ... and return it
+	logger.Debugf("Transformed request '%v' to '%v' and write to pipe '%s'", clearRequest, encryptedRequest, resultPipeName)
+	resultPipeFile.WriteString(fmt.Sprintf("%s\n", encryptedRequest))
+
+	// read encrypted response ...
+	encryptedResponse, err := reader.ReadString('\n')
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: couldn't read encrypted response: %v\n", err)
+		os.Exit(1)
+	}
+	encryptedResponse = strings.TrimSuffix(encryptedResponse, "\n")
+
+	// .. decrypt it ..
+	// TODO: requires fix in Conceal & ecc/mock
+	// - should be base64 encoded
+	// - encrypted response should be a proper (serialized) response object, not only a string, and hence conceal should
+	//   return the deserialized response, not a byte array ..
+	clearResponse, err := ctx.Reveal([]byte(encryptedResponse))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: could not decrypt response: %v\n", err)
+		os.Exit(1)
+	}
+	// TODO: create a (single-line) json encoding once we get above a proper response object ...
+
+	payload, err := utils.UnwrapResponse(clearResponse)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	logger.Debugf("Transformed response '%s' to '%s' and write to pipe '%s'", encryptedResponse, string(payload), resultPipeName)
+	resultPipeFile.WriteString(fmt.Sprintf("%s\n", payload))
+}
+
+type cliRequest struct {
+	Function *string   `json:"function,omitempty"`
+	Args     *[]string `json:"args,omitempty"`
+}
+
+// readClearRequest reads a (single line) json request in peer cli format and normalizes it
+func readClearRequest(reader *bufio.Reader) *cliRequest {
 	requestJSON, err := reader.ReadString('\n')
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: couldn't read json request: %v\n", err)
 		os.Exit(1)
 	}
 	requestJSON = strings.TrimSpace(requestJSON)
-	type Request struct {
-		Function *string   `json:"function,omitempty"`
-		Args     *[]string `json:"args,omitempty"`
-	}
-	clearRequest := &Request{}
+	clearRequest := &cliRequest{}
 	dec := json.NewDecoder(bytes.NewReader([]byte(requestJSON)))
 	dec.DisallowUnknownFields()
 	if err := dec.Decode(&clearRequest); err != nil {
@@ -125,11 +229,26 @@ func handleEncryptedRequestAndResponse(chaincodeEncryptionKey string, resultPipe
 	}
 	logger.Debugf("Normalized json args '%s' to function='%s'/args='%v'", requestJSON, *clearRequest.Function, *clearRequest.Args)
 
+	return clearRequest
+}
+
+func readPassphrase(passphraseFile string) []byte {
+	passphrase, err := os.ReadFile(passphraseFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: couldn't read passphrase file '%s': %v\n", passphraseFile, err)
+		os.Exit(1)
+	}
+	return bytes.TrimSuffix(passphrase, []byte("\n"))
+}
+
+func concealRequest(chaincodeEncryptionKey string, contextFile string, passphraseFile string) {
+	passphrase := readPassphrase(passphraseFile)
+	clearRequest := readClearRequest(bufio.NewReader(os.Stdin))
+
 	// setup crypto context
 	ep := &crypto.EncryptionProviderImpl{
 		CSP: crypto.GetDefaultCSP(),
 		GetCcEncryptionKey: func() ([]byte, error) {
-			// TODO: might have to do some re-formatting, e.g., de-hex, here?
 			return []byte(chaincodeEncryptionKey), nil
 		}}
 
@@ -138,37 +257,55 @@ func handleEncryptedRequestAndResponse(chaincodeEncryptionKey string, resultPipe
 		fmt.Fprintf(os.Stderr, "ERROR: could not setup crypto context: %v\n", err)
 		os.Exit(1)
 	}
-	logger.Debugf("Setup crypto context based on CC-ek '%v'", chaincodeEncryptionKey)
 
-	// encrypt request ...
 	encryptedRequest, err := ctx.Conceal(*clearRequest.Function, *clearRequest.Args)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: could not encrypt request: %v\n", err)
 		os.Exit(1)
 	}
-	// ... and return it
-	logger.Debugf("Transformed request '%v' to '%v' and write to pipe '%s'", clearRequest, encryptedRequest, resultPipeName)
-	resultPipeFile.WriteString(fmt.Sprintf("%s\n", encryptedRequest))
 
-	// read encrypted response ...
-	encryptedResponse, err := reader.ReadString('\n')
+	// keep the sealed context so the response can be revealed later, possibly by another process
+	sealedContext, err := ctx.(*crypto.EncryptionContextImpl).SealWithPassphrase(passphrase)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: could not seal crypto context: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(contextFile, sealedContext, 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: couldn't write context file '%s': %v\n", contextFile, err)
+		os.Exit(1)
+	}
+	logger.Debugf("Transformed request '%v' to '%v' and wrote sealed context to '%s'", clearRequest, encryptedRequest, contextFile)
+
+	fmt.Printf("%s\n", encryptedRequest)
+}
+
+func revealResponse(contextFile string, passphraseFile string) {
+	passphrase := readPassphrase(passphraseFile)
+
+	sealedContext, err := os.ReadFile(contextFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: couldn't read context file '%s': %v\n", contextFile, err)
+		os.Exit(1)
+	}
+
+	ctx, err := crypto.UnsealWithPassphrase(crypto.GetDefaultCSP(), sealedContext, passphrase)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: could not restore crypto context: %v\n", err)
+		os.Exit(1)
+	}
+
+	encryptedResponse, err := io.ReadAll(os.Stdin)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: couldn't read encrypted response: %v\n", err)
 		os.Exit(1)
 	}
-	encryptedResponse = strings.TrimSuffix(encryptedResponse, "\n")
 
-	// .. decrypt it ..
-	// TODO: requires fix in Conceal & ecc/mock
-	// - should be base64 encoded
-	// - encrypted response should be a proper (serialized) response object, not only a string, and hence conceal should
-	//   return the deserialized response, not a byte array ..
-	clearResponse, err := ctx.Reveal([]byte(encryptedResponse))
+	clearResponse, err := ctx.Reveal(bytes.TrimSpace(encryptedResponse))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: could not decrypt response: %v\n", err)
 		os.Exit(1)
 	}
-	// TODO: create a (single-line) json encoding once we get above a proper response object ...
 
 	payload, err := utils.UnwrapResponse(clearResponse)
 	if err != nil {
@@ -176,6 +313,63 @@ func handleEncryptedRequestAndResponse(chaincodeEncryptionKey string, resultPipe
 		os.Exit(1)
 	}
 
-	logger.Debugf("Transformed response '%s' to '%s' and write to pipe '%s'", encryptedResponse, string(payload), resultPipeName)
-	resultPipeFile.WriteString(fmt.Sprintf("%s\n", payload))
+	fmt.Printf("%s\n", payload)
+}
+
+func inspectCredentials(expectedMrenclave string) {
+	credentialsIn, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: couldn't read stdin: %v\n", err)
+		os.Exit(1)
+	}
+
+	credentials, err := utils.UnmarshalCredentials(strings.TrimSpace(string(credentialsIn)))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: couldn't decode credentials: %v\n", err)
+		os.Exit(1)
+	}
+
+	// use the same verifiers as ERCC
+	inspection := ercc.GetAvailableVerifier().InspectCredentials(credentials, expectedMrenclave)
+
+	if ad := inspection.AttestedData; ad != nil {
+		fmt.Printf("Attested data:\n")
+		fmt.Printf("  chaincode id:   %s\n", ad.GetCcParams().GetChaincodeId())
+		fmt.Printf("  version:        %s\n", ad.GetCcParams().GetVersion())
+		fmt.Printf("  sequence:       %d\n", ad.GetCcParams().GetSequence())
+		fmt.Printf("  channel id:     %s\n", ad.GetCcParams().GetChannelId())
+		fmt.Printf("  peer msp id:    %s\n", ad.GetHostParams().GetPeerMspId())
+		fmt.Printf("  peer endpoint:  %s\n", ad.GetHostParams().GetPeerEndpoint())
+		fmt.Printf("  nonce:          %s\n", ad.GetHostParams().GetNonce())
+		fmt.Printf("  enclave id:     %s\n", utils.GetEnclaveId(ad))
+		fmt.Printf("  enclave vk:     %s\n", base64.StdEncoding.EncodeToString(ad.GetEnclaveVk()))
+		fmt.Printf("  chaincode ek:   %s\n", base64.StdEncoding.EncodeToString(ad.GetChaincodeEk()))
+	}
+	if credentials.GetExpiresAt() != nil {
+		fmt.Printf("Expires at:       %s\n", credentials.GetExpiresAt().AsTime().Format(time.RFC3339))
+	}
+	fmt.Printf("Attestation type: %s\n", inspection.AttestationType)
+	fmt.Printf("Evidence type:    %s\n", inspection.EvidenceType)
+
+	if r := inspection.Report; r != nil {
+		fmt.Printf("Report:\n")
+		fmt.Printf("  %-15s %s\n", "mrenclave:", r.Mrenclave)
+		fmt.Printf("  %-15s %s\n", "report data:", hex.EncodeToString(r.ReportData))
+		for _, f := range r.Fields {
+			fmt.Printf("  %-15s %s\n", f.Name+":", f.Value)
+		}
+	}
+
+	fmt.Printf("Verification (expected mrenclave %s):\n", expectedMrenclave)
+	for _, step := range inspection.Steps {
+		if step.Err != nil {
+			fmt.Printf("  [FAIL] %s: %v\n", step.Name, step.Err)
+		} else {
+			fmt.Printf("  [PASS] %s\n", step.Name)
+		}
+	}
+
+	if !inspection.Passed() {
+		os.Exit(1)
+	}
 }